@@ -8,6 +8,7 @@ import (
 
 	"bazil.org/bazil/cas"
 	"bazil.org/bazil/cas/chunks"
+	"bazil.org/bazil/cas/chunks/chunkutil"
 	"bazil.org/bazil/cas/chunks/stash"
 	"golang.org/x/net/context"
 )
@@ -46,6 +47,9 @@ type Manifest struct {
 	ChunkSize uint32
 	// Must be >= 2.
 	Fanout uint32
+	// Hash is the algorithm used for the keys of all chunks of the
+	// blob. Defaults to the original algorithm.
+	Hash cas.Hash
 }
 
 // EmptyManifest returns an empty manifest of the given type with the
@@ -138,8 +142,11 @@ func Open(chunkStore chunks.Store, manifest *Manifest) (*Blob, error) {
 	if m.Fanout < 2 {
 		return nil, SmallFanoutError{m.Fanout}
 	}
+	if !m.Hash.IsValid() {
+		return nil, cas.UnknownHashError{Hash: m.Hash}
+	}
 	blob := &Blob{
-		stash: stash.New(chunkStore),
+		stash: stash.New(chunkStore, m.Hash),
 		m:     m,
 	}
 	blob.depth = blob.computeLevel(blob.m.Size)
@@ -475,6 +482,12 @@ func (blob *Blob) Size() uint64 {
 	return blob.m.Size
 }
 
+// MaxSize returns the largest size the Blob can grow to. Chunks are
+// indexed with 32 bits, so this depends on the chunk size.
+func (blob *Blob) MaxSize() uint64 {
+	return uint64(blob.m.ChunkSize) << 32
+}
+
 func trim(b []byte) []byte {
 	end := len(b)
 	for end > 0 && b[end-1] == 0x00 {
@@ -654,3 +667,87 @@ func (blob *Blob) Save(ctx context.Context) (*Manifest, error) {
 	m := blob.m
 	return &m, nil
 }
+
+// Verify reads every chunk of the Blob from the Store and checks that
+// its contents match its key, under the hash algorithm of the
+// Manifest. Chunks that have not been saved yet are skipped.
+func (blob *Blob) Verify(ctx context.Context) error {
+	return blob.verifyChunk(ctx, blob.m.Root, blob.depth)
+}
+
+func (blob *Blob) verifyChunk(ctx context.Context, key cas.Key, level uint8) error {
+	if key == cas.Empty || key.IsPrivate() {
+		return nil
+	}
+	chunk, err := blob.stash.Get(ctx, key, blob.m.Type, level)
+	if err != nil {
+		return err
+	}
+	// Stores return chunks without knowing what algorithm they were
+	// hashed with; copy to avoid mutating a cached chunk.
+	c := *chunk
+	c.Hash = blob.m.Hash
+	if err := chunkutil.Verify(&c, key); err != nil {
+		return err
+	}
+	if level == 0 {
+		return nil
+	}
+	for off := 0; off+cas.KeySize <= len(c.Buf); off += cas.KeySize {
+		cur := cas.NewKeyPrivate(c.Buf[off : off+cas.KeySize])
+		if cur.IsReserved() {
+			return fmt.Errorf("invalid stored key: key @%d in %v is %v", off, key, c.Buf[off:off+cas.KeySize])
+		}
+		// recurses at most `level` deep
+		if err := blob.verifyChunk(ctx, cur, level-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Chunks calls fn for every chunk the saved Blob consists of. Only
+// pointer chunks are read from the Store; the size of data chunks is
+// computed from the Manifest, and may be larger than what is stored
+// after zero trimming. Sparse areas and chunks that have not been
+// saved yet are skipped.
+func (blob *Blob) Chunks(ctx context.Context, fn func(key cas.Key, level uint8, size uint64) error) error {
+	return blob.walkChunks(ctx, blob.m.Root, blob.depth, 0, fn)
+}
+
+func (blob *Blob) walkChunks(ctx context.Context, key cas.Key, level uint8, idx uint64, fn func(key cas.Key, level uint8, size uint64) error) error {
+	if key == cas.Empty || key.IsPrivate() {
+		return nil
+	}
+	if level == 0 {
+		off := idx * uint64(blob.m.ChunkSize)
+		if off >= blob.m.Size {
+			return nil
+		}
+		size := blob.m.Size - off
+		if size > uint64(blob.m.ChunkSize) {
+			size = uint64(blob.m.ChunkSize)
+		}
+		return fn(key, level, size)
+	}
+
+	chunk, err := blob.stash.Get(ctx, key, blob.m.Type, level)
+	if err != nil {
+		return err
+	}
+	if err := fn(key, level, uint64(len(chunk.Buf))); err != nil {
+		return err
+	}
+	for i := 0; i*cas.KeySize+cas.KeySize <= len(chunk.Buf); i++ {
+		off := i * cas.KeySize
+		cur := cas.NewKeyPrivate(chunk.Buf[off : off+cas.KeySize])
+		if cur.IsReserved() {
+			return fmt.Errorf("invalid stored key: key @%d in %v is %v", off, key, chunk.Buf[off:off+cas.KeySize])
+		}
+		// recurses at most `level` deep
+		if err := blob.walkChunks(ctx, cur, level-1, idx*uint64(blob.m.Fanout)+uint64(i), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
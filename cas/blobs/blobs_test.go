@@ -439,6 +439,114 @@ func TestWriteAndSaveLarge(t *testing.T) {
 	}
 }
 
+func TestChunks(t *testing.T) {
+	const chunkSize = 4096
+	const fanout = 64
+	chunkStore := &mock.InMemory{}
+	blob, err := blobs.Open(chunkStore, &blobs.Manifest{
+		Type:      "footype",
+		ChunkSize: chunkSize,
+		Fanout:    fanout,
+	})
+	if err != nil {
+		t.Fatalf("cannot open blob: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := blob.IO(ctx).WriteAt(bytes.Repeat([]byte{'x'}, chunkSize+10), 0); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if _, err := blob.Save(ctx); err != nil {
+		t.Fatalf("unexpected error from Save: %v", err)
+	}
+
+	type seen struct {
+		level uint8
+		size  uint64
+	}
+	var got []seen
+	fn := func(key cas.Key, level uint8, size uint64) error {
+		got = append(got, seen{level, size})
+		return nil
+	}
+	if err := blob.Chunks(ctx, fn); err != nil {
+		t.Fatalf("chunks: %v", err)
+	}
+	want := []seen{
+		{1, 2 * cas.KeySize},
+		{0, chunkSize},
+		{0, 10},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("wrong chunks: %v != %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("wrong chunk %d: %v != %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestVerifyMixedHash(t *testing.T) {
+	const chunkSize = 4096
+	const fanout = 64
+	chunkStore := &mock.InMemory{}
+	ctx := context.Background()
+	save := func(hash cas.Hash) *blobs.Manifest {
+		blob, err := blobs.Open(chunkStore, &blobs.Manifest{
+			Type:      "footype",
+			ChunkSize: chunkSize,
+			Fanout:    fanout,
+			Hash:      hash,
+		})
+		if err != nil {
+			t.Fatalf("cannot open blob: %v", err)
+		}
+		if _, err := blob.IO(ctx).WriteAt(bytes.Repeat([]byte{'x'}, 2*chunkSize), 0); err != nil {
+			t.Fatalf("unexpected write error: %v", err)
+		}
+		saved, err := blob.Save(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error from Save: %v", err)
+		}
+		return saved
+	}
+	old := save(cas.HashBLAKE2b)
+	cur := save(cas.HashSHA512)
+	if old.Root == cur.Root {
+		t.Fatalf("hash algorithms gave the same key: %v", cur.Root)
+	}
+
+	for _, m := range []*blobs.Manifest{old, cur} {
+		blob, err := blobs.Open(chunkStore, m)
+		if err != nil {
+			t.Fatalf("cannot open blob: %v", err)
+		}
+		if err := blob.Verify(ctx); err != nil {
+			t.Errorf("verify %v: %v", m.Hash, err)
+		}
+	}
+
+	// claiming the wrong algorithm must not verify
+	wrong := *cur
+	wrong.Hash = cas.HashBLAKE2b
+	blob, err := blobs.Open(chunkStore, &wrong)
+	if err != nil {
+		t.Fatalf("cannot open blob: %v", err)
+	}
+	if _, ok := blob.Verify(ctx).(cas.CorruptError); !ok {
+		t.Errorf("expected CorruptError")
+	}
+}
+
+func TestOpenUnknownHash(t *testing.T) {
+	m := blobs.EmptyManifest("footype")
+	m.Hash = 200
+	_, err := blobs.Open(mock.NeverUsed{}, m)
+	if _, ok := err.(cas.UnknownHashError); !ok {
+		t.Fatalf("bad error: %v", err)
+	}
+}
+
 func TestWriteTruncateZero(t *testing.T) {
 	const chunkSize = 4096
 	const fanout = 64
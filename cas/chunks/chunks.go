@@ -15,6 +15,9 @@ type Chunk struct {
 	Type  string
 	Level uint8
 	Buf   []byte
+	// Hash is the algorithm used to compute the key of the chunk
+	// when it is added to a Store.
+	Hash cas.Hash
 }
 
 func (c *Chunk) String() string {
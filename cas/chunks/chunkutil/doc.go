@@ -1,4 +1,4 @@
-// Package chunkutil contains helper functions only needed by
-// chunks.Store implementations. Users of a Store don't need this
-// package.
+// Package chunkutil contains helper functions needed by chunks.Store
+// implementations. Users of a Store only need this package for
+// verifying chunk contents.
 package chunkutil
@@ -1,6 +1,7 @@
 package chunkutil
 
 import (
+	"crypto/sha512"
 	"fmt"
 
 	"bazil.org/bazil/cas"
@@ -16,10 +17,25 @@ const personalizationPrefix = "bazil:"
 // C0111DED = COLLIDED
 var replaceSpecial = []byte{0xC0, 0x11, 0x1D, 0xED, 0x00}
 
-// Hash hashes the data in a chunk into a cas.Key.
+// Hash hashes the data in a chunk into a cas.Key, using the algorithm
+// set in the chunk.
 //
-// Hash makes sure to never return a Special Key.
+// Hash makes sure to never return a Special Key, except for returning
+// cas.Invalid if the algorithm is not known.
 func Hash(chunk *chunks.Chunk) cas.Key {
+	if len(chunk.Buf) == 0 {
+		return cas.Empty
+	}
+	switch chunk.Hash {
+	case cas.HashBLAKE2b:
+		return hashBLAKE2b(chunk)
+	case cas.HashSHA512:
+		return hashSHA512(chunk)
+	}
+	return cas.Invalid
+}
+
+func hashBLAKE2b(chunk *chunks.Chunk) cas.Key {
 	var pers [blake2.PersonalSize]byte
 	copy(pers[:], personalizationPrefix)
 	copy(pers[len(personalizationPrefix):], chunk.Type)
@@ -39,14 +55,41 @@ func Hash(chunk *chunks.Chunk) cas.Key {
 		},
 	}
 	h := blake2.New(config)
-	if len(chunk.Buf) == 0 {
-		return cas.Empty
-	}
 	_, _ = h.Write(chunk.Buf)
 	keybuf := h.Sum(nil)
 	return makeKey(keybuf)
 }
 
+func hashSHA512(chunk *chunks.Chunk) cas.Key {
+	// SHA-512 has no personalization, so the type and level are
+	// prepended to the data. The NUL terminates the type, which
+	// cannot contain one.
+	h := sha512.New()
+	_, _ = h.Write([]byte(personalizationPrefix))
+	_, _ = h.Write([]byte(chunk.Type))
+	_, _ = h.Write([]byte{0, chunk.Level})
+	_, _ = h.Write(chunk.Buf)
+	keybuf := h.Sum(nil)
+	return makeKey(keybuf)
+}
+
+// Verify checks that the chunk contents match key, when hashed with
+// the algorithm set in the chunk.
+func Verify(chunk *chunks.Chunk, key cas.Key) error {
+	if !chunk.Hash.IsValid() {
+		return cas.UnknownHashError{Hash: chunk.Hash}
+	}
+	if k := Hash(chunk); k != key {
+		return cas.CorruptError{
+			Type:  chunk.Type,
+			Level: chunk.Level,
+			Key:   key,
+			Hash:  chunk.Hash,
+		}
+	}
+	return nil
+}
+
 func makeKey(keybuf []byte) cas.Key {
 	key := cas.NewKey(keybuf)
 	if key.IsSpecial() {
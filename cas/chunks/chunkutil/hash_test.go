@@ -31,3 +31,63 @@ func TestHashSomeZeroes(t *testing.T) {
 		t.Errorf("wrong key for some zero bytes: %v != %v", g, e)
 	}
 }
+
+func TestHashSHA512(t *testing.T) {
+	chunk := &chunks.Chunk{
+		Type:  "testchunk",
+		Level: 42,
+		Buf:   []byte{0x00, 0x00, 0x00},
+		Hash:  cas.HashSHA512,
+	}
+	k := chunkutil.Hash(chunk)
+	if k.IsSpecial() {
+		t.Fatalf("unexpected special key: %v", k)
+	}
+	chunk.Hash = cas.HashBLAKE2b
+	if g := chunkutil.Hash(chunk); g == k {
+		t.Errorf("algorithms gave the same key: %v", g)
+	}
+}
+
+func TestHashSHA512Empty(t *testing.T) {
+	chunk := &chunks.Chunk{
+		Type:  "testchunk",
+		Level: 42,
+		Buf:   []byte{},
+		Hash:  cas.HashSHA512,
+	}
+	k := chunkutil.Hash(chunk)
+	if g, e := k, cas.Empty; g != e {
+		t.Errorf("wrong key for zero chunk: %v != %v", g, e)
+	}
+}
+
+func TestHashUnknown(t *testing.T) {
+	chunk := &chunks.Chunk{
+		Type:  "testchunk",
+		Level: 42,
+		Buf:   []byte{0x00},
+		Hash:  200,
+	}
+	k := chunkutil.Hash(chunk)
+	if g, e := k, cas.Invalid; g != e {
+		t.Errorf("wrong key for unknown hash: %v != %v", g, e)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	chunk := &chunks.Chunk{
+		Type:  "testchunk",
+		Level: 1,
+		Buf:   []byte("hello"),
+		Hash:  cas.HashSHA512,
+	}
+	k := chunkutil.Hash(chunk)
+	if err := chunkutil.Verify(chunk, k); err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	chunk.Buf = []byte("jello")
+	if _, ok := chunkutil.Verify(chunk, k).(cas.CorruptError); !ok {
+		t.Errorf("expected CorruptError")
+	}
+}
@@ -39,6 +39,9 @@ func (s *storeInKV) Get(ctx context.Context, key cas.Key, type_ string, level ui
 }
 
 func (s *storeInKV) Add(ctx context.Context, chunk *chunks.Chunk) (key cas.Key, err error) {
+	if !chunk.Hash.IsValid() {
+		return cas.Invalid, cas.UnknownHashError{Hash: chunk.Hash}
+	}
 	key = chunkutil.Hash(chunk)
 	if key.IsSpecial() {
 		return key, nil
@@ -52,6 +55,12 @@ func (s *storeInKV) Add(ctx context.Context, chunk *chunks.Chunk) (key cas.Key,
 	return key, nil
 }
 
+var _ kv.SpaceReporter = (*storeInKV)(nil)
+
+func (s *storeInKV) Space(ctx context.Context) (kv.Space, error) {
+	return kv.SpaceOf(ctx, s.kv)
+}
+
 func New(keyval kv.KV) chunks.Store {
 	return &storeInKV{
 		kv: keyval,
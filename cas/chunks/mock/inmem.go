@@ -33,6 +33,9 @@ func (c *InMemory) Get(ctx context.Context, key cas.Key, typ string, level uint8
 
 // Add adds a Chunk to the Store. See chunks.Store.Add.
 func (c *InMemory) Add(ctx context.Context, chunk *chunks.Chunk) (key cas.Key, err error) {
+	if !chunk.Hash.IsValid() {
+		return cas.Invalid, cas.UnknownHashError{Hash: chunk.Hash}
+	}
 	key = chunkutil.Hash(chunk)
 	if c.m == nil {
 		c.m = make(map[mapkey][]byte)
@@ -7,10 +7,12 @@ import (
 	"golang.org/x/net/context"
 )
 
-// New creates a new Stash.
-func New(bs chunks.Store) *Stash {
+// New creates a new Stash. Chunks saved through it are hashed with
+// the given algorithm.
+func New(bs chunks.Store, hash cas.Hash) *Stash {
 	s := &Stash{
 		chunks: bs,
+		hash:   hash,
 		local:  make(map[uint64]*chunks.Chunk),
 	}
 	return s
@@ -20,6 +22,7 @@ func New(bs chunks.Store) *Stash {
 // local, only saving them to the Store when Save is called.
 type Stash struct {
 	chunks chunks.Store
+	hash   cas.Hash
 	ids    idpool.Pool
 	local  map[uint64]*chunks.Chunk
 }
@@ -117,6 +120,7 @@ func (s *Stash) Save(ctx context.Context, key cas.Key) (cas.Key, error) {
 		}
 	}
 
+	chunk.Hash = s.hash
 	newkey, err := s.chunks.Add(ctx, chunk)
 	if err != nil {
 		return key, err
@@ -2,6 +2,8 @@ package cas
 
 import (
 	"fmt"
+
+	"bazil.org/bazil/util/errkind"
 )
 
 // NotFoundError is the type of error returned by a CAS when it cannot
@@ -17,3 +19,26 @@ var _ error = NotFoundError{}
 func (n NotFoundError) Error() string {
 	return fmt.Sprintf("Not found: %q@%d %s", n.Type, n.Level, n.Key)
 }
+
+func (n NotFoundError) ErrorKind() errkind.Kind {
+	return errkind.NotFound
+}
+
+// CorruptError is the type of error returned when the data stored
+// under a key does not hash to that key.
+type CorruptError struct {
+	Type  string
+	Level uint8
+	Key   Key
+	Hash  Hash
+}
+
+var _ error = CorruptError{}
+
+func (c CorruptError) Error() string {
+	return fmt.Sprintf("Corrupt chunk: %q@%d %s (%s)", c.Type, c.Level, c.Key, c.Hash)
+}
+
+func (c CorruptError) ErrorKind() errkind.Kind {
+	return errkind.Integrity
+}
@@ -0,0 +1,69 @@
+package cas
+
+import (
+	"fmt"
+)
+
+// Hash identifies the algorithm used to compute Keys from chunk
+// contents.
+//
+// The zero value is the original algorithm, so data stored before
+// the algorithm was recorded anywhere is still interpreted
+// correctly.
+type Hash uint8
+
+const (
+	// HashBLAKE2b is BLAKE2b-512, personalized with the chunk type
+	// and level.
+	HashBLAKE2b Hash = iota
+	// HashSHA512 is SHA-512 over the chunk type, level and data.
+	HashSHA512
+)
+
+var hashNames = [...]string{
+	HashBLAKE2b: "blake2b",
+	HashSHA512:  "sha512",
+}
+
+// IsValid reports whether h is a known algorithm.
+func (h Hash) IsValid() bool {
+	return int(h) < len(hashNames)
+}
+
+// String returns the name of the algorithm.
+func (h Hash) String() string {
+	if !h.IsValid() {
+		return fmt.Sprintf("hash%d", uint8(h))
+	}
+	return hashNames[h]
+}
+
+// Set parses an algorithm name, as returned by String.
+//
+// See flag.Value.Set.
+func (h *Hash) Set(s string) error {
+	for i, name := range hashNames {
+		if name == s {
+			*h = Hash(i)
+			return nil
+		}
+	}
+	return UnknownHashError{Name: s}
+}
+
+// UnknownHashError is the type of error returned when a hash
+// algorithm is not supported.
+type UnknownHashError struct {
+	Hash Hash
+	// Name is set when parsing a name failed.
+	Name string
+}
+
+var _ error = UnknownHashError{}
+
+func (e UnknownHashError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("unknown hash algorithm: %q", e.Name)
+	}
+	return fmt.Sprintf("unknown hash algorithm: %d", uint8(e.Hash))
+}
@@ -0,0 +1,34 @@
+package cas_test
+
+import (
+	"flag"
+	"testing"
+
+	"bazil.org/bazil/cas"
+)
+
+var _ flag.Value = (*cas.Hash)(nil)
+
+func TestHashSet(t *testing.T) {
+	var h cas.Hash
+	if err := h.Set("sha512"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if g, e := h, cas.HashSHA512; g != e {
+		t.Errorf("wrong hash: %v != %v", g, e)
+	}
+	if g, e := h.String(), "sha512"; g != e {
+		t.Errorf("wrong name: %q != %q", g, e)
+	}
+}
+
+func TestHashSetUnknown(t *testing.T) {
+	var h cas.Hash
+	err := h.Set("md5")
+	if _, ok := err.(cas.UnknownHashError); !ok {
+		t.Fatalf("bad error: %v", err)
+	}
+	if g, e := h, cas.HashBLAKE2b; g != e {
+		t.Errorf("hash changed on error: %v != %v", g, e)
+	}
+}
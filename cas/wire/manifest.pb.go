@@ -23,6 +23,8 @@ type Manifest struct {
 	Size      uint64 `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
 	ChunkSize uint32 `protobuf:"varint,3,opt,name=chunkSize" json:"chunkSize,omitempty"`
 	Fanout    uint32 `protobuf:"varint,4,opt,name=fanout" json:"fanout,omitempty"`
+	// Hash algorithm of all chunks, see cas.Hash.
+	Hash uint32 `protobuf:"varint,5,opt,name=hash" json:"hash,omitempty"`
 }
 
 func (m *Manifest) Reset()         { *m = Manifest{} }
@@ -1,6 +1,8 @@
 package wire
 
 import (
+	"math"
+
 	"bazil.org/bazil/cas"
 	"bazil.org/bazil/cas/blobs"
 )
@@ -10,12 +12,16 @@ func (m *Manifest) ToBlob(type_ string) (*blobs.Manifest, error) {
 	if err := k.UnmarshalBinary(m.Root); err != nil {
 		return nil, err
 	}
+	if m.Hash > math.MaxUint8 || !cas.Hash(m.Hash).IsValid() {
+		return nil, cas.UnknownHashError{Hash: cas.Hash(m.Hash)}
+	}
 	manifest := &blobs.Manifest{
 		Type:      type_,
 		Root:      k,
 		Size:      m.Size,
 		ChunkSize: m.ChunkSize,
 		Fanout:    m.Fanout,
+		Hash:      cas.Hash(m.Hash),
 	}
 	return manifest, nil
 }
@@ -26,5 +32,6 @@ func FromBlob(m *blobs.Manifest) *Manifest {
 		Size:      m.Size,
 		ChunkSize: m.ChunkSize,
 		Fanout:    m.Fanout,
+		Hash:      uint32(m.Hash),
 	}
 }
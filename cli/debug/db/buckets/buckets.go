@@ -0,0 +1,64 @@
+package buckets
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/tokens"
+)
+
+type bucketsCommand struct {
+	subcommands.Description
+}
+
+func (c *bucketsCommand) Run() error {
+	app, err := server.New(clibazil.Bazil.Config.DataDir.String())
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(w, "BUCKET\tSINCE\tKEYS\n")
+	list := func(tx *db.Tx) error {
+		// registered buckets, even when missing
+		for _, r := range tokens.Buckets() {
+			b := tx.Bucket([]byte(r.Name))
+			if b == nil {
+				fmt.Fprintf(w, "%s\t%d\t-\n", r.Name, r.Since)
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\n", r.Name, r.Since, b.Stats().KeyN)
+		}
+		// anything else is unexpected
+		c := tx.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if _, ok := tokens.Lookup(tokens.ScopeTop, string(k)); ok {
+				continue
+			}
+			if v != nil {
+				fmt.Fprintf(w, "%q\tunregistered\tnot a bucket\n", k)
+				continue
+			}
+			fmt.Fprintf(w, "%q\tunregistered\t%d\n", k, tx.Bucket(k).Stats().KeyN)
+		}
+		return nil
+	}
+	if err := app.DB.View(list); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+var buckets = bucketsCommand{
+	Description: "list database buckets and their sizes",
+}
+
+func init() {
+	subcommands.Register(&buckets)
+}
@@ -3,10 +3,12 @@ package hash
 import (
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 
+	"bazil.org/bazil/cas"
 	"bazil.org/bazil/cas/chunks"
 	"bazil.org/bazil/cas/chunks/chunkutil"
 	"bazil.org/bazil/cliutil/subcommands"
@@ -15,6 +17,10 @@ import (
 type hashCommand struct {
 	subcommands.Description
 	subcommands.Synopsis
+	flag.FlagSet
+	Config struct {
+		Hash cas.Hash
+	}
 	Arguments struct {
 		Type  string
 		Level uint8
@@ -38,6 +44,7 @@ func (c *hashCommand) Run() error {
 		Type:  c.Arguments.Type,
 		Level: c.Arguments.Level,
 		Buf:   buf.Bytes(),
+		Hash:  c.Config.Hash,
 	}
 	key := chunkutil.Hash(chunk)
 	fmt.Printf("%s\n", key)
@@ -50,5 +57,6 @@ var hash = hashCommand{
 }
 
 func init() {
+	hash.Var(&hash.Config.Hash, "hash", "hash algorithm (blake2b, sha512)")
 	subcommands.Register(&hash)
 }
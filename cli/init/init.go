@@ -0,0 +1,197 @@
+// Package initcmd implements "bazil init". It is not named init, to
+// not confuse it with init functions.
+package initcmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type initCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Interactive bool
+		Volume      string
+		Mountpoint  string
+		Invite      string
+	}
+
+	in *bufio.Reader
+}
+
+// ask prompts for a value, with def used for an empty answer. When
+// not interactive, returns def right away.
+func (cmd *initCommand) ask(prompt string, def string) (string, error) {
+	if !cmd.Config.Interactive {
+		return def, nil
+	}
+	if def != "" {
+		fmt.Printf("%s [%s]: ", prompt, def)
+	} else {
+		fmt.Printf("%s: ", prompt)
+	}
+	line, err := cmd.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", errors.New("no answer given")
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def, nil
+	}
+	return line, nil
+}
+
+func (cmd *initCommand) Run() error {
+	cmd.in = bufio.NewReader(os.Stdin)
+	dataDir := clibazil.Bazil.Config.DataDir.String()
+	if cmd.Config.Interactive {
+		fmt.Printf("Setting up bazil in %s\n", dataDir)
+	}
+
+	app, err := server.New(dataDir)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	fmt.Printf("public key %s\n", (*peer.PublicKey)(app.Keys.Sign.Pub))
+
+	invite, err := cmd.ask("Invitation from another device (empty to skip)", cmd.Config.Invite)
+	if err != nil {
+		return err
+	}
+	var joined []string
+	if invite != "" {
+		joined, err = join(app, invite)
+		if err != nil {
+			return err
+		}
+		for _, name := range joined {
+			fmt.Printf("connected volume %s\n", name)
+		}
+	}
+
+	def := cmd.Config.Volume
+	if def == "" && cmd.Config.Interactive {
+		def = "default"
+		if len(joined) > 0 {
+			def = joined[0]
+		}
+	}
+	volumeName, err := cmd.ask("Volume to use (empty for none)", def)
+	if err != nil {
+		return err
+	}
+	if volumeName == "" {
+		return nil
+	}
+
+	def = cmd.Config.Mountpoint
+	if home := os.Getenv("HOME"); def == "" && home != "" && cmd.Config.Interactive {
+		def = filepath.Join(home, volumeName)
+	}
+	mountpoint, err := cmd.ask("Mount it at (empty to mount by hand)", def)
+	if err != nil {
+		return err
+	}
+	if mountpoint != "" {
+		mountpoint, err = filepath.Abs(mountpoint)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(mountpoint, 0755); err != nil {
+			return err
+		}
+	}
+
+	setup := func(tx *db.Tx) error {
+		vol, err := tx.Volumes().GetByName(volumeName)
+		switch err {
+		case nil:
+		case db.ErrVolNameNotFound:
+			sharingKey, err := tx.SharingKeys().Get("default")
+			if err != nil {
+				return err
+			}
+			vol, err = tx.Volumes().Create(volumeName, "local", sharingKey)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created volume %s\n", volumeName)
+		default:
+			return err
+		}
+		return vol.SetMountpoint(mountpoint)
+	}
+	if err := app.DB.Update(setup); err != nil {
+		return err
+	}
+	if mountpoint != "" {
+		fmt.Printf("volume %s is mounted at %s when the server runs\n", volumeName, mountpoint)
+	}
+	return nil
+}
+
+// join pairs with the device that made the invitation. The control
+// server is only needed for the duration of the call.
+func join(app *server.App, invite string) ([]string, error) {
+	c, err := control.New(app)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	go c.Serve()
+
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	resp, err := client.PairJoin(ctx, &wire.PairJoinRequest{Invite: invite})
+	if err != nil {
+		return nil, err
+	}
+	return resp.VolumeNames, nil
+}
+
+var initialize = initCommand{
+	Description: "set up a new data directory",
+	Overview: `
+
+Creates the data directory and the keys of this device, then
+optionally pairs with another device, and picks a volume to mount
+whenever "bazil server run" starts. Volumes that do not exist yet
+are created with local storage.
+
+With -interactive, asks for each of these in turn, offering the
+flags as defaults. Otherwise, only what the flags ask for is done.
+
+`,
+}
+
+func init() {
+	initialize.BoolVar(&initialize.Config.Interactive, "interactive", false, "ask questions to set things up")
+	initialize.StringVar(&initialize.Config.Volume, "volume", "", "volume to mount automatically")
+	initialize.StringVar(&initialize.Config.Mountpoint, "mountpoint", "", "where to mount the volume")
+	initialize.StringVar(&initialize.Config.Invite, "invite", "", "invitation from \"bazil pair invite\" to pair with")
+	subcommands.Register(&initialize)
+}
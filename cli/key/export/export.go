@@ -0,0 +1,44 @@
+package export
+
+import (
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/paperkey"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+)
+
+type exportCommand struct {
+	subcommands.Description
+}
+
+func (c *exportCommand) Run() error {
+	app, err := server.New(clibazil.Bazil.Config.DataDir.String())
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	b, err := app.PaperBackup()
+	if err != nil {
+		return err
+	}
+	text, err := paperkey.Encode(b)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("# bazil paper key for %s\n", (*peer.PublicKey)(app.Keys.Sign.Pub))
+	fmt.Printf("# anyone with this text can impersonate the node; keep it safe\n")
+	fmt.Println(text)
+	return nil
+}
+
+var export = exportCommand{
+	Description: "print node identity and sharing secrets for paper backup (server must be stopped)",
+}
+
+func init() {
+	subcommands.Register(&export)
+}
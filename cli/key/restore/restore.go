@@ -0,0 +1,56 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/paperkey"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+)
+
+type restoreCommand struct {
+	subcommands.Description
+}
+
+func (c *restoreCommand) Run() error {
+	dataDir := clibazil.Bazil.Config.DataDir.String()
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		if err != nil {
+			return err
+		}
+		return errors.New("data directory exists already; restore into a new one")
+	}
+
+	text, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	b, err := paperkey.Decode(string(text))
+	if err != nil {
+		return err
+	}
+
+	app, err := server.New(dataDir, server.RestoreIdentity(&b.Seed))
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+	if err := app.RestoreSharingKeys(b); err != nil {
+		return err
+	}
+	fmt.Printf("restored identity %s with %d sharing keys\n", (*peer.PublicKey)(app.Keys.Sign.Pub), len(b.Sharing))
+	return nil
+}
+
+var restore = restoreCommand{
+	Description: "create a data directory from a paper backup read from stdin",
+}
+
+func init() {
+	subcommands.Register(&restore)
+}
@@ -0,0 +1,83 @@
+package invite
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+// volumeNames is a flag that can be given multiple times.
+type volumeNames []string
+
+var _ flag.Value = (*volumeNames)(nil)
+
+func (v *volumeNames) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *volumeNames) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+type inviteCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Volumes volumeNames
+		Storage string
+		Valid   time.Duration
+	}
+	Arguments struct {
+		Addr string `positional:"metavar=HOST:PORT"`
+	}
+}
+
+func (cmd *inviteCommand) Run() error {
+	req := &wire.PairInviteRequest{
+		Addr:         cmd.Arguments.Addr,
+		VolumeNames:  cmd.Config.Volumes,
+		Storage:      cmd.Config.Storage,
+		ValidSeconds: uint32(cmd.Config.Valid / time.Second),
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PairInvite(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	fmt.Println(resp.Invite)
+	return nil
+}
+
+var invite = inviteCommand{
+	Description: "invite a new device to pair with this one",
+	Overview: `
+
+Prints an invitation to give to "bazil pair join" on the new device.
+The new device keeps an identity of its own, and is made a peer of
+this one with access to the given volumes. Anyone holding the
+invitation can use it once, until it expires.
+
+HOST:PORT is the address the new device can reach this server at.
+
+`,
+}
+
+func init() {
+	invite.Var(&invite.Config.Volumes, "volume", "volume to share with the new device (can repeat)")
+	invite.StringVar(&invite.Config.Storage, "storage", "local", "storage to offer to the new device")
+	invite.DurationVar(&invite.Config.Valid, "valid", time.Hour, "how long the invitation can be used for")
+	subcommands.Register(&invite)
+}
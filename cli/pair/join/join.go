@@ -0,0 +1,60 @@
+package join
+
+import (
+	"flag"
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type joinCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Backend string
+	}
+	Arguments struct {
+		Invite string `positional:"metavar=INVITE"`
+	}
+}
+
+func (cmd *joinCommand) Run() error {
+	req := &wire.PairJoinRequest{
+		Invite:  cmd.Arguments.Invite,
+		Backend: cmd.Config.Backend,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PairJoin(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, name := range resp.VolumeNames {
+		fmt.Printf("connected volume %s\n", name)
+	}
+	return nil
+}
+
+var join = joinCommand{
+	Description: "pair with another device using its invitation",
+	Overview: `
+
+INVITE is the output of "bazil pair invite" on the other device.
+The two devices become peers of each other, and the volumes shared
+in the invitation are connected here under the same names.
+
+`,
+}
+
+func init() {
+	join.StringVar(&join.Config.Backend, "backend", "", "storage for the joined volumes (default: the inviting device)")
+	subcommands.Register(&join)
+}
@@ -0,0 +1,62 @@
+package deletecmd
+
+import (
+	"fmt"
+	"os"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type deleteCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	Arguments struct {
+		Group string
+	}
+}
+
+func (cmd *deleteCommand) Run() error {
+	req := &wire.PeerGroupDeleteRequest{
+		Group: cmd.Arguments.Group,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerGroupDelete(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, r := range resp.Revoked {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(r.Pub); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		fmt.Printf("revoked\t%s\t%s\n", &pub, r.VolumeName)
+		if !r.Delivered {
+			fmt.Fprintf(os.Stderr, "peer %s could not be reached, it will be told later\n", &pub)
+		}
+	}
+	return nil
+}
+
+var deleteGroup = deleteCommand{
+	Description: "delete a peer group",
+	Overview: `
+
+The members stay peers, but lose the storage and volumes offered to
+them through the group. Members that can no longer see a volume are
+sent a signed notice, like with "bazil peer volume revoke".
+
+`,
+}
+
+func init() {
+	subcommands.Register(&deleteGroup)
+}
@@ -0,0 +1,54 @@
+package list
+
+import (
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type listCommand struct {
+	subcommands.Description
+}
+
+func (cmd *listCommand) Run() error {
+	req := &wire.PeerGroupListRequest{}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerGroupList(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, g := range resp.Groups {
+		fmt.Printf("%s\n", g.Name)
+		for _, buf := range g.Members {
+			var pub peer.PublicKey
+			if err := pub.UnmarshalBinary(buf); err != nil {
+				return fmt.Errorf("server sent bad public key: %v", err)
+			}
+			fmt.Printf("\tmember\t%s\n", &pub)
+		}
+		for _, backend := range g.Storage {
+			fmt.Printf("\tstorage\t%s\n", backend)
+		}
+		for _, name := range g.Volumes {
+			fmt.Printf("\tvolume\t%s\n", name)
+		}
+	}
+	return nil
+}
+
+var list = listCommand{
+	Description: "list peer groups with their members, storage and volumes",
+}
+
+func init() {
+	subcommands.Register(&list)
+}
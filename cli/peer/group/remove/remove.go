@@ -0,0 +1,42 @@
+package remove
+
+import (
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type removeCommand struct {
+	subcommands.Description
+	Arguments struct {
+		Group  string
+		PubKey peer.PublicKey
+	}
+}
+
+func (cmd *removeCommand) Run() error {
+	req := &wire.PeerGroupRemoveRequest{
+		Group: cmd.Arguments.Group,
+		Pub:   cmd.Arguments.PubKey[:],
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.PeerGroupRemove(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var remove = removeCommand{
+	Description: "remove a peer from a group",
+}
+
+func init() {
+	subcommands.Register(&remove)
+}
@@ -0,0 +1,41 @@
+package allow
+
+import (
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type allowCommand struct {
+	subcommands.Description
+	Arguments struct {
+		Group   string
+		Storage string
+	}
+}
+
+func (cmd *allowCommand) Run() error {
+	req := &wire.PeerGroupStorageAllowRequest{
+		Group:   cmd.Arguments.Group,
+		Backend: cmd.Arguments.Storage,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.PeerGroupStorageAllow(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var allow = allowCommand{
+	Description: "allow all peers in a group to use storage",
+}
+
+func init() {
+	subcommands.Register(&allow)
+}
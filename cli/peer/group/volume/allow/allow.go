@@ -0,0 +1,41 @@
+package allow
+
+import (
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type allowCommand struct {
+	subcommands.Description
+	Arguments struct {
+		Group      string
+		VolumeName string
+	}
+}
+
+func (cmd *allowCommand) Run() error {
+	req := &wire.PeerGroupVolumeAllowRequest{
+		Group:      cmd.Arguments.Group,
+		VolumeName: cmd.Arguments.VolumeName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.PeerGroupVolumeAllow(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var allow = allowCommand{
+	Description: "allow all peers in a group to see a volume",
+}
+
+func init() {
+	subcommands.Register(&allow)
+}
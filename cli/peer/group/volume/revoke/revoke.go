@@ -0,0 +1,64 @@
+package revoke
+
+import (
+	"fmt"
+	"os"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type revokeCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	Arguments struct {
+		Group      string
+		VolumeName string
+	}
+}
+
+func (cmd *revokeCommand) Run() error {
+	req := &wire.PeerGroupVolumeRevokeRequest{
+		Group:      cmd.Arguments.Group,
+		VolumeName: cmd.Arguments.VolumeName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerGroupVolumeRevoke(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, r := range resp.Revoked {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(r.Pub); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		fmt.Printf("revoked\t%s\n", &pub)
+		if !r.Delivered {
+			fmt.Fprintf(os.Stderr, "peer %s could not be reached, it will be told later\n", &pub)
+		}
+	}
+	return nil
+}
+
+var revoke = revokeCommand{
+	Description: "stop sharing a volume with a peer group",
+	Overview: `
+
+Members that can no longer see the volume, directly or through
+another group, are sent a signed notice, like with
+"bazil peer volume revoke".
+
+`,
+}
+
+func init() {
+	subcommands.Register(&revoke)
+}
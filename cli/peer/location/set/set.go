@@ -10,9 +10,10 @@ import (
 
 type setCommand struct {
 	subcommands.Description
+	subcommands.Overview
 	Arguments struct {
 		PubKey peer.PublicKey
-		Addr   string `positional:"metavar=HOST:PORT"`
+		Addr   string `positional:"metavar=ADDR"`
 	}
 }
 
@@ -35,6 +36,13 @@ func (cmd *setCommand) Run() error {
 
 var set = setCommand{
 	Description: "set network location for peer",
+	Overview: `
+
+ADDR is HOST:PORT for a peer reached over the network. A peer
+running on the same host can be reached with unix:PATH, where PATH
+is the socket named "peer" in its data directory.
+
+`,
 }
 
 func init() {
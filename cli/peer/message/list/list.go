@@ -0,0 +1,63 @@
+package list
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/positional"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type listCommand struct {
+	subcommands.Description
+	flag.FlagSet
+	Config struct {
+		Remove bool
+	}
+	Arguments struct {
+		positional.Optional
+		PubKey peer.PublicKey
+	}
+}
+
+func (cmd *listCommand) Run() error {
+	req := &wire.PeerMessageListRequest{
+		Remove: cmd.Config.Remove,
+	}
+	if cmd.Arguments.PubKey != (peer.PublicKey{}) {
+		req.Pub = cmd.Arguments.PubKey[:]
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerMessageList(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, msg := range resp.Messages {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(msg.Pub); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		sent := time.Unix(msg.Sent, 0).Format(time.RFC3339)
+		fmt.Printf("%s\t%s\t%s\t%s\n", &pub, sent, msg.Kind, msg.Body)
+	}
+	return nil
+}
+
+var list = listCommand{
+	Description: "list messages received from peers",
+}
+
+func init() {
+	list.BoolVar(&list.Config.Remove, "remove", false, "remove the listed messages")
+	subcommands.Register(&list)
+}
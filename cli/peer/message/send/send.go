@@ -0,0 +1,50 @@
+package send
+
+import (
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type sendCommand struct {
+	subcommands.Description
+	Arguments struct {
+		PubKey peer.PublicKey
+		Kind   string `positional:"metavar=KIND"`
+		Body   string `positional:"metavar=TEXT"`
+	}
+}
+
+func (cmd *sendCommand) Run() error {
+	req := &wire.PeerMessageSendRequest{
+		Pub:  cmd.Arguments.PubKey[:],
+		Kind: cmd.Arguments.Kind,
+		Body: []byte(cmd.Arguments.Body),
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerMessageSend(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	if !resp.Delivered {
+		fmt.Println("peer not reachable, message queued")
+	}
+	return nil
+}
+
+var send = sendCommand{
+	Description: "send a message to a peer (kinds: note, invitation, address, conflict)",
+}
+
+func init() {
+	subcommands.Register(&send)
+}
@@ -0,0 +1,54 @@
+package rekey
+
+import (
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type rekeyCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	Arguments struct {
+		PubKey    peer.PublicKey
+		NewPubKey peer.PublicKey
+	}
+}
+
+func (cmd *rekeyCommand) Run() error {
+	req := &wire.PeerRekeyRequest{
+		Pub:    cmd.Arguments.PubKey[:],
+		NewPub: cmd.Arguments.NewPubKey[:],
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.PeerRekey(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var rekey = rekeyCommand{
+	Description: "accept a new public key for a peer",
+	Overview: `
+
+Replaces the public key pinned for the peer. Everything else about
+the peer, such as its location, shared volumes and storage, and
+group memberships, stays as it was. Any key alert shown by "bazil
+peer status" is cleared.
+
+Only do this after making sure, out of band, that the new key really
+belongs to the peer.
+
+`,
+}
+
+func init() {
+	subcommands.Register(&rekey)
+}
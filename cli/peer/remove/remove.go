@@ -0,0 +1,79 @@
+package remove
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type removeCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		DryRun bool
+	}
+	Arguments struct {
+		PubKey peer.PublicKey
+	}
+}
+
+func (cmd *removeCommand) Run() error {
+	req := &wire.PeerRemoveRequest{
+		Pub:    cmd.Arguments.PubKey[:],
+		DryRun: cmd.Config.DryRun,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerRemove(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	verb := "removed"
+	if cmd.Config.DryRun {
+		verb = "would remove"
+	}
+	fmt.Printf("%s peer %v\n", verb, &cmd.Arguments.PubKey)
+	if len(resp.Volumes) > 0 {
+		fmt.Printf("volumes no longer shared: %s\n", strings.Join(resp.Volumes, " "))
+	}
+	if len(resp.Groups) > 0 {
+		fmt.Printf("removed from groups: %s\n", strings.Join(resp.Groups, " "))
+	}
+	if resp.UndeliveredMessages > 0 {
+		fmt.Printf("undelivered messages dropped: %d\n", resp.UndeliveredMessages)
+	}
+	return nil
+}
+
+var remove = removeCommand{
+	Description: "forget a peer",
+	Overview: `
+
+Forgets the peer, along with its location and the volumes and
+storage offered to it, and removes it from all peer groups. The
+peer can be added again later, but it gets a new identity in
+vector clocks.
+
+A peer that volumes store their chunks on cannot be removed.
+
+With -dry-run, only reports which volumes and groups the peer would
+be cut off from.
+
+`,
+}
+
+func init() {
+	remove.BoolVar(&remove.Config.DryRun, "dry-run", false, "only report what would be removed")
+	subcommands.Register(&remove)
+}
@@ -0,0 +1,73 @@
+package status
+
+import (
+	"fmt"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type statusCommand struct {
+	subcommands.Description
+	subcommands.Overview
+}
+
+func (cmd *statusCommand) Run() error {
+	req := &wire.PeerStatusRequest{}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerStatus(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, p := range resp.Peers {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(p.Pub); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		location := p.Location
+		if location == "" {
+			location = "-"
+		}
+		fmt.Printf("%s\t%d\t%s\n", &pub, p.Id, location)
+		if a := p.KeyAlert; a != nil {
+			var other peer.PublicKey
+			if err := other.UnmarshalBinary(a.Pub); err != nil {
+				return fmt.Errorf("server sent bad public key: %v", err)
+			}
+			first := time.Unix(a.First, 0).Format(time.RFC3339)
+			last := time.Unix(a.Last, 0).Format(time.RFC3339)
+			fmt.Printf("\tWARNING: %s answered at %s instead, %d times between %s and %s\n",
+				&other, a.Addr, a.Count, first, last)
+		}
+	}
+	return nil
+}
+
+var status = statusCommand{
+	Description: "show known peers and key alerts",
+	Overview: `
+
+Lists the known peers, with the public key pinned for each, its peer
+ID and network location.
+
+When a peer is dialed and a different public key answers, the
+connection is refused and a warning is shown here until dealt with.
+This could be an attacker impersonating the peer, or the peer may
+have been set up again with a new key. Only in the latter case, use
+"bazil peer rekey" to accept the new key.
+
+`,
+}
+
+func init() {
+	subcommands.Register(&status)
+}
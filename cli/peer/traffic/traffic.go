@@ -0,0 +1,104 @@
+package traffic
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/positional"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type trafficCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Days  uint
+		Daily bool
+	}
+	Arguments struct {
+		positional.Optional
+		PubKey peer.PublicKey
+	}
+}
+
+type total struct {
+	pub    string
+	volume string
+}
+
+func (cmd *trafficCommand) Run() error {
+	req := &wire.PeerTrafficRequest{
+		Days: uint32(cmd.Config.Days),
+	}
+	if cmd.Arguments.PubKey != (peer.PublicKey{}) {
+		req.Pub = cmd.Arguments.PubKey[:]
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerTraffic(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+
+	var order []total
+	totals := make(map[total]*wire.PeerTraffic)
+	for _, t := range resp.Traffic {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(t.Pub); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		volume := t.VolumeName
+		if volume == "" {
+			volume = "-"
+		}
+		if cmd.Config.Daily {
+			day := time.Unix(int64(t.Day)*24*60*60, 0).UTC().Format("2006-01-02")
+			fmt.Printf("%s\t%s\t%s\t%d\t%d\n", day, &pub, volume, t.Sent, t.Received)
+			continue
+		}
+		k := total{pub: pub.String(), volume: volume}
+		sum, ok := totals[k]
+		if !ok {
+			sum = &wire.PeerTraffic{}
+			totals[k] = sum
+			order = append(order, k)
+		}
+		sum.Sent += t.Sent
+		sum.Received += t.Received
+	}
+	for _, k := range order {
+		sum := totals[k]
+		fmt.Printf("%s\t%s\t%d\t%d\n", k.pub, k.volume, sum.Sent, sum.Received)
+	}
+	return nil
+}
+
+var traffic = trafficCommand{
+	Description: "show bytes transferred with peers",
+	Overview: `
+
+Shows how many bytes were sent to and received from each peer, per
+volume. Traffic for storing and fetching chunks for a peer is not tied
+to a volume, and is shown with volume "-".
+
+Counts are kept per day, for up to 90 days. Without -daily, the
+counts over the reported days are summed up.
+
+`,
+}
+
+func init() {
+	traffic.UintVar(&traffic.Config.Days, "days", 30, "number of days to report, including today")
+	traffic.BoolVar(&traffic.Config.Daily, "daily", false, "report each day separately")
+	subcommands.Register(&traffic)
+}
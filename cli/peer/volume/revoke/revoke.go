@@ -0,0 +1,63 @@
+package revoke
+
+import (
+	"fmt"
+	"os"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type revokeCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	Arguments struct {
+		PubKey     peer.PublicKey
+		VolumeName string
+	}
+}
+
+func (cmd *revokeCommand) Run() error {
+	req := &wire.PeerVolumeRevokeRequest{
+		Pub:        cmd.Arguments.PubKey[:],
+		VolumeName: cmd.Arguments.VolumeName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.PeerVolumeRevoke(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	if resp.GroupAccess {
+		fmt.Fprintf(os.Stderr, "peer can still see the volume through a peer group\n")
+		return nil
+	}
+	if !resp.Delivered {
+		fmt.Fprintf(os.Stderr, "peer could not be reached, it will be told later\n")
+	}
+	return nil
+}
+
+var revoke = revokeCommand{
+	Description: "stop sharing a volume with a peer",
+	Overview: `
+
+The peer is sent a signed notice, and stops syncing the volume from
+this node. Allowing the volume again undoes the revocation.
+
+Only sharing with the peer directly is revoked. If the peer can
+still see the volume through a peer group, it is not told anything.
+
+`,
+}
+
+func init() {
+	subcommands.Register(&revoke)
+}
@@ -1,19 +1,26 @@
 package run
 
 import (
+	"errors"
 	"flag"
 	"log"
+	"math"
 	"net"
+	"os"
 	"sync"
+	"syscall"
+	"time"
 
 	clibazil "bazil.org/bazil/cli"
 	"bazil.org/bazil/cliutil/flagx"
 	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/fs"
 	"bazil.org/bazil/server"
 	"bazil.org/bazil/server/control"
 	"bazil.org/bazil/server/http"
 	"bazil.org/bazil/tokens"
 	"bazil.org/bazil/util/trylisten"
+	"golang.org/x/net/context"
 )
 
 type tcpAddr struct {
@@ -34,22 +41,71 @@ type runCommand struct {
 	Config struct {
 		Addr    tcpAddr
 		AnyPort bool
+		Tier    struct {
+			Backend string
+			After   time.Duration
+		}
+		MaxOpenFiles uint64
+		Write        struct {
+			MaxPending    int
+			LatencyTarget time.Duration
+		}
+		Limits struct {
+			MaxFileSize  uint64
+			MaxNameBytes uint
+			MaxDepth     uint
+		}
+		StatsMaxAge time.Duration
+		MaxPause    time.Duration
 	}
 }
 
+// How long to keep the control socket open after an upgrade is
+// requested, so the reply gets through.
+const upgradeGrace = 500 * time.Millisecond
+
 func (cmd *runCommand) Run() error {
+	exe, err := cmd.serve()
+	if err != nil {
+		return err
+	}
+	log.Printf("upgrading to %s", exe)
+	return syscall.Exec(exe, os.Args, os.Environ())
+}
+
+// serve runs the server until it fails or an upgrade is requested,
+// and returns the executable to upgrade to.
+func (cmd *runCommand) serve() (upgrade string, err error) {
 	var options []server.AppOption
 	if clibazil.Bazil.Config.Debug {
 		options = append(options, server.Debug(clibazil.Bazil.Log.Event))
 	}
+	if cmd.Config.MaxOpenFiles != 0 {
+		options = append(options, server.HandleLimit(cmd.Config.MaxOpenFiles))
+	}
+	options = append(options, server.WriteThrottle(cmd.Config.Write.MaxPending, cmd.Config.Write.LatencyTarget))
+	if cmd.Config.Limits.MaxNameBytes > math.MaxUint32 || cmd.Config.Limits.MaxDepth > math.MaxUint32 {
+		return "", errors.New("name length and depth limits must fit in 32 bits")
+	}
+	options = append(options, server.Limits(fs.Limits{
+		MaxFileSize:  cmd.Config.Limits.MaxFileSize,
+		MaxNameBytes: uint32(cmd.Config.Limits.MaxNameBytes),
+		MaxDepth:     uint32(cmd.Config.Limits.MaxDepth),
+	}))
+	if cmd.Config.Tier.Backend != "" {
+		options = append(options, server.Tiering(cmd.Config.Tier.Backend, cmd.Config.Tier.After))
+	}
+	if cmd.Config.StatsMaxAge != 0 {
+		options = append(options, server.StatsReplica(cmd.Config.StatsMaxAge))
+	}
+	options = append(options, server.MaxPause(cmd.Config.MaxPause))
 	app, err := server.New(clibazil.Bazil.Config.DataDir.String(), options...)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer app.Close()
 
-	errCh := make(chan error)
-	var wg sync.WaitGroup
+	errCh := make(chan error, 3)
 
 	listenTCP := net.ListenTCP
 	if cmd.Config.AnyPort {
@@ -57,37 +113,128 @@ func (cmd *runCommand) Run() error {
 	}
 	l, err := listenTCP("tcp", cmd.Config.Addr.Addr)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	w, err := http.New(app, l)
 	if err != nil {
-		return err
+		return "", err
 	}
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
 		defer w.Close()
 		errCh <- w.Serve()
 	}()
 
+	u, err := http.NewUnix(app)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		defer u.Close()
+		errCh <- u.Serve()
+	}()
+
 	c, err := control.New(app)
 	if err != nil {
-		return err
+		return "", err
 	}
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
 		defer c.Close()
 		errCh <- c.Serve()
 	}()
 
-	log.Printf("Listening on %s", w.Addr())
+	if cmd.Config.Tier.Backend != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			demoteLoop(ctx, app, cmd.Config.Tier.After)
+		}()
+		// stop demoting before the app is closed
+		defer wg.Wait()
+		defer cancel()
+	}
+
+	go deliverLoop(app)
+
+	log.Printf("Listening on %s and %s%s", w.Addr(), server.UnixAddrPrefix, u.Addr())
+
+	report := func(name string, mountpoint string, err error) {
+		if err != nil {
+			log.Printf("mounting volume %s at %s: %v", name, mountpoint, err)
+			return
+		}
+		log.Printf("mounted volume %s at %s", name, mountpoint)
+	}
+	if err := app.Automount(report); err != nil {
+		log.Printf("finding volumes to mount: %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		// We only care about the first error; the rest are likely to
+		// be about closed listeners.
+		return "", err
+	case exe := <-app.UpgradeRequested():
+		w.Close()
+		u.Close()
+		// give the upgrade request a moment to be answered before
+		// the process is replaced
+		time.Sleep(upgradeGrace)
+		c.Close()
+		return exe, nil
+	}
+}
+
+// demoteLoop demotes cold chunks periodically, until ctx is
+// canceled.
+func demoteLoop(ctx context.Context, app *server.App, after time.Duration) {
+	// no point checking much more often than the access time
+	// granularity of a day
+	interval := after / 4
+	if interval < time.Hour {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := app.DemoteColdChunks(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("demoting cold chunks: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("demoted %d cold chunks", n)
+		}
+	}
+}
 
-	wg.Wait()
-	// We only care about the first error; the rest are likely to be
-	// about closed listeners.
-	return <-errCh
+// deliverLoop retries delivery of peer messages that could not be
+// delivered right away.
+func deliverLoop(app *server.App) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for range ticker.C {
+		pubs, err := app.PendingMessages()
+		if err != nil {
+			log.Printf("finding pending peer messages: %v", err)
+			continue
+		}
+		for _, pub := range pubs {
+			if err := app.DeliverMessages(context.Background(), pub); err != nil {
+				log.Printf("delivering messages to %v: %v", pub, err)
+			}
+		}
+	}
 }
 
 var run = runCommand{
@@ -100,5 +247,15 @@ func init() {
 	}
 	run.Var(&run.Config.Addr, "addr", "TCP address to listen on, also sets -any-port=false")
 	run.BoolVar(&run.Config.AnyPort, "any-port", true, "find a free port if port was taken")
+	run.Uint64Var(&run.Config.MaxOpenFiles, "max-open-files", 0, "limit on open files per volume, 0 for no limit")
+	run.IntVar(&run.Config.Write.MaxPending, "max-pending-writes", 64, "writes processed at once per volume before writers wait, 0 for no limit")
+	run.DurationVar(&run.Config.Write.LatencyTarget, "write-latency-target", 50*time.Millisecond, "slow down writes while storage is slower than this, 0 to never")
+	run.Uint64Var(&run.Config.Limits.MaxFileSize, "max-file-size", 0, "largest file size in bytes, 0 for no limit")
+	run.UintVar(&run.Config.Limits.MaxNameBytes, "max-name-bytes", 255, "longest file name in bytes")
+	run.UintVar(&run.Config.Limits.MaxDepth, "max-depth", 0, "deepest directory nesting, 0 for no limit")
+	run.StringVar(&run.Config.Tier.Backend, "tier-backend", "", "storage backend to demote cold chunks to")
+	run.DurationVar(&run.Config.Tier.After, "tier-after", 30*24*time.Hour, "demote chunks not accessed for this long")
+	run.DurationVar(&run.Config.StatsMaxAge, "stats-max-age", 0, "answer statistics from a copy of the database refreshed when older than this, 0 to read the live database")
+	run.DurationVar(&run.Config.MaxPause, "max-pause", 10*time.Second, "longest time maintenance may hold back requests to a volume")
 	subcommands.Register(&run)
 }
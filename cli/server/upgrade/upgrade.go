@@ -0,0 +1,62 @@
+package upgrade
+
+import (
+	"fmt"
+	"path/filepath"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type upgradeCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	Arguments struct {
+		Executable string `positional:"metavar=EXECUTABLE"`
+	}
+}
+
+func (cmd *upgradeCommand) Run() error {
+	exe, err := filepath.Abs(cmd.Arguments.Executable)
+	if err != nil {
+		return err
+	}
+	req := &wire.ServerUpgradeRequest{
+		Executable: exe,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.ServerUpgrade(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, mnt := range resp.Unmounted {
+		fmt.Printf("unmounted %s\n", mnt)
+	}
+	return nil
+}
+
+var upgrade = upgradeCommand{
+	Description: "replace the running server with a new executable",
+	Overview: `
+
+The server stops accepting new mounts and unmounts its volumes, then
+replaces itself with EXECUTABLE, keeping its command line. Volumes
+with a mountpoint configured are mounted again by the new server;
+others need to be mounted by hand.
+
+If a volume cannot be unmounted, for example because it is in use,
+the upgrade is called off.
+
+`,
+}
+
+func init() {
+	subcommands.Register(&upgrade)
+}
@@ -1,18 +1,47 @@
 package version
 
 import (
+	"flag"
 	"fmt"
+	"runtime"
+	"strings"
 
+	clibazil "bazil.org/bazil/cli"
 	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
 	v "bazil.org/bazil/version"
+	"golang.org/x/net/context"
 )
 
 type versionCommand struct {
 	subcommands.Description
+	flag.FlagSet
+	Config struct {
+		Remote bool
+	}
 }
 
 func (c *versionCommand) Run() error {
-	fmt.Println(v.Version)
+	if !c.Config.Remote {
+		fmt.Println(v.Version)
+		return nil
+	}
+
+	fmt.Printf("client: %s (%s %s/%s)\n", v.Version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("client features: %s\n", strings.Join(v.Features, " "))
+
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.Version(ctx, &wire.VersionRequest{})
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	fmt.Printf("server: %s (%s %s/%s)\n", resp.Version, resp.GoVersion, resp.Os, resp.Arch)
+	fmt.Printf("server features: %s\n", strings.Join(resp.Features, " "))
 	return nil
 }
 
@@ -21,5 +50,6 @@ var version = versionCommand{
 }
 
 func init() {
+	version.BoolVar(&version.Config.Remote, "remote", false, "also ask the running server")
 	subcommands.Register(&version)
 }
@@ -0,0 +1,68 @@
+// Package appendonly implements "bazil volume append-only".
+package appendonly
+
+import (
+	"flag"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type appendOnlyCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Off bool
+	}
+	Arguments struct {
+		VolumeName string
+		Path       string
+	}
+}
+
+func (cmd *appendOnlyCommand) Run() error {
+	req := &wire.VolumeAppendOnlySetRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Path:       cmd.Arguments.Path,
+		Off:        cmd.Config.Off,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.VolumeAppendOnlySet(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var appendOnly = appendOnlyCommand{
+	Description: "merge concurrent appends to a file",
+	Overview: `
+
+Marks a file, or every file below a directory, as append-only. When
+such a file has been changed both here and on a peer, syncing
+combines the two versions instead of leaving a conflict: the last
+version both sides had comes first, followed by what each side
+added. The additions are put in the same order on every peer. A file
+that is open while syncing is merged once it is closed.
+
+Only use this for files that are never modified other than by
+appending, such as logs. A file that was rewritten ends up as a mix
+of both versions.
+
+The mark only affects syncing into this node, but a merged version
+replaces the older ones on peers without the mark, too.
+
+`,
+}
+
+func init() {
+	appendOnly.BoolVar(&appendOnly.Config.Off, "off", false, "remove the mark")
+	subcommands.Register(&appendOnly)
+}
@@ -0,0 +1,74 @@
+package barrier
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type barrierCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Peers   uint
+		Timeout time.Duration
+	}
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *barrierCommand) Run() error {
+	req := &wire.VolumeSyncBarrierRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Peers:      uint32(cmd.Config.Peers),
+	}
+	ctx := context.Background()
+	if cmd.Config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cmd.Config.Timeout)
+		defer cancel()
+	}
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumeSyncBarrier(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, buf := range resp.Peers {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(buf); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		fmt.Println(pub.String())
+	}
+	return nil
+}
+
+var barrier = barrierCommand{
+	Description: "wait until peers have pulled recent writes",
+	Overview: `
+
+Waits until the given number of peers have pulled everything written
+to the volume so far, and prints their public keys. A peer counts
+once it confirms having saved what it pulled. Peers pull on their
+own schedule, with "bazil volume sync"; this only waits.
+
+`,
+}
+
+func init() {
+	barrier.UintVar(&barrier.Config.Peers, "peers", 1, "number of peers to wait for")
+	barrier.DurationVar(&barrier.Config.Timeout, "timeout", 0, "give up after this long, 0 to wait forever")
+	subcommands.Register(&barrier)
+}
@@ -0,0 +1,64 @@
+package changes
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/positional"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type changesCommand struct {
+	subcommands.Description
+	flag.FlagSet
+	Config struct {
+		Follow bool
+	}
+	Arguments struct {
+		VolumeName string
+		positional.Optional
+		After uint64
+	}
+}
+
+func (cmd *changesCommand) Run() error {
+	req := &wire.VolumeChangesRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		After:      cmd.Arguments.After,
+		Follow:     cmd.Config.Follow,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	stream, err := client.VolumeChanges(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d\t%d\t%v\t%d\t%q\t%d\n", msg.Seq, msg.Tx, msg.Op, msg.ParentInode, msg.Name, msg.Inode)
+	}
+	return nil
+}
+
+var changes = changesCommand{
+	Description: "list changes made to a volume",
+}
+
+func init() {
+	changes.BoolVar(&changes.Config.Follow, "follow", false, "keep waiting for new changes")
+	subcommands.Register(&changes)
+}
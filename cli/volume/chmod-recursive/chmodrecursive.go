@@ -0,0 +1,118 @@
+// Package chmodrecursive implements "bazil volume chmod-recursive".
+package chmodrecursive
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/positional"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type chmodCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		FilesOnly bool
+		DirsOnly  bool
+		UndoName  string
+	}
+	Arguments struct {
+		VolumeName string
+		Mode       string `positional:"metavar=MODE"`
+		positional.Optional
+		Path string
+	}
+}
+
+// parseMode parses an octal mode, optionally prefixed with + or - to
+// only add or remove those bits.
+func parseMode(s string) (clearBits, setBits uint32, err error) {
+	op := byte('=')
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		op = s[0]
+		s = s[1:]
+	}
+	bits, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || bits&^07777 != 0 {
+		return 0, 0, errors.New("mode must be octal, like 755, +111 or -022")
+	}
+	switch op {
+	case '+':
+		return 0, uint32(bits), nil
+	case '-':
+		return uint32(bits), 0, nil
+	}
+	return 07777, uint32(bits), nil
+}
+
+func (cmd *chmodCommand) Run() error {
+	clearBits, setBits, err := parseMode(cmd.Arguments.Mode)
+	if err != nil {
+		return err
+	}
+	req := &wire.VolumePermRewriteRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Path:       cmd.Arguments.Path,
+		ClearMode:  clearBits,
+		SetMode:    setBits,
+		FilesOnly:  cmd.Config.FilesOnly,
+		DirsOnly:   cmd.Config.DirsOnly,
+		UndoName:   cmd.Config.UndoName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	stream, err := client.VolumePermRewrite(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return errors.New("server did not finish the change")
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Done {
+			fmt.Printf("changed %d entries, undo name %s\n", msg.Changed, msg.UndoName)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "%d entries, %d changed\n", msg.Seen, msg.Changed)
+	}
+}
+
+var chmod = chmodCommand{
+	Description: "change permissions of a directory tree",
+	Overview: `
+
+Changes the permission bits of everything under PATH in the volume,
+or the whole volume if PATH is not given. MODE is octal; prefix it
+with + or - to only add or remove those bits.
+
+The change is made directly on the metadata stored by the server, in
+a single transaction. A record of the old permissions is kept, and
+"bazil volume perm-undo" puts them back. Permissions are local to
+this server, and are not synced to peers.
+
+`,
+}
+
+func init() {
+	chmod.BoolVar(&chmod.Config.FilesOnly, "files-only", false, "only change files")
+	chmod.BoolVar(&chmod.Config.DirsOnly, "dirs-only", false, "only change directories")
+	chmod.StringVar(&chmod.Config.UndoName, "undo-name", "", "name for the undo record (default is the current time)")
+	subcommands.Register(&chmod)
+}
@@ -0,0 +1,128 @@
+// Package chownrecursive implements "bazil volume chown-recursive".
+package chownrecursive
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/positional"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type chownCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		FilesOnly bool
+		DirsOnly  bool
+		UndoName  string
+	}
+	Arguments struct {
+		VolumeName string
+		Owner      string `positional:"metavar=UID[:GID]"`
+		positional.Optional
+		Path string
+	}
+}
+
+var errOwner = errors.New("owner must be numeric, like 1000, 1000:100 or :100")
+
+func parseID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, errOwner
+	}
+	return uint32(id), nil
+}
+
+func (cmd *chownCommand) Run() error {
+	req := &wire.VolumePermRewriteRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Path:       cmd.Arguments.Path,
+		FilesOnly:  cmd.Config.FilesOnly,
+		DirsOnly:   cmd.Config.DirsOnly,
+		UndoName:   cmd.Config.UndoName,
+	}
+	owner, group := cmd.Arguments.Owner, ""
+	if i := strings.IndexByte(owner, ':'); i >= 0 {
+		owner, group = owner[:i], owner[i+1:]
+		if group == "" {
+			return errOwner
+		}
+	}
+	if owner != "" {
+		uid, err := parseID(owner)
+		if err != nil {
+			return err
+		}
+		req.ChangeOwner = true
+		req.Uid = uid
+	}
+	if group != "" {
+		gid, err := parseID(group)
+		if err != nil {
+			return err
+		}
+		req.ChangeGroup = true
+		req.Gid = gid
+	}
+	if !req.ChangeOwner && !req.ChangeGroup {
+		return errOwner
+	}
+
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	stream, err := client.VolumePermRewrite(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return errors.New("server did not finish the change")
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Done {
+			fmt.Printf("changed %d entries, undo name %s\n", msg.Changed, msg.UndoName)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "%d entries, %d changed\n", msg.Seen, msg.Changed)
+	}
+}
+
+var chown = chownCommand{
+	Description: "change ownership of a directory tree",
+	Overview: `
+
+Changes the owner, group, or both of everything under PATH in the
+volume, or the whole volume if PATH is not given. Only numeric IDs
+are accepted, as names may map to different IDs on other machines.
+
+Like chmod-recursive, this rewrites the metadata stored by the server
+in a single transaction, and keeps a record that "bazil volume
+perm-undo" can use to put the old ownership back.
+
+`,
+}
+
+func init() {
+	chown.BoolVar(&chown.Config.FilesOnly, "files-only", false, "only change files")
+	chown.BoolVar(&chown.Config.DirsOnly, "dirs-only", false, "only change directories")
+	chown.StringVar(&chown.Config.UndoName, "undo-name", "", "name for the undo record (default is the current time)")
+	subcommands.Register(&chown)
+}
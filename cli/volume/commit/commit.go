@@ -0,0 +1,96 @@
+package commit
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+// removals is a flag that can be given multiple times.
+type removals []string
+
+var _ flag.Value = (*removals)(nil)
+
+func (r *removals) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *removals) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+type commitCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Remove removals
+	}
+	Arguments struct {
+		VolumeName string
+		Files      []string `positional:"metavar=PATH=FILE"`
+	}
+}
+
+func (cmd *commitCommand) Run() error {
+	req := &wire.VolumeCommitRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+	}
+	for _, arg := range cmd.Arguments.Files {
+		idx := strings.IndexByte(arg, '=')
+		if idx < 0 {
+			return fmt.Errorf("expected PATH=FILE: %q", arg)
+		}
+		data, err := ioutil.ReadFile(arg[idx+1:])
+		if err != nil {
+			return err
+		}
+		req.Changes = append(req.Changes, &wire.VolumeCommitChange{
+			Path: arg[:idx],
+			Data: data,
+		})
+	}
+	for _, p := range cmd.Config.Remove {
+		req.Changes = append(req.Changes, &wire.VolumeCommitChange{
+			Path:   p,
+			Remove: true,
+		})
+	}
+	if len(req.Changes) == 0 {
+		return errors.New("nothing to commit")
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.VolumeCommit(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var commit = commitCommand{
+	Description: "write several files in a volume atomically",
+	Overview: `
+
+Each PATH in the volume gets the contents of the local FILE. Either
+all of the changes become visible to snapshots and sync, or none of
+them do.
+
+`,
+}
+
+func init() {
+	commit.Var(&commit.Config.Remove, "remove", "path to remove as part of the commit (can repeat)")
+	subcommands.Register(&commit)
+}
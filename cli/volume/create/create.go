@@ -3,6 +3,7 @@ package create
 import (
 	"flag"
 
+	"bazil.org/bazil/cas"
 	clibazil "bazil.org/bazil/cli"
 	"bazil.org/bazil/cliutil/subcommands"
 	"bazil.org/bazil/server/control/wire"
@@ -15,6 +16,7 @@ type createCommand struct {
 	Config struct {
 		Backend string
 		Sharing string
+		Hash    cas.Hash
 	}
 	Arguments struct {
 		VolumeName string
@@ -26,6 +28,7 @@ func (cmd *createCommand) Run() error {
 		VolumeName:     cmd.Arguments.VolumeName,
 		Backend:        cmd.Config.Backend,
 		SharingKeyName: cmd.Config.Sharing,
+		Hash:           cmd.Config.Hash.String(),
 	}
 	ctx := context.Background()
 	client, err := clibazil.Bazil.Control()
@@ -46,5 +49,6 @@ var create = createCommand{
 func init() {
 	create.StringVar(&create.Config.Backend, "backend", "local", "storage backend to use")
 	create.StringVar(&create.Config.Sharing, "sharing", "default", "sharing group to encrypt content for")
+	create.Var(&create.Config.Hash, "hash", "hash algorithm for content (blake2b, sha512)")
 	subcommands.Register(&create)
 }
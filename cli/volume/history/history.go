@@ -0,0 +1,82 @@
+package history
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type historyCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Restore int64
+	}
+	Arguments struct {
+		VolumeName string
+		Path       string
+	}
+}
+
+func (cmd *historyCommand) Run() error {
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+
+	if cmd.Config.Restore != 0 {
+		req := &wire.VolumeRestoreRequest{
+			VolumeName: cmd.Arguments.VolumeName,
+			Path:       cmd.Arguments.Path,
+			Version:    cmd.Config.Restore,
+		}
+		if _, err := client.VolumeRestore(ctx, req); err != nil {
+			// TODO unwrap error
+			return err
+		}
+		return nil
+	}
+
+	req := &wire.VolumeHistoryRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Path:       cmd.Arguments.Path,
+	}
+	resp, err := client.VolumeHistory(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, v := range resp.Versions {
+		t := time.Unix(0, v.Version)
+		fmt.Printf("%d\t%s\t%d\n", v.Version, t.Format(time.RFC3339), v.Size)
+	}
+	return nil
+}
+
+var history = historyCommand{
+	Description: "list or restore earlier versions of a file",
+	Overview: `
+
+Lists the recent versions of the file at PATH in the volume, oldest
+first, as VERSION, time saved and size. The last one is the current
+content. A new version is remembered every time the file is saved
+with different content; only the most recent ones are kept.
+
+With -restore=VERSION, the file gets the content of that version
+back. Restoring does not lose anything; the content it replaces
+stays in the history.
+
+`,
+}
+
+func init() {
+	history.Int64Var(&history.Config.Restore, "restore", 0, "version to restore, as listed")
+	subcommands.Register(&history)
+}
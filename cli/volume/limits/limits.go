@@ -0,0 +1,66 @@
+package limits
+
+import (
+	"errors"
+	"flag"
+	"math"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type limitsCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		MaxFileSize  uint64
+		MaxNameBytes uint
+		MaxDepth     uint
+	}
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *limitsCommand) Run() error {
+	if cmd.Config.MaxNameBytes > math.MaxUint32 || cmd.Config.MaxDepth > math.MaxUint32 {
+		return errors.New("name length and depth limits must fit in 32 bits")
+	}
+	req := &wire.VolumeLimitsSetRequest{
+		VolumeName:   cmd.Arguments.VolumeName,
+		MaxFileSize:  cmd.Config.MaxFileSize,
+		MaxNameBytes: uint32(cmd.Config.MaxNameBytes),
+		MaxDepth:     uint32(cmd.Config.MaxDepth),
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.VolumeLimitsSet(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var limits = limitsCommand{
+	Description: "set size and name limits of a volume",
+	Overview: `
+
+Replaces the limits of the volume with the ones given. Limits left
+out, or set to 0, follow the limits "bazil server run" was started
+with.
+
+`,
+}
+
+func init() {
+	limits.Uint64Var(&limits.Config.MaxFileSize, "max-file-size", 0, "largest file size in bytes")
+	limits.UintVar(&limits.Config.MaxNameBytes, "max-name-bytes", 0, "longest file name in bytes")
+	limits.UintVar(&limits.Config.MaxDepth, "max-depth", 0, "deepest directory nesting")
+	subcommands.Register(&limits)
+}
@@ -1,6 +1,12 @@
 package mount
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
 	clibazil "bazil.org/bazil/cli"
 	"bazil.org/bazil/cliutil/flagx"
 	"bazil.org/bazil/cliutil/subcommands"
@@ -8,18 +14,98 @@ import (
 	"golang.org/x/net/context"
 )
 
+func parseAccess(s string) (wire.VolumeMountRequest_Access, error) {
+	v, ok := wire.VolumeMountRequest_Access_value[strings.ToUpper(s)]
+	if !ok {
+		return 0, fmt.Errorf("unknown access level %q, want none, read or owner", s)
+	}
+	return wire.VolumeMountRequest_Access(v), nil
+}
+
+type accessFlag wire.VolumeMountRequest_Access
+
+var _ flag.Value = (*accessFlag)(nil)
+
+func (a *accessFlag) String() string {
+	return strings.ToLower(wire.VolumeMountRequest_Access(*a).String())
+}
+
+func (a *accessFlag) Set(value string) error {
+	v, err := parseAccess(value)
+	if err != nil {
+		return err
+	}
+	*a = accessFlag(v)
+	return nil
+}
+
+// userAccess is a flag of the form UID=LEVEL that can be given
+// multiple times.
+type userAccess map[uint32]wire.VolumeMountRequest_Access
+
+var _ flag.Value = (*userAccess)(nil)
+
+func (u *userAccess) String() string {
+	var s []string
+	for uid, a := range *u {
+		s = append(s, fmt.Sprintf("%d=%s", uid, strings.ToLower(a.String())))
+	}
+	return strings.Join(s, ",")
+}
+
+func (u *userAccess) Set(value string) error {
+	idx := strings.IndexByte(value, '=')
+	if idx < 0 {
+		return errors.New("expected UID=LEVEL")
+	}
+	uid, err := strconv.ParseUint(value[:idx], 10, 32)
+	if err != nil {
+		return fmt.Errorf("bad UID: %v", err)
+	}
+	a, err := parseAccess(value[idx+1:])
+	if err != nil {
+		return err
+	}
+	if *u == nil {
+		*u = make(userAccess)
+	}
+	(*u)[uint32(uid)] = a
+	return nil
+}
+
 type mountCommand struct {
 	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		AllowOther bool
+		Others     accessFlag
+		Users      userAccess
+	}
 	Arguments struct {
-		VolumeName string
+		VolumeName string `positional:"metavar=VOLUME[@SNAPSHOT]"`
 		Mountpoint flagx.AbsPath
 	}
 }
 
 func (cmd *mountCommand) Run() error {
+	if !cmd.Config.AllowOther && (cmd.Config.Others != accessFlag(wire.VolumeMountRequest_NONE) || len(cmd.Config.Users) > 0) {
+		return errors.New("access for other users needs -allow-other")
+	}
+	volumeName, snapshot := cmd.Arguments.VolumeName, ""
+	if idx := strings.LastIndex(volumeName, "@"); idx >= 0 {
+		volumeName, snapshot = volumeName[:idx], volumeName[idx+1:]
+		if snapshot == "" {
+			return errors.New("missing snapshot name after @")
+		}
+	}
 	req := &wire.VolumeMountRequest{
-		VolumeName: cmd.Arguments.VolumeName,
+		VolumeName: volumeName,
+		Snapshot:   snapshot,
 		Mountpoint: cmd.Arguments.Mountpoint.String(),
+		AllowOther: cmd.Config.AllowOther,
+		Others:     wire.VolumeMountRequest_Access(cmd.Config.Others),
+		Users:      cmd.Config.Users,
 	}
 	ctx := context.Background()
 	client, err := clibazil.Bazil.Control()
@@ -35,8 +121,19 @@ func (cmd *mountCommand) Run() error {
 
 var mount = mountCommand{
 	Description: "mount a volume",
+	Overview: `
+
+VOLUME@SNAPSHOT mounts the named snapshot of the volume instead, as a
+read-only filesystem of its own, separate from the live volume. The
+name is split at the last @. Snapshots are only visible to the user
+running the server.
+
+`,
 }
 
 func init() {
+	mount.BoolVar(&mount.Config.AllowOther, "allow-other", false, "let other local users access the mount")
+	mount.Var(&mount.Config.Others, "others", "access for other local users: none, read or owner")
+	mount.Var(&mount.Config.Users, "user", "access for a specific local user, as UID=LEVEL (can repeat)")
 	subcommands.Register(&mount)
 }
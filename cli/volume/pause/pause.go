@@ -0,0 +1,65 @@
+package pause
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type pauseCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		For time.Duration
+	}
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *pauseCommand) Run() error {
+	if cmd.Config.For <= 0 {
+		return errors.New("pause duration must be positive")
+	}
+	req := &wire.VolumePauseRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Duration:   int64(cmd.Config.For),
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	if _, err := client.VolumePause(ctx, req); err != nil {
+		// TODO unwrap error
+		return err
+	}
+	return nil
+}
+
+var pause = pauseCommand{
+	Description: "briefly hold back requests to a volume",
+	Overview: `
+
+Makes file access through the mounts of the volume wait, for
+maintenance that must not race with applications. Requests are
+queued, not failed, and continue once the pause is over; the command
+returns then. The pause starts counting once requests already in
+progress are done. Interrupting the command ends the pause early.
+
+A pause never lasts longer than the server allows with -max-pause,
+after which requests go through regardless.
+
+`,
+}
+
+func init() {
+	pause.DurationVar(&pause.Config.For, "for", 5*time.Second, "how long to pause for")
+	subcommands.Register(&pause)
+}
@@ -0,0 +1,46 @@
+// Package permundo implements "bazil volume perm-undo".
+package permundo
+
+import (
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type undoCommand struct {
+	subcommands.Description
+	Arguments struct {
+		VolumeName string
+		UndoName   string
+	}
+}
+
+func (cmd *undoCommand) Run() error {
+	req := &wire.VolumePermUndoRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		UndoName:   cmd.Arguments.UndoName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumePermUndo(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	fmt.Printf("restored %d entries\n", resp.Restored)
+	return nil
+}
+
+var undo = undoCommand{
+	Description: "undo a chmod-recursive or chown-recursive",
+}
+
+func init() {
+	subcommands.Register(&undo)
+}
@@ -0,0 +1,80 @@
+package publish
+
+import (
+	"flag"
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type publishCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		Off bool
+	}
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *publishCommand) Run() error {
+	req := &wire.VolumePublishRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Off:        cmd.Config.Off,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumePublish(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	if cmd.Config.Off {
+		return nil
+	}
+	var id db.PublicID
+	if err := id.UnmarshalBinary(resp.PublicID); err != nil {
+		return err
+	}
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(resp.Publisher); err != nil {
+		return err
+	}
+	fmt.Printf("public-id\t%s\npublisher\t%s\n", &id, &pub)
+	return nil
+}
+
+var publish = publishCommand{
+	Description: "publish the snapshots of a volume",
+	Overview: `
+
+Makes the snapshots of the volume readable by anyone who knows its
+public identifier, including nodes that are not configured as peers.
+Consumers fetch a feed listing the snapshots, signed by this node,
+and the chunks the snapshots refer to. The current contents of the
+volume are never served, only what has been snapshotted.
+
+Prints the public identifier and the public key of this node; hand
+both to consumers. Publishing the volume again later reuses the same
+identifier.
+
+Snapshots made before usage was tracked are listed in the feed, but
+their contents cannot be fetched.
+
+`,
+}
+
+func init() {
+	publish.BoolVar(&publish.Config.Off, "off", false, "stop publishing")
+	subcommands.Register(&publish)
+}
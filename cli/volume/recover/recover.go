@@ -0,0 +1,71 @@
+// Package recovercmd implements "bazil volume recover". It is not
+// named recover, to not shadow the builtin.
+package recovercmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/positional"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type recoverCommand struct {
+	subcommands.Description
+	flag.FlagSet
+	Config struct {
+		FromPeer peer.PublicKey
+		Backend  string
+		Sharing  string
+	}
+	Arguments struct {
+		VolumeName string
+		positional.Optional
+		LocalVolumeName string
+	}
+}
+
+func (cmd *recoverCommand) Run() error {
+	if cmd.Config.FromPeer == (peer.PublicKey{}) {
+		return errors.New("peer to recover from must be given with -from-peer")
+	}
+	localVolumeName := cmd.Arguments.LocalVolumeName
+	if localVolumeName == "" {
+		localVolumeName = cmd.Arguments.VolumeName
+	}
+	req := &wire.VolumeRecoverRequest{
+		Pub:             cmd.Config.FromPeer[:],
+		VolumeName:      cmd.Arguments.VolumeName,
+		LocalVolumeName: localVolumeName,
+		Backend:         cmd.Config.Backend,
+		SharingKeyName:  cmd.Config.Sharing,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumeRecover(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	fmt.Printf("recovered %d directories, %d entries\n", resp.Dirs, resp.Dirents)
+	return nil
+}
+
+var recoverCmd = recoverCommand{
+	Description: "rebuild a lost volume from a copy held by a peer",
+}
+
+func init() {
+	recoverCmd.Var(&recoverCmd.Config.FromPeer, "from-peer", "public key of the peer holding a copy of the volume")
+	recoverCmd.StringVar(&recoverCmd.Config.Backend, "backend", "", "storage backend to use (default: storage offered by the peer)")
+	recoverCmd.StringVar(&recoverCmd.Config.Sharing, "sharing", "default", "sharing group the content is encrypted for")
+	subcommands.Register(&recoverCmd)
+}
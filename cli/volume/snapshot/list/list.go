@@ -0,0 +1,61 @@
+package list
+
+import (
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type listCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *listCommand) Run() error {
+	req := &wire.VolumeSnapshotListRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumeSnapshotList(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for _, s := range resp.Snapshots {
+		if !s.UsageKnown {
+			fmt.Printf("%s\t-\t-\n", s.Name)
+			continue
+		}
+		fmt.Printf("%s\t%d\t%d\n", s.Name, s.TotalBytes, s.ExclusiveBytes)
+	}
+	return nil
+}
+
+var list = listCommand{
+	Description: "list snapshots of a volume",
+	Overview: `
+
+Lists the snapshots of the volume, with the total bytes each one
+refers to, and how many bytes no other snapshot refers to. Deleting
+the snapshot frees at most the latter, as the live volume may still
+use some of that data. Snapshots made before usage was tracked show
+"-".
+
+Snapshots are deleted with "bazil volume snapshot remove".
+
+`,
+}
+
+func init() {
+	subcommands.Register(&list)
+}
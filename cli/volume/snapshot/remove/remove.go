@@ -0,0 +1,76 @@
+package remove
+
+import (
+	"flag"
+	"fmt"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type removeCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		DryRun bool
+	}
+	Arguments struct {
+		VolumeName string
+		Names      []string `positional:"metavar=SNAPSHOT"`
+	}
+}
+
+func (cmd *removeCommand) Run() error {
+	req := &wire.VolumeSnapshotRemoveRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+		Names:      cmd.Arguments.Names,
+		DryRun:     cmd.Config.DryRun,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumeSnapshotRemove(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	verb := "removed"
+	if cmd.Config.DryRun {
+		verb = "would remove"
+	}
+	atLeast := ""
+	if !resp.UsageKnown {
+		atLeast = "at least "
+	}
+	fmt.Printf("%s %d snapshots referring to %s%d bytes, %s%d bytes of it not in other snapshots\n",
+		verb, len(req.Names),
+		atLeast, resp.TotalBytes,
+		atLeast, resp.ExclusiveBytes,
+	)
+	return nil
+}
+
+var remove = removeCommand{
+	Description: "remove snapshots of a volume",
+	Overview: `
+
+Removes the named snapshots, and reports how much stored data they
+referred to, and how much of it no remaining snapshot refers to.
+At most that much is freed; less, if the live volume still uses
+some of it.
+
+With -dry-run, only reports the sizes without removing anything.
+Sizes are not known for snapshots made before usage was tracked.
+
+`,
+}
+
+func init() {
+	remove.BoolVar(&remove.Config.DryRun, "dry-run", false, "only report what would be removed")
+	subcommands.Register(&remove)
+}
@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type statsCommand struct {
+	subcommands.Description
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *statsCommand) Run() error {
+	req := &wire.VolumeStatsRequest{
+		VolumeName: cmd.Arguments.VolumeName,
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	resp, err := client.VolumeStats(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	limit := "none"
+	if resp.HandleLimit != 0 {
+		limit = fmt.Sprint(resp.HandleLimit)
+	}
+	fmt.Printf("open files:\t%d\n", resp.OpenHandles)
+	fmt.Printf("limit:\t%s\n", limit)
+	fmt.Printf("peak:\t%d\n", resp.PeakHandles)
+	fmt.Printf("refused:\t%d\n", resp.RefusedHandles)
+	fmt.Printf("pending writes:\t%d\n", resp.PendingWrites)
+	fmt.Printf("throttled writes:\t%d\n", resp.ThrottledWrites)
+	fmt.Printf("write latency:\t%v\n", time.Duration(resp.WriteLatency))
+	fmt.Printf("paused:\t%v\n", resp.Paused)
+	fmt.Printf("queued requests:\t%d\n", resp.QueuedRequests)
+	fmt.Printf("expired pauses:\t%d\n", resp.ExpiredPauses)
+	return nil
+}
+
+var stats = statsCommand{
+	Description: "show volume statistics",
+}
+
+func init() {
+	subcommands.Register(&stats)
+}
@@ -1,6 +1,12 @@
 package sync
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
 	clibazil "bazil.org/bazil/cli"
 	"bazil.org/bazil/cliutil/positional"
 	"bazil.org/bazil/cliutil/subcommands"
@@ -9,8 +15,34 @@ import (
 	"golang.org/x/net/context"
 )
 
+// pubKeys is a flag that can be given multiple times.
+type pubKeys []peer.PublicKey
+
+var _ flag.Value = (*pubKeys)(nil)
+
+func (p *pubKeys) String() string {
+	var s []string
+	for i := range *p {
+		s = append(s, (*p)[i].String())
+	}
+	return strings.Join(s, ",")
+}
+
+func (p *pubKeys) Set(value string) error {
+	var pub peer.PublicKey
+	if err := pub.Set(value); err != nil {
+		return err
+	}
+	*p = append(*p, pub)
+	return nil
+}
+
 type syncCommand struct {
 	subcommands.Description
+	flag.FlagSet
+	Config struct {
+		Also pubKeys
+	}
 	Arguments struct {
 		VolumeName string
 		PubKey     peer.PublicKey
@@ -24,15 +56,38 @@ func (cmd *syncCommand) Run() error {
 		Pub:        cmd.Arguments.PubKey[:],
 		VolumeName: cmd.Arguments.VolumeName,
 	}
+	for i := range cmd.Config.Also {
+		req.Pubs = append(req.Pubs, cmd.Config.Also[i][:])
+	}
 	ctx := context.Background()
 	client, err := clibazil.Bazil.Control()
 	if err != nil {
 		return err
 	}
-	if _, err := client.VolumeSync(ctx, req); err != nil {
+	resp, err := client.VolumeSync(ctx, req)
+	if err != nil {
 		// TODO unwrap error
 		return err
 	}
+	if len(resp.Results) <= 1 {
+		return nil
+	}
+	failed := 0
+	for _, r := range resp.Results {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(r.Pub); err != nil {
+			return fmt.Errorf("server sent bad public key: %v", err)
+		}
+		if r.Error != "" {
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %s\n", &pub, r.Error)
+			continue
+		}
+		fmt.Printf("%s: %d entries\n", &pub, r.Dirents)
+	}
+	if failed > 0 {
+		return errors.New("sync failed with some peers")
+	}
 	return nil
 }
 
@@ -41,5 +96,6 @@ var sync = syncCommand{
 }
 
 func init() {
+	sync.Var(&sync.Config.Also, "also", "public key of another peer to sync from concurrently (can repeat)")
 	subcommands.Register(&sync)
 }
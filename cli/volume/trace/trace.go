@@ -0,0 +1,94 @@
+package trace
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	clibazil "bazil.org/bazil/cli"
+	"bazil.org/bazil/cliutil/subcommands"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+)
+
+type traceCommand struct {
+	subcommands.Description
+	subcommands.Overview
+	flag.FlagSet
+	Config struct {
+		SampleEvery  uint64
+		MaxPerSecond uint
+	}
+	Arguments struct {
+		VolumeName string
+	}
+}
+
+func (cmd *traceCommand) Run() error {
+	if cmd.Config.MaxPerSecond > math.MaxUint32 {
+		return errors.New("rate limit must fit in 32 bits")
+	}
+	req := &wire.VolumeTraceRequest{
+		VolumeName:   cmd.Arguments.VolumeName,
+		SampleEvery:  cmd.Config.SampleEvery,
+		MaxPerSecond: uint32(cmd.Config.MaxPerSecond),
+	}
+	ctx := context.Background()
+	client, err := clibazil.Bazil.Control()
+	if err != nil {
+		return err
+	}
+	stream, err := client.VolumeTrace(ctx, req)
+	if err != nil {
+		// TODO unwrap error
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Dropped > 0 {
+			fmt.Printf("(%d events dropped)\n", msg.Dropped)
+		}
+		start := time.Unix(0, msg.Start)
+		fmt.Printf("%s\t%v\t%s\n", start.Format("15:04:05.000000"), time.Duration(msg.Duration), msg.Request)
+		for _, call := range msg.Kv {
+			result := "ok"
+			if call.Error != "" {
+				result = call.Error
+			}
+			fmt.Printf("\t%v\t%s\t%s %x %d bytes\n", time.Duration(call.Duration), result, call.Op, call.Key, call.Size)
+		}
+		if msg.KvOmitted > 0 {
+			fmt.Printf("\t(%d more storage calls)\n", msg.KvOmitted)
+		}
+	}
+}
+
+var trace = traceCommand{
+	Description: "show requests made to a mounted volume",
+	Overview: `
+
+Prints FUSE requests served for the volume as they complete, with
+their arguments, how long they took, and the storage calls they made.
+Tracing is on only while this command runs.
+
+Busy volumes serve many requests; use -sample and -max-rate to see
+only some of them. Requests are never slowed down for tracing, events
+are dropped instead.
+
+`,
+}
+
+func init() {
+	trace.Uint64Var(&trace.Config.SampleEvery, "sample", 1, "trace one in this many requests")
+	trace.UintVar(&trace.Config.MaxPerSecond, "max-rate", 100, "most requests to trace per second, 0 for no limit")
+	subcommands.Register(&trace)
+}
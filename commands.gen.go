@@ -7,21 +7,58 @@ import (
 	_ "bazil.org/bazil/cli/debug/cas/chunk/add"
 	_ "bazil.org/bazil/cli/debug/cas/chunk/get"
 	_ "bazil.org/bazil/cli/debug/clock/decode"
+	_ "bazil.org/bazil/cli/debug/db/buckets"
 	_ "bazil.org/bazil/cli/debug/hash"
 	_ "bazil.org/bazil/cli/debug/peer/ping"
 	_ "bazil.org/bazil/cli/debug/pubkey"
+	_ "bazil.org/bazil/cli/init"
+	_ "bazil.org/bazil/cli/key/export"
+	_ "bazil.org/bazil/cli/key/restore"
+	_ "bazil.org/bazil/cli/pair/invite"
+	_ "bazil.org/bazil/cli/pair/join"
 	_ "bazil.org/bazil/cli/peer/add"
+	_ "bazil.org/bazil/cli/peer/group/add"
+	_ "bazil.org/bazil/cli/peer/group/delete"
+	_ "bazil.org/bazil/cli/peer/group/list"
+	_ "bazil.org/bazil/cli/peer/group/remove"
+	_ "bazil.org/bazil/cli/peer/group/storage/allow"
+	_ "bazil.org/bazil/cli/peer/group/volume/allow"
+	_ "bazil.org/bazil/cli/peer/group/volume/revoke"
 	_ "bazil.org/bazil/cli/peer/location/set"
+	_ "bazil.org/bazil/cli/peer/message/list"
+	_ "bazil.org/bazil/cli/peer/message/send"
+	_ "bazil.org/bazil/cli/peer/rekey"
+	_ "bazil.org/bazil/cli/peer/remove"
+	_ "bazil.org/bazil/cli/peer/status"
 	_ "bazil.org/bazil/cli/peer/storage/allow"
+	_ "bazil.org/bazil/cli/peer/traffic"
 	_ "bazil.org/bazil/cli/peer/volume/allow"
+	_ "bazil.org/bazil/cli/peer/volume/revoke"
 	_ "bazil.org/bazil/cli/pubkey"
 	_ "bazil.org/bazil/cli/server/ping"
 	_ "bazil.org/bazil/cli/server/run"
+	_ "bazil.org/bazil/cli/server/upgrade"
 	_ "bazil.org/bazil/cli/sharing/add"
 	_ "bazil.org/bazil/cli/version"
+	_ "bazil.org/bazil/cli/volume/append-only"
+	_ "bazil.org/bazil/cli/volume/barrier"
+	_ "bazil.org/bazil/cli/volume/changes"
+	_ "bazil.org/bazil/cli/volume/chmod-recursive"
+	_ "bazil.org/bazil/cli/volume/chown-recursive"
+	_ "bazil.org/bazil/cli/volume/commit"
 	_ "bazil.org/bazil/cli/volume/connect"
 	_ "bazil.org/bazil/cli/volume/create"
+	_ "bazil.org/bazil/cli/volume/history"
+	_ "bazil.org/bazil/cli/volume/limits"
 	_ "bazil.org/bazil/cli/volume/mount"
+	_ "bazil.org/bazil/cli/volume/pause"
+	_ "bazil.org/bazil/cli/volume/perm-undo"
+	_ "bazil.org/bazil/cli/volume/publish"
+	_ "bazil.org/bazil/cli/volume/recover"
+	_ "bazil.org/bazil/cli/volume/snapshot/list"
+	_ "bazil.org/bazil/cli/volume/snapshot/remove"
+	_ "bazil.org/bazil/cli/volume/stats"
 	_ "bazil.org/bazil/cli/volume/storage/add"
 	_ "bazil.org/bazil/cli/volume/sync"
+	_ "bazil.org/bazil/cli/volume/trace"
 )
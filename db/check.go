@@ -0,0 +1,210 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strings"
+
+	"bazil.org/bazil/db/wire"
+	"github.com/golang/protobuf/proto"
+)
+
+// CorruptError is returned by Open when the database has
+// inconsistencies that cannot be repaired automatically.
+type CorruptError struct {
+	Problems []string
+}
+
+var _ error = (*CorruptError)(nil)
+
+func (e *CorruptError) Error() string {
+	return "database is corrupt:\n\t" + strings.Join(e.Problems, "\n\t")
+}
+
+// checker gathers problems found while checking the database.
+type checker struct {
+	tx *Tx
+	// problems that need a human
+	problems []string
+	// trivially fixable problems, applied after the walk so buckets
+	// are not modified while iterating them
+	repairs []repair
+}
+
+type repair struct {
+	desc string
+	fn   func() error
+}
+
+func (c *checker) problem(format string, args ...interface{}) {
+	c.problems = append(c.problems, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) repair(desc string, fn func() error) {
+	c.repairs = append(c.repairs, repair{desc: desc, fn: fn})
+}
+
+// check verifies the invariants between the top-level buckets, and
+// fixes the ones that can be fixed without losing information.
+//
+// If serious problems are found, returns a *CorruptError and repairs
+// nothing.
+func (tx *Tx) check() error {
+	c := &checker{tx: tx}
+	c.checkPeers()
+	c.checkVolumes()
+	if len(c.problems) > 0 {
+		return &CorruptError{Problems: c.problems}
+	}
+	for _, r := range c.repairs {
+		log.Printf("db repair: %s", r.desc)
+		if err := r.fn(); err != nil {
+			return fmt.Errorf("db repair failed: %s: %v", r.desc, err)
+		}
+	}
+	return nil
+}
+
+func (c *checker) checkPeers() {
+	peers := c.tx.Bucket(bucketPeer)
+	ids := c.tx.Bucket(bucketPeerID)
+
+	// every peer has an ID, and the ID maps back to the peer
+	seen := make(map[uint32][]byte)
+	cur := peers.Cursor()
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		pub := append([]byte(nil), k...)
+		if v != nil {
+			c.problem("peer %x: not a bucket", pub)
+			continue
+		}
+		idKey := peers.Bucket(k).Get(peerStateID)
+		if len(idKey) != 4 {
+			c.problem("peer %x: missing or malformed id", pub)
+			continue
+		}
+		id := binary.BigEndian.Uint32(idKey)
+		if other, ok := seen[id]; ok {
+			c.problem("peers %x and %x share id %d", other, pub, id)
+			continue
+		}
+		seen[id] = pub
+
+		idKey = append([]byte(nil), idKey...)
+		switch indexed := ids.Get(idKey); {
+		case indexed == nil:
+			c.repair(fmt.Sprintf("restoring id index for peer %x", pub), func() error {
+				return ids.Put(idKey, pub)
+			})
+		case !bytes.Equal(indexed, pub):
+			c.problem("peer id %d refers to %x, but peer %x claims it", id, indexed, pub)
+		}
+	}
+
+	// every ID refers to a peer, or is a tombstone
+	cur = ids.Cursor()
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		if len(k) != 4 {
+			c.problem("peer id index: malformed key %x", k)
+			continue
+		}
+		if len(v) == 0 {
+			// tombstone
+			continue
+		}
+		if peers.Bucket(v) != nil {
+			continue
+		}
+		idKey := append([]byte(nil), k...)
+		c.repair(fmt.Sprintf("tombstoning id %d of missing peer %x", binary.BigEndian.Uint32(k), v), func() error {
+			return ids.Put(idKey, []byte{})
+		})
+	}
+}
+
+func (c *checker) checkVolumes() {
+	volumes := c.tx.Bucket(bucketVolume)
+	names := c.tx.Bucket(bucketVolName)
+	sharing := c.tx.Bucket(bucketSharing)
+
+	// every name refers to a volume
+	named := make(map[string]bool)
+	cur := names.Cursor()
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		if volumes.Bucket(v) == nil {
+			name := append([]byte(nil), k...)
+			c.repair(fmt.Sprintf("removing name %q of missing volume %x", name, v), func() error {
+				return names.Delete(name)
+			})
+			continue
+		}
+		named[string(v)] = true
+	}
+
+	cur = volumes.Cursor()
+	for k, v := cur.First(); k != nil; k, v = cur.Next() {
+		volID := append([]byte(nil), k...)
+		if v != nil {
+			c.problem("volume %x: not a bucket", volID)
+			continue
+		}
+		if !named[string(volID)] {
+			// unreachable, but harmless
+			log.Printf("db check: volume %x has no name", volID)
+		}
+
+		bv := volumes.Bucket(k)
+		for _, required := range [][]byte{
+			volumeStateDir,
+			volumeStateInode,
+			volumeStateSnap,
+			volumeStateStorage,
+			volumeStateClock,
+		} {
+			if bv.Bucket(required) == nil {
+				c.problem("volume %x: missing %s", volID, required)
+			}
+		}
+		// bookkeeping that can start over empty
+		for _, optional := range [][]byte{
+			volumeStateConflict,
+			volumeStateJournal,
+			volumeStateSnapChunks,
+			volumeStateChunkRef,
+			volumeStateHistory,
+			volumeStateRevoked,
+			volumeStatePermUndo,
+			volumeStateAppendOnly,
+			volumeStateHistoryClock,
+		} {
+			if bv.Bucket(optional) == nil {
+				name := optional
+				c.repair(fmt.Sprintf("volume %x: recreating %s", volID, name), func() error {
+					_, err := bv.CreateBucket(name)
+					return err
+				})
+			}
+		}
+		if len(bv.Get(volumeStateEpoch)) == 0 {
+			c.problem("volume %x: missing epoch", volID)
+		}
+
+		storage := bv.Bucket(volumeStateStorage)
+		if storage == nil {
+			continue
+		}
+		sc := storage.Cursor()
+		for sk, sv := sc.First(); sk != nil; sk, sv = sc.Next() {
+			var conf wire.VolumeStorage
+			if err := proto.Unmarshal(sv, &conf); err != nil {
+				c.problem("volume %x: storage %q: %v", volID, sk, err)
+				continue
+			}
+			if sharing.Get([]byte(conf.SharingKeyName)) == nil {
+				c.problem("volume %x: storage %q uses missing sharing key %q", volID, sk, conf.SharingKeyName)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package db_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/tokens"
+	"github.com/boltdb/bolt"
+)
+
+// reopen closes the database and opens it again, running the
+// startup checks.
+func reopen(t testing.TB, DB *TestDB) (*db.DB, error) {
+	path := DB.Path()
+	DB.DB.Close()
+	return db.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Nanosecond})
+}
+
+func TestCheckRepairsPeerIDIndex(t *testing.T) {
+	DB := NewTestDB(t)
+	defer os.Remove(DB.Path())
+
+	pub := &peer.PublicKey{0x42, 0x42, 0x42}
+	setup := func(tx *db.Tx) error {
+		if _, err := tx.Peers().Make(pub); err != nil {
+			return err
+		}
+		// lose the index entry
+		return tx.Bucket([]byte(tokens.BucketPeerID)).Delete([]byte{0, 0, 0, 1})
+	}
+	if err := DB.Update(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := reopen(t, DB)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+
+	check := func(tx *db.Tx) error {
+		v := tx.Bucket([]byte(tokens.BucketPeerID)).Get([]byte{0, 0, 0, 1})
+		if g, e := string(v), string(pub[:]); g != e {
+			t.Errorf("peer id index not repaired: %x != %x", g, e)
+		}
+		return nil
+	}
+	if err := d.View(check); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckRemovesDanglingVolumeName(t *testing.T) {
+	DB := NewTestDB(t)
+	defer os.Remove(DB.Path())
+
+	setup := func(tx *db.Tx) error {
+		return tx.Bucket([]byte(tokens.BucketVolName)).Put([]byte("ghost"), []byte("no-such-volume"))
+	}
+	if err := DB.Update(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := reopen(t, DB)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer d.Close()
+
+	check := func(tx *db.Tx) error {
+		if _, err := tx.Volumes().GetByName("ghost"); err != db.ErrVolNameNotFound {
+			t.Errorf("dangling volume name not removed: %v", err)
+		}
+		return nil
+	}
+	if err := d.View(check); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckRefusesDuplicatePeerID(t *testing.T) {
+	DB := NewTestDB(t)
+	defer os.Remove(DB.Path())
+
+	pub1 := &peer.PublicKey{0x42, 0x42, 0x42}
+	pub2 := &peer.PublicKey{0xC0, 0xFF, 0xEE}
+	setup := func(tx *db.Tx) error {
+		if _, err := tx.Peers().Make(pub1); err != nil {
+			return err
+		}
+		if _, err := tx.Peers().Make(pub2); err != nil {
+			return err
+		}
+		// make the second peer claim the first peer's ID
+		b := tx.Bucket([]byte(tokens.BucketPeer)).Bucket(pub2[:])
+		return b.Put([]byte(tokens.PeerStateID), []byte{0, 0, 0, 1})
+	}
+	if err := DB.Update(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := reopen(t, DB)
+	if err == nil {
+		d.Close()
+		t.Fatal("expected error")
+	}
+	if _, ok := err.(*db.CorruptError); !ok {
+		t.Fatalf("expected CorruptError, got %T: %v", err, err)
+	}
+}
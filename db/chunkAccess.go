@@ -0,0 +1,90 @@
+package db
+
+import (
+	"encoding/binary"
+
+	"bazil.org/bazil/tokens"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	bucketChunkAccess = []byte(tokens.BucketChunkAccess)
+)
+
+const (
+	chunkAccessLocal   byte = 0
+	chunkAccessDemoted byte = 1
+)
+
+func (tx *Tx) initChunkAccess() error {
+	if _, err := tx.CreateBucketIfNotExists(bucketChunkAccess); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ChunkAccess tracks when values in the local chunk store were last
+// accessed.
+func (tx *Tx) ChunkAccess() *ChunkAccess {
+	b := tx.Bucket(bucketChunkAccess)
+	return &ChunkAccess{b}
+}
+
+type ChunkAccess struct {
+	b *bolt.Bucket
+}
+
+func (ca *ChunkAccess) put(key []byte, day uint32, state byte) error {
+	var buf [5]byte
+	binary.BigEndian.PutUint32(buf[:4], day)
+	buf[4] = state
+	return ca.b.Put(key, buf[:])
+}
+
+// Touch records that the value for key was accessed on day, counted
+// in days since the Unix epoch. Values previously marked as demoted
+// are considered local again.
+func (ca *ChunkAccess) Touch(key []byte, day uint32) error {
+	if v := ca.b.Get(key); len(v) == 5 &&
+		binary.BigEndian.Uint32(v[:4]) >= day &&
+		v[4] == chunkAccessLocal {
+		// nothing to do
+		return nil
+	}
+	return ca.put(key, day, chunkAccessLocal)
+}
+
+// Demoted marks the value for key as moved out of the local chunk
+// store.
+func (ca *ChunkAccess) Demoted(key []byte) error {
+	v := ca.b.Get(key)
+	var day uint32
+	if len(v) == 5 {
+		day = binary.BigEndian.Uint32(v[:4])
+	}
+	return ca.put(key, day, chunkAccessDemoted)
+}
+
+// Cold calls fn for every local value last accessed before day.
+//
+// key is valid during the call to fn only. fn must not modify the
+// database.
+func (ca *ChunkAccess) Cold(day uint32, fn func(key []byte) error) error {
+	c := ca.b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(v) != 5 {
+			// corrupt entry; treat it as recently used, to be safe
+			continue
+		}
+		if v[4] != chunkAccessLocal {
+			continue
+		}
+		if binary.BigEndian.Uint32(v[:4]) >= day {
+			continue
+		}
+		if err := fn(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -29,15 +29,33 @@ func Open(path string, mode os.FileMode, options *bolt.Options) (*DB, error) {
 // init sets up the initial database contents. It is guaranteed to be
 // idempotent and safe to run on pre-existing databases.
 func (db *DB) init(tx *Tx) error {
+	if err := tx.initSchema(); err != nil {
+		return err
+	}
 	if err := tx.initVolumes(); err != nil {
 		return err
 	}
 	if err := tx.initPeers(); err != nil {
 		return err
 	}
+	if err := tx.initPeerGroups(); err != nil {
+		return err
+	}
 	if err := tx.initSharingKeys(); err != nil {
 		return err
 	}
+	if err := tx.initChunkAccess(); err != nil {
+		return err
+	}
+	if err := tx.initPairInvites(); err != nil {
+		return err
+	}
+	if err := tx.initTraffic(); err != nil {
+		return err
+	}
+	if err := tx.check(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"encoding/binary"
+	"time"
+
+	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	ErrPairInviteNotFound = errkind.New(errkind.NotFound, "pairing invitation not found or expired")
+)
+
+var (
+	bucketPairInvite       = []byte(tokens.BucketPairInvite)
+	pairInviteStateExpires = []byte(tokens.PairInviteStateExpires)
+	pairInviteStateVolume  = []byte(tokens.PairInviteStateVolume)
+	pairInviteStateStorage = []byte(tokens.PairInviteStateStorage)
+)
+
+func (tx *Tx) initPairInvites() error {
+	if _, err := tx.CreateBucketIfNotExists(bucketPairInvite); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PairInvites returns the pending invitations for new devices to pair
+// with this node.
+func (tx *Tx) PairInvites() *PairInvites {
+	b := tx.Bucket(bucketPairInvite)
+	return &PairInvites{b}
+}
+
+type PairInvites struct {
+	b *bolt.Bucket
+}
+
+// Add a new invitation, valid until expires. Anyone who knows the
+// token can use the invitation, once.
+func (b *PairInvites) Add(token []byte, expires time.Time) (*PairInvite, error) {
+	bi, err := b.b.CreateBucket(token)
+	if err != nil {
+		return nil, err
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(expires.Unix()))
+	if err := bi.Put(pairInviteStateExpires, buf[:]); err != nil {
+		return nil, err
+	}
+	if _, err := bi.CreateBucket(pairInviteStateVolume); err != nil {
+		return nil, err
+	}
+	if _, err := bi.CreateBucket(pairInviteStateStorage); err != nil {
+		return nil, err
+	}
+	return &PairInvite{bi}, nil
+}
+
+// Expire removes invitations that are no longer valid at now.
+func (b *PairInvites) Expire(now time.Time) error {
+	var expired [][]byte
+	c := b.b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		inv := &PairInvite{b.b.Bucket(k)}
+		if !inv.validAt(now) {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+	}
+	for _, k := range expired {
+		if err := b.b.DeleteBucket(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Use consumes the invitation with the given token, and calls fn with
+// it. The invitation is removed even if fn fails; the caller is
+// expected to abort the transaction in that case.
+//
+// If there is no such invitation, or it has expired, returns
+// ErrPairInviteNotFound.
+func (b *PairInvites) Use(token []byte, now time.Time, fn func(*PairInvite) error) error {
+	bi := b.b.Bucket(token)
+	if bi == nil {
+		return ErrPairInviteNotFound
+	}
+	inv := &PairInvite{bi}
+	valid := inv.validAt(now)
+	if valid {
+		if err := fn(inv); err != nil {
+			return err
+		}
+	}
+	if err := b.b.DeleteBucket(token); err != nil {
+		return err
+	}
+	if !valid {
+		return ErrPairInviteNotFound
+	}
+	return nil
+}
+
+type PairInvite struct {
+	b *bolt.Bucket
+}
+
+func (inv *PairInvite) validAt(now time.Time) bool {
+	v := inv.b.Get(pairInviteStateExpires)
+	if len(v) != 8 {
+		return false
+	}
+	return now.Unix() < int64(binary.BigEndian.Uint64(v))
+}
+
+// AllowVolume gives the invited peer access to the volume, by the
+// given name.
+func (inv *PairInvite) AllowVolume(name string, vol *Volume) error {
+	return inv.b.Bucket(pairInviteStateVolume).Put([]byte(name), vol.id)
+}
+
+// AllowStorage offers the storage backend to the invited peer.
+func (inv *PairInvite) AllowStorage(backend string) error {
+	return inv.b.Bucket(pairInviteStateStorage).Put([]byte(backend), nil)
+}
+
+// Volumes calls fn for every volume included in the invitation.
+//
+// name and volID are valid during the call to fn only.
+func (inv *PairInvite) Volumes(fn func(name string, volID *VolumeID) error) error {
+	c := inv.b.Bucket(pairInviteStateVolume).Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var volID VolumeID
+		if err := volID.UnmarshalBinary(v); err != nil {
+			return err
+		}
+		if err := fn(string(k), &volID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Storage calls fn for every storage backend included in the
+// invitation.
+func (inv *PairInvite) Storage(fn func(backend string) error) error {
+	c := inv.b.Bucket(pairInviteStateStorage).Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if err := fn(string(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+)
+
+func TestPairInviteUse(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	token := []byte("sekrit")
+	now := time.Unix(1400000000, 0)
+	var volID db.VolumeID
+	add := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		v.VolumeID(&volID)
+		inv, err := tx.PairInvites().Add(token, now.Add(time.Hour))
+		if err != nil {
+			return err
+		}
+		if err := inv.AllowVolume("foo", v); err != nil {
+			return err
+		}
+		if err := inv.AllowStorage("local"); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := DB.Update(add); err != nil {
+		t.Fatal(err)
+	}
+
+	use := func(tx *db.Tx) error {
+		var volumes []string
+		var storage []string
+		fn := func(inv *db.PairInvite) error {
+			gatherVolumes := func(name string, id *db.VolumeID) error {
+				if g, e := *id, volID; g != e {
+					t.Errorf("wrong volume ID: %x != %x", g, e)
+				}
+				volumes = append(volumes, name)
+				return nil
+			}
+			if err := inv.Volumes(gatherVolumes); err != nil {
+				return err
+			}
+			gatherStorage := func(backend string) error {
+				storage = append(storage, backend)
+				return nil
+			}
+			return inv.Storage(gatherStorage)
+		}
+		if err := tx.PairInvites().Use(token, now, fn); err != nil {
+			return err
+		}
+		if g, e := len(volumes), 1; g != e {
+			t.Fatalf("wrong number of volumes: %v != %v", g, e)
+		}
+		if g, e := volumes[0], "foo"; g != e {
+			t.Errorf("wrong volume name: %q != %q", g, e)
+		}
+		if g, e := len(storage), 1; g != e {
+			t.Fatalf("wrong number of storage: %v != %v", g, e)
+		}
+		if g, e := storage[0], "local"; g != e {
+			t.Errorf("wrong storage: %q != %q", g, e)
+		}
+		return nil
+	}
+	if err := DB.Update(use); err != nil {
+		t.Fatal(err)
+	}
+
+	again := func(tx *db.Tx) error {
+		fn := func(inv *db.PairInvite) error {
+			t.Error("invitation was used twice")
+			return nil
+		}
+		if g, e := tx.PairInvites().Use(token, now, fn), db.ErrPairInviteNotFound; g != e {
+			t.Errorf("wrong error: %v != %v", g, e)
+		}
+		return nil
+	}
+	if err := DB.Update(again); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPairInviteExpired(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	token := []byte("sekrit")
+	now := time.Unix(1400000000, 0)
+	add := func(tx *db.Tx) error {
+		_, err := tx.PairInvites().Add(token, now)
+		return err
+	}
+	if err := DB.Update(add); err != nil {
+		t.Fatal(err)
+	}
+
+	use := func(tx *db.Tx) error {
+		fn := func(inv *db.PairInvite) error {
+			t.Error("expired invitation was used")
+			return nil
+		}
+		if g, e := tx.PairInvites().Use(token, now, fn), db.ErrPairInviteNotFound; g != e {
+			t.Errorf("wrong error: %v != %v", g, e)
+		}
+		return nil
+	}
+	if err := DB.Update(use); err != nil {
+		t.Fatal(err)
+	}
+}
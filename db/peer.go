@@ -3,18 +3,22 @@ package db
 import (
 	"encoding/binary"
 	"errors"
+	"math"
 
 	"bazil.org/bazil/kv"
 	"bazil.org/bazil/kv/kvmulti"
 	"bazil.org/bazil/peer"
 	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
 	"github.com/boltdb/bolt"
 )
 
 var (
-	ErrPeerNotFound      = errors.New("peer not found")
-	ErrNoStorageForPeer  = errors.New("no storage offered to peer")
+	ErrPeerNotFound      = errkind.New(errkind.NotFound, "peer not found")
+	ErrPeerIDsExhausted  = errors.New("out of peer IDs")
+	ErrNoStorageForPeer  = errkind.New(errkind.PermissionDenied, "no storage offered to peer")
 	ErrNoLocationForPeer = errors.New("no network location known for peer")
+	ErrPeerStorageInUse  = errors.New("peer is used as storage by a volume")
 )
 
 var (
@@ -33,20 +37,41 @@ func (tx *Tx) initPeers() error {
 	if _, err := tx.CreateBucketIfNotExists(bucketPeerID); err != nil {
 		return err
 	}
+
+	// message queues came later; add them to existing peers
+	peers := tx.Bucket(bucketPeer)
+	c := peers.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v != nil {
+			// not a bucket; the consistency check complains
+			continue
+		}
+		bp := peers.Bucket(k)
+		if _, err := bp.CreateBucketIfNotExists(peerStateOutbox); err != nil {
+			return err
+		}
+		if _, err := bp.CreateBucketIfNotExists(peerStateInbox); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (tx *Tx) Peers() *Peers {
 	p := &Peers{
-		peers: tx.Bucket(bucketPeer),
-		ids:   tx.Bucket(bucketPeerID),
+		peers:   tx.Bucket(bucketPeer),
+		ids:     tx.Bucket(bucketPeerID),
+		groups:  tx.PeerGroups(),
+		volumes: tx.Volumes(),
 	}
 	return p
 }
 
 type Peers struct {
-	peers *bolt.Bucket
-	ids   *bolt.Bucket
+	peers   *bolt.Bucket
+	ids     *bolt.Bucket
+	groups  *PeerGroups
+	volumes *Volumes
 }
 
 // Get returns a Peer for the given public key.
@@ -58,8 +83,9 @@ func (b *Peers) Get(pub *peer.PublicKey) (*Peer, error) {
 		return nil, ErrPeerNotFound
 	}
 	p := &Peer{
-		b:   bp,
-		pub: pub,
+		b:      bp,
+		pub:    pub,
+		groups: b.groups,
 	}
 	return p, nil
 }
@@ -73,15 +99,10 @@ func (b *Peers) Make(pub *peer.PublicKey) (*Peer, error) {
 		return p, err
 	}
 
-	// really not found -> add it; first,pick a free id
-	var id peer.ID
-	c := b.ids.Cursor()
-	if k, _ := c.Last(); k != nil {
-		id = peer.ID(binary.BigEndian.Uint32(k))
-	}
-	id++
-	if id == 0 {
-		return nil, errors.New("out of peer IDs")
+	// really not found -> add it; first, pick a free id
+	id, err := b.allocID()
+	if err != nil {
+		return nil, err
 	}
 	var idKey [4]byte
 	binary.BigEndian.PutUint32(idKey[:], uint32(id))
@@ -106,20 +127,103 @@ func (b *Peers) Make(pub *peer.PublicKey) (*Peer, error) {
 	if _, err := bp.CreateBucket(peerStateVolume); err != nil {
 		return nil, err
 	}
+	if _, err := bp.CreateBucket(peerStateOutbox); err != nil {
+		return nil, err
+	}
+	if _, err := bp.CreateBucket(peerStateInbox); err != nil {
+		return nil, err
+	}
 
 	p = &Peer{
-		b:   bp,
-		pub: pub,
+		b:      bp,
+		pub:    pub,
+		groups: b.groups,
 	}
 	return p, nil
 }
 
+// allocID returns a peer ID that has never been used.
+//
+// IDs come from a persistent sequence, so IDs of removed peers are
+// never handed out again; clocks may still refer to them. Databases
+// created before the sequence was used have it at zero, so IDs
+// already present in the index, including tombstones, are skipped.
+func (b *Peers) allocID() (peer.ID, error) {
+	for {
+		seq, err := b.ids.NextSequence()
+		if err != nil {
+			return 0, err
+		}
+		if seq > math.MaxUint32 {
+			return 0, ErrPeerIDsExhausted
+		}
+		var idKey [4]byte
+		binary.BigEndian.PutUint32(idKey[:], uint32(seq))
+		if b.ids.Get(idKey[:]) == nil {
+			return peer.ID(seq), nil
+		}
+	}
+}
+
+// Remove forgets the peer. Its ID stays reserved, as a tombstone in
+// the ID index, so that clocks referring to it are not confused with
+// a later peer. The peer is also removed from all peer groups.
+//
+// If the peer does not exist, returns ErrPeerNotFound. If a volume
+// stores its chunks on the peer, returns ErrPeerStorageInUse.
+func (b *Peers) Remove(pub *peer.PublicKey) error {
+	p, err := b.Get(pub)
+	if err != nil {
+		return err
+	}
+	used, err := b.volumes.usesStorage(peerBackend(pub))
+	if err != nil {
+		return err
+	}
+	if used {
+		return ErrPeerStorageInUse
+	}
+	var idKey [4]byte
+	binary.BigEndian.PutUint32(idKey[:], uint32(p.ID()))
+	if err := b.ids.Put(idKey[:], []byte{}); err != nil {
+		return err
+	}
+	for _, g := range b.groups.memberOf(pub) {
+		if err := g.Bucket(peerGroupStateMember).Delete(pub[:]); err != nil {
+			return err
+		}
+	}
+	return b.peers.DeleteBucket(pub[:])
+}
+
+// ByID returns the peer with the given ID.
+//
+// If no peer has the ID, or the peer has been removed, returns
+// ErrPeerNotFound.
+func (b *Peers) ByID(id peer.ID) (*Peer, error) {
+	var idKey [4]byte
+	binary.BigEndian.PutUint32(idKey[:], uint32(id))
+	v := b.ids.Get(idKey[:])
+	if len(v) == 0 {
+		return nil, ErrPeerNotFound
+	}
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(v); err != nil {
+		return nil, err
+	}
+	return b.Get(&pub)
+}
+
 func (b *Peers) Cursor() *PeersCursor {
-	return &PeersCursor{b.peers.Cursor()}
+	return &PeersCursor{
+		c:      b.peers.Cursor(),
+		groups: b.groups,
+	}
 }
 
 type PeersCursor struct {
-	c *bolt.Cursor
+	c      *bolt.Cursor
+	groups *PeerGroups
 }
 
 func (c *PeersCursor) item(k, _ []byte) *Peer {
@@ -135,8 +239,9 @@ func (c *PeersCursor) item(k, _ []byte) *Peer {
 		panic("db peer corrupt: " + err.Error())
 	}
 	p := &Peer{
-		b:   bucket,
-		pub: &pub,
+		b:      bucket,
+		pub:    &pub,
+		groups: c.groups,
 	}
 	return p
 }
@@ -150,8 +255,9 @@ func (c *PeersCursor) Next() *Peer {
 }
 
 type Peer struct {
-	b   *bolt.Bucket
-	pub *peer.PublicKey
+	b      *bolt.Bucket
+	pub    *peer.PublicKey
+	groups *PeerGroups
 }
 
 func (p *Peer) Pub() *peer.PublicKey {
@@ -175,7 +281,8 @@ type PeerLocations struct {
 	b *bolt.Bucket
 }
 
-// Set the network location where the peer can be contacted.
+// Set the network location where the peer can be contacted. The
+// location is HOST:PORT, or unix:PATH for a peer on the same host.
 //
 // TODO support multiple addresses to attempt, with some idea of
 // preferring recent ones.
@@ -206,19 +313,26 @@ func (p *PeerLocations) Get() (addr string, err error) {
 
 func (p *Peer) Storage() *PeerStorage {
 	b := p.b.Bucket(peerStateStorage)
-	return &PeerStorage{b}
+	s := &PeerStorage{
+		b:      b,
+		groups: p.groups.memberOf(p.pub),
+	}
+	return s
 }
 
 type PeerStorage struct {
 	b *bolt.Bucket
+	// buckets of the groups the peer is a member of
+	groups []*bolt.Bucket
 }
 
 func (p *PeerStorage) Allow(backend string) error {
 	return p.b.Put([]byte(backend), nil)
 }
 
-// Open key-value stores as allowed for this peer. Uses the opener
-// function for the actual open action.
+// Open key-value stores as allowed for this peer, directly or through
+// its peer groups. Uses the opener function for the actual open
+// action.
 //
 // If the peer is not allowed to use any storage, returns
 // ErrNoStorageForPeer.
@@ -227,16 +341,32 @@ func (p *PeerStorage) Allow(backend string) error {
 // opener function are valid after the transaction.
 func (p *PeerStorage) Open(opener func(string) (kv.KV, error)) (kv.KV, error) {
 	var kvstores []kv.KV
-	c := p.b.Cursor()
-	for k, _ := c.First(); k != nil; k, _ = c.Next() {
-		backend := string(k)
-		// later value may include quota style restrictions
-		s, err := opener(backend)
-		if err != nil {
-			// TODO once kv.KV has Close, close all in kvstores
+	seen := make(map[string]struct{})
+	open := func(b *bolt.Bucket) error {
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			backend := string(k)
+			if _, ok := seen[backend]; ok {
+				continue
+			}
+			seen[backend] = struct{}{}
+			// later value may include quota style restrictions
+			s, err := opener(backend)
+			if err != nil {
+				return err
+			}
+			kvstores = append(kvstores, s)
+		}
+		return nil
+	}
+	if err := open(p.b); err != nil {
+		// TODO once kv.KV has Close, close all in kvstores
+		return nil, err
+	}
+	for _, g := range p.groups {
+		if err := open(g.Bucket(peerGroupStateStorage)); err != nil {
 			return nil, err
 		}
-		kvstores = append(kvstores, s)
 	}
 	if len(kvstores) == 0 {
 		return nil, ErrNoStorageForPeer
@@ -246,20 +376,39 @@ func (p *PeerStorage) Open(opener func(string) (kv.KV, error)) (kv.KV, error) {
 
 func (p *Peer) Volumes() *PeerVolumes {
 	b := p.b.Bucket(peerStateVolume)
-	return &PeerVolumes{b}
+	v := &PeerVolumes{
+		b:      b,
+		groups: p.groups.memberOf(p.pub),
+	}
+	return v
 }
 
 type PeerVolumes struct {
 	b *bolt.Bucket
+	// buckets of the groups the peer is a member of
+	groups []*bolt.Bucket
 }
 
 func (p *PeerVolumes) Allow(vol *Volume) error {
 	return p.b.Put([]byte(vol.id), nil)
 }
 
+// Revoke stops sharing the volume with the peer directly. The peer
+// may still see the volume through its peer groups.
+func (p *PeerVolumes) Revoke(vol *Volume) error {
+	return p.b.Delete([]byte(vol.id))
+}
+
+// IsAllowed reports whether the peer can see the volume, either
+// directly or through one of its peer groups.
 func (p *PeerVolumes) IsAllowed(vol *Volume) bool {
-	if p.b.Get([]byte(vol.id)) == nil {
-		return false
+	if p.b.Get([]byte(vol.id)) != nil {
+		return true
+	}
+	for _, g := range p.groups {
+		if g.Bucket(peerGroupStateVolume).Get([]byte(vol.id)) != nil {
+			return true
+		}
 	}
-	return true
+	return false
 }
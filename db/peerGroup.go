@@ -0,0 +1,241 @@
+package db
+
+import (
+	"errors"
+
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	ErrPeerGroupNameInvalid = errors.New("invalid peer group name")
+	ErrPeerGroupNotFound    = errkind.New(errkind.NotFound, "peer group not found")
+)
+
+var (
+	bucketPeerGroup       = []byte(tokens.BucketPeerGroup)
+	peerGroupStateMember  = []byte(tokens.PeerGroupStateMember)
+	peerGroupStateStorage = []byte(tokens.PeerGroupStateStorage)
+	peerGroupStateVolume  = []byte(tokens.PeerGroupStateVolume)
+)
+
+func (tx *Tx) initPeerGroups() error {
+	if _, err := tx.CreateBucketIfNotExists(bucketPeerGroup); err != nil {
+		return err
+	}
+	return nil
+}
+
+// PeerGroups returns the named groups of peers. Storage and volumes
+// allowed for a group are allowed for all of its members.
+func (tx *Tx) PeerGroups() *PeerGroups {
+	b := tx.Bucket(bucketPeerGroup)
+	return &PeerGroups{b}
+}
+
+type PeerGroups struct {
+	b *bolt.Bucket
+}
+
+// Get returns the named peer group.
+//
+// If the group does not exist, returns ErrPeerGroupNotFound.
+func (b *PeerGroups) Get(name string) (*PeerGroup, error) {
+	n := []byte(name)
+	bg := b.b.Bucket(n)
+	if bg == nil {
+		return nil, ErrPeerGroupNotFound
+	}
+	g := &PeerGroup{
+		b:    bg,
+		name: n,
+	}
+	return g, nil
+}
+
+// Make returns the named peer group, creating it if necessary.
+//
+// If name is invalid, returns ErrPeerGroupNameInvalid.
+func (b *PeerGroups) Make(name string) (*PeerGroup, error) {
+	if name == "" {
+		return nil, ErrPeerGroupNameInvalid
+	}
+	g, err := b.Get(name)
+	if err != ErrPeerGroupNotFound {
+		return g, err
+	}
+
+	n := []byte(name)
+	bg, err := b.b.CreateBucket(n)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := bg.CreateBucket(peerGroupStateMember); err != nil {
+		return nil, err
+	}
+	if _, err := bg.CreateBucket(peerGroupStateStorage); err != nil {
+		return nil, err
+	}
+	if _, err := bg.CreateBucket(peerGroupStateVolume); err != nil {
+		return nil, err
+	}
+	g = &PeerGroup{
+		b:    bg,
+		name: n,
+	}
+	return g, nil
+}
+
+// Delete the named peer group. The member peers themselves are not
+// affected, but they lose the access granted through the group.
+//
+// If the group does not exist, returns ErrPeerGroupNotFound.
+func (b *PeerGroups) Delete(name string) error {
+	err := b.b.DeleteBucket([]byte(name))
+	if err == bolt.ErrBucketNotFound {
+		return ErrPeerGroupNotFound
+	}
+	return err
+}
+
+// memberOf returns the buckets of all groups pub is a member of.
+func (b *PeerGroups) memberOf(pub *peer.PublicKey) []*bolt.Bucket {
+	if b.b == nil {
+		return nil
+	}
+	var groups []*bolt.Bucket
+	c := b.b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		bg := b.b.Bucket(k)
+		if bg == nil {
+			panic("db peer group corrupt, not a bucket")
+		}
+		if bg.Bucket(peerGroupStateMember).Get(pub[:]) != nil {
+			groups = append(groups, bg)
+		}
+	}
+	return groups
+}
+
+func (b *PeerGroups) Cursor() *PeerGroupsCursor {
+	return &PeerGroupsCursor{b.b.Cursor()}
+}
+
+type PeerGroupsCursor struct {
+	c *bolt.Cursor
+}
+
+func (c *PeerGroupsCursor) item(k, _ []byte) *PeerGroup {
+	if k == nil {
+		return nil
+	}
+	bucket := c.c.Bucket().Bucket(k)
+	if bucket == nil {
+		panic("db peer group corrupt, not a bucket")
+	}
+	g := &PeerGroup{
+		b:    bucket,
+		name: k,
+	}
+	return g
+}
+
+func (c *PeerGroupsCursor) First() *PeerGroup {
+	return c.item(c.c.First())
+}
+
+func (c *PeerGroupsCursor) Next() *PeerGroup {
+	return c.item(c.c.Next())
+}
+
+type PeerGroup struct {
+	b    *bolt.Bucket
+	name []byte
+}
+
+// Name returns the name of the peer group.
+//
+// Returned value is valid after the transaction.
+func (g *PeerGroup) Name() string {
+	return string(g.name)
+}
+
+// Add makes the peer a member of the group.
+func (g *PeerGroup) Add(p *Peer) error {
+	return g.b.Bucket(peerGroupStateMember).Put(p.Pub()[:], nil)
+}
+
+// Remove the peer from the group. Removing a peer that is not a
+// member is not an error.
+func (g *PeerGroup) Remove(pub *peer.PublicKey) error {
+	return g.b.Bucket(peerGroupStateMember).Delete(pub[:])
+}
+
+// IsMember reports whether the peer is a member of the group.
+func (g *PeerGroup) IsMember(pub *peer.PublicKey) bool {
+	return g.b.Bucket(peerGroupStateMember).Get(pub[:]) != nil
+}
+
+// Members calls fn for every member of the group.
+//
+// pub is valid during the call to fn only.
+func (g *PeerGroup) Members(fn func(pub *peer.PublicKey) error) error {
+	c := g.b.Bucket(peerGroupStateMember).Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(k); err != nil {
+			return err
+		}
+		if err := fn(&pub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllowStorage offers the storage backend to all members of the
+// group.
+func (g *PeerGroup) AllowStorage(backend string) error {
+	return g.b.Bucket(peerGroupStateStorage).Put([]byte(backend), nil)
+}
+
+// AllowVolume lets all members of the group see the volume.
+func (g *PeerGroup) AllowVolume(vol *Volume) error {
+	return g.b.Bucket(peerGroupStateVolume).Put([]byte(vol.id), nil)
+}
+
+// RevokeVolume stops sharing the volume with the group. Members may
+// still see the volume directly, or through their other groups.
+func (g *PeerGroup) RevokeVolume(vol *Volume) error {
+	return g.b.Bucket(peerGroupStateVolume).Delete([]byte(vol.id))
+}
+
+// Storage calls fn for every storage backend offered to the group.
+func (g *PeerGroup) Storage(fn func(backend string) error) error {
+	c := g.b.Bucket(peerGroupStateStorage).Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if err := fn(string(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Volumes calls fn for every volume shared with the group.
+//
+// volID is valid during the call to fn only.
+func (g *PeerGroup) Volumes(fn func(volID *VolumeID) error) error {
+	c := g.b.Bucket(peerGroupStateVolume).Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		var volID VolumeID
+		if err := volID.UnmarshalBinary(k); err != nil {
+			return err
+		}
+		if err := fn(&volID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
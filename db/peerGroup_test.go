@@ -0,0 +1,115 @@
+package db_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+)
+
+func TestPeerGroupVolumeAllow(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub1 := &peer.PublicKey{0x42, 0x42, 0x42}
+	pub2 := &peer.PublicKey{0xC0, 0xFF, 0xEE}
+
+	setup := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		p1, err := tx.Peers().Make(pub1)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Peers().Make(pub2); err != nil {
+			return err
+		}
+		g, err := tx.PeerGroups().Make("home")
+		if err != nil {
+			return err
+		}
+		if err := g.Add(p1); err != nil {
+			return err
+		}
+		return g.AllowVolume(v)
+	}
+	if err := DB.Update(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName("foo")
+		if err != nil {
+			return err
+		}
+		p1, err := tx.Peers().Get(pub1)
+		if err != nil {
+			return err
+		}
+		if !p1.Volumes().IsAllowed(v) {
+			t.Errorf("group member should see volume")
+		}
+		p2, err := tx.Peers().Get(pub2)
+		if err != nil {
+			return err
+		}
+		if p2.Volumes().IsAllowed(v) {
+			t.Errorf("non-member should not see volume")
+		}
+		return nil
+	}
+	if err := DB.View(check); err != nil {
+		t.Fatal(err)
+	}
+
+	remove := func(tx *db.Tx) error {
+		g, err := tx.PeerGroups().Get("home")
+		if err != nil {
+			return err
+		}
+		return g.Remove(pub1)
+	}
+	if err := DB.Update(remove); err != nil {
+		t.Fatal(err)
+	}
+
+	checkRemoved := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName("foo")
+		if err != nil {
+			return err
+		}
+		p1, err := tx.Peers().Get(pub1)
+		if err != nil {
+			return err
+		}
+		if p1.Volumes().IsAllowed(v) {
+			t.Errorf("removed member should not see volume")
+		}
+		return nil
+	}
+	if err := DB.View(checkRemoved); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPeerGroupNotFound(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	get := func(tx *db.Tx) error {
+		g, err := tx.PeerGroups().Get("nope")
+		if g != nil || err != db.ErrPeerGroupNotFound {
+			t.Errorf("expected ErrPeerGroupNotFound, got %v, %v", g, err)
+		}
+		return nil
+	}
+	if err := DB.View(get); err != nil {
+		t.Fatal(err)
+	}
+}
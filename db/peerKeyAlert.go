@@ -0,0 +1,113 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"bazil.org/bazil/db/wire"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/tokens"
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	ErrPeerKeyInUse = errors.New("new key belongs to another peer")
+)
+
+var peerStateKeyAlert = []byte(tokens.PeerStateKeyAlert)
+
+// KeyAlert returns what was seen instead of the peer, or nil if
+// nothing was.
+func (p *Peer) KeyAlert() (*wire.PeerKeyAlert, error) {
+	buf := p.b.Get(peerStateKeyAlert)
+	if buf == nil {
+		return nil, nil
+	}
+	var alert wire.PeerKeyAlert
+	if err := proto.Unmarshal(buf, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// RecordKeyAlert notes that pub answered at addr, when the peer was
+// expected. The alert stays until the peer is rekeyed.
+func (p *Peer) RecordKeyAlert(pub *peer.PublicKey, addr string, now time.Time) error {
+	alert, err := p.KeyAlert()
+	if err != nil {
+		return err
+	}
+	if alert == nil || string(alert.Pub) != string(pub[:]) {
+		alert = &wire.PeerKeyAlert{
+			Pub:   pub[:],
+			First: now.Unix(),
+		}
+	}
+	alert.Addr = addr
+	alert.Last = now.Unix()
+	alert.Count++
+	buf, err := proto.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return p.b.Put(peerStateKeyAlert, buf)
+}
+
+// Rekey moves everything known about the peer over to a new public
+// key, and clears its key alert. The peer keeps its ID, so clocks
+// stay valid. Volumes storing chunks on the peer switch to the new
+// key; active Volume instances are not notified.
+//
+// If the old peer does not exist, returns ErrPeerNotFound. If the
+// new key is already used by a peer, returns ErrPeerKeyInUse.
+func (b *Peers) Rekey(old, pub *peer.PublicKey) error {
+	p, err := b.Get(old)
+	if err != nil {
+		return err
+	}
+	if b.peers.Bucket(pub[:]) != nil {
+		return ErrPeerKeyInUse
+	}
+	idKey := append([]byte(nil), p.b.Get(peerStateID)...)
+	bp, err := b.peers.CreateBucket(pub[:])
+	if err != nil {
+		return err
+	}
+	if err := copyBucket(bp, p.b); err != nil {
+		return err
+	}
+	if err := bp.Delete(peerStateKeyAlert); err != nil {
+		return err
+	}
+	if err := b.ids.Put(idKey, pub[:]); err != nil {
+		return err
+	}
+	for _, g := range b.groups.memberOf(old) {
+		members := g.Bucket(peerGroupStateMember)
+		if err := members.Delete(old[:]); err != nil {
+			return err
+		}
+		if err := members.Put(pub[:], nil); err != nil {
+			return err
+		}
+	}
+	if err := b.volumes.replaceStorage(peerBackend(old), peerBackend(pub)); err != nil {
+		return err
+	}
+	return b.peers.DeleteBucket(old[:])
+}
+
+// copyBucket copies all keys and nested buckets of src into dst.
+func copyBucket(dst, src *bolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+		child, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(child, src.Bucket(k))
+	})
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"encoding/binary"
+
+	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	ErrOutboxFull = errkind.New(errkind.QuotaExceeded, "too many messages waiting for peer")
+	ErrInboxFull  = errkind.New(errkind.QuotaExceeded, "too many messages received from peer")
+)
+
+var (
+	peerStateOutbox = []byte(tokens.PeerStateOutbox)
+	peerStateInbox  = []byte(tokens.PeerStateInbox)
+)
+
+// The number of messages to keep in a single outbox or inbox.
+const peerMessagesMaxEntries = 1000
+
+// Outbox returns the messages waiting to be delivered to the peer.
+func (p *Peer) Outbox() *PeerMessages {
+	b := p.b.Bucket(peerStateOutbox)
+	return &PeerMessages{b, ErrOutboxFull}
+}
+
+// Inbox returns the messages received from the peer.
+func (p *Peer) Inbox() *PeerMessages {
+	b := p.b.Bucket(peerStateInbox)
+	return &PeerMessages{b, ErrInboxFull}
+}
+
+// PeerMessages is a queue of messages, ordered by sequence number.
+// The messages are opaque to the database.
+type PeerMessages struct {
+	b *bolt.Bucket
+	// returned when the queue has no room
+	errFull error
+}
+
+// full reports whether the queue has no room for more messages. It
+// counts with a cursor, as bucket statistics do not include changes
+// made earlier in the same transaction.
+func (m *PeerMessages) full() bool {
+	n := 0
+	c := m.b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		n++
+		if n >= peerMessagesMaxEntries {
+			return true
+		}
+	}
+	return false
+}
+
+// Add queues a message under the next local sequence number, and
+// returns that sequence number.
+//
+// If too many messages are already queued, returns ErrOutboxFull or
+// ErrInboxFull.
+func (m *PeerMessages) Add(msg []byte) (uint64, error) {
+	if m.full() {
+		return 0, m.errFull
+	}
+	seq, err := m.b.NextSequence()
+	if err != nil {
+		return 0, err
+	}
+	if err := m.Put(seq, msg); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// Put stores a message with a sequence number chosen by someone
+// else. Storing the same sequence number again replaces the message.
+//
+// If too many messages are already queued, returns ErrOutboxFull or
+// ErrInboxFull.
+func (m *PeerMessages) Put(seq uint64, msg []byte) error {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	if m.b.Get(key[:]) == nil && m.full() {
+		return m.errFull
+	}
+	return m.b.Put(key[:], msg)
+}
+
+// Remove the message with the given sequence number. Removing a
+// message that does not exist is not an error.
+func (m *PeerMessages) Remove(seq uint64) error {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	return m.b.Delete(key[:])
+}
+
+func (m *PeerMessages) Cursor() *PeerMessagesCursor {
+	return &PeerMessagesCursor{m.b.Cursor()}
+}
+
+type PeerMessagesCursor struct {
+	c *bolt.Cursor
+}
+
+func (c *PeerMessagesCursor) item(k, v []byte) *PeerMessage {
+	if k == nil {
+		return nil
+	}
+	if len(k) != 8 {
+		panic("db peer message corrupt")
+	}
+	m := &PeerMessage{
+		Seq:  binary.BigEndian.Uint64(k),
+		Data: v,
+	}
+	return m
+}
+
+func (c *PeerMessagesCursor) First() *PeerMessage {
+	return c.item(c.c.First())
+}
+
+func (c *PeerMessagesCursor) Next() *PeerMessage {
+	return c.item(c.c.Next())
+}
+
+// PeerMessage is a single queued message.
+//
+// Data is only valid during the transaction.
+type PeerMessage struct {
+	Seq  uint64
+	Data []byte
+}
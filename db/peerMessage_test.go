@@ -0,0 +1,45 @@
+package db_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+)
+
+func TestInboxFull(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub := &peer.PublicKey{0x42, 0x42, 0x42}
+
+	fill := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pub)
+		if err != nil {
+			return err
+		}
+		inbox := p.Inbox()
+		for seq := uint64(1); seq <= 1000; seq++ {
+			if err := inbox.Put(seq, []byte("hello")); err != nil {
+				t.Fatalf("put %d: %v", seq, err)
+			}
+		}
+		if err := inbox.Put(1001, []byte("hello")); err != db.ErrInboxFull {
+			t.Errorf("expected ErrInboxFull, got %v", err)
+		}
+		// redelivery of a stored message still works
+		if err := inbox.Put(1000, []byte("again")); err != nil {
+			t.Errorf("redelivery: %v", err)
+		}
+		if err := inbox.Remove(1); err != nil {
+			return err
+		}
+		if err := inbox.Put(1001, []byte("hello")); err != nil {
+			t.Errorf("put after remove: %v", err)
+		}
+		return nil
+	}
+	if err := DB.Update(fill); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,8 +1,10 @@
 package db_test
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
+	"time"
 
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/peer"
@@ -68,3 +70,196 @@ func TestMakePeer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRemovePeerDoesNotReuseID(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub1 := &peer.PublicKey{0x42, 0x42, 0x42}
+	pub2 := &peer.PublicKey{0xC0, 0xFF, 0xEE}
+	pub3 := &peer.PublicKey{0xFA, 0xCE}
+
+	check := func(tx *db.Tx) error {
+		if err := checkMakePeer(tx, pub1, 1); err != nil {
+			t.Error(err)
+		}
+		if err := checkMakePeer(tx, pub2, 2); err != nil {
+			t.Error(err)
+		}
+		if err := tx.Peers().Remove(pub2); err != nil {
+			t.Fatalf("remove: %v", err)
+		}
+		if _, err := tx.Peers().Get(pub2); err != db.ErrPeerNotFound {
+			t.Errorf("expected ErrPeerNotFound after remove, got %v", err)
+		}
+		if _, err := tx.Peers().ByID(2); err != db.ErrPeerNotFound {
+			t.Errorf("expected ErrPeerNotFound for removed id, got %v", err)
+		}
+		if err := checkMakePeer(tx, pub3, 3); err != nil {
+			t.Error(err)
+		}
+		// coming back gets a new identity
+		if err := checkMakePeer(tx, pub2, 4); err != nil {
+			t.Error(err)
+		}
+		return nil
+	}
+	if err := DB.Update(check); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemovePeerUsedAsStorage(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub1 := &peer.PublicKey{0x42, 0x42, 0x42}
+
+	check := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Peers().Make(pub1); err != nil {
+			return err
+		}
+		if _, err := tx.Volumes().Create("foo", "peerkey:"+pub1.String(), sharingKey); err != nil {
+			return err
+		}
+		if err := tx.Peers().Remove(pub1); err != db.ErrPeerStorageInUse {
+			t.Errorf("expected ErrPeerStorageInUse, got %v", err)
+		}
+		if _, err := tx.Peers().Get(pub1); err != nil {
+			t.Errorf("peer should still exist: %v", err)
+		}
+		return nil
+	}
+	if err := DB.Update(check); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPeerByID(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub1 := &peer.PublicKey{0x42, 0x42, 0x42}
+
+	check := func(tx *db.Tx) error {
+		if err := checkMakePeer(tx, pub1, 1); err != nil {
+			t.Error(err)
+		}
+		p, err := tx.Peers().ByID(1)
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		if g, e := *p.Pub(), *pub1; g != e {
+			t.Errorf("wrong peer: %v != %v", g, e)
+		}
+		if _, err := tx.Peers().ByID(42); err != db.ErrPeerNotFound {
+			t.Errorf("expected ErrPeerNotFound, got %v", err)
+		}
+		return nil
+	}
+	if err := DB.Update(check); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPeerRekey(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub1 := &peer.PublicKey{0x42, 0x42, 0x42}
+	pub2 := &peer.PublicKey{0xC0, 0xFF, 0xEE}
+	pub3 := &peer.PublicKey{0xFA, 0xCE}
+
+	check := func(tx *db.Tx) error {
+		if err := checkMakePeer(tx, pub1, 1); err != nil {
+			t.Error(err)
+		}
+		if err := checkMakePeer(tx, pub3, 2); err != nil {
+			t.Error(err)
+		}
+		p, err := tx.Peers().Get(pub1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Locations().Set("example.com:1234"); err != nil {
+			t.Fatal(err)
+		}
+		g, err := tx.PeerGroups().Make("friends")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Add(p); err != nil {
+			t.Fatal(err)
+		}
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Volumes().Create("foo", "peerkey:"+pub1.String(), sharingKey); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Unix(1000, 0)
+		for i := 0; i < 2; i++ {
+			if err := p.RecordKeyAlert(pub2, "example.com:1234", now); err != nil {
+				t.Fatalf("recording key alert: %v", err)
+			}
+		}
+		alert, err := p.KeyAlert()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alert == nil || !bytes.Equal(alert.Pub, pub2[:]) || alert.Count != 2 {
+			t.Errorf("wrong key alert: %v", alert)
+		}
+
+		if err := tx.Peers().Rekey(pub1, pub3); err != db.ErrPeerKeyInUse {
+			t.Errorf("expected ErrPeerKeyInUse, got %v", err)
+		}
+		if err := tx.Peers().Rekey(pub1, pub2); err != nil {
+			t.Fatalf("rekey: %v", err)
+		}
+		if _, err := tx.Peers().Get(pub1); err != db.ErrPeerNotFound {
+			t.Errorf("expected ErrPeerNotFound for old key, got %v", err)
+		}
+		p, err = tx.Peers().ByID(1)
+		if err != nil {
+			t.Fatalf("ByID: %v", err)
+		}
+		if g, e := *p.Pub(), *pub2; g != e {
+			t.Errorf("wrong peer: %v != %v", g, e)
+		}
+		addr, err := p.Locations().Get()
+		if err != nil || addr != "example.com:1234" {
+			t.Errorf("location not kept: %q, %v", addr, err)
+		}
+		if alert, err := p.KeyAlert(); err != nil || alert != nil {
+			t.Errorf("key alert not cleared: %v, %v", alert, err)
+		}
+		if !g.IsMember(pub2) || g.IsMember(pub1) {
+			t.Error("group membership not moved")
+		}
+		v, err := tx.Volumes().GetByName("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := v.Storage().Cursor()
+		for item := c.First(); item != nil; item = c.Next() {
+			backend, err := item.Backend()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if g, e := backend, "peerkey:"+pub2.String(); g != e {
+				t.Errorf("storage not moved: %q != %q", g, e)
+			}
+		}
+		return nil
+	}
+	if err := DB.Update(check); err != nil {
+		t.Fatal(err)
+	}
+}
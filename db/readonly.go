@@ -0,0 +1,25 @@
+package db
+
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+// How long OpenReadOnly waits for a writer to let go of the file.
+const readOnlyTimeout = 5 * time.Second
+
+// OpenReadOnly opens a database for reading only, typically a copy
+// made with Tx.CopyFile. Unlike Open, it leaves the contents as they
+// are, without setting up or checking anything.
+func OpenReadOnly(path string) (*DB, error) {
+	options := &bolt.Options{
+		ReadOnly: true,
+		Timeout:  readOnlyTimeout,
+	}
+	d, err := bolt.Open(path, 0600, options)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{d}, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"errors"
+	"strconv"
+
+	"bazil.org/bazil/tokens"
+)
+
+var (
+	ErrSchemaTooNew = errors.New("database was written by a newer version of bazil")
+)
+
+var (
+	bucketBazil              = []byte(tokens.BucketBazil)
+	globalStateSchemaVersion = []byte(tokens.GlobalStateSchemaVersion)
+)
+
+// initSchema refuses to touch databases written with a newer
+// layout, and records the current version otherwise.
+func (tx *Tx) initSchema() error {
+	b, err := tx.CreateBucketIfNotExists(bucketBazil)
+	if err != nil {
+		return err
+	}
+	if v := b.Get(globalStateSchemaVersion); v != nil {
+		version, err := strconv.Atoi(string(v))
+		if err != nil {
+			return errors.New("corrupt schema version: " + err.Error())
+		}
+		if version > tokens.SchemaVersion {
+			return ErrSchemaTooNew
+		}
+		if version == tokens.SchemaVersion {
+			return nil
+		}
+	}
+	return b.Put(globalStateSchemaVersion, []byte(strconv.Itoa(tokens.SchemaVersion)))
+}
@@ -0,0 +1,34 @@
+package db_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/tokens"
+	"github.com/boltdb/bolt"
+)
+
+func TestSchemaTooNew(t *testing.T) {
+	DB := NewTestDB(t)
+	path := DB.Path()
+	defer os.Remove(path)
+
+	future := func(tx *db.Tx) error {
+		b := tx.Bucket([]byte(tokens.BucketBazil))
+		return b.Put([]byte(tokens.GlobalStateSchemaVersion), []byte("9999"))
+	}
+	if err := DB.Update(future); err != nil {
+		t.Fatal(err)
+	}
+	DB.DB.Close()
+
+	d, err := db.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Nanosecond})
+	if err == nil {
+		d.Close()
+	}
+	if g, e := err, db.ErrSchemaTooNew; g != e {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", g)
+	}
+}
@@ -5,12 +5,13 @@ import (
 	"errors"
 
 	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
 	"github.com/boltdb/bolt"
 )
 
 var (
 	ErrSharingKeyNameInvalid = errors.New("invalid sharing key name")
-	ErrSharingKeyNotFound    = errors.New("sharing key not found")
+	ErrSharingKeyNotFound    = errkind.New(errkind.NotFound, "sharing key not found")
 	ErrSharingKeyExist       = errors.New("sharing key exists already")
 )
 
@@ -95,6 +96,51 @@ func (b *SharingKeys) Add(name string, key *[32]byte) (*SharingKey, error) {
 	return s, nil
 }
 
+// Delete a sharing key. Volumes that use the key can no longer be
+// read or written.
+//
+// If the sharing key name is not found, returns
+// ErrSharingKeyNotFound.
+func (b *SharingKeys) Delete(name string) error {
+	n := []byte(name)
+	if v := b.b.Get(n); v == nil {
+		return ErrSharingKeyNotFound
+	}
+	return b.b.Delete(n)
+}
+
+func (b *SharingKeys) Cursor() *SharingKeysCursor {
+	return &SharingKeysCursor{
+		b: b,
+		c: b.b.Cursor(),
+	}
+}
+
+type SharingKeysCursor struct {
+	b *SharingKeys
+	c *bolt.Cursor
+}
+
+func (c *SharingKeysCursor) item(k, v []byte) *SharingKey {
+	if k == nil {
+		return nil
+	}
+	s := &SharingKey{
+		b:      c.b,
+		name:   k,
+		secret: v,
+	}
+	return s
+}
+
+func (c *SharingKeysCursor) First() *SharingKey {
+	return c.item(c.c.First())
+}
+
+func (c *SharingKeysCursor) Next() *SharingKey {
+	return c.item(c.c.Next())
+}
+
 type SharingKey struct {
 	b      *SharingKeys
 	name   []byte
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"encoding/binary"
+
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/tokens"
+	"github.com/agl/ed25519"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	bucketTraffic = []byte(tokens.BucketTraffic)
+)
+
+func (tx *Tx) initTraffic() error {
+	if _, err := tx.CreateBucketIfNotExists(bucketTraffic); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Traffic counts bytes transferred to and from peers, per day and
+// volume.
+func (tx *Tx) Traffic() *Traffic {
+	b := tx.Bucket(bucketTraffic)
+	return &Traffic{b}
+}
+
+type Traffic struct {
+	b *bolt.Bucket
+}
+
+const trafficKeyMin = 4 + ed25519.PublicKeySize
+
+func trafficKey(day uint32, pub *peer.PublicKey, volID *VolumeID) []byte {
+	k := make([]byte, trafficKeyMin, trafficKeyMin+VolumeIDLen)
+	binary.BigEndian.PutUint32(k[:4], day)
+	copy(k[4:], pub[:])
+	if volID != nil {
+		k = append(k, volID[:]...)
+	}
+	return k
+}
+
+// Add adds to the byte counts of the peer and volume on day, counted
+// in days since the Unix epoch. volID is nil for traffic not tied to
+// a volume.
+func (t *Traffic) Add(day uint32, pub *peer.PublicKey, volID *VolumeID, sent, received uint64) error {
+	k := trafficKey(day, pub, volID)
+	var buf [16]byte
+	if v := t.b.Get(k); len(v) == len(buf) {
+		sent += binary.BigEndian.Uint64(v[:8])
+		received += binary.BigEndian.Uint64(v[8:])
+	}
+	binary.BigEndian.PutUint64(buf[:8], sent)
+	binary.BigEndian.PutUint64(buf[8:], received)
+	return t.b.Put(k, buf[:])
+}
+
+// TrafficEntry is the byte counts of one peer and volume on one day.
+type TrafficEntry struct {
+	Day uint32
+	Pub peer.PublicKey
+	// VolumeID is nil for traffic not tied to a volume.
+	VolumeID *VolumeID
+	Sent     uint64
+	Received uint64
+}
+
+// List calls fn for every entry on or after day, in order of day.
+func (t *Traffic) List(since uint32, fn func(*TrafficEntry) error) error {
+	var start [4]byte
+	binary.BigEndian.PutUint32(start[:], since)
+	c := t.b.Cursor()
+	for k, v := c.Seek(start[:]); k != nil; k, v = c.Next() {
+		if (len(k) != trafficKeyMin && len(k) != trafficKeyMin+VolumeIDLen) ||
+			len(v) != 16 {
+			// corrupt entry; the counts are only informational
+			continue
+		}
+		e := &TrafficEntry{
+			Day:      binary.BigEndian.Uint32(k[:4]),
+			Sent:     binary.BigEndian.Uint64(v[:8]),
+			Received: binary.BigEndian.Uint64(v[8:]),
+		}
+		copy(e.Pub[:], k[4:trafficKeyMin])
+		if len(k) > trafficKeyMin {
+			e.VolumeID = new(VolumeID)
+			copy(e.VolumeID[:], k[trafficKeyMin:])
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Expire forgets the counts of days before day.
+func (t *Traffic) Expire(before uint32) error {
+	// deleting while iterating confuses the cursor
+	var old [][]byte
+	c := t.b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if len(k) >= 4 && binary.BigEndian.Uint32(k[:4]) >= before {
+			break
+		}
+		old = append(old, append([]byte(nil), k...))
+	}
+	for _, k := range old {
+		if err := t.b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package db_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+)
+
+func TestTraffic(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub := peer.PublicKey{1, 2, 3}
+	var volID db.VolumeID
+	volID[0] = 42
+
+	add := func(tx *db.Tx) error {
+		tr := tx.Traffic()
+		if err := tr.Add(10, &pub, nil, 1, 2); err != nil {
+			return err
+		}
+		if err := tr.Add(11, &pub, &volID, 100, 0); err != nil {
+			return err
+		}
+		if err := tr.Add(11, &pub, &volID, 5, 7); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := DB.Update(add); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(since uint32) []db.TrafficEntry {
+		var got []db.TrafficEntry
+		fn := func(e *db.TrafficEntry) error {
+			got = append(got, *e)
+			return nil
+		}
+		view := func(tx *db.Tx) error {
+			return tx.Traffic().List(since, fn)
+		}
+		if err := DB.View(view); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	got := list(0)
+	if g, e := len(got), 2; g != e {
+		t.Fatalf("wrong number of entries: %d != %d: %v", g, e, got)
+	}
+	if got[0].Day != 10 || got[0].VolumeID != nil || got[0].Sent != 1 || got[0].Received != 2 {
+		t.Errorf("bad storage entry: %+v", got[0])
+	}
+	if got[1].Day != 11 || got[1].VolumeID == nil || *got[1].VolumeID != volID ||
+		got[1].Pub != pub || got[1].Sent != 105 || got[1].Received != 7 {
+		t.Errorf("bad volume entry: %+v", got[1])
+	}
+
+	if g, e := len(list(11)), 1; g != e {
+		t.Errorf("wrong number of recent entries: %d != %d", g, e)
+	}
+
+	expire := func(tx *db.Tx) error {
+		return tx.Traffic().Expire(11)
+	}
+	if err := DB.Update(expire); err != nil {
+		t.Fatal(err)
+	}
+	if got := list(0); len(got) != 1 || got[0].Day != 11 {
+		t.Errorf("wrong entries after expire: %v", got)
+	}
+}
@@ -3,31 +3,49 @@ package db
 import (
 	"crypto/rand"
 	"errors"
+	"fmt"
 
+	"bazil.org/bazil/cas"
+	"bazil.org/bazil/db/wire"
 	"bazil.org/bazil/fs/clock"
 	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
 	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
 )
 
 var (
 	ErrVolNameInvalid        = errors.New("invalid volume name")
-	ErrVolNameNotFound       = errors.New("volume name not found")
+	ErrVolNameNotFound       = errkind.New(errkind.NotFound, "volume name not found")
 	ErrVolNameExist          = errors.New("volume name exists already")
 	ErrVolumeIDExist         = errors.New("volume ID exists already")
-	ErrVolumeIDNotFound      = errors.New("volume ID not found")
+	ErrVolumeIDNotFound      = errkind.New(errkind.NotFound, "volume ID not found")
 	ErrVolumeEpochWraparound = errors.New("volume epoch wraparound")
+	ErrSnapshotNotFound      = errkind.New(errkind.NotFound, "snapshot not found")
 )
 
 var (
-	bucketVolume        = []byte(tokens.BucketVolume)
-	bucketVolName       = []byte(tokens.BucketVolName)
-	volumeStateDir      = []byte(tokens.VolumeStateDir)
-	volumeStateInode    = []byte(tokens.VolumeStateInode)
-	volumeStateSnap     = []byte(tokens.VolumeStateSnap)
-	volumeStateStorage  = []byte(tokens.VolumeStateStorage)
-	volumeStateEpoch    = []byte(tokens.VolumeStateEpoch)
-	volumeStateClock    = []byte(tokens.VolumeStateClock)
-	volumeStateConflict = []byte(tokens.VolumeStateConflict)
+	bucketVolume            = []byte(tokens.BucketVolume)
+	bucketVolName           = []byte(tokens.BucketVolName)
+	volumeStateDir          = []byte(tokens.VolumeStateDir)
+	volumeStateInode        = []byte(tokens.VolumeStateInode)
+	volumeStateSnap         = []byte(tokens.VolumeStateSnap)
+	volumeStateStorage      = []byte(tokens.VolumeStateStorage)
+	volumeStateEpoch        = []byte(tokens.VolumeStateEpoch)
+	volumeStateClock        = []byte(tokens.VolumeStateClock)
+	volumeStateConflict     = []byte(tokens.VolumeStateConflict)
+	volumeStateJournal      = []byte(tokens.VolumeStateJournal)
+	volumeStateHash         = []byte(tokens.VolumeStateHash)
+	volumeStateSnapChunks   = []byte(tokens.VolumeStateSnapChunks)
+	volumeStateChunkRef     = []byte(tokens.VolumeStateChunkRef)
+	volumeStateHistory      = []byte(tokens.VolumeStateHistory)
+	volumeStateRevoked      = []byte(tokens.VolumeStateRevoked)
+	volumeStateMountpoint   = []byte(tokens.VolumeStateMountpoint)
+	volumeStateLimits       = []byte(tokens.VolumeStateLimits)
+	volumeStatePermUndo     = []byte(tokens.VolumeStatePermUndo)
+	volumeStatePublish      = []byte(tokens.VolumeStatePublish)
+	volumeStateAppendOnly   = []byte(tokens.VolumeStateAppendOnly)
+	volumeStateHistoryClock = []byte(tokens.VolumeStateHistoryClock)
 )
 
 func (tx *Tx) initVolumes() error {
@@ -37,6 +55,29 @@ func (tx *Tx) initVolumes() error {
 	if _, err := tx.CreateBucketIfNotExists(bucketVolName); err != nil {
 		return err
 	}
+
+	// volumes created before the change journal, permission undo
+	// records, append-only marks or file version clocks existed
+	volumes := tx.Bucket(bucketVolume)
+	c := volumes.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v != nil {
+			// not a bucket
+			continue
+		}
+		if _, err := volumes.Bucket(k).CreateBucketIfNotExists(volumeStateJournal); err != nil {
+			return err
+		}
+		if _, err := volumes.Bucket(k).CreateBucketIfNotExists(volumeStatePermUndo); err != nil {
+			return err
+		}
+		if _, err := volumes.Bucket(k).CreateBucketIfNotExists(volumeStateAppendOnly); err != nil {
+			return err
+		}
+		if _, err := volumes.Bucket(k).CreateBucketIfNotExists(volumeStateHistoryClock); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -78,6 +119,23 @@ func (b *Volumes) GetByVolumeID(volID *VolumeID) (*Volume, error) {
 	return v, nil
 }
 
+// Names calls fn for every volume name, in order.
+//
+// name and volID are valid during the call to fn only.
+func (b *Volumes) Names(fn func(name string, volID *VolumeID) error) error {
+	c := b.names.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var volID VolumeID
+		if err := volID.UnmarshalBinary(v); err != nil {
+			return err
+		}
+		if err := fn(string(k), &volID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // add a new volume.
 //
 // If the name exists already, returns ErrVolNameExist.
@@ -121,6 +179,30 @@ func (b *Volumes) add(name string, volID *VolumeID, storage string, sharingKey *
 	if _, err := bv.CreateBucket(volumeStateConflict); err != nil {
 		return nil, err
 	}
+	if _, err := bv.CreateBucket(volumeStateJournal); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStateSnapChunks); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStateChunkRef); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStateHistory); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStateRevoked); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStatePermUndo); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStateAppendOnly); err != nil {
+		return nil, err
+	}
+	if _, err := bv.CreateBucket(volumeStateHistoryClock); err != nil {
+		return nil, err
+	}
 	v := &Volume{
 		b:  bv,
 		id: volID[:],
@@ -132,7 +214,9 @@ func (b *Volumes) add(name string, volID *VolumeID, storage string, sharingKey *
 	if err := v.setEpoch(epoch); err != nil {
 		return nil, err
 	}
-	if _, err := v.Clock().Create(0, "", epoch); err != nil {
+	// the journal starts out with the first change made to the new
+	// volume
+	if _, err := v.Clock().create(0, "", epoch); err != nil {
 		return nil, err
 	}
 	return v, nil
@@ -192,7 +276,7 @@ func (v *Volume) Storage() *VolumeStorage {
 
 func (v *Volume) Clock() *VolumeClock {
 	b := v.b.Bucket(volumeStateClock)
-	return &VolumeClock{b}
+	return &VolumeClock{b, v.Journal()}
 }
 
 func (v *Volume) Conflicts() *VolumeConflicts {
@@ -203,7 +287,11 @@ func (v *Volume) Conflicts() *VolumeConflicts {
 // Dirs provides a way of accessing the directory entries stored in
 // this volume.
 func (v *Volume) Dirs() *Dirs {
-	return &Dirs{b: v.b.Bucket(volumeStateDir)}
+	d := &Dirs{
+		b:       v.b.Bucket(volumeStateDir),
+		journal: v.Journal(),
+	}
+	return d
 }
 
 // InodeBucket returns a bolt bucket for storing inodes in.
@@ -216,6 +304,21 @@ func (v *Volume) SnapBucket() *bolt.Bucket {
 	return v.b.Bucket(volumeStateSnap)
 }
 
+// RemoveSnapshot deletes the named snapshot, and forgets what chunks
+// it refers to.
+//
+// If there is no such snapshot, returns ErrSnapshotNotFound.
+func (v *Volume) RemoveSnapshot(name string) error {
+	b := v.SnapBucket()
+	if b.Get([]byte(name)) == nil {
+		return ErrSnapshotNotFound
+	}
+	if err := b.Delete([]byte(name)); err != nil {
+		return err
+	}
+	return v.SnapshotChunks().Forget(name)
+}
+
 // Epoch returns the current mutation epoch of the volume.
 //
 // Returned value is valid after the transaction.
@@ -239,6 +342,72 @@ func (v *Volume) setEpoch(epoch clock.Epoch) error {
 	return nil
 }
 
+// Hash returns the hash algorithm used for new content in the volume.
+// Existing content keeps the algorithm it was stored with, so a
+// volume may hold content hashed with several algorithms.
+func (v *Volume) Hash() (cas.Hash, error) {
+	val := v.b.Get(volumeStateHash)
+	switch len(val) {
+	case 0:
+		return cas.HashBLAKE2b, nil
+	case 1:
+		h := cas.Hash(val[0])
+		if !h.IsValid() {
+			return h, cas.UnknownHashError{Hash: h}
+		}
+		return h, nil
+	}
+	return 0, fmt.Errorf("volume hash is corrupt: %x", val)
+}
+
+// SetHash changes the hash algorithm used for new content in the
+// volume.
+func (v *Volume) SetHash(h cas.Hash) error {
+	if !h.IsValid() {
+		return cas.UnknownHashError{Hash: h}
+	}
+	return v.b.Put(volumeStateHash, []byte{byte(h)})
+}
+
+// Mountpoint returns where the volume is mounted when the server
+// starts, or "" if it is not mounted automatically.
+func (v *Volume) Mountpoint() string {
+	return string(v.b.Get(volumeStateMountpoint))
+}
+
+// SetMountpoint changes where the volume is mounted when the server
+// starts. An empty mountpoint stops mounting it automatically.
+func (v *Volume) SetMountpoint(mountpoint string) error {
+	if mountpoint == "" {
+		return v.b.Delete(volumeStateMountpoint)
+	}
+	return v.b.Put(volumeStateMountpoint, []byte(mountpoint))
+}
+
+// Limits returns the limits configured for the volume. Zero fields
+// are not configured.
+func (v *Volume) Limits() (*wire.VolumeLimits, error) {
+	var l wire.VolumeLimits
+	if val := v.b.Get(volumeStateLimits); val != nil {
+		if err := proto.Unmarshal(val, &l); err != nil {
+			return nil, fmt.Errorf("volume limits are corrupt: %v", err)
+		}
+	}
+	return &l, nil
+}
+
+// SetLimits replaces the limits configured for the volume.
+func (v *Volume) SetLimits(l *wire.VolumeLimits) error {
+	if *l == (wire.VolumeLimits{}) {
+		return v.b.Delete(volumeStateLimits)
+	}
+	buf, err := proto.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return v.b.Put(volumeStateLimits, buf)
+}
+
 // NextEpoch increments the epoch and returns the new value. The value
 // is only safe to use if the transaction commits.
 //
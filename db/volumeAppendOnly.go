@@ -0,0 +1,39 @@
+package db
+
+import (
+	"encoding/binary"
+
+	"github.com/boltdb/bolt"
+)
+
+// AppendOnly returns the append-only marks of this volume.
+func (v *Volume) AppendOnly() *AppendOnly {
+	b := v.b.Bucket(volumeStateAppendOnly)
+	return &AppendOnly{b}
+}
+
+// AppendOnly remembers which files and directories, by inode, only
+// ever get appended to. For those, versions changed concurrently on
+// different peers are merged rather than kept as conflicts.
+type AppendOnly struct {
+	b *bolt.Bucket
+}
+
+func appendOnlyKey(inode uint64) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], inode)
+	return k[:]
+}
+
+// Set marks the inode as append-only, or removes the mark.
+func (a *AppendOnly) Set(inode uint64, on bool) error {
+	if !on {
+		return a.b.Delete(appendOnlyKey(inode))
+	}
+	return a.b.Put(appendOnlyKey(inode), []byte{})
+}
+
+// Is tells whether the inode is marked append-only.
+func (a *AppendOnly) Is(inode uint64) bool {
+	return a.b.Get(appendOnlyKey(inode)) != nil
+}
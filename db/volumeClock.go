@@ -27,8 +27,13 @@ func (e *ClockNotFoundError) Error() string {
 // cause modification times to trickle upward in the tree, and keeping
 // the clocks separate allows us to do this as a pure database
 // operation, without coordinating with the active FS Node objects.
+//
+// Changes to the clock of an entry are recorded in the change
+// journal, except for directories picking up the clocks of their
+// children.
 type VolumeClock struct {
-	b *bolt.Bucket
+	b       *bolt.Bucket
+	journal *VolumeJournal
 }
 
 func (VolumeClock) pathToKey(parentInode uint64, name string) []byte {
@@ -60,10 +65,26 @@ func (vc *VolumeClock) Put(parentInode uint64, name string, c *clock.Clock) erro
 	if err := vc.b.Put(key, buf); err != nil {
 		return err
 	}
-	return nil
+	return vc.journal.clockChanged(key, buf)
 }
 
 func (vc *VolumeClock) Create(parentInode uint64, name string, now clock.Epoch) (*clock.Clock, error) {
+	c, err := vc.create(parentInode, name, now)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if err := vc.journal.clockChanged(vc.pathToKey(parentInode, name), buf); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// create is Create without recording the change in the journal.
+func (vc *VolumeClock) create(parentInode uint64, name string, now clock.Epoch) (*clock.Clock, error) {
 	c := clock.Create(0, now)
 	buf, err := c.MarshalBinary()
 	if err != nil {
@@ -99,6 +120,9 @@ func (vc *VolumeClock) Update(parentInode uint64, name string, now clock.Epoch)
 	if err := vc.b.Put(key, buf); err != nil {
 		return nil, true, err
 	}
+	if err := vc.journal.clockChanged(key, buf); err != nil {
+		return nil, true, err
+	}
 	return c, true, nil
 }
 
@@ -127,6 +151,9 @@ func (vc *VolumeClock) UpdateOrCreate(parentInode uint64, name string, now clock
 	if err := vc.b.Put(key, buf); err != nil {
 		return nil, true, err
 	}
+	if err := vc.journal.clockChanged(key, buf); err != nil {
+		return nil, true, err
+	}
 	return c, true, nil
 }
 
@@ -173,5 +200,5 @@ func (vc *VolumeClock) Tombstone(parentInode uint64, name string, now clock.Epoc
 	if err := vc.b.Put(key, buf); err != nil {
 		return err
 	}
-	return nil
+	return vc.journal.clockChanged(key, buf)
 }
@@ -11,7 +11,8 @@ import (
 )
 
 type Dirs struct {
-	b *bolt.Bucket
+	b       *bolt.Bucket
+	journal *VolumeJournal
 }
 
 func dirKey(parentInode uint64, name string) []byte {
@@ -52,6 +53,13 @@ func (b *Dirs) Put(parentInode uint64, name string, de *wirefs.Dirent) error {
 	if err := b.b.Put(key, buf); err != nil {
 		return err
 	}
+	op := JournalPut
+	if de.Tombstone != nil {
+		op = JournalTombstone
+	}
+	if err := b.journal.add(op, key, buf); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -66,6 +74,9 @@ func (b *Dirs) Delete(parentInode uint64, name string) error {
 	if err := b.b.Delete(key); err != nil {
 		return err
 	}
+	if err := b.journal.add(JournalDelete, key, nil); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -122,6 +133,9 @@ func (b *Dirs) Rename(parentInode uint64, oldName string, newName string) (*DirE
 	if err := b.b.Put(keyNew, bufOld); err != nil {
 		return nil, err
 	}
+	if err := b.journal.add(JournalPut, keyNew, bufOld); err != nil {
+		return nil, err
+	}
 	tombDE := &wirefs.Dirent{Tombstone: &wirefs.Tombstone{}}
 	tombBuf, err := proto.Marshal(tombDE)
 	if err != nil {
@@ -130,6 +144,9 @@ func (b *Dirs) Rename(parentInode uint64, oldName string, newName string) (*DirE
 	if err := b.b.Put(keyOld, tombBuf); err != nil {
 		return nil, err
 	}
+	if err := b.journal.add(JournalTombstone, keyOld, tombBuf); err != nil {
+		return nil, err
+	}
 
 	return loser, nil
 }
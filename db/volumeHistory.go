@@ -0,0 +1,153 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	wirecas "bazil.org/bazil/cas/wire"
+	"bazil.org/bazil/fs/clock"
+	"bazil.org/bazil/util/errkind"
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	ErrFileVersionNotFound = errkind.New(errkind.NotFound, "file version not found")
+)
+
+// The number of most recent versions to keep for each file.
+const historyMaxVersions = 32
+
+// History returns the file version history of this volume.
+func (v *Volume) History() *VolumeHistory {
+	h := &VolumeHistory{
+		b:      v.b.Bucket(volumeStateHistory),
+		clocks: v.b.Bucket(volumeStateHistoryClock),
+	}
+	return h
+}
+
+// VolumeHistory remembers the recent contents of files, so they can
+// be restored without waiting for a snapshot to have been made.
+type VolumeHistory struct {
+	b      *bolt.Bucket
+	clocks *bolt.Bucket
+}
+
+// FileVersion is one saved version of a file.
+type FileVersion struct {
+	// Time is when the version was saved. It also identifies the
+	// version, at nanosecond precision.
+	Time     time.Time
+	Manifest *wirecas.Manifest
+	// Clock is the logical clock of the file when the version was
+	// saved. It is nil for versions saved before clocks were kept.
+	Clock *clock.Clock
+}
+
+func historyKey(inode uint64, t time.Time) []byte {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], inode)
+	binary.BigEndian.PutUint64(buf[8:], uint64(t.UnixNano()))
+	return buf[:]
+}
+
+// Add records a new version of the file with the given inode, with
+// c the clock of the file at that point. If the contents are the same
+// as in the latest version, nothing is recorded; the earlier clock is
+// kept. Only the most recent versions are kept.
+func (h *VolumeHistory) Add(inode uint64, t time.Time, manifest *wirecas.Manifest, c *clock.Clock) error {
+	buf, err := proto.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	clockBuf, err := c.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	key := historyKey(inode, t)
+	prefix := key[:8]
+
+	var keys [][]byte
+	var latest []byte
+	cur := h.b.Cursor()
+	for k, v := cur.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cur.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+		latest = v
+	}
+	if latest != nil && bytes.Equal(latest, buf) {
+		return nil
+	}
+	if err := h.b.Put(key, buf); err != nil {
+		return err
+	}
+	if err := h.clocks.Put(key, clockBuf); err != nil {
+		return err
+	}
+	keys = append(keys, key)
+	if len(keys) <= historyMaxVersions {
+		return nil
+	}
+	for _, k := range keys[:len(keys)-historyMaxVersions] {
+		if err := h.b.Delete(k); err != nil {
+			return err
+		}
+		if err := h.clocks.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List calls fn for each version of the file with the given inode,
+// oldest first.
+//
+// The FileVersion is only valid during the call.
+func (h *VolumeHistory) List(inode uint64, fn func(*FileVersion) error) error {
+	var prefix [8]byte
+	binary.BigEndian.PutUint64(prefix[:], inode)
+	c := h.b.Cursor()
+	for k, v := c.Seek(prefix[:]); k != nil && bytes.HasPrefix(k, prefix[:]); k, v = c.Next() {
+		fv, err := h.unmarshalFileVersion(k, v)
+		if err != nil {
+			return err
+		}
+		if err := fn(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the version of the file with the given inode saved at
+// exactly time t.
+//
+// If there is no such version, returns ErrFileVersionNotFound.
+func (h *VolumeHistory) Get(inode uint64, t time.Time) (*FileVersion, error) {
+	k := historyKey(inode, t)
+	v := h.b.Get(k)
+	if v == nil {
+		return nil, ErrFileVersionNotFound
+	}
+	return h.unmarshalFileVersion(k, v)
+}
+
+func (h *VolumeHistory) unmarshalFileVersion(k, v []byte) (*FileVersion, error) {
+	var manifest wirecas.Manifest
+	if err := proto.Unmarshal(v, &manifest); err != nil {
+		return nil, err
+	}
+	fv := &FileVersion{
+		Time:     time.Unix(0, int64(binary.BigEndian.Uint64(k[8:]))),
+		Manifest: &manifest,
+	}
+	if buf := h.clocks.Get(k); buf != nil {
+		var c clock.Clock
+		if err := c.UnmarshalBinary(buf); err != nil {
+			return nil, err
+		}
+		fv.Clock = &c
+	}
+	return fv, nil
+}
@@ -0,0 +1,82 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	wirecas "bazil.org/bazil/cas/wire"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/clock"
+)
+
+func TestHistoryBounded(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	const inode = 42
+	start := time.Unix(1000000, 0)
+	change := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		h := v.History()
+		for i := 0; i < 100; i++ {
+			m := &wirecas.Manifest{Size: uint64(i)}
+			c := clock.Create(1, clock.Epoch(i+1))
+			if err := h.Add(inode, start.Add(time.Duration(i)*time.Second), m, c); err != nil {
+				return err
+			}
+			// unchanged content is not recorded again
+			later := clock.Create(1, clock.Epoch(i+1000))
+			if err := h.Add(inode, start.Add(time.Duration(i)*time.Second+1), m, later); err != nil {
+				return err
+			}
+		}
+		if err := h.Add(inode+1, start, &wirecas.Manifest{}, clock.Create(1, 1)); err != nil {
+			return err
+		}
+
+		var sizes []uint64
+		list := func(fv *db.FileVersion) error {
+			sizes = append(sizes, fv.Manifest.Size)
+			return nil
+		}
+		if err := h.List(inode, list); err != nil {
+			return err
+		}
+		if len(sizes) == 0 || len(sizes) >= 100 {
+			t.Fatalf("history not bounded: %d versions", len(sizes))
+		}
+		for i, size := range sizes {
+			if g, e := size, uint64(100-len(sizes)+i); g != e {
+				t.Errorf("wrong version at %d: %d != %d", i, g, e)
+			}
+		}
+
+		fv, err := h.Get(inode, start.Add(99*time.Second))
+		if err != nil {
+			return err
+		}
+		if g, e := fv.Manifest.Size, uint64(99); g != e {
+			t.Errorf("wrong version from Get: %d != %d", g, e)
+		}
+		if fv.Clock == nil {
+			t.Fatal("version has no clock")
+		}
+		if g, e := fv.Clock.String(), clock.Create(1, 100).String(); g != e {
+			t.Errorf("wrong clock from Get: %s != %s", g, e)
+		}
+		if _, err := h.Get(inode, start); err != db.ErrFileVersionNotFound {
+			t.Errorf("expected ErrFileVersionNotFound for trimmed version: %v", err)
+		}
+		return nil
+	}
+	if err := DB.Update(change); err != nil {
+		t.Fatal(err)
+	}
+}
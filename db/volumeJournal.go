@@ -0,0 +1,286 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"bazil.org/bazil/fs/clock"
+	wirefs "bazil.org/bazil/fs/wire"
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	ErrJournalTruncated = errors.New("change journal no longer has entries that old")
+)
+
+// JournalOp is the kind of change recorded in the change journal.
+type JournalOp byte
+
+const (
+	_ JournalOp = iota
+	// JournalPut means the directory entry was created or changed.
+	JournalPut
+	// JournalDelete means the directory entry was removed without a
+	// trace.
+	JournalDelete
+	// JournalTombstone means the directory entry was replaced by a
+	// tombstone.
+	JournalTombstone
+	// JournalClock means only the vector clock of the directory
+	// entry changed.
+	JournalClock
+)
+
+// The number of most recent changes to keep in the journal.
+const journalMaxEntries = 100000
+
+// Journal returns the change journal of this volume.
+func (v *Volume) Journal() *VolumeJournal {
+	b := v.b.Bucket(volumeStateJournal)
+	clocks := v.b.Bucket(volumeStateClock)
+	return &VolumeJournal{b, clocks}
+}
+
+// VolumeJournal records changes to directory entries, in order.
+type VolumeJournal struct {
+	b *bolt.Bucket
+	// current vector clocks of the entries
+	clocks *bolt.Bucket
+}
+
+type journalRecord struct {
+	op    JournalOp
+	tx    uint64
+	key   []byte
+	clock []byte
+	// marshaled wirefs.Dirent; nil for deletes and clock changes
+	dirent []byte
+}
+
+func (r *journalRecord) marshal() []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	var buf bytes.Buffer
+	buf.WriteByte(byte(r.op))
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], r.tx)])
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(r.key)))])
+	buf.Write(r.key)
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(r.clock)))])
+	buf.Write(r.clock)
+	buf.Write(r.dirent)
+	return buf.Bytes()
+}
+
+var errJournalCorrupt = errors.New("db journal corrupt")
+
+func (r *journalRecord) unmarshal(v []byte) error {
+	if len(v) < 1 {
+		return errJournalCorrupt
+	}
+	r.op = JournalOp(v[0])
+	v = v[1:]
+	tx, n := binary.Uvarint(v)
+	if n <= 0 {
+		return errJournalCorrupt
+	}
+	r.tx = tx
+	v = v[n:]
+	field := func() ([]byte, error) {
+		l, n := binary.Uvarint(v)
+		if n <= 0 || uint64(len(v)-n) < l {
+			return nil, errJournalCorrupt
+		}
+		f := v[n : n+int(l)]
+		v = v[n+int(l):]
+		return f, nil
+	}
+	var err error
+	if r.key, err = field(); err != nil {
+		return err
+	}
+	if len(r.key) < 8 {
+		return errJournalCorrupt
+	}
+	if r.clock, err = field(); err != nil {
+		return err
+	}
+	r.dirent = v
+	return nil
+}
+
+// last returns the most recent record, if it was made in the current
+// transaction.
+func (j *VolumeJournal) last() (seq uint64, rec *journalRecord, err error) {
+	seq = j.b.Sequence()
+	if seq == 0 {
+		return 0, nil, nil
+	}
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	v := j.b.Get(key[:])
+	if v == nil {
+		return 0, nil, nil
+	}
+	rec = &journalRecord{}
+	if err := rec.unmarshal(v); err != nil {
+		return 0, nil, err
+	}
+	if rec.tx != uint64(j.b.Tx().ID()) {
+		return 0, nil, nil
+	}
+	return seq, rec, nil
+}
+
+func (j *VolumeJournal) put(seq uint64, rec *journalRecord) error {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	return j.b.Put(key[:], rec.marshal())
+}
+
+func (j *VolumeJournal) add(op JournalOp, dirKey []byte, dirent []byte) error {
+	rec := &journalRecord{
+		op:     op,
+		tx:     uint64(j.b.Tx().ID()),
+		key:    dirKey,
+		dirent: dirent,
+	}
+	if c := j.clocks.Get(dirKey); c != nil {
+		rec.clock = append([]byte(nil), c...)
+	}
+
+	// a clock change just before, in the same transaction, is part
+	// of this change
+	seq, prev, err := j.last()
+	if err != nil {
+		return err
+	}
+	if prev != nil && prev.op == JournalClock && bytes.Equal(prev.key, dirKey) {
+		return j.put(seq, rec)
+	}
+
+	seq, err = j.b.NextSequence()
+	if err != nil {
+		return err
+	}
+	if err := j.put(seq, rec); err != nil {
+		return err
+	}
+
+	if seq > journalMaxEntries {
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], seq-journalMaxEntries)
+		if err := j.b.Delete(key[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clockChanged records that the clock of the entry at dirKey is now
+// buf. If the entry itself was changed earlier in the same
+// transaction, that change gets the new clock instead.
+func (j *VolumeJournal) clockChanged(dirKey []byte, buf []byte) error {
+	seq, prev, err := j.last()
+	if err != nil {
+		return err
+	}
+	if prev != nil && bytes.Equal(prev.key, dirKey) {
+		prev.clock = buf
+		return j.put(seq, prev)
+	}
+	// the caller has already stored the new clock, which add picks up
+	return j.add(JournalClock, dirKey, nil)
+}
+
+// Last returns the sequence number of the most recent change, or 0 if
+// nothing has been recorded yet.
+func (j *VolumeJournal) Last() uint64 {
+	return j.b.Sequence()
+}
+
+// List returns a cursor for the changes after sequence number after.
+// Pass 0 to start from the oldest change kept.
+//
+// If changes right after the given sequence number have already been
+// discarded, returns ErrJournalTruncated; the caller has missed
+// changes and needs to rescan the volume.
+func (j *VolumeJournal) List(after uint64) (*JournalCursor, error) {
+	c := j.b.Cursor()
+	if after > 0 && after < j.b.Sequence() {
+		k, _ := c.First()
+		if k == nil || binary.BigEndian.Uint64(k) > after+1 {
+			return nil, ErrJournalTruncated
+		}
+	}
+	jc := &JournalCursor{
+		c:     c,
+		after: after,
+	}
+	return jc, nil
+}
+
+type JournalCursor struct {
+	c     *bolt.Cursor
+	after uint64
+}
+
+func (c *JournalCursor) item(k, v []byte) *JournalEntry {
+	if k == nil {
+		return nil
+	}
+	var rec journalRecord
+	if len(k) != 8 || rec.unmarshal(v) != nil {
+		panic("db journal corrupt")
+	}
+	e := &JournalEntry{
+		Seq:         binary.BigEndian.Uint64(k),
+		Op:          rec.op,
+		Tx:          rec.tx,
+		ParentInode: binary.BigEndian.Uint64(rec.key),
+		Name:        string(rec.key[8:]),
+	}
+	if rec.clock != nil {
+		e.Clock = &clock.Clock{}
+		if err := e.Clock.UnmarshalBinary(rec.clock); err != nil {
+			panic("db journal corrupt")
+		}
+	}
+	switch rec.op {
+	case JournalPut, JournalTombstone:
+		e.Dirent = &wirefs.Dirent{}
+		if err := proto.Unmarshal(rec.dirent, e.Dirent); err != nil {
+			panic("db journal corrupt")
+		}
+	}
+	return e
+}
+
+func (c *JournalCursor) First() *JournalEntry {
+	var start [8]byte
+	binary.BigEndian.PutUint64(start[:], c.after+1)
+	return c.item(c.c.Seek(start[:]))
+}
+
+func (c *JournalCursor) Next() *JournalEntry {
+	return c.item(c.c.Next())
+}
+
+// JournalEntry is a single change in the change journal.
+//
+// Values are valid after the transaction.
+type JournalEntry struct {
+	Seq uint64
+	Op  JournalOp
+	// Changes made in the same transaction have the same Tx, and
+	// became visible together.
+	Tx          uint64
+	ParentInode uint64
+	Name        string
+	// The vector clock of the entry after the change, or nil if it
+	// had none yet. Peer identifiers are local to this node.
+	Clock *clock.Clock
+	// The entry after the change, with its inode and contents. Nil
+	// for JournalDelete and JournalClock.
+	Dirent *wirefs.Dirent
+}
@@ -0,0 +1,176 @@
+package db_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/db"
+	wirefs "bazil.org/bazil/fs/wire"
+)
+
+func TestJournal(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	var want1Tx uint64
+	change := func(tx *db.Tx) error {
+		want1Tx = uint64(tx.ID())
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		dirs := v.Dirs()
+		if err := dirs.Put(1, "a", &wirefs.Dirent{Inode: 2}); err != nil {
+			return err
+		}
+		if _, err := dirs.Rename(1, "a", "b"); err != nil {
+			return err
+		}
+		if err := dirs.Delete(1, "a"); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := DB.Update(change); err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		op   db.JournalOp
+		name string
+	}
+	want := []entry{
+		{db.JournalPut, "a"},
+		{db.JournalPut, "b"},
+		{db.JournalTombstone, "a"},
+		{db.JournalDelete, "a"},
+	}
+	check := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName("foo")
+		if err != nil {
+			return err
+		}
+		c, err := v.Journal().List(1)
+		if err != nil {
+			return err
+		}
+		// skipped the first entry
+		i := 1
+		for item := c.First(); item != nil; item = c.Next() {
+			if i >= len(want) {
+				t.Errorf("unexpected journal entry: %+v", item)
+				continue
+			}
+			if g, e := item.Seq, uint64(i+1); g != e {
+				t.Errorf("wrong seq: %d != %d", g, e)
+			}
+			if g, e := (entry{item.Op, item.Name}), want[i]; g != e {
+				t.Errorf("wrong journal entry: %+v != %+v", g, e)
+			}
+			if g, e := item.ParentInode, uint64(1); g != e {
+				t.Errorf("wrong parent inode: %d != %d", g, e)
+			}
+			if item.Op == db.JournalPut {
+				if item.Dirent == nil {
+					t.Errorf("journal entry has no dirent: %+v", item)
+				} else if g, e := item.Dirent.Inode, uint64(2); g != e {
+					t.Errorf("wrong inode: %d != %d", g, e)
+				}
+			}
+			if g, e := item.Tx, want1Tx; g != e {
+				t.Errorf("wrong tx: %d != %d", g, e)
+			}
+			i++
+		}
+		if i != len(want) {
+			t.Errorf("journal ended early after %d entries", i)
+		}
+		if g, e := v.Journal().Last(), uint64(len(want)); g != e {
+			t.Errorf("wrong last seq: %d != %d", g, e)
+		}
+		return nil
+	}
+	if err := DB.View(check); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJournalClock(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	create := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		// clock written after the entry, in the same transaction
+		if err := v.Dirs().Put(1, "a", &wirefs.Dirent{Inode: 2}); err != nil {
+			return err
+		}
+		if _, err := v.Clock().Create(1, "a", 1); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := DB.Update(create); err != nil {
+		t.Fatal(err)
+	}
+	update := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName("foo")
+		if err != nil {
+			return err
+		}
+		if _, _, err := v.Clock().Update(1, "a", 2); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := DB.Update(update); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName("foo")
+		if err != nil {
+			return err
+		}
+		c, err := v.Journal().List(0)
+		if err != nil {
+			return err
+		}
+		var entries []*db.JournalEntry
+		for item := c.First(); item != nil; item = c.Next() {
+			entries = append(entries, item)
+		}
+		if g, e := len(entries), 2; g != e {
+			t.Fatalf("wrong number of journal entries: %d != %d", g, e)
+		}
+		if g, e := entries[0].Op, db.JournalPut; g != e {
+			t.Errorf("wrong op: %v != %v", g, e)
+		}
+		if entries[0].Clock == nil {
+			t.Errorf("clock not recorded with the change")
+		}
+		if g, e := entries[1].Op, db.JournalClock; g != e {
+			t.Errorf("wrong op: %v != %v", g, e)
+		}
+		if entries[1].Dirent != nil {
+			t.Errorf("clock change has a dirent: %v", entries[1].Dirent)
+		}
+		if entries[1].Clock == nil || entries[1].Clock.String() == entries[0].Clock.String() {
+			t.Errorf("clock change has the old clock: %v", entries[1].Clock)
+		}
+		return nil
+	}
+	if err := DB.View(check); err != nil {
+		t.Fatal(err)
+	}
+}
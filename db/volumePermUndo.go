@@ -0,0 +1,119 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+
+	wirefs "bazil.org/bazil/fs/wire"
+	"bazil.org/bazil/util/errkind"
+	"github.com/boltdb/bolt"
+	"github.com/golang/protobuf/proto"
+)
+
+var (
+	ErrPermUndoNameInvalid = errors.New("invalid undo record name")
+	ErrPermUndoExist       = errors.New("undo record exists already")
+	ErrPermUndoNotFound    = errkind.New(errkind.NotFound, "undo record not found")
+)
+
+// PermUndo returns the undo records of bulk permission changes in
+// this volume.
+func (v *Volume) PermUndo() *PermUndos {
+	b := v.b.Bucket(volumeStatePermUndo)
+	return &PermUndos{b}
+}
+
+// PermUndos keeps, for each bulk permission change, what the entries
+// it changed looked like before, so the change can be undone.
+type PermUndos struct {
+	b *bolt.Bucket
+}
+
+// Create starts a new, empty undo record.
+func (u *PermUndos) Create(name string) (*PermUndo, error) {
+	if name == "" {
+		return nil, ErrPermUndoNameInvalid
+	}
+	b, err := u.b.CreateBucket([]byte(name))
+	if err == bolt.ErrBucketExists {
+		return nil, ErrPermUndoExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &PermUndo{b}, nil
+}
+
+// Get returns the undo record by that name.
+func (u *PermUndos) Get(name string) (*PermUndo, error) {
+	b := u.b.Bucket([]byte(name))
+	if b == nil {
+		return nil, ErrPermUndoNotFound
+	}
+	return &PermUndo{b}, nil
+}
+
+// Delete removes the undo record by that name.
+func (u *PermUndos) Delete(name string) error {
+	err := u.b.DeleteBucket([]byte(name))
+	if err == bolt.ErrBucketNotFound {
+		return ErrPermUndoNotFound
+	}
+	return err
+}
+
+// List calls fn with the name of each undo record, in sorted order.
+func (u *PermUndos) List(fn func(name string) error) error {
+	c := u.b.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if err := fn(string(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PermUndo is the record of a single bulk permission change.
+type PermUndo struct {
+	b *bolt.Bucket
+}
+
+// Add remembers the permissions the entry had before the change. A
+// nil old means the entry had the default permissions.
+func (u *PermUndo) Add(parentInode uint64, name string, old *wirefs.Perm) error {
+	var buf []byte
+	if old != nil {
+		var err error
+		buf, err = proto.Marshal(old)
+		if err != nil {
+			return err
+		}
+	}
+	// bolt does not allow nil values
+	if buf == nil {
+		buf = []byte{}
+	}
+	return u.b.Put(dirKey(parentInode, name), buf)
+}
+
+// Each calls fn for every entry in the record. fn must not modify
+// the record.
+func (u *PermUndo) Each(fn func(parentInode uint64, name string, old *wirefs.Perm) error) error {
+	c := u.b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(k) < 8 {
+			return errors.New("corrupt undo record key")
+		}
+		var old *wirefs.Perm
+		if len(v) > 0 {
+			old = &wirefs.Perm{}
+			if err := proto.Unmarshal(v, old); err != nil {
+				return err
+			}
+		}
+		if err := fn(binary.BigEndian.Uint64(k[:8]), string(basename(k)), old); err != nil {
+			return err
+		}
+	}
+	return nil
+}
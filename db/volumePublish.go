@@ -0,0 +1,142 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	"bazil.org/bazil/util/errkind"
+)
+
+var (
+	ErrNotPublished = errkind.New(errkind.NotFound, "volume is not published")
+	ErrBadPublish   = errors.New("corrupt volume publish state")
+)
+
+const PublicIDLen = 16
+
+// PublicID identifies a published volume to consumers that know
+// nothing else about it. Unlike the volume ID, it can be handed out
+// freely.
+type PublicID [PublicIDLen]byte
+
+var _ encoding.BinaryUnmarshaler = (*PublicID)(nil)
+
+func (p *PublicID) UnmarshalBinary(data []byte) error {
+	if len(data) != len(p) {
+		return fmt.Errorf("public volume id must be exactly %d bytes", PublicIDLen)
+	}
+	copy(p[:], data)
+	return nil
+}
+
+var _ flag.Value = (*PublicID)(nil)
+
+func (p *PublicID) String() string {
+	return hex.EncodeToString(p[:])
+}
+
+func (p *PublicID) Set(value string) error {
+	if hex.DecodedLen(len(value)) != PublicIDLen {
+		return fmt.Errorf("not a valid public volume id: wrong size")
+	}
+	if _, err := hex.Decode(p[:], []byte(value)); err != nil {
+		return fmt.Errorf("not a valid public volume id: %v", err)
+	}
+	return nil
+}
+
+func (v *Volume) publishState() (id *PublicID, on bool, err error) {
+	val := v.b.Get(volumeStatePublish)
+	if val == nil {
+		return nil, false, nil
+	}
+	if len(val) != PublicIDLen+1 {
+		return nil, false, ErrBadPublish
+	}
+	id = new(PublicID)
+	copy(id[:], val)
+	return id, val[PublicIDLen] == 1, nil
+}
+
+// Published returns the public identifier of the volume. If the
+// volume is not published, returns ErrNotPublished.
+func (v *Volume) Published() (*PublicID, error) {
+	id, on, err := v.publishState()
+	if err != nil {
+		return nil, err
+	}
+	if !on {
+		return nil, ErrNotPublished
+	}
+	return id, nil
+}
+
+// Publish makes the snapshots of the volume available to anyone who
+// knows the returned public identifier. A volume published before
+// gets the same identifier again.
+func (v *Volume) Publish() (*PublicID, error) {
+	id, _, err := v.publishState()
+	if err != nil {
+		return nil, err
+	}
+	if id == nil {
+		id = new(PublicID)
+		if _, err := rand.Read(id[:]); err != nil {
+			return nil, err
+		}
+	}
+	buf := make([]byte, 0, PublicIDLen+1)
+	buf = append(buf, id[:]...)
+	buf = append(buf, 1)
+	if err := v.b.Put(volumeStatePublish, buf); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// Unpublish stops publishing the volume. Unpublishing a volume that
+// is not published is not an error.
+func (v *Volume) Unpublish() error {
+	id, on, err := v.publishState()
+	if err != nil {
+		return err
+	}
+	if !on {
+		return nil
+	}
+	buf := make([]byte, 0, PublicIDLen+1)
+	buf = append(buf, id[:]...)
+	buf = append(buf, 0)
+	return v.b.Put(volumeStatePublish, buf)
+}
+
+// GetByPublicID returns the published volume with the given public
+// identifier. If there is no such volume, or it is no longer
+// published, returns ErrNotPublished.
+func (b *Volumes) GetByPublicID(id *PublicID) (*Volume, error) {
+	c := b.volumes.Cursor()
+	for k, val := c.First(); k != nil; k, val = c.Next() {
+		if val != nil {
+			// not a bucket
+			continue
+		}
+		v := &Volume{
+			b:  b.volumes.Bucket(k),
+			id: k,
+		}
+		got, err := v.Published()
+		if err != nil {
+			// unpublished, or corrupt; either way not something
+			// to hand out
+			continue
+		}
+		if *got == *id {
+			return v, nil
+		}
+	}
+	return nil, ErrNotPublished
+}
@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/db"
+)
+
+func TestVolumePublish(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	change := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		if _, err := v.Published(); err != db.ErrNotPublished {
+			t.Errorf("expected ErrNotPublished before publishing: %v", err)
+		}
+
+		id, err := v.Publish()
+		if err != nil {
+			return err
+		}
+		got, err := tx.Volumes().GetByPublicID(id)
+		if err != nil {
+			t.Fatalf("GetByPublicID: %v", err)
+		}
+		var want, found db.VolumeID
+		v.VolumeID(&want)
+		got.VolumeID(&found)
+		if found != want {
+			t.Errorf("wrong volume: %v != %v", found, want)
+		}
+
+		if err := v.Unpublish(); err != nil {
+			return err
+		}
+		if _, err := tx.Volumes().GetByPublicID(id); err != db.ErrNotPublished {
+			t.Errorf("expected ErrNotPublished after unpublishing: %v", err)
+		}
+
+		// publishing again keeps the identifier
+		again, err := v.Publish()
+		if err != nil {
+			return err
+		}
+		if *again != *id {
+			t.Errorf("public id changed: %v != %v", again, id)
+		}
+		return nil
+	}
+	if err := DB.Update(change); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"bazil.org/bazil/peer"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	ErrBadRevocation = errors.New("corrupt volume revocation")
+)
+
+// Revocations returns the record of peers that stopped sharing this
+// volume.
+func (v *Volume) Revocations() *VolumeRevocations {
+	b := v.b.Bucket(volumeStateRevoked)
+	return &VolumeRevocations{b}
+}
+
+// VolumeRevocations remembers, for each peer, whether sharing the
+// volume between us and the peer has been revoked, and when.
+type VolumeRevocations struct {
+	b *bolt.Bucket
+}
+
+// Record notes that sharing with the peer was revoked, or restored,
+// at time t. Changes older than the one already recorded are
+// ignored, so that messages delivered out of order cannot undo a
+// later decision.
+func (r *VolumeRevocations) Record(pub *peer.PublicKey, t time.Time, revoked bool) error {
+	if old, _, err := r.get(pub); err == nil && old.After(t) {
+		return nil
+	}
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(t.Unix()))
+	if revoked {
+		buf[8] = 1
+	}
+	return r.b.Put(pub[:], buf[:])
+}
+
+// IsRevoked reports whether sharing the volume with the peer is
+// currently revoked, and since when.
+func (r *VolumeRevocations) IsRevoked(pub *peer.PublicKey) (since time.Time, revoked bool, err error) {
+	return r.get(pub)
+}
+
+func (r *VolumeRevocations) get(pub *peer.PublicKey) (time.Time, bool, error) {
+	v := r.b.Get(pub[:])
+	if v == nil {
+		return time.Time{}, false, nil
+	}
+	if len(v) != 9 {
+		return time.Time{}, false, ErrBadRevocation
+	}
+	t := time.Unix(int64(binary.BigEndian.Uint64(v[:8])), 0)
+	return t, v[8] != 0, nil
+}
@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+)
+
+func TestVolumeRevocations(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	pub := &peer.PublicKey{1, 2, 3}
+	t1 := time.Unix(1000, 0)
+	t2 := time.Unix(2000, 0)
+
+	check := func(r *db.VolumeRevocations, wantSince time.Time, wantRevoked bool) {
+		since, revoked, err := r.IsRevoked(pub)
+		if err != nil {
+			t.Fatalf("IsRevoked: %v", err)
+		}
+		if revoked != wantRevoked || !since.Equal(wantSince) {
+			t.Errorf("wrong revocation: %v %v != %v %v", since, revoked, wantSince, wantRevoked)
+		}
+	}
+
+	change := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		r := v.Revocations()
+		check(r, time.Time{}, false)
+
+		if err := r.Record(pub, t2, true); err != nil {
+			return err
+		}
+		check(r, t2, true)
+
+		// an older restore arriving late must not undo the revocation
+		if err := r.Record(pub, t1, false); err != nil {
+			return err
+		}
+		check(r, t2, true)
+
+		if err := r.Record(pub, t2.Add(time.Second), false); err != nil {
+			return err
+		}
+		check(r, t2.Add(time.Second), false)
+		return nil
+	}
+	if err := DB.Update(change); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"bazil.org/bazil/cas"
+	"github.com/boltdb/bolt"
+)
+
+var (
+	ErrSnapshotChunksExist = errors.New("snapshot chunks recorded already")
+)
+
+// SnapshotChunk is a chunk that a snapshot refers to.
+type SnapshotChunk struct {
+	Key   cas.Key
+	Type  string
+	Level uint8
+	// Size is the size of the chunk contents, in bytes.
+	Size uint64
+}
+
+func snapshotChunkKey(c *SnapshotChunk) []byte {
+	k := make([]byte, 0, cas.KeySize+1+len(c.Type))
+	k = append(k, c.Key.Bytes()...)
+	k = append(k, c.Level)
+	k = append(k, c.Type...)
+	return k
+}
+
+// SnapshotChunks returns the chunk reference counts of this volume.
+// They are used to tell how much storage each snapshot is
+// responsible for.
+func (v *Volume) SnapshotChunks() *SnapshotChunks {
+	s := &SnapshotChunks{
+		lists: v.b.Bucket(volumeStateSnapChunks),
+		refs:  v.b.Bucket(volumeStateChunkRef),
+	}
+	return s
+}
+
+type SnapshotChunks struct {
+	lists *bolt.Bucket
+	refs  *bolt.Bucket
+}
+
+// Record remembers that the named snapshot refers to the given
+// chunks, and adds to their reference counts. Duplicates are counted
+// once.
+//
+// If chunks have already been recorded for the name, returns
+// ErrSnapshotChunksExist.
+func (s *SnapshotChunks) Record(name string, chunks []SnapshotChunk) error {
+	list, err := s.lists.CreateBucket([]byte(name))
+	if err == bolt.ErrBucketExists {
+		return ErrSnapshotChunksExist
+	}
+	if err != nil {
+		return err
+	}
+	var size [8]byte
+	for i := range chunks {
+		k := snapshotChunkKey(&chunks[i])
+		if list.Get(k) != nil {
+			continue
+		}
+		binary.BigEndian.PutUint64(size[:], chunks[i].Size)
+		if err := list.Put(k, size[:]); err != nil {
+			return err
+		}
+		if err := s.adjust(k, +1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SnapshotChunks) count(k []byte) uint32 {
+	v := s.refs.Get(k)
+	if len(v) != 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(v)
+}
+
+func (s *SnapshotChunks) adjust(k []byte, delta int) error {
+	n := int64(s.count(k)) + int64(delta)
+	if n <= 0 {
+		// going below zero means the counts were reset, e.g. by a
+		// database repair; there is nothing better to do than
+		// forget the chunk
+		return s.refs.Delete(k)
+	}
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(n))
+	return s.refs.Put(k, buf[:])
+}
+
+// Forget removes the record of what chunks the named snapshot refers
+// to, and drops their reference counts. Forgetting a snapshot that
+// has no record is not an error.
+func (s *SnapshotChunks) Forget(name string) error {
+	n := []byte(name)
+	list := s.lists.Bucket(n)
+	if list == nil {
+		return nil
+	}
+	c := list.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if err := s.adjust(k, -1); err != nil {
+			return err
+		}
+	}
+	return s.lists.DeleteBucket(n)
+}
+
+// Refers tells whether any snapshot refers to the chunk.
+func (s *SnapshotChunks) Refers(c *SnapshotChunk) bool {
+	return s.count(snapshotChunkKey(c)) > 0
+}
+
+// SnapshotUsage tells how much storage a snapshot refers to.
+type SnapshotUsage struct {
+	// Total size of all chunks the snapshot refers to.
+	Total uint64
+	// Size of chunks no other snapshot refers to. Deleting the
+	// snapshot frees at most this much; chunks the live volume still
+	// uses are not freed, and are not tracked here.
+	Exclusive uint64
+}
+
+// Usage returns the storage usage of the named snapshot. If no
+// chunks are recorded for the snapshot, ok is false.
+func (s *SnapshotChunks) Usage(name string) (usage SnapshotUsage, ok bool) {
+	list := s.lists.Bucket([]byte(name))
+	if list == nil {
+		return usage, false
+	}
+	c := list.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if len(v) != 8 {
+			continue
+		}
+		size := binary.BigEndian.Uint64(v)
+		usage.Total += size
+		if s.count(k) <= 1 {
+			usage.Exclusive += size
+		}
+	}
+	return usage, true
+}
+
+// Freed tells how much storage removing all of the named snapshots
+// together could free; that is, the chunks no other snapshot refers
+// to. See SnapshotUsage.Exclusive.
+//
+// If any of the snapshots has no record of its chunks, ok is false
+// and the result is too low.
+func (s *SnapshotChunks) Freed(names []string) (freed SnapshotUsage, ok bool) {
+	ok = true
+	refs := make(map[string]uint32)
+	sizes := make(map[string]uint64)
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if _, dup := seen[name]; dup {
+			continue
+		}
+		seen[name] = struct{}{}
+		list := s.lists.Bucket([]byte(name))
+		if list == nil {
+			ok = false
+			continue
+		}
+		c := list.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if len(v) != 8 {
+				continue
+			}
+			refs[string(k)]++
+			sizes[string(k)] = binary.BigEndian.Uint64(v)
+		}
+	}
+	for k, n := range refs {
+		size := sizes[k]
+		freed.Total += size
+		if s.count([]byte(k)) <= n {
+			freed.Exclusive += size
+		}
+	}
+	return freed, ok
+}
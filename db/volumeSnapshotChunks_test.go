@@ -0,0 +1,126 @@
+package db_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/cas"
+	"bazil.org/bazil/db"
+)
+
+func TestSnapshotChunksUsage(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	key := func(b byte) cas.Key {
+		buf := make([]byte, cas.KeySize)
+		buf[0] = b
+		return cas.NewKey(buf)
+	}
+	shared := db.SnapshotChunk{Key: key(1), Type: "file", Size: 100}
+	mine := db.SnapshotChunk{Key: key(2), Type: "file", Size: 10}
+	theirs := db.SnapshotChunk{Key: key(3), Type: "dir", Size: 1}
+
+	usage := func(v *db.Volume, name string, total, unique uint64) {
+		u, ok := v.SnapshotChunks().Usage(name)
+		if !ok {
+			t.Fatalf("no usage for %q", name)
+		}
+		if g, e := u, (db.SnapshotUsage{Total: total, Exclusive: unique}); g != e {
+			t.Errorf("wrong usage for %q: %+v != %+v", name, g, e)
+		}
+	}
+
+	change := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		sc := v.SnapshotChunks()
+		if err := sc.Record("a", []db.SnapshotChunk{shared, mine, mine}); err != nil {
+			return err
+		}
+		usage(v, "a", 110, 110)
+
+		if err := sc.Record("b", []db.SnapshotChunk{shared, theirs}); err != nil {
+			return err
+		}
+		usage(v, "a", 110, 10)
+		usage(v, "b", 101, 1)
+
+		if err := sc.Record("b", nil); err != db.ErrSnapshotChunksExist {
+			t.Errorf("expected ErrSnapshotChunksExist: %v", err)
+		}
+
+		if err := sc.Forget("a"); err != nil {
+			return err
+		}
+		if _, ok := sc.Usage("a"); ok {
+			t.Errorf("forgotten snapshot still has usage")
+		}
+		usage(v, "b", 101, 101)
+		return nil
+	}
+	if err := DB.Update(change); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotChunksFreed(t *testing.T) {
+	DB := NewTestDB(t)
+	defer DB.Close()
+
+	key := func(b byte) cas.Key {
+		buf := make([]byte, cas.KeySize)
+		buf[0] = b
+		return cas.NewKey(buf)
+	}
+	shared := db.SnapshotChunk{Key: key(1), Type: "file", Size: 100}
+	ab := db.SnapshotChunk{Key: key(2), Type: "file", Size: 10}
+	c := db.SnapshotChunk{Key: key(3), Type: "file", Size: 1}
+
+	change := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		sc := v.SnapshotChunks()
+		if err := sc.Record("a", []db.SnapshotChunk{shared, ab}); err != nil {
+			return err
+		}
+		if err := sc.Record("b", []db.SnapshotChunk{shared, ab}); err != nil {
+			return err
+		}
+		if err := sc.Record("c", []db.SnapshotChunk{shared, c}); err != nil {
+			return err
+		}
+
+		for _, tc := range []struct {
+			names []string
+			want  db.SnapshotUsage
+			ok    bool
+		}{
+			{[]string{"a"}, db.SnapshotUsage{Total: 110, Exclusive: 0}, true},
+			{[]string{"a", "b"}, db.SnapshotUsage{Total: 110, Exclusive: 10}, true},
+			{[]string{"a", "a"}, db.SnapshotUsage{Total: 110, Exclusive: 0}, true},
+			{[]string{"a", "b", "c"}, db.SnapshotUsage{Total: 111, Exclusive: 111}, true},
+			{[]string{"c", "unknown"}, db.SnapshotUsage{Total: 101, Exclusive: 1}, false},
+		} {
+			got, ok := sc.Freed(tc.names)
+			if got != tc.want || ok != tc.ok {
+				t.Errorf("wrong result for %q: %+v %v != %+v %v", tc.names, got, ok, tc.want, tc.ok)
+			}
+		}
+		return nil
+	}
+	if err := DB.Update(change); err != nil {
+		t.Fatal(err)
+	}
+}
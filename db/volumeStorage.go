@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"bazil.org/bazil/db/wire"
+	"bazil.org/bazil/peer"
 	"github.com/boltdb/bolt"
 	"github.com/golang/protobuf/proto"
 )
@@ -94,3 +95,78 @@ func (item *VolumeStorageItem) SharingKeyName() (string, error) {
 	}
 	return item.conf.SharingKeyName, nil
 }
+
+// peerBackend returns the storage backend that stores chunks on the
+// given peer.
+func peerBackend(pub *peer.PublicKey) string {
+	return "peerkey:" + pub.String()
+}
+
+// usesStorage reports whether any volume uses the given storage
+// backend.
+func (b *Volumes) usesStorage(backend string) (bool, error) {
+	found := false
+	check := func(name string, volID *VolumeID) error {
+		v, err := b.GetByVolumeID(volID)
+		if err != nil {
+			return err
+		}
+		c := v.Storage().Cursor()
+		for item := c.First(); item != nil; item = c.Next() {
+			s, err := item.Backend()
+			if err != nil {
+				return err
+			}
+			if s == backend {
+				found = true
+			}
+		}
+		return nil
+	}
+	if err := b.Names(check); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// replaceStorage switches every volume using the storage backend old
+// over to backend.
+//
+// Active Volume instances are not notified.
+func (b *Volumes) replaceStorage(old, backend string) error {
+	replace := func(name string, volID *VolumeID) error {
+		v, err := b.GetByVolumeID(volID)
+		if err != nil {
+			return err
+		}
+		return v.Storage().replaceBackend(old, backend)
+	}
+	return b.Names(replace)
+}
+
+func (vs *VolumeStorage) replaceBackend(old, backend string) error {
+	changed := make(map[string][]byte)
+	c := vs.b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var msg wire.VolumeStorage
+		if err := proto.Unmarshal(v, &msg); err != nil {
+			return err
+		}
+		if msg.Backend != old {
+			continue
+		}
+		msg.Backend = backend
+		buf, err := proto.Marshal(&msg)
+		if err != nil {
+			return err
+		}
+		changed[string(k)] = buf
+	}
+	// not while iterating, that would confuse the cursor
+	for k, buf := range changed {
+		if err := vs.b.Put([]byte(k), buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
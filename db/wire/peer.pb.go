@@ -0,0 +1,28 @@
+// Code generated by protoc-gen-go.
+// source: bazil.org/bazil/db/wire/peer.proto
+// DO NOT EDIT!
+
+package wire
+
+import proto "github.com/golang/protobuf/proto"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+
+// PeerKeyAlert records that something other than the peer answered
+// at one of its addresses, presenting a different public key.
+type PeerKeyAlert struct {
+	// The public key presented instead of the one of the peer.
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// Address it was seen at.
+	Addr string `protobuf:"bytes,2,opt,name=addr" json:"addr,omitempty"`
+	// When it was first and last seen, as Unix seconds.
+	First int64 `protobuf:"varint,3,opt,name=first" json:"first,omitempty"`
+	Last  int64 `protobuf:"varint,4,opt,name=last" json:"last,omitempty"`
+	// Number of connections refused because of it.
+	Count uint64 `protobuf:"varint,5,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *PeerKeyAlert) Reset()         { *m = PeerKeyAlert{} }
+func (m *PeerKeyAlert) String() string { return proto.CompactTextString(m) }
+func (*PeerKeyAlert) ProtoMessage()    {}
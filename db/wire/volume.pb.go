@@ -10,6 +10,7 @@ It is generated from these files:
 
 It has these top-level messages:
 	VolumeStorage
+	VolumeLimits
 */
 package wire
 
@@ -26,3 +27,15 @@ type VolumeStorage struct {
 func (m *VolumeStorage) Reset()         { *m = VolumeStorage{} }
 func (m *VolumeStorage) String() string { return proto.CompactTextString(m) }
 func (*VolumeStorage) ProtoMessage()    {}
+
+// Limits configured for a single volume. Zero means the server-wide
+// default applies.
+type VolumeLimits struct {
+	MaxFileSize  uint64 `protobuf:"varint,1,opt,name=maxFileSize" json:"maxFileSize,omitempty"`
+	MaxNameBytes uint32 `protobuf:"varint,2,opt,name=maxNameBytes" json:"maxNameBytes,omitempty"`
+	MaxDepth     uint32 `protobuf:"varint,3,opt,name=maxDepth" json:"maxDepth,omitempty"`
+}
+
+func (m *VolumeLimits) Reset()         { *m = VolumeLimits{} }
+func (m *VolumeLimits) String() string { return proto.CompactTextString(m) }
+func (*VolumeLimits) ProtoMessage()    {}
@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"sync"
+
+	"bazil.org/bazil/util/env"
+	"bazil.org/fuse"
+)
+
+// Access is what a local user may do in a volume.
+type Access int
+
+const (
+	// AccessNone denies all access.
+	AccessNone Access = iota
+	// AccessRead allows reading files and listing directories.
+	AccessRead
+	// AccessOwner allows everything the owner of the volume can do.
+	AccessOwner
+)
+
+// UserAccess decides what local users other than the one running
+// the server may do, when the volume is mounted so that they can see
+// it. Their requests are squashed to the given access level instead
+// of being checked against file permissions.
+type UserAccess struct {
+	// Access for users not listed in Users.
+	Others Access
+	// Access by UID.
+	Users map[uint32]Access
+}
+
+func (u *UserAccess) lookup(uid uint32) Access {
+	if uid == env.MyUID {
+		return AccessOwner
+	}
+	if a, ok := u.Users[uid]; ok {
+		return a
+	}
+	return u.Others
+}
+
+type userAccess struct {
+	mu sync.Mutex
+	// nil when only the owner can reach the mount; the kernel keeps
+	// everyone else out
+	conf *UserAccess
+}
+
+// SetUserAccess configures access for other local users. Pass nil
+// when the volume is not visible to other users.
+func (v *Volume) SetUserAccess(conf *UserAccess) {
+	v.access.mu.Lock()
+	defer v.access.mu.Unlock()
+	v.access.conf = conf
+}
+
+// checkAccess returns an error if the user making the request may
+// not perform it. write tells whether the request changes anything.
+func (v *Volume) checkAccess(hdr *fuse.Header, write bool) error {
+	v.access.mu.Lock()
+	conf := v.access.conf
+	v.access.mu.Unlock()
+	if conf == nil {
+		return nil
+	}
+	switch conf.lookup(hdr.Uid) {
+	case AccessOwner:
+		return nil
+	case AccessRead:
+		if !write {
+			return nil
+		}
+	}
+	return fuse.EPERM
+}
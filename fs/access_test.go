@@ -0,0 +1,205 @@
+package fs_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"bazil.org/bazil/fs"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/controltest"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/server/http/httptest"
+	"bazil.org/bazil/util/env"
+	"bazil.org/bazil/util/grpcunix"
+	"bazil.org/bazil/util/tempdir"
+)
+
+// actAsOtherUser makes the filesystem see the test process as a user
+// other than the one running the server. Call the returned function
+// to undo it.
+func actAsOtherUser() func() {
+	orig := env.MyUID
+	env.MyUID = uint32(os.Getuid()) + 1
+	return func() { env.MyUID = orig }
+}
+
+// mountedWithAccess mounts the volume at a new mountpoint, so that
+// nothing is in the kernel caches yet, and sets the access for other
+// users.
+func mountedWithAccess(t testing.TB, app *server.App, volumeName string, access fs.Access) *bazfstestutil.Mount {
+	mnt := bazfstestutil.Mounted(t, app, volumeName)
+	ref, err := app.GetVolumeByName(volumeName)
+	if err != nil {
+		mnt.Close()
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	ref.FS().SetUserAccess(&fs.UserAccess{Others: access})
+	return mnt
+}
+
+func checkAccessError(t testing.TB, op string, err error, allowed bool) {
+	switch {
+	case allowed && err != nil:
+		t.Errorf("%s: unexpected error: %v", op, err)
+	case !allowed && !os.IsPermission(err):
+		t.Errorf("%s: expected permission error, got %v", op, err)
+	}
+}
+
+func TestUserAccess(t *testing.T) {
+	defer actAsOtherUser()()
+
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+
+	for _, tc := range []struct {
+		access fs.Access
+		read   bool
+		write  bool
+	}{
+		{fs.AccessNone, false, false},
+		{fs.AccessRead, true, false},
+		{fs.AccessOwner, true, true},
+	} {
+		volumeName := fmt.Sprintf("vol%d", tc.access)
+		bazfstestutil.CreateVolume(t, app, volumeName)
+		ref, err := app.GetVolumeByName(volumeName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := []fs.Change{
+			{Path: "looked-up", Data: []byte("hello\n")},
+			{Path: "opened", Data: []byte("hello\n")},
+			{Path: "removed", Data: []byte("hello\n")},
+			{Path: "truncated", Data: []byte("hello\n")},
+		}
+		err = ref.FS().Commit(context.Background(), changes)
+		ref.Close()
+		if err != nil {
+			t.Fatalf("commit failed: %v", err)
+		}
+
+		mnt := mountedWithAccess(t, app, volumeName, tc.access)
+		t.Logf("access %d", tc.access)
+
+		_, err = os.Stat(path.Join(mnt.Dir, "looked-up"))
+		checkAccessError(t, "lookup", err, tc.read)
+
+		f, err := os.OpenFile(path.Join(mnt.Dir, "opened"), os.O_WRONLY, 0)
+		if err == nil {
+			f.Close()
+		}
+		checkAccessError(t, "open for write", err, tc.write)
+
+		f, err = os.Create(path.Join(mnt.Dir, "created"))
+		if err == nil {
+			f.Close()
+		}
+		checkAccessError(t, "create", err, tc.write)
+
+		err = os.Remove(path.Join(mnt.Dir, "removed"))
+		checkAccessError(t, "remove", err, tc.write)
+
+		err = os.Truncate(path.Join(mnt.Dir, "truncated"), 0)
+		checkAccessError(t, "setattr", err, tc.write)
+
+		mnt.Close()
+	}
+}
+
+func TestUserAccessPending(t *testing.T) {
+	defer actAsOtherUser()()
+
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+
+	const (
+		volumeName1 = "testvol1"
+		volumeName2 = "testvol2"
+	)
+	createAndConnectVolume(t, app1, volumeName1, app2, volumeName2)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+	setLocation(t, app2, app1.Keys.Sign.Pub, web1.Addr())
+
+	const filename = "greeting"
+	mnt1 := bazfstestutil.Mounted(t, app1, volumeName1)
+	defer mnt1.Close()
+	if err := ioutil.WriteFile(path.Join(mnt1.Dir, filename), []byte("hello, world"), 0644); err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+
+	mnt2 := bazfstestutil.Mounted(t, app2, volumeName2)
+	defer mnt2.Close()
+	if err := ioutil.WriteFile(path.Join(mnt2.Dir, filename), []byte("goodbye"), 0644); err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+
+	ctrl := controltest.ListenAndServe(t, &wg, app2)
+	defer ctrl.Close()
+	rpcConn, err := grpcunix.Dial(filepath.Join(app2.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+	req := &wire.VolumeSyncRequest{
+		VolumeName: volumeName2,
+		Pub:        pub1[:],
+	}
+	if _, err := rpcClient.VolumeSync(context.Background(), req); err != nil {
+		t.Fatalf("error while syncing: %v", err)
+	}
+
+	entries, err := ioutil.ReadDir(path.Join(mnt2.Dir, ".bazil", "pending", filename))
+	if err != nil {
+		t.Fatalf("cannot list pending clocks: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one pending clock, got %d", len(entries))
+	}
+	pending := path.Join(".bazil", "pending", filename, entries[0].Name())
+
+	// owner last, as it resolves the conflict
+	for _, tc := range []struct {
+		access fs.Access
+		read   bool
+		write  bool
+	}{
+		{fs.AccessNone, false, false},
+		{fs.AccessRead, true, false},
+		{fs.AccessOwner, true, true},
+	} {
+		mnt := mountedWithAccess(t, app2, volumeName2, tc.access)
+		t.Logf("access %d", tc.access)
+
+		_, err := ioutil.ReadFile(path.Join(mnt.Dir, pending))
+		checkAccessError(t, "read pending", err, tc.read)
+
+		err = os.Remove(path.Join(mnt.Dir, pending))
+		checkAccessError(t, "remove pending", err, tc.write)
+
+		mnt.Close()
+	}
+}
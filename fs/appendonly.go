@@ -0,0 +1,330 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"bazil.org/bazil/cas/blobs"
+	wirecas "bazil.org/bazil/cas/wire"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/clock"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"bazil.org/fuse"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// SetAppendOnly marks the file or directory at p as append-only, or
+// removes the mark. When an append-only file has been changed both
+// here and on a peer, sync merges the two versions instead of
+// keeping theirs as a conflict; see mergeAppends. Files that are
+// open are merged once closed. Marking a directory covers every
+// file below it.
+//
+// The mark is local to this node. It is enough for one of the peers
+// to have it, as the merged version then wins on the others.
+func (v *Volume) SetAppendOnly(ctx context.Context, p string, on bool) error {
+	set := func(tx *db.Tx) error {
+		bucket := v.bucket(tx)
+		_, _, de, err := v.lookupDirent(bucket.Dirs(), p)
+		if err != nil {
+			return err
+		}
+		inode := v.root.inode
+		if de != nil {
+			inode = de.Inode
+		}
+		return bucket.AppendOnly().Set(inode, on)
+	}
+	return v.db.Update(set)
+}
+
+// isAppendOnly tells whether the child of d with the given inode is
+// append-only, through its own mark or that of a directory above it.
+func (d *dir) isAppendOnly(marks *db.AppendOnly, inode uint64) bool {
+	if marks.Is(inode) {
+		return true
+	}
+	// inode and parent never change, no need to lock
+	for cur := d; cur != nil; cur = cur.parent {
+		if marks.Is(cur.inode) {
+			return true
+		}
+	}
+	return false
+}
+
+// How much of each version mergeAppends reads at a time.
+const appendMergeBufSize = 64 * 1024
+
+// commonPrefix returns how many bytes at the start of a and b are
+// the same.
+func commonPrefix(ctx context.Context, a, b *blobs.Blob) (uint64, error) {
+	r1 := a.IO(ctx)
+	r2 := b.IO(ctx)
+	size := a.Size()
+	if s := b.Size(); s < size {
+		size = s
+	}
+	buf1 := make([]byte, appendMergeBufSize)
+	buf2 := make([]byte, appendMergeBufSize)
+	var common uint64
+	for common < size {
+		n := appendMergeBufSize
+		if rest := size - common; rest < uint64(n) {
+			n = int(rest)
+		}
+		if _, err := r1.ReadAt(buf1[:n], int64(common)); err != nil && err != io.EOF {
+			return 0, err
+		}
+		if _, err := r2.ReadAt(buf2[:n], int64(common)); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := 0; i < n; i++ {
+			if buf1[i] != buf2[i] {
+				return common + uint64(i), nil
+			}
+		}
+		common += uint64(n)
+	}
+	return common, nil
+}
+
+var errNoSharedVersion = errors.New("no version known to be shared with the peer")
+
+// mergeAppends combines two versions of a file that were appended to
+// independently. The result is the last version both had, followed
+// by what each added after it. The version with the smaller root key
+// goes first, so that every peer doing the same merge ends up with
+// identical contents.
+//
+// The last version both had is found among the remembered versions
+// of the file that the other side is known to have seen, given by
+// their sizes in shared: it is the largest one that both versions
+// still start with. Earlier versions of an append-only file are
+// assumed to be the start of later ones. Merely comparing the two
+// versions, or trusting versions only this side has had, would count
+// additions that happen to begin the same way as shared, and keep
+// them only once.
+//
+// If no such version is found, returns errNoSharedVersion.
+func mergeAppends(ctx context.Context, v *Volume, shared []uint64, a, b *blobs.Manifest) (*blobs.Manifest, error) {
+	if bytes.Compare(a.Root.Bytes(), b.Root.Bytes()) > 0 {
+		a, b = b, a
+	}
+	first, err := blobs.Open(v.chunkStore, a)
+	if err != nil {
+		return nil, err
+	}
+	second, err := blobs.Open(v.chunkStore, b)
+	if err != nil {
+		return nil, err
+	}
+	common, err := commonPrefix(ctx, first, second)
+	if err != nil {
+		return nil, err
+	}
+	base := uint64(0)
+	found := false
+	for _, size := range shared {
+		if size <= common && (!found || size > base) {
+			base = size
+			found = true
+		}
+	}
+	if !found {
+		return nil, errNoSharedVersion
+	}
+
+	// the first version already holds the base and its own
+	// additions; copy the rest of the second after them
+	r := second.IO(ctx)
+	w := first.IO(ctx)
+	buf := make([]byte, appendMergeBufSize)
+	dst := int64(first.Size())
+	for off := int64(base); uint64(off) < second.Size(); {
+		n, err := r.ReadAt(buf, off)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		if _, err := w.WriteAt(buf[:n], dst); err != nil {
+			return nil, err
+		}
+		off += int64(n)
+		dst += int64(n)
+	}
+	return first.Save(ctx)
+}
+
+var errAppendsChanged = errors.New("file changed during append merge")
+
+// mergeConflictingAppends merges the postponed syncs of the file
+// name into it, if it is append-only. Merging reads and writes the
+// file contents, so it is done outside of transactions and locks.
+// The result is only kept if nothing changed meanwhile; otherwise
+// the sync stays postponed, and is retried on the next sync or when
+// the file is closed.
+//
+// If no remembered version is known to be shared with the other
+// side, the sync is left postponed, as there is no telling what
+// both sides added.
+//
+// Files that are open are left alone, as sync never changes them.
+func (d *dir) mergeConflictingAppends(ctx context.Context, name string) error {
+	for {
+		var ours *wirecas.Manifest
+		var theirs *wirepeer.Dirent
+		var theirsClock *clock.Clock
+		var shared []uint64
+		find := func(tx *db.Tx) error {
+			bucket := d.fs.bucket(tx)
+			de, err := bucket.Dirs().Get(d.inode, name)
+			if err == fuse.ENOENT {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if de.File == nil || !d.isAppendOnly(bucket.AppendOnly(), de.Inode) {
+				return nil
+			}
+			mine, err := bucket.Clock().Get(d.inode, name)
+			if err != nil {
+				return err
+			}
+			cursor := bucket.Conflicts().List(d.inode, name)
+			for item := cursor.First(); item != nil; item = cursor.Next() {
+				var wde wirepeer.Dirent
+				if err := item.Dirent(&wde); err != nil {
+					return err
+				}
+				if wde.File == nil {
+					continue
+				}
+				c, err := item.Clock()
+				if err != nil {
+					return err
+				}
+				// postponed syncs that are no longer concurrent
+				// with ours are left for tryResolveConflicts
+				if clock.Sync(c, mine) != clock.Conflict {
+					continue
+				}
+				wde.Name = name
+				ours, theirs, theirsClock = de.File.Manifest, &wde, c
+				break
+			}
+			if theirs == nil {
+				return nil
+			}
+			return bucket.History().List(de.Inode, func(fv *db.FileVersion) error {
+				// versions they have seen are in their history too
+				if fv.Clock != nil && clock.Sync(fv.Clock, theirsClock) == clock.Nothing {
+					shared = append(shared, fv.Manifest.Size)
+				}
+				return nil
+			})
+		}
+		if err := d.fs.db.View(find); err != nil {
+			return err
+		}
+		if theirs == nil {
+			return nil
+		}
+
+		a, err := ours.ToBlob("file")
+		if err != nil {
+			return err
+		}
+		b, err := theirs.File.Manifest.ToBlob("file")
+		if err != nil {
+			return err
+		}
+		merged, err := mergeAppends(ctx, d.fs, shared, a, b)
+		if err == errNoSharedVersion {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		apply := func(tx *db.Tx) error {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+
+			bucket := d.fs.bucket(tx)
+			de, err := bucket.Dirs().Get(d.inode, name)
+			if err == fuse.ENOENT {
+				return errAppendsChanged
+			}
+			if err != nil {
+				return err
+			}
+			if de.File == nil || !proto.Equal(de.File.Manifest, ours) {
+				return errAppendsChanged
+			}
+			clockBuf, err := theirsClock.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if bucket.Conflicts().Get(d.inode, name, clockBuf) == nil {
+				return errAppendsChanged
+			}
+			ref, err := d.lookup(txViewer{tx}, name)
+			if err != nil {
+				return err
+			}
+			f, ok := ref.node.(*file)
+			if !ok {
+				return errAppendsChanged
+			}
+			blob, err := blobs.Open(d.fs.chunkStore, merged)
+			if err != nil {
+				return err
+			}
+
+			if err := bucket.Conflicts().Delete(d.inode, name, clockBuf); err != nil {
+				return err
+			}
+			clocks := bucket.Clock()
+			mine, err := clocks.Get(d.inode, name)
+			if err != nil {
+				return err
+			}
+			if clock.Sync(theirsClock, mine) != clock.Conflict {
+				return errAppendsChanged
+			}
+			mine.ResolveNew(theirsClock)
+			if err := clocks.Put(d.inode, name, mine); err != nil {
+				return err
+			}
+			de.File.Manifest = wirecas.FromBlob(merged)
+			if err := bucket.Dirs().Put(d.inode, name, de); err != nil {
+				return err
+			}
+			if err := d.fs.rememberVersion(tx, de, mine); err != nil {
+				return err
+			}
+
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.handles > 0 {
+				return errAppendsChanged
+			}
+			f.blob = blob
+			return nil
+		}
+		err = d.fs.db.Update(apply)
+		d.fs.dirCache.forgetDir(d.inode)
+		if err == errAppendsChanged {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
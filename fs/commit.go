@@ -0,0 +1,303 @@
+package fs
+
+import (
+	"fmt"
+	"path"
+	"syscall"
+
+	"bazil.org/bazil/cas/blobs"
+	wirecas "bazil.org/bazil/cas/wire"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/inodes"
+	"bazil.org/bazil/fs/wire"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+)
+
+// Change is a single file update, as part of a Commit.
+type Change struct {
+	// Path of the file, relative to the root of the volume. The
+	// parent directory must exist already.
+	Path string
+	// Data is the full new content of the file.
+	Data []byte
+	// Remove the file, instead of writing Data to it.
+	Remove bool
+}
+
+// pendingChange is a Change with the parent directory resolved and
+// the new content already stored.
+type pendingChange struct {
+	*Change
+	dir  *dir
+	name string
+	// only set for writes
+	manifest *wirecas.Manifest
+	// set during the transaction, for updating in-memory nodes
+	inode uint64
+}
+
+// Commit applies all the changes in one database transaction.
+// Snapshots and syncs either see the volume as it was before, or
+// with all the changes applied.
+//
+// File contents are stored in the chunk store before anything is
+// committed, so a failure halfway leaves the volume untouched.
+//
+// Files that have unsaved writes through the FUSE mount cannot be
+// part of a commit, and cause it to fail with EBUSY.
+func (v *Volume) Commit(ctx context.Context, changes []Change) error {
+	seen := make(map[string]struct{}, len(changes))
+	pending := make([]pendingChange, 0, len(changes))
+	var drops []func()
+	defer func() {
+		for _, drop := range drops {
+			drop()
+		}
+	}()
+	for i := range changes {
+		ch := &changes[i]
+		p := path.Clean("/" + ch.Path)[1:]
+		if p == "" {
+			return fuse.Errno(syscall.EISDIR)
+		}
+		if _, ok := seen[p]; ok {
+			return fmt.Errorf("path changed twice in one commit: %q", p)
+		}
+		seen[p] = struct{}{}
+
+		d, name, drop, err := v.lookupParent(p)
+		if err != nil {
+			return err
+		}
+		drops = append(drops, drop)
+		pc := pendingChange{
+			Change: ch,
+			dir:    d,
+			name:   name,
+		}
+		if !ch.Remove {
+			m, err := v.storeContent(ctx, ch.Data)
+			if err != nil {
+				return err
+			}
+			pc.manifest = m
+		}
+		pending = append(pending, pc)
+	}
+
+	return v.commitPending(pending)
+}
+
+// commitPending writes changes that have been prepared by Commit.
+func (v *Volume) commitPending(pending []pendingChange) error {
+	commit := func(tx *db.Tx) error {
+		for i := range pending {
+			if err := pending[i].dir.commitChange(tx, &pending[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := v.db.Update(commit); err != nil {
+		return err
+	}
+
+	for _, pc := range pending {
+		v.dirCache.forgetDir(pc.dir.inode)
+		replaced, err := pc.dir.commitActive(&pc)
+		if err != nil {
+			return err
+		}
+		if replaced != nil {
+			if err := v.invalidateAttr(replaced); err != nil {
+				return err
+			}
+		}
+		if err := v.invalidateEntry(pc.dir, pc.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupParent finds the directory containing the file at path p,
+// which must be clean and relative to the root of the volume. The
+// caller must call drop when done with the directory.
+func (v *Volume) lookupParent(p string) (d *dir, name string, drop func(), err error) {
+	dirPath, name := path.Split(p)
+	if name == ".bazil" || (dirPath == "" && name == ".snap") {
+		return nil, "", nil, fuse.EPERM
+	}
+	var n node
+	lookupPath := func(tx *db.Tx) error {
+		var err error
+		n, drop, err = v.lookupPath(tx, dirPath)
+		return err
+	}
+	if err := v.db.View(lookupPath); err != nil {
+		return nil, "", nil, err
+	}
+	d, ok := n.(*dir)
+	if !ok {
+		drop()
+		return nil, "", nil, fuse.Errno(syscall.ENOTDIR)
+	}
+	return d, name, drop, nil
+}
+
+func (v *Volume) storeContent(ctx context.Context, data []byte) (*wirecas.Manifest, error) {
+	blob, err := blobs.Open(v.chunkStore, v.emptyManifest("file"))
+	if err != nil {
+		return nil, fmt.Errorf("blob open problem: %v", err)
+	}
+	if _, err := blob.IO(ctx).WriteAt(data, 0); err != nil {
+		return nil, err
+	}
+	manifest, err := blob.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return wirecas.FromBlob(manifest), nil
+}
+
+// isDirty reports whether the active child by that name has writes
+// that have not been saved yet.
+//
+// Caller must hold dir.mu.
+func (d *dir) isDirty(name string) bool {
+	a, ok := d.active[name]
+	if !ok {
+		return false
+	}
+	f, ok := a.node.(*file)
+	if !ok {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dirty != clean
+}
+
+// commitChange writes one change of a Commit to the database.
+//
+// Changes going past the limits of the volume fail like they would
+// through the FUSE mount. A file with writes that have not been
+// saved yet makes the commit fail with EBUSY. This is checked inside
+// the transaction, as the file may have been written to while Commit
+// stored the contents.
+func (d *dir) commitChange(tx *db.Tx, pc *pendingChange) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isDirty(pc.name) {
+		return fuse.Errno(syscall.EBUSY)
+	}
+
+	bucket := d.fs.bucket(tx)
+	vc := bucket.Clock()
+	old, err := bucket.Dirs().Get(d.inode, pc.name)
+	switch {
+	case err == fuse.ENOENT:
+		old = nil
+	case err != nil:
+		return err
+	case old.Tombstone != nil:
+		old = nil
+	case old.Dir != nil:
+		return fuse.Errno(syscall.EISDIR)
+	}
+
+	if pc.Remove {
+		if old == nil {
+			return fuse.ENOENT
+		}
+		if err := bucket.Dirs().Tombstone(d.inode, pc.name); err != nil {
+			return err
+		}
+		c, err := vc.Get(d.inode, pc.name)
+		if err != nil {
+			return err
+		}
+		c.Update(0, d.fs.dirtyEpoch())
+		if err := d.updateParents(vc, c); err != nil {
+			return err
+		}
+		c.Tombstone()
+		return vc.Put(d.inode, pc.name, c)
+	}
+
+	blobMax := uint64(pc.manifest.ChunkSize) << 32
+	if err := d.fs.checkFileSize(pc.manifest.Size, blobMax); err != nil {
+		return err
+	}
+	if old != nil {
+		pc.inode = old.Inode
+	} else {
+		if err := d.fs.checkChild(d, pc.name); err != nil {
+			return err
+		}
+		inode, err := inodes.Allocate(bucket.InodeBucket())
+		if err != nil {
+			return err
+		}
+		pc.inode = inode
+	}
+	de := &wire.Dirent{
+		Inode: pc.inode,
+		File: &wire.File{
+			Manifest: pc.manifest,
+		},
+	}
+	if old != nil {
+		de.Perm = old.Perm
+	}
+	if err := bucket.Dirs().Put(d.inode, pc.name, de); err != nil {
+		return fmt.Errorf("dirent save error: %v", err)
+	}
+	c, changed, err := vc.UpdateOrCreate(d.inode, pc.name, d.fs.dirtyEpoch())
+	if err != nil {
+		return err
+	}
+	if err := d.fs.rememberVersion(tx, de, c); err != nil {
+		return err
+	}
+	if changed {
+		if err := d.updateParents(vc, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitActive brings the in-memory child touched by a committed
+// change up to date. If the child stays in use with new content, it
+// is returned.
+func (d *dir) commitActive(pc *pendingChange) (*file, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	a, ok := d.active[pc.name]
+	if !ok {
+		return nil, nil
+	}
+	f, isFile := a.node.(*file)
+	if pc.Remove || !isFile || f.inode != pc.inode {
+		delete(d.active, pc.name)
+		a.node.setName("")
+		return nil, nil
+	}
+
+	manifest, err := pc.manifest.ToBlob("file")
+	if err != nil {
+		return nil, err
+	}
+	blob, err := blobs.Open(d.fs.chunkStore, manifest)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	f.blob = blob
+	f.mu.Unlock()
+	return f, nil
+}
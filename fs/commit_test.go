@@ -0,0 +1,125 @@
+package fs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"syscall"
+	"testing"
+
+	"bazil.org/bazil/fs"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/util/tempdir"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+)
+
+func TestCommit(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	if err := os.Mkdir(path.Join(mnt.Dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(mnt.Dir, "old"), []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(mnt.Dir, "sub", "catalog"), []byte("v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	changes := []fs.Change{
+		{Path: "sub/catalog", Data: []byte("v2\n")},
+		{Path: "sub/thumb", Data: []byte("thumbnail\n")},
+		{Path: "old", Remove: true},
+	}
+	if err := ref.FS().Commit(context.Background(), changes); err != nil {
+		t.Fatalf("commit failed: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"sub/catalog": "v2\n",
+		"sub/thumb":   "thumbnail\n",
+	} {
+		buf, err := ioutil.ReadFile(path.Join(mnt.Dir, name))
+		if err != nil {
+			t.Errorf("cannot read %s: %v", name, err)
+			continue
+		}
+		if g, e := string(buf), want; g != e {
+			t.Errorf("wrong content in %s: %q != %q", name, g, e)
+		}
+	}
+	if _, err := os.Stat(path.Join(mnt.Dir, "old")); !os.IsNotExist(err) {
+		t.Errorf("removed file still exists: %v", err)
+	}
+}
+
+func TestCommitMissingParentChangesNothing(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	changes := []fs.Change{
+		{Path: "a", Data: []byte("a\n")},
+		{Path: "missing/b", Data: []byte("b\n")},
+	}
+	if err := ref.FS().Commit(context.Background(), changes); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, err := os.Stat(path.Join(mnt.Dir, "a")); !os.IsNotExist(err) {
+		t.Errorf("partial commit: %v", err)
+	}
+}
+
+func TestCommitLimits(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	ref.FS().SetLimits(fs.Limits{
+		MaxFileSize:  4,
+		MaxNameBytes: 8,
+	})
+
+	ctx := context.Background()
+	err = ref.FS().Commit(ctx, []fs.Change{{Path: "too-long-name", Data: []byte("a")}})
+	if err != fuse.Errno(syscall.ENAMETOOLONG) {
+		t.Errorf("expected ENAMETOOLONG for long name, got %v", err)
+	}
+	err = ref.FS().Commit(ctx, []fs.Change{{Path: "big", Data: []byte("01234")}})
+	if err != fuse.Errno(syscall.EFBIG) {
+		t.Errorf("expected EFBIG for file past limit, got %v", err)
+	}
+	if err := ref.FS().Commit(ctx, []fs.Change{{Path: "small", Data: []byte("0123")}}); err != nil {
+		t.Errorf("commit within limits failed: %v", err)
+	}
+}
@@ -1,7 +1,6 @@
 package fs
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,16 +9,12 @@ import (
 	"syscall"
 
 	"bazil.org/bazil/cas/blobs"
-	wirecas "bazil.org/bazil/cas/wire"
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/fs/clock"
 	"bazil.org/bazil/fs/inodes"
-	"bazil.org/bazil/fs/snap"
-	wiresnap "bazil.org/bazil/fs/snap/wire"
 	"bazil.org/bazil/fs/wire"
 	"bazil.org/bazil/peer"
 	wirepeer "bazil.org/bazil/peer/wire"
-	"bazil.org/bazil/util/env"
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
 	"github.com/golang/protobuf/proto"
@@ -31,6 +26,12 @@ type dir struct {
 	parent *dir
 	fs     *Volume
 
+	// syncMu serializes incoming syncs of this directory. Each one
+	// tombstones the entries its peer does not mention, and two of
+	// them interleaving would remove what the other just added. Taken
+	// before mu, and may be held across db.Update.
+	syncMu sync.Mutex
+
 	// mu protects the fields below.
 	//
 	// If multiple dir.mu instances need to be locked at the same
@@ -44,6 +45,8 @@ type dir struct {
 	mu sync.Mutex
 
 	name string
+	// nil for the defaults
+	perm *wire.Perm
 
 	// each in-memory child, so we can return the same node on
 	// multiple Lookups and know what to do on .save()
@@ -82,10 +85,12 @@ var _ node = (*dir)(nil)
 var _ fs.Node = (*dir)(nil)
 var _ fs.NodeCreater = (*dir)(nil)
 var _ fs.NodeForgetter = (*dir)(nil)
+var _ fs.NodeGetattrer = (*dir)(nil)
 var _ fs.NodeMkdirer = (*dir)(nil)
+var _ fs.NodeOpener = (*dir)(nil)
 var _ fs.NodeRemover = (*dir)(nil)
 var _ fs.NodeRenamer = (*dir)(nil)
-var _ fs.NodeStringLookuper = (*dir)(nil)
+var _ fs.NodeRequestLookuper = (*dir)(nil)
 var _ fs.HandleReadDirAller = (*dir)(nil)
 
 func (d *dir) setName(name string) {
@@ -95,13 +100,24 @@ func (d *dir) setName(name string) {
 }
 
 func (d *dir) Attr(ctx context.Context, a *fuse.Attr) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	a.Inode = d.inode
-	a.Mode = os.ModeDir | 0755
-	a.Uid = env.MyUID
-	a.Gid = env.MyGID
+	a.Mode = os.ModeDir
+	setPermAttr(a, d.perm, 0755)
 	return nil
 }
 
+// Getattr is Attr for requests from the kernel, which are subject to
+// the access settings.
+func (d *dir) Getattr(ctx context.Context, req *fuse.GetattrRequest, resp *fuse.GetattrResponse) error {
+	if err := d.fs.checkAccess(&req.Header, false); err != nil {
+		return err
+	}
+	return d.Attr(ctx, &resp.Attr)
+}
+
 type viewer interface {
 	// View calls the function inside a database transaction.
 	View(func(tx *db.Tx) error) error
@@ -126,20 +142,40 @@ func (d *dir) lookup(v viewer, name string) (*refcount, error) {
 		return a, nil
 	}
 
+	// Only use the cache for standalone lookups. Inside a
+	// transaction, we may be looking at changes not committed yet.
+	_, inTx := v.(txViewer)
+
 	var de *wire.Dirent
-	lookup := func(tx *db.Tx) error {
-		var err error
-		de, err = d.fs.bucket(tx).Dirs().Get(d.inode, name)
-		if err != nil {
-			return err
-		}
-		if de.Tombstone != nil {
-			return fuse.ENOENT
+	cached := false
+	if !inTx {
+		de, cached = d.fs.dirCache.get(d.inode, name)
+		if cached && de == nil {
+			return nil, fuse.ENOENT
 		}
-		return nil
 	}
-	if err := v.View(lookup); err != nil {
-		return nil, err
+	if !cached {
+		gen := d.fs.dirCache.generation()
+		lookup := func(tx *db.Tx) error {
+			var err error
+			de, err = d.fs.bucket(tx).Dirs().Get(d.inode, name)
+			if err != nil {
+				return err
+			}
+			if de.Tombstone != nil {
+				return fuse.ENOENT
+			}
+			return nil
+		}
+		if err := v.View(lookup); err != nil {
+			if err == fuse.ENOENT && !inTx {
+				d.fs.dirCache.add(gen, d.inode, name, nil)
+			}
+			return nil, err
+		}
+		if !inTx {
+			d.fs.dirCache.add(gen, d.inode, name, de)
+		}
 	}
 	child, err := d.reviveNode(de, name)
 	if err != nil {
@@ -150,7 +186,12 @@ func (d *dir) lookup(v viewer, name string) (*refcount, error) {
 	return a, nil
 }
 
-func (d *dir) Lookup(ctx context.Context, name string) (fs.Node, error) {
+func (d *dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.LookupResponse) (fs.Node, error) {
+	if err := d.fs.checkAccess(&req.Header, false); err != nil {
+		return nil, err
+	}
+	name := req.Name
+
 	if d.inode == 1 && name == ".snap" {
 		return &listSnaps{
 			fs: d.fs,
@@ -188,6 +229,7 @@ func (d *dir) reviveDir(de *wire.Dirent, name string) (*dir, error) {
 		return nil, fmt.Errorf("tried to revive non-directory as directory: %v", de)
 	}
 	child := newDir(d.fs, de.Inode, d, name)
+	child.perm = de.Perm
 	return child, nil
 }
 
@@ -210,6 +252,7 @@ func (d *dir) reviveNode(de *wire.Dirent, name string) (node, error) {
 			name:   name,
 			parent: d,
 			blob:   blob,
+			perm:   de.Perm,
 		}
 		return child, nil
 	}
@@ -217,6 +260,15 @@ func (d *dir) reviveNode(de *wire.Dirent, name string) (node, error) {
 	return nil, fmt.Errorf("dirent unknown type: %v", de)
 }
 
+// Open only checks whether the caller may list the directory; the
+// directory itself serves as the handle.
+func (d *dir) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	if err := d.fs.checkAccess(&req.Header, false); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 func (d *dir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -299,10 +351,14 @@ func (d *dir) updateParents(vc *db.VolumeClock, c *clock.Clock) error {
 }
 
 func (d *dir) marshal(ctx context.Context) (*wire.Dirent, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	de := &wire.Dirent{
 		Inode: d.inode,
 	}
 	de.Dir = &wire.Dir{}
+	de.Perm = d.perm
 	return de, nil
 }
 
@@ -322,9 +378,16 @@ func (d *dir) save(tx *db.Tx, name string, de *wire.Dirent) error {
 	if err != nil {
 		return err
 	}
+	// permissions may have been rewritten since de was marshaled
+	if old, err := bucket.Dirs().Get(d.inode, name); err == nil {
+		de.Perm = old.Perm
+	}
 	if err := d.fs.bucket(tx).Dirs().Put(d.inode, name, de); err != nil {
 		return fmt.Errorf("dirent save error: %v", err)
 	}
+	if err := d.fs.rememberVersion(tx, de, clock); err != nil {
+		return err
+	}
 	if changed {
 		if err := d.updateParents(vc, clock); err != nil {
 			return err
@@ -336,10 +399,20 @@ func (d *dir) save(tx *db.Tx, name string, de *wire.Dirent) error {
 const debugCreateExisting = true
 
 func (d *dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.CreateResponse) (fs.Node, fs.Handle, error) {
+	if err := d.fs.checkAccess(&req.Header, true); err != nil {
+		return nil, nil, err
+	}
+	if err := d.fs.checkChild(d, req.Name); err != nil {
+		return nil, nil, err
+	}
 	// TODO check for duplicate name
 
 	switch req.Mode & os.ModeType {
 	case 0:
+		// the new file starts with one open handle
+		if err := d.fs.handles.acquire(); err != nil {
+			return nil, nil, err
+		}
 		var child node
 		createFile := func(tx *db.Tx) error {
 			bucket := d.fs.bucket(tx)
@@ -348,7 +421,7 @@ func (d *dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 				return err
 			}
 
-			manifest := blobs.EmptyManifest("file")
+			manifest := d.fs.emptyManifest("file")
 			blob, err := blobs.Open(d.fs.chunkStore, manifest)
 			if err != nil {
 				return fmt.Errorf("blob open problem: %v", err)
@@ -374,8 +447,11 @@ func (d *dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 			return nil
 		}
 		if err := d.fs.db.Update(createFile); err != nil {
+			d.fs.handles.release()
 			return nil, nil, err
 		}
+		d.fs.dirCache.forgetDir(d.inode)
+		d.fs.notifyEntry(d, req.Name)
 
 		d.mu.Lock()
 		defer d.mu.Unlock()
@@ -438,6 +514,12 @@ func (d *dir) Forget() {
 const debugMkdirExisting = true
 
 func (d *dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
+	if err := d.fs.checkAccess(&req.Header, true); err != nil {
+		return nil, err
+	}
+	if err := d.fs.checkChild(d, req.Name); err != nil {
+		return nil, err
+	}
 	// TODO handle req.Mode
 
 	var child node
@@ -467,6 +549,8 @@ func (d *dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 		}
 		return nil, err
 	}
+	d.fs.dirCache.forgetDir(d.inode)
+	d.fs.notifyEntry(d, req.Name)
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -481,6 +565,9 @@ func (d *dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 }
 
 func (d *dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
+	if err := d.fs.checkAccess(&req.Header, true); err != nil {
+		return err
+	}
 	remove := func(tx *db.Tx) error {
 		bucket := d.fs.bucket(tx)
 		if err := bucket.Dirs().Tombstone(d.inode, req.Name); err != nil {
@@ -507,6 +594,8 @@ func (d *dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 	if err := d.fs.db.Update(remove); err != nil {
 		return err
 	}
+	d.fs.dirCache.forgetDir(d.inode)
+	d.fs.notifyEntry(d, req.Name)
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -518,6 +607,9 @@ func (d *dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 }
 
 func (d *dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Node) error {
+	if err := d.fs.checkAccess(&req.Header, true); err != nil {
+		return err
+	}
 	// if you ever change this, also guard against renaming into
 	// special directories like .snap; check type of newDir is *dir
 	//
@@ -525,6 +617,9 @@ func (d *dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 	if newDir != d {
 		return fuse.Errno(syscall.EXDEV)
 	}
+	if err := d.fs.checkName(req.NewName); err != nil {
+		return err
+	}
 
 	// TODO this gets clocks wrong for when moving whole subtrees; the
 	// grandchildren don't realize they've been moved, and their
@@ -590,6 +685,9 @@ func (d *dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 	if err := d.fs.db.Update(rename); err != nil {
 		return err
 	}
+	d.fs.dirCache.forgetDir(d.inode)
+	d.fs.notifyEntry(d, req.OldName)
+	d.fs.notifyEntry(d, req.NewName)
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -609,71 +707,6 @@ func (d *dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 	return nil
 }
 
-// snapshot records a snapshot of the directory and stores it in wde
-func (d *dir) snapshot(ctx context.Context, tx *db.Tx) (*wiresnap.Dirent, error) {
-	// NOT HOLDING THE LOCK, accessing database snapshot ONLY
-
-	// TODO move bucket lookup to caller?
-	bucket := d.fs.bucket(tx)
-
-	manifest := blobs.EmptyManifest("dir")
-	blob, err := blobs.Open(d.fs.chunkStore, manifest)
-	if err != nil {
-		return nil, err
-	}
-	w := snap.NewWriter(blob.IO(ctx))
-
-	c := bucket.Dirs().List(d.inode)
-loop:
-	for item := c.First(); item != nil; item = c.Next() {
-		var de wire.Dirent
-		if err := item.Unmarshal(&de); err != nil {
-			return nil, err
-		}
-		var sde *wiresnap.Dirent
-		switch {
-		case de.File != nil:
-			// TODO d.reviveNode would do blobs.Open and that's a bit
-			// too much work; rework the apis
-			sde = &wiresnap.Dirent{
-				File: &wiresnap.File{
-					Manifest: de.File.Manifest,
-				},
-			}
-		case de.Dir != nil:
-			child, err := d.reviveDir(&de, item.Name())
-			if err != nil {
-				return nil, err
-			}
-			sde, err = child.snapshot(ctx, tx)
-			if err != nil {
-				return nil, err
-			}
-		case de.Tombstone != nil:
-			continue loop
-		default:
-			return nil, errors.New("TODO")
-		}
-		sde.Name = item.Name()
-		err = w.Add(sde)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	manifest, err = blob.Save(ctx)
-	if err != nil {
-		return nil, err
-	}
-	msg := wiresnap.Dirent{
-		Dir: &wiresnap.Dir{
-			Manifest: wirecas.FromBlob(manifest),
-			Align:    w.Align(),
-		},
-	}
-	return &msg, nil
-}
-
 // makePeerMap returns a mapping from the peerids in peers to the ones
 // in the local database.
 func makePeerMap(tx *db.Tx, me peer.PublicKey, peers map[uint32][]byte) (map[clock.Peer]clock.Peer, error) {
@@ -757,6 +790,11 @@ func (d *dir) syncToMissing(ctx context.Context, tx *db.Tx, volume *db.Volume, w
 			if err := volume.Dirs().Put(d.inode, wde.Name, de); err != nil {
 				return fmt.Errorf("dirent save error: %v", err)
 			}
+			// kept to find the last version both sides had, when
+			// merging appends
+			if err := d.fs.rememberVersion(tx, de, theirs); err != nil {
+				return err
+			}
 		}
 		return nil
 
@@ -781,6 +819,8 @@ func (d *dir) syncToNode(ctx context.Context, tx *db.Tx, volume *db.Volume, chil
 	case clock.Nothing:
 		// they lose, do nothing
 	case clock.Conflict:
+		// append-only files are merged later, see
+		// mergeConflictingAppends
 		if err := volume.Conflicts().Add(d.inode, theirs, wde); err != nil {
 			return err
 		}
@@ -837,6 +877,15 @@ func (d *dir) syncToNode(ctx context.Context, tx *db.Tx, volume *db.Volume, chil
 		if err := d.saveInternal(ctx, tx, wde.Name, child); err != nil {
 			return err
 		}
+		if wde.File != nil {
+			de, err := volume.Dirs().Get(d.inode, wde.Name)
+			if err != nil {
+				return err
+			}
+			if err := d.fs.rememberVersion(tx, de, mine); err != nil {
+				return err
+			}
+		}
 		// sync never changes files that are open, and we don't let
 		// the kernel cache data across opens, so there's no need for
 		// InvalidateNodeData here.
@@ -847,6 +896,9 @@ func (d *dir) syncToNode(ctx context.Context, tx *db.Tx, volume *db.Volume, chil
 }
 
 func (d *dir) syncReceive(ctx context.Context, peers map[uint32][]byte, dirClockBuf []byte, recv func() ([]*wirepeer.Dirent, error)) error {
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+
 	var peerMap map[clock.Peer]clock.Peer
 	peerMapFn := func(tx *db.Tx) error {
 		m, err := makePeerMap(tx, d.fs.pubKey, peers)
@@ -875,6 +927,8 @@ func (d *dir) syncReceive(ctx context.Context, peers map[uint32][]byte, dirClock
 
 	oursPrev := ""
 	oursEOF := false
+	// append-only files that may have new postponed syncs to merge
+	var appends []string
 	for {
 		dirents, err := recv()
 		if err == io.EOF {
@@ -975,13 +1029,23 @@ func (d *dir) syncReceive(ctx context.Context, peers map[uint32][]byte, dirClock
 				if err := d.syncToNode(ctx, tx, bucket, ref.node, wde, &theirs); err != nil {
 					return err
 				}
-
+				if f, ok := ref.node.(*file); ok && wde.File != nil && d.isAppendOnly(bucket.AppendOnly(), f.inode) {
+					appends = append(appends, wde.Name)
+				}
 			}
 			return nil
 		}
-		if err := d.fs.db.Update(sync); err != nil {
+		appends = appends[:0]
+		err = d.fs.db.Update(sync)
+		d.fs.dirCache.forgetDir(d.inode)
+		if err != nil {
 			return err
 		}
+		for _, name := range appends {
+			if err := d.mergeConflictingAppends(ctx, name); err != nil {
+				return err
+			}
+		}
 	}
 
 	if !oursEOF {
@@ -1002,7 +1066,9 @@ func (d *dir) syncReceive(ctx context.Context, peers map[uint32][]byte, dirClock
 			}
 			return nil
 		}
-		if err := d.fs.db.Update(syncImpliedTombs); err != nil {
+		err := d.fs.db.Update(syncImpliedTombs)
+		d.fs.dirCache.forgetDir(d.inode)
+		if err != nil {
 			return err
 		}
 	}
@@ -1081,7 +1147,12 @@ func (d *dir) tryResolveConflicts(name string) {
 
 		return nil
 	}
-	if err := d.fs.db.Update(resolve); err != nil {
+	err := d.fs.db.Update(resolve)
+	d.fs.dirCache.forgetDir(d.inode)
+	if err == nil {
+		err = d.mergeConflictingAppends(ctx, name)
+	}
+	if err != nil {
 		// ignore errors, but log for debugging
 		log.Printf("resolving postponed sync:: %v", err)
 	}
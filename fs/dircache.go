@@ -0,0 +1,113 @@
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"bazil.org/bazil/fs/wire"
+)
+
+// dirCache remembers the results of recent directory entry lookups,
+// including names that were not found, to save a database
+// transaction when the same names are looked up repeatedly.
+//
+// Entries for a directory are forgotten whenever the directory is
+// modified. Negative entries expire quickly anyway, as they are the
+// most likely to be wrong if an invalidation is missed.
+type dirCache struct {
+	mu sync.Mutex
+	// gen changes on every invalidation, so lookups that raced with
+	// a change do not add stale entries.
+	gen  uint64
+	size int
+	dirs map[uint64]map[string]dirCacheEntry
+	now  func() time.Time
+}
+
+type dirCacheEntry struct {
+	// nil means the name does not exist
+	de      *wire.Dirent
+	expires time.Time
+}
+
+const (
+	dirCacheMaxEntries  = 10000
+	dirCachePositiveTTL = 1 * time.Minute
+	dirCacheNegativeTTL = 2 * time.Second
+)
+
+func newDirCache() *dirCache {
+	c := &dirCache{
+		dirs: make(map[uint64]map[string]dirCacheEntry),
+		now:  time.Now,
+	}
+	return c
+}
+
+// get returns a cached lookup result. If found is false, nothing is
+// known. Otherwise, a nil de means the name does not exist.
+//
+// The returned Dirent must not be modified.
+func (c *dirCache) get(dirInode uint64, name string) (de *wire.Dirent, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.dirs[dirInode][name]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(e.expires) {
+		delete(c.dirs[dirInode], name)
+		c.size--
+		return nil, false
+	}
+	return e.de, true
+}
+
+// generation returns a token to pass to add. Call it before reading
+// from the database.
+func (c *dirCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// add caches a lookup result, unless the cache was invalidated after
+// gen was fetched. A nil de records that the name does not exist.
+func (c *dirCache) add(gen uint64, dirInode uint64, name string, de *wire.Dirent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if c.size >= dirCacheMaxEntries {
+		// crude, but bounded
+		c.dirs = make(map[uint64]map[string]dirCacheEntry)
+		c.size = 0
+	}
+	entries := c.dirs[dirInode]
+	if entries == nil {
+		entries = make(map[string]dirCacheEntry)
+		c.dirs[dirInode] = entries
+	}
+	ttl := dirCachePositiveTTL
+	if de == nil {
+		ttl = dirCacheNegativeTTL
+	}
+	if _, ok := entries[name]; !ok {
+		c.size++
+	}
+	entries[name] = dirCacheEntry{
+		de:      de,
+		expires: c.now().Add(ttl),
+	}
+}
+
+// forgetDir drops all cached entries of the directory. Call it after
+// a transaction modifying the directory has committed.
+func (c *dirCache) forgetDir(dirInode uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.size -= len(c.dirs[dirInode])
+	delete(c.dirs, dirInode)
+}
@@ -3,6 +3,7 @@ package fs
 import (
 	"errors"
 	"fmt"
+	"log"
 	"os"
 
 	"bazil.org/bazil/cas"
@@ -25,6 +26,7 @@ type listSnaps struct {
 
 var _ fs.Node = (*listSnaps)(nil)
 var _ fs.Handle = (*listSnaps)(nil)
+var _ fs.NodeGetattrer = (*listSnaps)(nil)
 
 func (d *listSnaps) Attr(ctx context.Context, a *fuse.Attr) error {
 	a.Inode = tokens.InodeSnap
@@ -34,25 +36,47 @@ func (d *listSnaps) Attr(ctx context.Context, a *fuse.Attr) error {
 	return nil
 }
 
-var _ fs.NodeStringLookuper = (*listSnaps)(nil)
+func (d *listSnaps) Getattr(ctx context.Context, req *fuse.GetattrRequest, resp *fuse.GetattrResponse) error {
+	if err := d.fs.checkAccess(&req.Header, false); err != nil {
+		return err
+	}
+	return d.Attr(ctx, &resp.Attr)
+}
+
+var _ fs.NodeRequestLookuper = (*listSnaps)(nil)
 
-func (d *listSnaps) Lookup(ctx context.Context, name string) (fs.Node, error) {
+func (d *listSnaps) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.LookupResponse) (fs.Node, error) {
+	if err := d.fs.checkAccess(&req.Header, false); err != nil {
+		return nil, err
+	}
+	n, err := d.fs.OpenSnapshot(ctx, req.Name)
+	if err == db.ErrSnapshotNotFound {
+		return nil, fuse.ENOENT
+	}
+	return n, err
+}
+
+// OpenSnapshot returns the root directory of the named snapshot, to
+// be served with FUSE. Snapshots are read-only.
+//
+// If there is no such snapshot, returns db.ErrSnapshotNotFound.
+func (v *Volume) OpenSnapshot(ctx context.Context, name string) (fs.Node, error) {
 	var ref wire.SnapshotRef
 	lookup := func(tx *db.Tx) error {
-		bucket := d.fs.bucket(tx).SnapBucket()
+		bucket := v.bucket(tx).SnapBucket()
 		if bucket == nil {
 			return errors.New("snapshot bucket missing")
 		}
 		buf := bucket.Get([]byte(name))
 		if buf == nil {
-			return fuse.ENOENT
+			return db.ErrSnapshotNotFound
 		}
 		if err := proto.Unmarshal(buf, &ref); err != nil {
 			return fmt.Errorf("corrupt snapshot reference: %q: %v", name, err)
 		}
 		return nil
 	}
-	if err := d.fs.db.View(lookup); err != nil {
+	if err := v.db.View(lookup); err != nil {
 		return nil, err
 	}
 
@@ -61,7 +85,7 @@ func (d *listSnaps) Lookup(ctx context.Context, name string) (fs.Node, error) {
 		return nil, fmt.Errorf("corrupt snapshot reference: %q: %v", name, err)
 	}
 
-	chunk, err := d.fs.chunkStore.Get(ctx, k, "snap", 0)
+	chunk, err := v.chunkStore.Get(ctx, k, "snap", 0)
 	if err != nil {
 		return nil, fmt.Errorf("cannot fetch snapshot: %v", err)
 	}
@@ -72,7 +96,7 @@ func (d *listSnaps) Lookup(ctx context.Context, name string) (fs.Node, error) {
 		return nil, fmt.Errorf("corrupt snapshot: %v: %v", ref.Key, err)
 	}
 
-	n, err := snap.Open(d.fs.chunkStore, snapshot.Contents)
+	n, err := snap.Open(v.chunkStore, snapshot.Contents)
 	if err != nil {
 		return nil, fmt.Errorf("cannot serve snapshot: %v", err)
 	}
@@ -84,13 +108,21 @@ var _ fs.NodeMkdirer = (*listSnaps)(nil)
 // Mkdir takes a snapshot of this volume and records it under the
 // given name.
 func (d *listSnaps) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
+	if err := d.fs.checkAccess(&req.Header, true); err != nil {
+		return nil, err
+	}
 	var snapshot *wiresnap.Snapshot
+	var refs []db.SnapshotChunk
+	progress := func(p SnapshotProgress) {
+		log.Printf("snapshot %q: %d directories, %d files", req.Name, p.Dirs, p.Files)
+	}
 	record := func(tx *db.Tx) error {
-		s, err := d.fs.Snapshot(ctx, tx)
+		s, r, err := d.fs.snapshot(ctx, tx, progress)
 		if err != nil {
 			return err
 		}
 		snapshot = s
+		refs = r
 		return nil
 	}
 	if err := d.fs.db.View(record); err != nil {
@@ -114,10 +146,16 @@ func (d *listSnaps) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node,
 			Type:  "snap",
 			Level: 0,
 			Buf:   buf,
+			Hash:  d.fs.hash,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("cannot store snapshot: %v", err)
 		}
+		refs = append(refs, db.SnapshotChunk{
+			Key:  key,
+			Type: "snap",
+			Size: uint64(len(buf)),
+		})
 	}
 
 	var ref = wire.SnapshotRef{
@@ -129,11 +167,20 @@ func (d *listSnaps) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node,
 	}
 
 	add := func(tx *db.Tx) error {
-		b := d.fs.bucket(tx).SnapBucket()
+		bucket := d.fs.bucket(tx)
+		b := bucket.SnapBucket()
 		if b == nil {
 			return errors.New("snapshot bucket missing")
 		}
-		return b.Put([]byte(req.Name), buf)
+		if err := b.Put([]byte(req.Name), buf); err != nil {
+			return err
+		}
+		// a snapshot by the same name is replaced
+		sc := bucket.SnapshotChunks()
+		if err := sc.Forget(req.Name); err != nil {
+			return err
+		}
+		return sc.Record(req.Name, refs)
 	}
 	if err := d.fs.db.Update(add); err != nil {
 		return nil, fmt.Errorf("cannot save snapshot pointer: %v", err)
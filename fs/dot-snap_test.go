@@ -1,11 +1,14 @@
 package fs_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"syscall"
 	"testing"
 
+	"bazil.org/bazil/db"
 	bazfstestutil "bazil.org/bazil/fs/fstestutil"
 	"bazil.org/bazil/util/tempdir"
 )
@@ -67,6 +70,55 @@ func TestSnapRecord(t *testing.T) {
 	}
 }
 
+func TestSnapRecordWide(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	// more directories than snapshot workers, some nested, to
+	// exercise both walking in parallel and inline
+	const width = 40
+	for i := 0; i < width; i++ {
+		p := path.Join(mnt.Dir, fmt.Sprintf("dir%02d", i), "sub")
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatalf("cannot make directory: %v", err)
+		}
+		if err := ioutil.WriteFile(path.Join(p, "file"), []byte(p), 0644); err != nil {
+			t.Fatalf("cannot write file: %v", err)
+		}
+	}
+
+	if err := os.Mkdir(path.Join(mnt.Dir, ".snap", "mysnap"), 0755); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	fis, err := ioutil.ReadDir(path.Join(mnt.Dir, ".snap", "mysnap"))
+	if err != nil {
+		t.Fatalf("listing snapshot failed: %v", err)
+	}
+	if g, e := len(fis), width; g != e {
+		t.Fatalf("wrong number of entries: %d != %d", g, e)
+	}
+	for i, fi := range fis {
+		if g, e := fi.Name(), fmt.Sprintf("dir%02d", i); g != e {
+			t.Errorf("wrong entry: %q != %q", g, e)
+		}
+		orig := path.Join(mnt.Dir, fi.Name(), "sub")
+		data, err := ioutil.ReadFile(path.Join(mnt.Dir, ".snap", "mysnap", fi.Name(), "sub", "file"))
+		if err != nil {
+			t.Fatalf("reading snapshot file failed: %v", err)
+		}
+		if g, e := string(data), orig; g != e {
+			t.Errorf("wrong content: %q != %q", g, e)
+		}
+	}
+}
+
 func TestSnapList(t *testing.T) {
 	tmp := tempdir.New(t)
 	defer tmp.Cleanup()
@@ -116,3 +168,105 @@ func TestSnapList(t *testing.T) {
 		}
 	}
 }
+
+func TestSnapUsage(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	if err := ioutil.WriteFile(path.Join(mnt.Dir, "shared"), []byte(GREETING), 0644); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+	if err := os.Mkdir(path.Join(mnt.Dir, ".snap", "one"), 0755); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if err := ioutil.WriteFile(path.Join(mnt.Dir, "extra"), []byte("more data\n"), 0644); err != nil {
+		t.Fatalf("cannot write file: %v", err)
+	}
+	if err := os.Mkdir(path.Join(mnt.Dir, ".snap", "two"), 0755); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+
+	usage := func(name string) (db.SnapshotUsage, bool) {
+		var u db.SnapshotUsage
+		var ok bool
+		get := func(tx *db.Tx) error {
+			vol, err := tx.Volumes().GetByName("default")
+			if err != nil {
+				return err
+			}
+			u, ok = vol.SnapshotChunks().Usage(name)
+			return nil
+		}
+		if err := app.DB.View(get); err != nil {
+			t.Fatalf("db error: %v", err)
+		}
+		return u, ok
+	}
+
+	two, ok := usage("two")
+	if !ok {
+		t.Fatalf("no usage recorded for snapshot")
+	}
+	// "shared" is pinned by both snapshots, "extra" only by two
+	if two.Exclusive >= two.Total {
+		t.Errorf("expected shared data: %+v", two)
+	}
+	if two.Exclusive < uint64(len("more data\n")) {
+		t.Errorf("unique data not accounted: %+v", two)
+	}
+
+	if err := syscall.Rmdir(path.Join(mnt.Dir, ".snap", "one")); err != nil {
+		t.Fatalf("removing snapshot failed: %v", err)
+	}
+	if _, ok := usage("one"); ok {
+		t.Errorf("removed snapshot still has usage")
+	}
+	after, _ := usage("two")
+	if g, e := after.Exclusive, after.Total; g != e {
+		t.Errorf("last snapshot should own all its data: %d != %d", g, e)
+	}
+}
+
+func TestSnapMount(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	p := path.Join(mnt.Dir, "hello")
+	if err := ioutil.WriteFile(p, []byte(GREETING), 0644); err != nil {
+		t.Fatalf("cannot write hello: %v", err)
+	}
+	if err := os.Mkdir(path.Join(mnt.Dir, ".snap", "mysnap"), 0755); err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	if err := ioutil.WriteFile(p, []byte("changed\n"), 0644); err != nil {
+		t.Fatalf("cannot change hello: %v", err)
+	}
+
+	old := bazfstestutil.MountedSnapshot(t, app, "default", "mysnap")
+	defer old.Close()
+
+	data, err := ioutil.ReadFile(path.Join(old.Dir, "hello"))
+	if err != nil {
+		t.Fatalf("reading old greeting failed: %v", err)
+	}
+	if g, e := string(data), GREETING; g != e {
+		t.Errorf("wrong greeting: %q != %q", g, e)
+	}
+
+	err = ioutil.WriteFile(path.Join(old.Dir, "new"), nil, 0644)
+	if nerr, ok := err.(*os.PathError); !ok || nerr.Err != syscall.EROFS {
+		t.Errorf("expected EROFS when writing to snapshot: %v", err)
+	}
+}
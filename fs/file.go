@@ -5,12 +5,13 @@ import (
 	"log"
 	"sync"
 	"syscall"
+	"time"
 
 	"bazil.org/bazil/cas/blobs"
 	wirecas "bazil.org/bazil/cas/wire"
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/fs/wire"
-	"bazil.org/bazil/util/env"
+	"bazil.org/bazil/util/errkind"
 	"bazil.org/fuse"
 	"bazil.org/fuse/fs"
 	"golang.org/x/net/context"
@@ -35,6 +36,8 @@ type file struct {
 	blob    *blobs.Blob
 	dirty   dirtiness
 	handles uint32
+	// nil for the defaults
+	perm *wire.Perm
 
 	// when was this entry last changed
 	// TODO: written time.Time
@@ -68,6 +71,7 @@ func (f *file) marshalInternal(ctx context.Context) (*wire.Dirent, error) {
 	de.File = &wire.File{
 		Manifest: wirecas.FromBlob(manifest),
 	}
+	de.Perm = f.perm
 	return de, nil
 }
 
@@ -83,9 +87,7 @@ func (f *file) Attr(ctx context.Context, a *fuse.Attr) error {
 	defer f.mu.Unlock()
 
 	a.Inode = f.inode
-	a.Mode = 0644
-	a.Uid = env.MyUID
-	a.Gid = env.MyGID
+	setPermAttr(a, f.perm, 0644)
 	a.Size = f.blob.Size()
 	return nil
 }
@@ -99,6 +101,9 @@ func (f *file) Forget() {
 }
 
 func (f *file) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	if err := f.parent.fs.checkAccess(&req.Header, !req.Flags.IsReadOnly()); err != nil {
+		return nil, err
+	}
 	// allow kernel to use buffer cache
 	resp.Flags &^= fuse.OpenDirectIO
 	f.mu.Lock()
@@ -107,21 +112,49 @@ func (f *file) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 	if tmp == 0 {
 		return nil, fuse.Errno(syscall.ENFILE)
 	}
+	if err := f.parent.fs.handles.acquire(); err != nil {
+		return nil, err
+	}
 	f.handles = tmp
 	return f, nil
 }
 
 func (f *file) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.WriteResponse) error {
+	throttle := &f.parent.fs.throttle
+	release, err := throttle.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := time.Now()
+	if err := f.write(ctx, req, resp); err != nil {
+		return err
+	}
+	throttle.observe(time.Since(start))
+	// the data is in already; holding back the reply is what slows
+	// down the writer
+	throttle.pause(ctx)
+	return nil
+}
+
+func (f *file) write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.WriteResponse) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	if req.Offset < 0 {
+		return fuse.Errno(syscall.EINVAL)
+	}
+	if err := f.checkSize(uint64(req.Offset) + uint64(len(req.Data))); err != nil {
+		return err
+	}
 	f.dirty = dirty
 
 	n, err := f.blob.IO(ctx).WriteAt(req.Data, req.Offset)
 	resp.Size = n
 	if err != nil {
 		log.Printf("write error: %v", err)
-		return fuse.EIO
+		return dataErrno(err)
 	}
 	return nil
 }
@@ -152,9 +185,13 @@ func (f *file) flush(ctx context.Context) error {
 	save := func(tx *db.Tx) error {
 		return f.parent.save(tx, f.name, de)
 	}
+	start := time.Now()
 	if err := f.parent.fs.db.Update(save); err != nil {
 		return err
 	}
+	f.parent.fs.throttle.observe(time.Since(start))
+	f.parent.fs.dirCache.forgetDir(f.parent.inode)
+	f.parent.fs.notifyAttr(f)
 
 	f.mu.Lock()
 	if f.dirty == writing {
@@ -171,6 +208,16 @@ func (f *file) Flush(ctx context.Context, req *fuse.FlushRequest) error {
 
 const maxInt64 = 9223372036854775807
 
+// dataErrno chooses the error to report when file contents cannot be
+// read or written. A chunk that cannot be found means lost data, not
+// a missing file.
+func dataErrno(err error) error {
+	if errkind.Of(err) == errkind.NotFound {
+		return fuse.EIO
+	}
+	return fuse.Errno(errkind.Errno(err))
+}
+
 func (f *file) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -186,7 +233,7 @@ func (f *file) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 	n, err := f.blob.IO(ctx).ReadAt(resp.Data, int64(req.Offset))
 	if err != nil && err != io.EOF {
 		log.Printf("read error: %v", err)
-		return fuse.EIO
+		return dataErrno(err)
 	}
 	resp.Data = resp.Data[:n]
 
@@ -194,6 +241,10 @@ func (f *file) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 }
 
 func (f *file) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
+	if err := f.parent.fs.checkAccess(&req.Header, true); err != nil {
+		return err
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
@@ -201,6 +252,9 @@ func (f *file) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 
 	valid := req.Valid
 	if valid.Size() {
+		if err := f.checkSize(req.Size); err != nil {
+			return err
+		}
 		err := f.blob.Truncate(ctx, req.Size)
 		if err != nil {
 			return err
@@ -235,6 +289,7 @@ func (f *file) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
 		name = f.name
 	}
 	f.mu.Unlock()
+	f.parent.fs.handles.release()
 	if name != "" {
 		f.parent.tryResolveConflicts(name)
 	}
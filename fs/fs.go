@@ -6,14 +6,14 @@ import (
 	"path"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"syscall"
 
+	"bazil.org/bazil/cas"
+	"bazil.org/bazil/cas/blobs"
 	"bazil.org/bazil/cas/chunks"
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/fs/clock"
 	"bazil.org/bazil/fs/inodes"
-	wiresnap "bazil.org/bazil/fs/snap/wire"
 	"bazil.org/bazil/fs/wire"
 	"bazil.org/bazil/peer"
 	wirepeer "bazil.org/bazil/peer/wire"
@@ -28,10 +28,17 @@ type Volume struct {
 	volID      db.VolumeID
 	pubKey     peer.PublicKey
 	chunkStore chunks.Store
+	hash       cas.Hash
 	root       *dir
+	dirCache   *dirCache
+	handles    handleCount
+	throttle   writeThrottle
+	pause      pauseGate
+	limits     limits
+	access     userAccess
 
-	// Only set while the Volume is mounted.
-	fuse atomic.Value
+	// FUSE servers for the mounts of this volume.
+	mounts mounts
 
 	epoch struct {
 		mu sync.Mutex
@@ -73,6 +80,11 @@ func Open(db *db.DB, chunkStore chunks.Store, volumeID *db.VolumeID, pubKey *pee
 	fs.pubKey = *pubKey
 	fs.chunkStore = chunkStore
 	fs.root = newDir(fs, tokens.InodeRoot, nil, "")
+	fs.dirCache = newDirCache()
+	fs.mounts.init()
+	fs.SetWriteThrottle(defaultMaxPendingWrites, defaultWriteLatencyTarget)
+	fs.SetMaxPause(defaultMaxPause)
+	fs.SetLimits(Limits{})
 	// assume we crashed, to be safe
 	fs.epoch.dirty = true
 	if err := fs.db.View(fs.initFromDB); err != nil {
@@ -87,31 +99,37 @@ func (v *Volume) initFromDB(tx *db.Tx) error {
 		return err
 	}
 	v.epoch.ticks = epoch
+	hash, err := v.bucket(tx).Hash()
+	if err != nil {
+		return err
+	}
+	v.hash = hash
 	return nil
 }
 
+// emptyManifest returns an empty manifest for new content in the
+// volume.
+func (v *Volume) emptyManifest(type_ string) *blobs.Manifest {
+	m := blobs.EmptyManifest(type_)
+	m.Hash = v.hash
+	return m
+}
+
 func (v *Volume) Root() (fs.Node, error) {
 	return v.root, nil
 }
 
+// ChunkStore returns the store holding the contents of the volume.
+func (v *Volume) ChunkStore() chunks.Store {
+	return v.chunkStore
+}
+
 func (*Volume) GenerateInode(parent uint64, name string) uint64 {
 	return inodes.Dynamic(parent, name)
 }
 
 var _ fs.FSInodeGenerator = (*Volume)(nil)
 
-// Snapshot records a snapshot of the volume. The Snapshot message
-// itself has not been persisted yet.
-func (v *Volume) Snapshot(ctx context.Context, tx *db.Tx) (*wiresnap.Snapshot, error) {
-	snapshot := &wiresnap.Snapshot{}
-	sde, err := v.root.snapshot(ctx, tx)
-	if err != nil {
-		return nil, err
-	}
-	snapshot.Contents = sde
-	return snapshot, nil
-}
-
 // caller is responsible for locking
 //
 // TODO nextEpoch only needs to tick if the volume is seeing mutation;
@@ -162,6 +180,30 @@ func splitPath(p string) (string, string) {
 	return p[:idx], p[idx+1:]
 }
 
+// lookupDirent finds the stored entry at p, relative to the root of
+// the volume. Everything along the way must be a directory. For the
+// root itself, de is nil.
+func (v *Volume) lookupDirent(dirs *db.Dirs, p string) (parent uint64, name string, de *wire.Dirent, err error) {
+	inode := v.root.inode
+	for p = path.Clean("/" + p)[1:]; p != ""; {
+		if de != nil && de.Dir == nil {
+			return 0, "", nil, fuse.Errno(syscall.ENOTDIR)
+		}
+		var seg string
+		seg, p = splitPath(p)
+		de, err = dirs.Get(inode, seg)
+		if err != nil {
+			return 0, "", nil, err
+		}
+		if de.Tombstone != nil {
+			return 0, "", nil, fuse.ENOENT
+		}
+		parent, name = inode, seg
+		inode = de.Inode
+	}
+	return parent, name, de, nil
+}
+
 func (v *Volume) SyncSend(ctx context.Context, dirPath string, send func(*wirepeer.VolumeSyncPullItem) error) error {
 	dirPath = path.Clean("/" + dirPath)[1:]
 
@@ -240,6 +282,9 @@ func (v *Volume) SyncSend(ctx context.Context, dirPath string, send func(*wirepe
 				// PeerID 0 always refers to myself.
 				0: v.pubKey[:],
 			},
+			Peers64: map[uint64][]byte{
+				0: v.pubKey[:],
+			},
 			DirClock: dirClockBuf,
 		}
 
@@ -252,8 +297,10 @@ func (v *Volume) SyncSend(ctx context.Context, dirPath string, send func(*wirepe
 				continue
 			}
 
-			// TODO hardcoded knowledge of size of peer.ID
+			// TODO hardcoded knowledge of size of peer.ID; the 32-bit
+			// map is kept for peers that do not know peers64 yet
 			msg.Peers[uint32(peer.ID())] = peer.Pub()[:]
+			msg.Peers64[uint64(peer.ID())] = peer.Pub()[:]
 		}
 
 		c := dirs.List(dirInode)
@@ -423,21 +470,69 @@ func (v *Volume) SyncReceive(ctx context.Context, dirPath string, peers map[uint
 	return nil
 }
 
-func (v *Volume) SetFUSE(srv *fs.Server) {
-	v.fuse.Store(srv)
+// AddFUSE starts sending cache invalidations to the kernel through
+// srv. A volume can be served through several mounts at once.
+func (v *Volume) AddFUSE(srv *fs.Server) {
+	v.mounts.add(srv)
 }
 
-func (v *Volume) invalidateEntry(d node, name string) error {
-	i := v.fuse.Load()
-	if i == nil {
-		return fuse.ErrNotCached
+// RemoveFUSE undoes AddFUSE.
+func (v *Volume) RemoveFUSE(srv *fs.Server) {
+	v.mounts.remove(srv)
+}
+
+// Flush saves writes made through the mounts that have not been
+// saved yet, so that snapshots and syncs started after it returns
+// see them. Writes that happen during the call may or may not be
+// included.
+func (v *Volume) Flush(ctx context.Context) error {
+	var files []*file
+	var walk func(d *dir)
+	walk = func(d *dir) {
+		var dirs []*dir
+		d.mu.Lock()
+		for _, a := range d.active {
+			switch n := a.node.(type) {
+			case *file:
+				files = append(files, n)
+			case *dir:
+				dirs = append(dirs, n)
+			}
+		}
+		d.mu.Unlock()
+		for _, child := range dirs {
+			walk(child)
+		}
 	}
-	srv := i.(*fs.Server)
-	if srv == nil {
-		return fuse.ErrNotCached
+	walk(v.root)
+
+	for _, f := range files {
+		if err := f.flush(ctx); err != nil {
+			return err
+		}
 	}
-	if err := srv.InvalidateEntry(d, name); err != nil {
-		return err
+	return nil
+}
+
+// invalidateEntry makes every mount forget the entry right away. Must
+// not be called while serving a FUSE request; see notifyEntry.
+func (v *Volume) invalidateEntry(d node, name string) error {
+	for _, srv := range v.mounts.list() {
+		if err := srv.InvalidateEntry(d, name); err != nil && err != fuse.ErrNotCached {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidateAttr makes every mount forget the attributes of n right
+// away. Must not be called while serving a FUSE request; see
+// notifyAttr.
+func (v *Volume) invalidateAttr(n node) error {
+	for _, srv := range v.mounts.list() {
+		if err := srv.InvalidateNodeAttr(n); err != nil && err != fuse.ErrNotCached {
+			return err
+		}
 	}
 	return nil
 }
@@ -9,8 +9,11 @@ import (
 	"testing"
 	"time"
 
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs"
 	bazfstestutil "bazil.org/bazil/fs/fstestutil"
 	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
 )
 
 func init() {
@@ -925,3 +928,490 @@ func TestRenameOverwriteWhileOpen(t *testing.T) {
 		}
 	}()
 }
+
+func TestLookupMissingThenCreate(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	p := path.Join(mnt.Dir, "hello")
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("expected ENOENT, got %v", err)
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("cannot create hello: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("closing hello failed: %v", err)
+	}
+
+	if _, err := os.Stat(p); err != nil {
+		t.Fatalf("stat after create failed: %v", err)
+	}
+
+	if err := os.Remove(p); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Fatalf("expected ENOENT after remove, got %v", err)
+	}
+}
+
+func TestOpenHandleLimit(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref.FS().SetHandleLimit(1)
+	defer ref.Close()
+
+	f, err := os.Create(path.Join(mnt.Dir, "one"))
+	if err != nil {
+		t.Fatalf("cannot create one: %v", err)
+	}
+	defer f.Close()
+
+	_, err = os.Create(path.Join(mnt.Dir, "two"))
+	if perr, ok := err.(*os.PathError); !ok || perr.Err != syscall.ENFILE {
+		t.Fatalf("expected ENFILE, got %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("closing one failed: %v", err)
+	}
+	// release happens asynchronously after close
+	deadline := time.Now().Add(2 * time.Second)
+	for ref.FS().HandleStats().Open != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	g, err := os.Open(path.Join(mnt.Dir, "one"))
+	if err != nil {
+		t.Fatalf("open after close failed: %v", err)
+	}
+	g.Close()
+
+	stats := ref.FS().HandleStats()
+	if g, e := stats.Refused, uint64(1); g != e {
+		t.Errorf("wrong refused count: %d != %d", g, e)
+	}
+	if g, e := stats.Peak, uint64(1); g != e {
+		t.Errorf("wrong peak count: %d != %d", g, e)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	ref.FS().SetLimits(fs.Limits{
+		MaxFileSize:  10,
+		MaxNameBytes: 8,
+		MaxDepth:     2,
+	})
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(mnt.Dir, &st); err != nil {
+		t.Fatalf("statfs failed: %v", err)
+	}
+	if g, e := st.Namelen, int64(8); int64(g) != e {
+		t.Errorf("wrong name length in statfs: %d != %d", g, e)
+	}
+
+	_, err = os.Create(path.Join(mnt.Dir, "too-long-name"))
+	if perr, ok := err.(*os.PathError); !ok || perr.Err != syscall.ENAMETOOLONG {
+		t.Errorf("expected ENAMETOOLONG for long name, got %v", err)
+	}
+
+	if err := os.Mkdir(path.Join(mnt.Dir, "a"), 0755); err != nil {
+		t.Fatalf("mkdir a: %v", err)
+	}
+	if err := os.Mkdir(path.Join(mnt.Dir, "a", "b"), 0755); err != nil {
+		t.Fatalf("mkdir a/b: %v", err)
+	}
+	err = os.Mkdir(path.Join(mnt.Dir, "a", "b", "c"), 0755)
+	if perr, ok := err.(*os.PathError); !ok || perr.Err != syscall.ENAMETOOLONG {
+		t.Errorf("expected ENAMETOOLONG for deep directory, got %v", err)
+	}
+
+	f, err := os.Create(path.Join(mnt.Dir, "big"))
+	if err != nil {
+		t.Fatalf("cannot create big: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte("0123456789")); err != nil {
+		t.Fatalf("write up to limit failed: %v", err)
+	}
+	_, err = f.Write([]byte("x"))
+	if perr, ok := err.(*os.PathError); !ok || perr.Err != syscall.EFBIG {
+		t.Errorf("expected EFBIG for write past limit, got %v", err)
+	}
+	err = f.Truncate(11)
+	if perr, ok := err.(*os.PathError); !ok || perr.Err != syscall.EFBIG {
+		t.Errorf("expected EFBIG for truncate past limit, got %v", err)
+	}
+}
+
+func TestStatfs(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	var before syscall.Statfs_t
+	if err := syscall.Statfs(mnt.Dir, &before); err != nil {
+		t.Fatalf("statfs failed: %v", err)
+	}
+	if before.Blocks == 0 {
+		t.Errorf("no blocks reported")
+	}
+	if before.Bfree > before.Blocks {
+		t.Errorf("more free than total blocks: %d > %d", before.Bfree, before.Blocks)
+	}
+
+	if err := os.Mkdir(path.Join(mnt.Dir, "sub"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	var after syscall.Statfs_t
+	if err := syscall.Statfs(mnt.Dir, &after); err != nil {
+		t.Fatalf("statfs failed: %v", err)
+	}
+	if g, e := after.Ffree, before.Ffree-1; g != e {
+		t.Errorf("wrong free inodes after mkdir: %d != %d", g, e)
+	}
+}
+
+func TestMountTwice(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt1 := bazfstestutil.Mounted(t, app, "default")
+	defer mnt1.Close()
+	mnt2 := bazfstestutil.Mounted(t, app, "default")
+	defer mnt2.Close()
+
+	const greeting = "hello, world\n"
+	if err := ioutil.WriteFile(path.Join(mnt1.Dir, "hello"), []byte(greeting), 0644); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := ioutil.ReadFile(path.Join(mnt2.Dir, "hello"))
+	if err != nil {
+		t.Fatalf("cannot read through second mount: %v", err)
+	}
+	if g, e := string(buf), greeting; g != e {
+		t.Errorf("wrong content through second mount: %q != %q", g, e)
+	}
+
+	// grow the file while the second mount has its size cached
+	const more = "hello, again\n"
+	f, err := os.OpenFile(path.Join(mnt1.Dir, "hello"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte(more)); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	buf, err = ioutil.ReadFile(path.Join(mnt2.Dir, "hello"))
+	if err != nil {
+		t.Fatalf("cannot read through second mount: %v", err)
+	}
+	if g, e := string(buf), greeting+more; g != e {
+		t.Errorf("stale content through second mount: %q != %q", g, e)
+	}
+
+	if err := os.Remove(path.Join(mnt1.Dir, "hello")); err != nil {
+		t.Fatal(err)
+	}
+	// entry invalidations are delivered right after the request
+	// returns; give them a moment
+	deadline := time.Now().Add(time.Second)
+	for {
+		_, err := os.Stat(path.Join(mnt2.Dir, "hello"))
+		if os.IsNotExist(err) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("removed file still visible through second mount: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteThrottle(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	// any storage is too slow for this target
+	ref.FS().SetWriteThrottle(1, time.Nanosecond)
+
+	f, err := os.Create(path.Join(mnt.Dir, "hello"))
+	if err != nil {
+		t.Fatalf("cannot create hello: %v", err)
+	}
+	defer f.Close()
+	for i := 0; i < 3; i++ {
+		if _, err := f.Write([]byte(GREETING)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatalf("fsync failed: %v", err)
+	}
+
+	stats := ref.FS().WriteStats()
+	if stats.Pending != 0 {
+		t.Errorf("writes still pending: %d", stats.Pending)
+	}
+	if stats.Throttled == 0 {
+		t.Errorf("writes were not throttled")
+	}
+	if stats.Latency <= 0 {
+		t.Errorf("no latency recorded: %v", stats.Latency)
+	}
+}
+
+func TestPause(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+
+	ctx := context.Background()
+	resume, err := ref.FS().Pause(ctx)
+	if err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if _, err := ref.FS().Pause(ctx); err != fs.ErrPaused {
+		t.Errorf("expected ErrPaused when pausing twice: %v", err)
+	}
+
+	created := make(chan error, 1)
+	go func() {
+		f, err := os.Create(path.Join(mnt.Dir, "hello"))
+		if err == nil {
+			err = f.Close()
+		}
+		created <- err
+	}()
+	for ref.FS().PauseStats().Waiting == 0 {
+		select {
+		case err := <-created:
+			t.Fatalf("create was not held back: %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	resume()
+	if err := <-created; err != nil {
+		t.Fatalf("create failed after pause: %v", err)
+	}
+	stats := ref.FS().PauseStats()
+	if stats.Paused {
+		t.Error("still paused after resume")
+	}
+	if stats.Queued == 0 {
+		t.Error("no requests were queued")
+	}
+
+	// a pause that goes on too long lets requests through
+	ref.FS().SetMaxPause(time.Millisecond)
+	// requests still finishing may make the pause expire already
+	if _, err := ref.FS().Pause(ctx); err != nil && err != fs.ErrPauseExpired {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if _, err := os.Stat(path.Join(mnt.Dir, "hello")); err != nil {
+		t.Fatalf("stat failed during expired pause: %v", err)
+	}
+	for ref.FS().PauseStats().Paused {
+		time.Sleep(time.Millisecond)
+	}
+	if g, e := ref.FS().PauseStats().Expired, uint64(1); g != e {
+		t.Errorf("wrong number of expired pauses: %d != %d", g, e)
+	}
+}
+
+func TestPauseWaitsForRequests(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+
+	// a request being served until canceled
+	reqCtx, done := context.WithCancel(context.Background())
+	defer done()
+	ref.FS().WaitPause(reqCtx)
+	if g, e := ref.FS().PauseStats().Serving, uint64(1); g != e {
+		t.Fatalf("wrong number of requests being served: %d != %d", g, e)
+	}
+
+	paused := make(chan error, 1)
+	go func() {
+		resume, err := ref.FS().Pause(context.Background())
+		if err == nil {
+			resume()
+		}
+		paused <- err
+	}()
+	select {
+	case err := <-paused:
+		t.Fatalf("pause did not wait for the request: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	done()
+	if err := <-paused; err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+}
+
+func TestRewritePerms(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	if err := os.MkdirAll(path.Join(mnt.Dir, "a", "b"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, p := range []string{"a/f", "a/b/g", "outside"} {
+		if err := ioutil.WriteFile(path.Join(mnt.Dir, p), []byte("x"), 0644); err != nil {
+			t.Fatalf("cannot create %s: %v", p, err)
+		}
+	}
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	ctx := context.Background()
+
+	checkMode := func(p string, want os.FileMode) {
+		fi, err := os.Stat(path.Join(mnt.Dir, p))
+		if err != nil {
+			t.Fatalf("stat %s: %v", p, err)
+		}
+		if g := fi.Mode() & (os.ModePerm | os.ModeSetgid); g != want {
+			t.Errorf("wrong mode for %s: %v != %v", p, g, want)
+		}
+	}
+
+	change := &fs.PermChange{ClearMode: 07777, SetMode: 0600, FilesOnly: true}
+	changed, err := ref.FS().RewritePerms(ctx, "a", change, "files", nil)
+	if err != nil {
+		t.Fatalf("rewrite failed: %v", err)
+	}
+	if g, e := changed, uint64(2); g != e {
+		t.Errorf("wrong number of changed entries: %d != %d", g, e)
+	}
+	checkMode("a", 0755)
+	checkMode("a/f", 0600)
+	checkMode("a/b/g", 0600)
+	checkMode("outside", 0644)
+
+	uid := uint32(12345)
+	change = &fs.PermChange{SetMode: 02000, UID: &uid, DirsOnly: true}
+	if _, err := ref.FS().RewritePerms(ctx, "a", change, "dirs", nil); err != nil {
+		t.Fatalf("rewrite failed: %v", err)
+	}
+	checkMode("a", 0755|os.ModeSetgid)
+	checkMode("a/b", 0755|os.ModeSetgid)
+	fi, err := os.Stat(path.Join(mnt.Dir, "a", "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := fi.Sys().(*syscall.Stat_t).Uid, uid; g != e {
+		t.Errorf("wrong owner: %d != %d", g, e)
+	}
+
+	if _, err := ref.FS().RewritePerms(ctx, "a", change, "dirs", nil); err != db.ErrPermUndoExist {
+		t.Errorf("expected ErrPermUndoExist, got %v", err)
+	}
+
+	// data written after a rewrite keeps the new permissions
+	if err := ioutil.WriteFile(path.Join(mnt.Dir, "a", "f"), []byte("y"), 0644); err != nil {
+		t.Fatalf("cannot rewrite a/f: %v", err)
+	}
+	checkMode("a/f", 0600)
+
+	for _, name := range []string{"dirs", "files"} {
+		if _, err := ref.FS().UndoPerms(ctx, name); err != nil {
+			t.Fatalf("undo %s failed: %v", name, err)
+		}
+	}
+	checkMode("a", 0755)
+	checkMode("a/b", 0755)
+	checkMode("a/f", 0644)
+	checkMode("a/b/g", 0644)
+	if _, err := ref.FS().UndoPerms(ctx, "files"); err != db.ErrPermUndoNotFound {
+		t.Errorf("expected ErrPermUndoNotFound, got %v", err)
+	}
+}
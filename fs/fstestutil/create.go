@@ -10,6 +10,7 @@ import (
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/server"
 	"bazil.org/fuse"
+	"golang.org/x/net/context"
 )
 
 func NewApp(t testing.TB, dataDir string) *server.App {
@@ -82,7 +83,7 @@ func (mnt *Mount) Close() {
 		}
 		break
 	}
-	mnt.ref.WaitForUnmount()
+	mnt.ref.WaitForUnmountAt(mnt.Dir)
 	os.Remove(mnt.Dir)
 }
 
@@ -119,3 +120,33 @@ func Mounted(t testing.TB, app *server.App, volumeName string) *Mount {
 	ref = nil
 	return mnt
 }
+
+// MountedSnapshot mounts the named snapshot of the volume, as a
+// filesystem of its own.
+func MountedSnapshot(t testing.TB, app *server.App, volumeName string, snapshot string) *Mount {
+	mountpoint, err := ioutil.TempDir("", "bazil-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := app.GetVolumeByName(volumeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if ref != nil {
+			ref.Close()
+		}
+	}()
+	if err := ref.MountSnapshot(context.Background(), snapshot, mountpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	mnt := &Mount{
+		Dir: mountpoint,
+		ref: ref,
+	}
+	// success -> tell the defer to not close the ref
+	ref = nil
+	return mnt
+}
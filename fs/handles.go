@@ -0,0 +1,74 @@
+package fs
+
+import (
+	"sync"
+	"syscall"
+
+	"bazil.org/fuse"
+)
+
+// handleCount keeps track of open file handles in a volume.
+type handleCount struct {
+	mu sync.Mutex
+	// zero means no limit
+	limit   uint64
+	open    uint64
+	peak    uint64
+	refused uint64
+}
+
+// acquire accounts for a new open handle. If the volume is at its
+// limit, it returns ENFILE.
+func (h *handleCount) acquire() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.limit != 0 && h.open >= h.limit {
+		h.refused++
+		return fuse.Errno(syscall.ENFILE)
+	}
+	h.open++
+	if h.open > h.peak {
+		h.peak = h.open
+	}
+	return nil
+}
+
+func (h *handleCount) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.open--
+}
+
+// SetHandleLimit limits how many files can be open in the volume at
+// once. Opening more fails with ENFILE. Zero means no limit.
+//
+// Handles open at the time of the call are not affected.
+func (v *Volume) SetHandleLimit(n uint64) {
+	v.handles.mu.Lock()
+	defer v.handles.mu.Unlock()
+	v.handles.limit = n
+}
+
+// HandleStats is a snapshot of the file handle accounting of a
+// volume.
+type HandleStats struct {
+	Open  uint64
+	Limit uint64
+	// Most handles open at once since the volume was opened.
+	Peak uint64
+	// Number of opens that failed due to the limit.
+	Refused uint64
+}
+
+// HandleStats returns the current file handle statistics.
+func (v *Volume) HandleStats() HandleStats {
+	v.handles.mu.Lock()
+	defer v.handles.mu.Unlock()
+	s := HandleStats{
+		Open:    v.handles.open,
+		Limit:   v.handles.limit,
+		Peak:    v.handles.peak,
+		Refused: v.handles.refused,
+	}
+	return s
+}
@@ -0,0 +1,105 @@
+package fs
+
+import (
+	"path"
+	"syscall"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/clock"
+	"bazil.org/bazil/fs/wire"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+)
+
+// rememberVersion adds the file contents in de to the version
+// history, if de is a file. c is the clock of the file with these
+// contents.
+func (v *Volume) rememberVersion(tx *db.Tx, de *wire.Dirent, c *clock.Clock) error {
+	if de.File == nil {
+		return nil
+	}
+	return v.bucket(tx).History().Add(de.Inode, time.Now(), de.File.Manifest, c)
+}
+
+// fileDirent returns the directory entry of a file, refusing
+// anything else.
+func (v *Volume) fileDirent(tx *db.Tx, d *dir, name string) (*wire.Dirent, error) {
+	de, err := v.bucket(tx).Dirs().Get(d.inode, name)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case de.Tombstone != nil:
+		return nil, fuse.ENOENT
+	case de.File == nil:
+		return nil, fuse.Errno(syscall.EISDIR)
+	}
+	return de, nil
+}
+
+// History calls fn for each remembered version of the file at path
+// p, oldest first. A version is remembered every time the file is
+// saved with new contents, so the newest one is the current content.
+//
+// The FileVersion is only valid during the call.
+func (v *Volume) History(p string, fn func(*db.FileVersion) error) error {
+	p = path.Clean("/" + p)[1:]
+	if p == "" {
+		return fuse.Errno(syscall.EISDIR)
+	}
+	d, name, drop, err := v.lookupParent(p)
+	if err != nil {
+		return err
+	}
+	defer drop()
+
+	list := func(tx *db.Tx) error {
+		de, err := v.fileDirent(tx, d, name)
+		if err != nil {
+			return err
+		}
+		return v.bucket(tx).History().List(de.Inode, fn)
+	}
+	return v.db.View(list)
+}
+
+// Restore makes an earlier version of the file at path p current
+// again. The version is identified by the time it was saved, as
+// given by History. The change is applied like a Commit, and
+// becomes the newest version in the history.
+//
+// If there is no such version, returns db.ErrFileVersionNotFound.
+func (v *Volume) Restore(ctx context.Context, p string, version time.Time) error {
+	p = path.Clean("/" + p)[1:]
+	if p == "" {
+		return fuse.Errno(syscall.EISDIR)
+	}
+	d, name, drop, err := v.lookupParent(p)
+	if err != nil {
+		return err
+	}
+	defer drop()
+
+	pc := pendingChange{
+		Change: &Change{Path: p},
+		dir:    d,
+		name:   name,
+	}
+	find := func(tx *db.Tx) error {
+		de, err := v.fileDirent(tx, d, name)
+		if err != nil {
+			return err
+		}
+		fv, err := v.bucket(tx).History().Get(de.Inode, version)
+		if err != nil {
+			return err
+		}
+		pc.manifest = fv.Manifest
+		return nil
+	}
+	if err := v.db.View(find); err != nil {
+		return err
+	}
+	return v.commitPending([]pendingChange{pc})
+}
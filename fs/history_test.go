@@ -0,0 +1,79 @@
+package fs_test
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
+)
+
+func TestHistoryRestore(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	p := path.Join(mnt.Dir, "greeting")
+	for _, s := range []string{"hello\n", "hello\n", "goodbye, world\n"} {
+		if err := ioutil.WriteFile(p, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ref, err := app.GetVolumeByName("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+
+	var versions []time.Time
+	var sizes []uint64
+	list := func(fv *db.FileVersion) error {
+		versions = append(versions, fv.Time)
+		sizes = append(sizes, fv.Manifest.Size)
+		return nil
+	}
+	if err := ref.FS().History("greeting", list); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	// writing the same content again is not a new version
+	if g, e := len(versions), 2; g != e {
+		t.Fatalf("wrong number of versions: %d != %d: %v", g, e, sizes)
+	}
+	if g, e := sizes[0], uint64(len("hello\n")); g != e {
+		t.Errorf("wrong size of first version: %d != %d", g, e)
+	}
+
+	if err := ref.FS().Restore(context.Background(), "greeting", versions[0]); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	buf, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := string(buf), "hello\n"; g != e {
+		t.Errorf("wrong content after restore: %q != %q", g, e)
+	}
+
+	versions = versions[:0]
+	sizes = sizes[:0]
+	if err := ref.FS().History("greeting", list); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	if g, e := len(versions), 3; g != e {
+		t.Fatalf("restore did not add a version: %d != %d", g, e)
+	}
+
+	if err := ref.FS().Restore(context.Background(), "greeting", time.Unix(0, 42)); err != db.ErrFileVersionNotFound {
+		t.Errorf("expected ErrFileVersionNotFound: %v", err)
+	}
+}
@@ -68,3 +68,18 @@ func Allocate(bucket *bolt.Bucket) (uint64, error) {
 	}
 	return i, nil
 }
+
+// Count returns how many inodes have been allocated, and how many
+// are left.
+func Count(bucket *bolt.Bucket) (used, free uint64) {
+	c := bucket.Cursor()
+	last := tokens.MaxReservedInode
+	if k, _ := c.Last(); k != nil {
+		if i := bytesToInode(k); i > last {
+			last = i
+		}
+	}
+	used = last - tokens.MaxReservedInode
+	free = tokens.InodeKindMask - 1 - last
+	return used, free
+}
@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"sync"
+	"syscall"
+
+	"bazil.org/fuse"
+)
+
+// Longest name allowed unless configured otherwise. This matches
+// NAME_MAX of common local file systems, so files can be copied out
+// of a volume.
+const defaultMaxNameBytes = 255
+
+// Limits restricts what can be stored in a volume. Operations that
+// would go past a limit fail with EFBIG or ENAMETOOLONG, before
+// anything is changed.
+type Limits struct {
+	// Largest file size, in bytes. Zero means only the limit of the
+	// storage format applies.
+	MaxFileSize uint64
+	// Longest name of a single file or directory, in bytes. Zero
+	// means 255.
+	MaxNameBytes uint32
+	// Deepest nesting of files and directories; entries in the root
+	// directory are at depth one. Zero means no limit.
+	MaxDepth uint32
+}
+
+type limits struct {
+	mu sync.Mutex
+	Limits
+}
+
+func (l *limits) get() Limits {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.Limits
+}
+
+// SetLimits changes the limits of the volume. Existing files and
+// directories going past the new limits stay as they are, but cannot
+// grow further.
+func (v *Volume) SetLimits(l Limits) {
+	if l.MaxNameBytes == 0 {
+		l.MaxNameBytes = defaultMaxNameBytes
+	}
+	v.limits.mu.Lock()
+	defer v.limits.mu.Unlock()
+	v.limits.Limits = l
+}
+
+// Limits returns the limits currently in effect for the volume.
+func (v *Volume) Limits() Limits {
+	return v.limits.get()
+}
+
+func (v *Volume) checkName(name string) error {
+	if uint64(len(name)) > uint64(v.limits.get().MaxNameBytes) {
+		return fuse.Errno(syscall.ENAMETOOLONG)
+	}
+	return nil
+}
+
+// checkChild verifies that a new entry called name can be created in
+// d.
+func (v *Volume) checkChild(d *dir, name string) error {
+	if err := v.checkName(name); err != nil {
+		return err
+	}
+	max := v.limits.get().MaxDepth
+	if max == 0 {
+		return nil
+	}
+	// the parent of a dir never changes, as directories cannot be
+	// renamed
+	depth := uint32(1)
+	for p := d.parent; p != nil; p = p.parent {
+		depth++
+	}
+	if depth > max {
+		return fuse.Errno(syscall.ENAMETOOLONG)
+	}
+	return nil
+}
+
+// checkSize verifies that a file may grow to size bytes.
+func (f *file) checkSize(size uint64) error {
+	return f.parent.fs.checkFileSize(size, f.blob.MaxSize())
+}
+
+// checkFileSize verifies that a file of size bytes may be stored, in
+// a blob that can grow to blobMax bytes.
+func (v *Volume) checkFileSize(size uint64, blobMax uint64) error {
+	max := v.limits.get().MaxFileSize
+	if max == 0 || max > blobMax {
+		max = blobMax
+	}
+	if size > max {
+		return fuse.Errno(syscall.EFBIG)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"log"
+	"sync"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+)
+
+// mounts keeps track of the FUSE servers a volume is served through.
+//
+// Every mount of the volume shares the same in-memory nodes, and
+// file data is never cached by the kernel across opens, so all
+// mounts agree on content. What each kernel may still hold on to is
+// attributes and directory entries. When there is more than one
+// mount, changes made through one of them are followed by
+// invalidations sent to all of them, in the order the changes were
+// made.
+//
+// This gives close-to-open consistency between mounts: once a file
+// written through one mount has been closed, opening it through
+// another mount sees the new content and size. Names created,
+// removed or renamed become visible in the other mounts shortly
+// after the operation returns.
+type mounts struct {
+	mu   sync.Mutex
+	cond sync.Cond
+
+	servers []*fs.Server
+	// invalidations not yet delivered, oldest first
+	queue   []func(srv *fs.Server) error
+	running bool
+}
+
+func (m *mounts) init() {
+	m.cond.L = &m.mu
+}
+
+func (m *mounts) add(srv *fs.Server) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.servers = append(m.servers, srv)
+	if !m.running {
+		m.running = true
+		go m.deliver()
+	}
+}
+
+func (m *mounts) remove(srv *fs.Server) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.servers {
+		if s == srv {
+			m.servers = append(m.servers[:i], m.servers[i+1:]...)
+			break
+		}
+	}
+	m.cond.Broadcast()
+}
+
+// list returns the servers currently serving the volume.
+func (m *mounts) list() []*fs.Server {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*fs.Server(nil), m.servers...)
+}
+
+// notify queues fn to be called for every mount. It is safe to call
+// while serving a FUSE request, as the kernel is only told after the
+// request has been answered.
+//
+// With a single mount, the kernel that made the change knows about
+// it already, and nothing is queued.
+func (m *mounts) notify(fn func(srv *fs.Server) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.servers) < 2 {
+		return
+	}
+	m.queue = append(m.queue, fn)
+	m.cond.Signal()
+}
+
+func (m *mounts) deliver() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		for len(m.queue) == 0 && len(m.servers) > 0 {
+			m.cond.Wait()
+		}
+		if len(m.servers) == 0 {
+			m.queue = nil
+			m.running = false
+			return
+		}
+		fn := m.queue[0]
+		m.queue[0] = nil
+		m.queue = m.queue[1:]
+		servers := append([]*fs.Server(nil), m.servers...)
+
+		m.mu.Unlock()
+		for _, srv := range servers {
+			if err := fn(srv); err != nil && err != fuse.ErrNotCached {
+				log.Printf("mount invalidation error: %v", err)
+			}
+		}
+		m.mu.Lock()
+	}
+}
+
+// notifyEntry tells all mounts to forget what they know about name
+// in d.
+func (v *Volume) notifyEntry(d *dir, name string) {
+	v.mounts.notify(func(srv *fs.Server) error {
+		return srv.InvalidateEntry(d, name)
+	})
+}
+
+// notifyAttr tells all mounts to forget the cached attributes of n.
+func (v *Volume) notifyAttr(n node) {
+	v.mounts.notify(func(srv *fs.Server) error {
+		return srv.InvalidateNodeAttr(n)
+	})
+}
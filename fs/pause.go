@@ -0,0 +1,222 @@
+package fs
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Default longest time a pause holds back requests, see SetMaxPause.
+const defaultMaxPause = 10 * time.Second
+
+// Requests being served are swept for ones that are done when their
+// number doubles, but not below this.
+const minPauseSweep = 64
+
+var (
+	ErrPaused = errors.New("volume is already paused")
+	// ErrPauseExpired means requests that were being served did not
+	// finish before the pause ran over its maximum duration.
+	ErrPauseExpired = errors.New("volume pause expired waiting for requests in progress")
+)
+
+// pauseGate holds back incoming FUSE requests while maintenance runs
+// on the volume. Requests wait in line instead of failing, and a
+// pause that goes on too long ends by itself, so applications only
+// ever see the volume being slow.
+type pauseGate struct {
+	mu  sync.Mutex
+	max time.Duration
+	// closed when the current pause ends; nil when not paused
+	done  chan struct{}
+	timer *time.Timer
+	// requests waiting for the pause to end
+	waiting uint64
+	queued  uint64
+	expired uint64
+	// contexts of the requests let through, some of which may be
+	// done by now
+	serving []context.Context
+	sweepAt int
+}
+
+// sweep forgets the requests in serving that are done.
+//
+// caller must hold p.mu
+func (p *pauseGate) sweep() {
+	live := p.serving[:0]
+	for _, ctx := range p.serving {
+		if ctx.Err() == nil {
+			live = append(live, ctx)
+		}
+	}
+	// let the contexts that were dropped be freed
+	for i := len(live); i < len(p.serving); i++ {
+		p.serving[i] = nil
+	}
+	p.serving = live
+	p.sweepAt = 2 * len(live)
+	if p.sweepAt < minPauseSweep {
+		p.sweepAt = minPauseSweep
+	}
+}
+
+// end lets the requests waiting on the pause done through. It does
+// nothing if that pause has ended already.
+func (p *pauseGate) end(done chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done != done {
+		return
+	}
+	p.timer.Stop()
+	p.timer = nil
+	close(p.done)
+	p.done = nil
+}
+
+// SetMaxPause sets how long a pause may hold back requests before
+// they are let through anyway. It applies to pauses started after
+// the call.
+func (v *Volume) SetMaxPause(max time.Duration) {
+	v.pause.mu.Lock()
+	defer v.pause.mu.Unlock()
+	v.pause.max = max
+}
+
+// Pause holds back new FUSE requests to the volume until resume is
+// called, for maintenance that must not race with them. It returns
+// once the requests already being served are done. If resume has not
+// been called within the time set with SetMaxPause, requests are let
+// through regardless, and resume does nothing.
+//
+// If requests being served do not finish before the pause runs over
+// its maximum duration, returns ErrPauseExpired. If ctx is canceled
+// first, the pause ends and ctx.Err() is returned.
+//
+// A volume can only be paused by one caller at a time; ErrPaused is
+// returned otherwise.
+func (v *Volume) Pause(ctx context.Context) (resume func(), err error) {
+	v.pause.mu.Lock()
+	if v.pause.done != nil {
+		v.pause.mu.Unlock()
+		return nil, ErrPaused
+	}
+	done := make(chan struct{})
+	v.pause.done = done
+	max := v.pause.max
+	v.pause.timer = time.AfterFunc(max, func() {
+		v.pause.mu.Lock()
+		expired := v.pause.done == done
+		if expired {
+			v.pause.expired++
+		}
+		v.pause.mu.Unlock()
+		if expired {
+			log.Printf("volume %v paused for over %v, letting requests through", &v.volID, max)
+			v.pause.end(done)
+		}
+	})
+	var drained chan struct{}
+	v.pause.sweep()
+	if len(v.pause.serving) > 0 {
+		drained = make(chan struct{})
+		serving := append([]context.Context(nil), v.pause.serving...)
+		go func() {
+			for _, ctx := range serving {
+				select {
+				case <-ctx.Done():
+				case <-done:
+					return
+				}
+			}
+			close(drained)
+		}()
+	}
+	v.pause.mu.Unlock()
+
+	resume = func() { v.pause.end(done) }
+	if drained == nil {
+		return resume, nil
+	}
+	select {
+	case <-drained:
+		return resume, nil
+	case <-done:
+		return nil, ErrPauseExpired
+	case <-ctx.Done():
+		resume()
+		return nil, ctx.Err()
+	}
+}
+
+// WaitPause blocks while the volume is paused, and then counts the
+// request as being served until ctx is done. It is meant to be called
+// as each FUSE request starts being served, with the context of the
+// request, which the FUSE server cancels once it has responded.
+// Canceling ctx also stops the wait early.
+//
+// When the volume is not paused, it returns right away; nothing
+// watches ctx until a pause needs to wait for the request.
+func (v *Volume) WaitPause(ctx context.Context) {
+	v.pause.mu.Lock()
+	queued := false
+	// another pause may have started by the time we get to run
+	for v.pause.done != nil {
+		done := v.pause.done
+		v.pause.waiting++
+		if !queued {
+			v.pause.queued++
+			queued = true
+		}
+		v.pause.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		v.pause.mu.Lock()
+		v.pause.waiting--
+		if ctx.Err() != nil {
+			v.pause.mu.Unlock()
+			return
+		}
+	}
+	// counted while still holding the lock, so a pause starting
+	// now waits for this request
+	v.pause.serving = append(v.pause.serving, ctx)
+	if len(v.pause.serving) > v.pause.sweepAt {
+		v.pause.sweep()
+	}
+	v.pause.mu.Unlock()
+}
+
+// PauseStats is a snapshot of the pause state of a volume.
+type PauseStats struct {
+	Paused bool
+	// Requests currently held back.
+	Waiting uint64
+	// Requests currently being served.
+	Serving uint64
+	// Requests that were ever held back.
+	Queued uint64
+	// Pauses that ran over the maximum duration.
+	Expired uint64
+}
+
+// PauseStats returns the current pause statistics.
+func (v *Volume) PauseStats() PauseStats {
+	v.pause.mu.Lock()
+	defer v.pause.mu.Unlock()
+	v.pause.sweep()
+	s := PauseStats{
+		Paused:  v.pause.done != nil,
+		Waiting: v.pause.waiting,
+		Serving: uint64(len(v.pause.serving)),
+		Queued:  v.pause.queued,
+		Expired: v.pause.expired,
+	}
+	return s
+}
@@ -177,6 +177,9 @@ func (e *pendingEntry) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
 var _ fs.NodeRemover = (*pendingEntry)(nil)
 
 func (e *pendingEntry) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
+	if err := e.list.dir.fs.checkAccess(&req.Header, true); err != nil {
+		return err
+	}
 	if req.Dir {
 		return fuse.Errno(syscall.ENOTDIR)
 	}
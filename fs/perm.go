@@ -0,0 +1,332 @@
+package fs
+
+import (
+	"log"
+	"os"
+	"syscall"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/wire"
+	"bazil.org/bazil/util/env"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+)
+
+// How many entries RewritePerms goes through between progress
+// reports.
+const permProgressInterval = 1000
+
+// setPermAttr fills in the permission bits and ownership of a, from
+// p or the defaults if p is nil. Any file type bits already in
+// a.Mode are kept.
+func setPermAttr(a *fuse.Attr, p *wire.Perm, def os.FileMode) {
+	if p == nil {
+		a.Mode |= def
+		a.Uid = env.MyUID
+		a.Gid = env.MyGID
+		return
+	}
+	a.Mode |= os.FileMode(p.Mode & 0777)
+	if p.Mode&syscall.S_ISUID != 0 {
+		a.Mode |= os.ModeSetuid
+	}
+	if p.Mode&syscall.S_ISGID != 0 {
+		a.Mode |= os.ModeSetgid
+	}
+	if p.Mode&syscall.S_ISVTX != 0 {
+		a.Mode |= os.ModeSticky
+	}
+	a.Uid = p.Uid
+	a.Gid = p.Gid
+}
+
+// PermChange describes a bulk change of permissions, see
+// RewritePerms.
+type PermChange struct {
+	// Unix permission bits to clear, and then to set. Both include
+	// setuid, setgid and sticky.
+	ClearMode uint32
+	SetMode   uint32
+	// New owner and group, if not nil.
+	UID *uint32
+	GID *uint32
+	// Only change files, or only directories.
+	FilesOnly bool
+	DirsOnly  bool
+}
+
+// apply returns the permissions resulting from the change, and
+// whether they differ from old.
+func (c *PermChange) apply(old *wire.Perm, isDir bool) (*wire.Perm, bool) {
+	if isDir && c.FilesOnly || !isDir && c.DirsOnly {
+		return old, false
+	}
+	cur := wire.Perm{Mode: 0644, Uid: env.MyUID, Gid: env.MyGID}
+	if isDir {
+		cur.Mode = 0755
+	}
+	if old != nil {
+		cur = *old
+	}
+	p := cur
+	p.Mode = p.Mode&^c.ClearMode | c.SetMode
+	if c.UID != nil {
+		p.Uid = *c.UID
+	}
+	if c.GID != nil {
+		p.Gid = *c.GID
+	}
+	return &p, p != cur || old == nil
+}
+
+// RewritePerms changes the permissions of everything under the
+// directory at dirPath, and the directory itself unless it is the
+// root. The change is done in a single transaction, directly on the
+// stored metadata, so it either happens completely or not at all.
+//
+// What the changed entries looked like before is kept as an undo
+// record by the name undoName, see UndoPerms.
+//
+// If progress is not nil, it is called every now and then with the
+// number of entries looked at and changed so far. Canceling ctx
+// aborts the change.
+//
+// Requests to the volume are held back while the permissions change,
+// so none sees the new ones stored but the old ones on nodes in use;
+// see Pause for the errors that can cause.
+//
+// Permissions are local to this node, and changing them does not
+// make the entries newer for syncing.
+func (v *Volume) RewritePerms(ctx context.Context, dirPath string, change *PermChange, undoName string, progress func(seen, changed uint64)) (changed uint64, err error) {
+	// directories with changed entries, for the directory cache
+	var touched map[uint64]struct{}
+	rewrite := func(tx *db.Tx) error {
+		changed = 0
+		touched = make(map[uint64]struct{})
+		bucket := v.bucket(tx)
+		dirs := bucket.Dirs()
+		undo, err := bucket.PermUndo().Create(undoName)
+		if err != nil {
+			return err
+		}
+		var seen uint64
+		visit := func(parent uint64, name string, de *wire.Dirent) error {
+			seen++
+			if seen%permProgressInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if progress != nil {
+					progress(seen, changed)
+				}
+			}
+			p, ok := change.apply(de.Perm, de.Dir != nil)
+			if !ok {
+				return nil
+			}
+			if err := undo.Add(parent, name, de.Perm); err != nil {
+				return err
+			}
+			de.Perm = p
+			if err := dirs.Put(parent, name, de); err != nil {
+				return err
+			}
+			touched[parent] = struct{}{}
+			changed++
+			return nil
+		}
+
+		parent, name, de, err := v.lookupDirent(dirs, dirPath)
+		if err != nil {
+			return err
+		}
+		inode := v.root.inode
+		if de != nil {
+			if de.Dir == nil {
+				return fuse.Errno(syscall.ENOTDIR)
+			}
+			inode = de.Inode
+			if err := visit(parent, name, de); err != nil {
+				return err
+			}
+		}
+
+		type entry struct {
+			name string
+			de   *wire.Dirent
+		}
+		todo := []uint64{inode}
+		for len(todo) > 0 {
+			inode := todo[len(todo)-1]
+			todo = todo[:len(todo)-1]
+
+			// bolt cursors do not survive changes to their bucket,
+			// so read the whole directory first
+			var entries []entry
+			c := dirs.List(inode)
+			for item := c.First(); item != nil; item = c.Next() {
+				var de wire.Dirent
+				if err := item.Unmarshal(&de); err != nil {
+					return err
+				}
+				if de.Tombstone != nil {
+					continue
+				}
+				entries = append(entries, entry{item.Name(), &de})
+			}
+			for _, e := range entries {
+				if e.de.Dir != nil {
+					todo = append(todo, e.de.Inode)
+				}
+				if err := visit(inode, e.name, e.de); err != nil {
+					return err
+				}
+			}
+		}
+		if progress != nil {
+			progress(seen, changed)
+		}
+		return nil
+	}
+	resume, err := v.Pause(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := v.db.Update(rewrite); err != nil {
+		resume()
+		return 0, err
+	}
+	for inode := range touched {
+		v.dirCache.forgetDir(inode)
+	}
+	nodes := v.refreshPerms()
+	// the kernel may wait for requests still held back before it
+	// lets go of what it cached
+	resume()
+	v.invalidateAttrs(nodes)
+	return changed, nil
+}
+
+// UndoPerms puts back the permissions recorded in the undo record by
+// that name, and removes the record. Entries that were removed since
+// are skipped; any other change to their permissions made since is
+// lost. Requests are held back like in RewritePerms.
+func (v *Volume) UndoPerms(ctx context.Context, undoName string) (restored uint64, err error) {
+	// directories with changed entries, for the directory cache
+	var touched map[uint64]struct{}
+	undo := func(tx *db.Tx) error {
+		restored = 0
+		touched = make(map[uint64]struct{})
+		bucket := v.bucket(tx)
+		dirs := bucket.Dirs()
+		record, err := bucket.PermUndo().Get(undoName)
+		if err != nil {
+			return err
+		}
+		restore := func(parent uint64, name string, old *wire.Perm) error {
+			de, err := dirs.Get(parent, name)
+			if err == fuse.ENOENT {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if de.Tombstone != nil {
+				return nil
+			}
+			de.Perm = old
+			if err := dirs.Put(parent, name, de); err != nil {
+				return err
+			}
+			touched[parent] = struct{}{}
+			restored++
+			return nil
+		}
+		if err := record.Each(restore); err != nil {
+			return err
+		}
+		return bucket.PermUndo().Delete(undoName)
+	}
+	resume, err := v.Pause(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := v.db.Update(undo); err != nil {
+		resume()
+		return 0, err
+	}
+	for inode := range touched {
+		v.dirCache.forgetDir(inode)
+	}
+	nodes := v.refreshPerms()
+	// the kernel may wait for requests still held back before it
+	// lets go of what it cached
+	resume()
+	v.invalidateAttrs(nodes)
+	return restored, nil
+}
+
+// refreshPerms loads the permissions of all active nodes from the
+// database. The mounts still need to forget what they had cached
+// about the returned nodes, see invalidateAttrs.
+func (v *Volume) refreshPerms() []node {
+	type perm struct {
+		n node
+		p *wire.Perm
+	}
+	var perms []perm
+	var walk func(tx *db.Tx, d *dir)
+	walk = func(tx *db.Tx, d *dir) {
+		var dirs []*dir
+		d.mu.Lock()
+		for name, a := range d.active {
+			de, err := v.bucket(tx).Dirs().Get(d.inode, name)
+			if err != nil {
+				// not saved yet
+				continue
+			}
+			perms = append(perms, perm{a.node, de.Perm})
+			if child, ok := a.node.(*dir); ok {
+				dirs = append(dirs, child)
+			}
+		}
+		d.mu.Unlock()
+		for _, child := range dirs {
+			walk(tx, child)
+		}
+	}
+	load := func(tx *db.Tx) error {
+		walk(tx, v.root)
+		return nil
+	}
+	if err := v.db.View(load); err != nil {
+		log.Printf("cannot refresh permissions: %v", err)
+		return nil
+	}
+
+	nodes := make([]node, 0, len(perms))
+	for _, p := range perms {
+		switch n := p.n.(type) {
+		case *file:
+			n.mu.Lock()
+			n.perm = p.p
+			n.mu.Unlock()
+		case *dir:
+			n.mu.Lock()
+			n.perm = p.p
+			n.mu.Unlock()
+		}
+		nodes = append(nodes, p.n)
+	}
+	return nodes
+}
+
+// invalidateAttrs makes the mounts forget the attributes they had
+// cached for nodes.
+func (v *Volume) invalidateAttrs(nodes []node) {
+	for _, n := range nodes {
+		if err := v.invalidateAttr(n); err != nil {
+			log.Printf("FUSE invalidate error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package fs
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bazil.org/bazil/cas"
+	"bazil.org/bazil/cas/blobs"
+	wirecas "bazil.org/bazil/cas/wire"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/snap"
+	wiresnap "bazil.org/bazil/fs/snap/wire"
+	"bazil.org/bazil/fs/wire"
+	"golang.org/x/net/context"
+)
+
+// How many directories to snapshot concurrently. Most of the time
+// goes to hashing and storing the directory listings, not to reading
+// the database.
+const snapshotWorkers = 16
+
+// How often to report progress of a snapshot in progress.
+const snapshotProgressInterval = 5 * time.Second
+
+// SnapshotProgress tells how far along a snapshot is.
+type SnapshotProgress struct {
+	Dirs  uint64
+	Files uint64
+}
+
+// Snapshot records a snapshot of the volume. The Snapshot message
+// itself has not been persisted yet.
+//
+// Directories are walked in parallel. If progress is not nil, it is
+// called periodically while the snapshot is being made, and once
+// more at the end.
+func (v *Volume) Snapshot(ctx context.Context, tx *db.Tx, progress func(SnapshotProgress)) (*wiresnap.Snapshot, error) {
+	snapshot, _, err := v.snapshot(ctx, tx, progress)
+	return snapshot, err
+}
+
+// snapshot is like Snapshot, but also returns the chunks the snapshot
+// refers to, for storage accounting.
+func (v *Volume) snapshot(ctx context.Context, tx *db.Tx, progress func(SnapshotProgress)) (*wiresnap.Snapshot, []db.SnapshotChunk, error) {
+	w := &snapshotWalker{
+		fs:     v,
+		bucket: v.bucket(tx),
+		sem:    make(chan struct{}, snapshotWorkers-1),
+	}
+
+	if progress != nil {
+		done := make(chan struct{})
+		defer func() {
+			close(done)
+			progress(w.progress())
+		}()
+		go func() {
+			t := time.NewTicker(snapshotProgressInterval)
+			defer t.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-t.C:
+					progress(w.progress())
+				}
+			}
+		}()
+	}
+
+	sde, err := w.dir(ctx, v.root.inode)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshot := &wiresnap.Snapshot{
+		Contents: sde,
+	}
+	return snapshot, w.chunks, nil
+}
+
+// snapshotWalker records a snapshot of a directory tree, reading it
+// from one database transaction.
+type snapshotWalker struct {
+	fs *Volume
+	// txMu serializes access to the transaction, which is not meant
+	// to be shared between goroutines.
+	txMu   sync.Mutex
+	bucket *db.Volume
+	// a token is needed to walk a directory in a new goroutine; when
+	// none are available, the caller walks it itself
+	sem   chan struct{}
+	dirs  uint64
+	files uint64
+
+	chunksMu sync.Mutex
+	chunks   []db.SnapshotChunk
+}
+
+func (w *snapshotWalker) progress() SnapshotProgress {
+	return SnapshotProgress{
+		Dirs:  atomic.LoadUint64(&w.dirs),
+		Files: atomic.LoadUint64(&w.files),
+	}
+}
+
+// addChunks remembers the chunks of the blob as referred to by the
+// snapshot.
+func (w *snapshotWalker) addChunks(ctx context.Context, manifest *blobs.Manifest) error {
+	blob, err := blobs.Open(w.fs.chunkStore, manifest)
+	if err != nil {
+		return err
+	}
+	var found []db.SnapshotChunk
+	add := func(key cas.Key, level uint8, size uint64) error {
+		found = append(found, db.SnapshotChunk{
+			Key:   key,
+			Type:  manifest.Type,
+			Level: level,
+			Size:  size,
+		})
+		return nil
+	}
+	if err := blob.Chunks(ctx, add); err != nil {
+		return err
+	}
+	w.chunksMu.Lock()
+	w.chunks = append(w.chunks, found...)
+	w.chunksMu.Unlock()
+	return nil
+}
+
+type snapshotEntry struct {
+	name string
+	de   wire.Dirent
+}
+
+// list reads all the live entries of the directory in one go, so the
+// transaction is not held while the slow work is done.
+func (w *snapshotWalker) list(inode uint64) ([]snapshotEntry, error) {
+	w.txMu.Lock()
+	defer w.txMu.Unlock()
+
+	var entries []snapshotEntry
+	c := w.bucket.Dirs().List(inode)
+	for item := c.First(); item != nil; item = c.Next() {
+		e := snapshotEntry{name: item.Name()}
+		if err := item.Unmarshal(&e.de); err != nil {
+			return nil, err
+		}
+		if e.de.Tombstone != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// dir records a snapshot of the directory and its subdirectories.
+func (w *snapshotWalker) dir(ctx context.Context, inode uint64) (*wiresnap.Dirent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	entries, err := w.list(inode)
+	if err != nil {
+		return nil, err
+	}
+
+	sdes := make([]*wiresnap.Dirent, len(entries))
+	errs := make([]error, len(entries))
+	var wg sync.WaitGroup
+	for i := range entries {
+		e := &entries[i]
+		switch {
+		case e.de.File != nil:
+			sdes[i] = &wiresnap.Dirent{
+				File: &wiresnap.File{
+					Manifest: e.de.File.Manifest,
+				},
+			}
+			manifest, err := e.de.File.Manifest.ToBlob("file")
+			if err != nil {
+				errs[i] = err
+				break
+			}
+			if err := w.addChunks(ctx, manifest); err != nil {
+				errs[i] = err
+				break
+			}
+			atomic.AddUint64(&w.files, 1)
+		case e.de.Dir != nil:
+			select {
+			case w.sem <- struct{}{}:
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					defer func() { <-w.sem }()
+					sdes[i], errs[i] = w.dir(ctx, entries[i].de.Inode)
+				}(i)
+			default:
+				sdes[i], errs[i] = w.dir(ctx, e.de.Inode)
+			}
+		default:
+			errs[i] = errors.New("TODO")
+		}
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	blob, err := blobs.Open(w.fs.chunkStore, w.fs.emptyManifest("dir"))
+	if err != nil {
+		return nil, err
+	}
+	sw := snap.NewWriter(blob.IO(ctx))
+	for i, sde := range sdes {
+		sde.Name = entries[i].name
+		if err := sw.Add(sde); err != nil {
+			return nil, err
+		}
+	}
+	manifest, err := blob.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.addChunks(ctx, manifest); err != nil {
+		return nil, err
+	}
+	atomic.AddUint64(&w.dirs, 1)
+	msg := &wiresnap.Dirent{
+		Dir: &wiresnap.Dir{
+			Manifest: wirecas.FromBlob(manifest),
+			Align:    sw.Align(),
+		},
+	}
+	return msg, nil
+}
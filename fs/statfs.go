@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/inodes"
+	"bazil.org/bazil/kv"
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+	"golang.org/x/net/context"
+)
+
+// Block size reported to statfs. Storage is not allocated in blocks,
+// this only sets the unit of the space counts.
+const statfsBlockSize = 4096
+
+var _ fs.FSStatfser = (*Volume)(nil)
+
+// Statfs reports the space of the storage the volume keeps its data
+// in, the number of files that can still be created, and the name
+// length limit.
+//
+// If the storage cannot tell its space, for example when it is on a
+// peer, space is reported as zero.
+func (v *Volume) Statfs(ctx context.Context, req *fuse.StatfsRequest, resp *fuse.StatfsResponse) error {
+	resp.Bsize = statfsBlockSize
+	resp.Frsize = statfsBlockSize
+	resp.Namelen = v.limits.get().MaxNameBytes
+
+	if r, ok := v.chunkStore.(kv.SpaceReporter); ok {
+		space, err := r.Space(ctx)
+		switch err {
+		case nil:
+			resp.Blocks = space.Total / statfsBlockSize
+			resp.Bfree = space.Free / statfsBlockSize
+			resp.Bavail = resp.Bfree
+		case kv.ErrSpaceUnknown:
+		default:
+			log.Printf("statfs: cannot get storage space: %v", err)
+		}
+	}
+
+	count := func(tx *db.Tx) error {
+		used, free := inodes.Count(v.bucket(tx).InodeBucket())
+		resp.Files = used + free
+		resp.Ffree = free
+		return nil
+	}
+	if err := v.db.View(count); err != nil {
+		return err
+	}
+	return nil
+}
@@ -14,6 +14,8 @@ import (
 
 	"bazil.org/fuse/fs/fstestutil"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"bazil.org/bazil/cas"
 	wirecas "bazil.org/bazil/cas/wire"
@@ -217,6 +219,69 @@ func TestSyncSimple(t *testing.T) {
 	}
 }
 
+func TestSyncSeveralPeers(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+
+	const (
+		volumeName1 = "testvol1"
+		volumeName2 = "testvol2"
+	)
+	createAndConnectVolume(t, app1, volumeName1, app2, volumeName2)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+	setLocation(t, app2, app1.Keys.Sign.Pub, web1.Addr())
+
+	ctrl := controltest.ListenAndServe(t, &wg, app2)
+	defer ctrl.Close()
+	rpcConn, err := grpcunix.Dial(filepath.Join(app2.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+	ctx := context.Background()
+
+	// not known to app2
+	unknown1 := peer.PublicKey{1}
+	unknown2 := peer.PublicKey{2}
+
+	req := &wire.VolumeSyncRequest{
+		VolumeName: volumeName2,
+		Pubs:       [][]byte{pub1[:], unknown1[:], pub1[:]},
+	}
+	resp, err := rpcClient.VolumeSync(ctx, req)
+	if err != nil {
+		t.Fatalf("error while syncing: %v", err)
+	}
+	if g, e := len(resp.Results), 2; g != e {
+		t.Fatalf("wrong number of results: %d != %d: %v", g, e, resp.Results)
+	}
+	if resp.Results[0].Error != "" {
+		t.Errorf("sync with peer failed: %v", resp.Results[0].Error)
+	}
+	if resp.Results[1].Error == "" {
+		t.Errorf("sync with unknown peer succeeded")
+	}
+
+	req = &wire.VolumeSyncRequest{
+		VolumeName: volumeName2,
+		Pubs:       [][]byte{unknown1[:], unknown2[:]},
+	}
+	if _, err := rpcClient.VolumeSync(ctx, req); grpc.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable when every peer fails: %v", err)
+	}
+}
+
 func TestSyncOpen(t *testing.T) {
 	tmp := tempdir.New(t)
 	defer tmp.Cleanup()
@@ -958,6 +1023,182 @@ func TestSyncRenameWithResolvedConflict(t *testing.T) {
 	}
 }
 
+func TestSyncAppendOnlyMerge(t *testing.T) {
+	testSyncAppendOnlyMerge(t, "one\n", "two\n", false)
+}
+
+// Appends that begin the same way are still kept in full.
+func TestSyncAppendOnlyMergeSharedPrefix(t *testing.T) {
+	testSyncAppendOnlyMerge(t, "2016-01-02 one\n", "2016-01-02 two\n", false)
+}
+
+// Identical appends on both sides are both kept; only what both had
+// before is shared.
+func TestSyncAppendOnlyMergeSame(t *testing.T) {
+	testSyncAppendOnlyMerge(t, "same\n", "same\n", false)
+}
+
+// Open files are merged once they are closed.
+func TestSyncAppendOnlyMergeOpen(t *testing.T) {
+	testSyncAppendOnlyMerge(t, "one\n", "two\n", true)
+}
+
+func testSyncAppendOnlyMerge(t *testing.T, append1, append2 string, open bool) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+	pub2 := (*peer.PublicKey)(app2.Keys.Sign.Pub)
+
+	const (
+		volumeName1 = "testvol1"
+		volumeName2 = "testvol2"
+	)
+	createAndConnectVolume(t, app1, volumeName1, app2, volumeName2)
+	connectVolume(t, app2, volumeName2, app1, volumeName1)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+	setLocation(t, app2, app1.Keys.Sign.Pub, web1.Addr())
+
+	web2 := httptest.ServeHTTP(t, &wg, app2)
+	defer web2.Close()
+	setLocation(t, app1, app2.Keys.Sign.Pub, web2.Addr())
+
+	mnt1 := bazfstestutil.Mounted(t, app1, volumeName1)
+	defer mnt1.Close()
+	mnt2 := bazfstestutil.Mounted(t, app2, volumeName2)
+	defer mnt2.Close()
+
+	const (
+		filename = "log"
+		input    = "start\n"
+	)
+
+	if err := ioutil.WriteFile(path.Join(mnt1.Dir, filename), []byte(input), 0644); err != nil {
+		t.Fatalf("cannot create file: %v", err)
+	}
+
+	ctrl1 := controltest.ListenAndServe(t, &wg, app1)
+	defer ctrl1.Close()
+	rpcConn1, err := grpcunix.Dial(filepath.Join(app1.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn1.Close()
+	rpcClient1 := wire.NewControlClient(rpcConn1)
+
+	ctrl2 := controltest.ListenAndServe(t, &wg, app2)
+	defer ctrl2.Close()
+	rpcConn2, err := grpcunix.Dial(filepath.Join(app2.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn2.Close()
+	rpcClient2 := wire.NewControlClient(rpcConn2)
+
+	ctx := context.Background()
+	syncFrom := func(client wire.ControlClient, volumeName string, pub *peer.PublicKey) {
+		req := &wire.VolumeSyncRequest{
+			VolumeName: volumeName,
+			Pub:        pub[:],
+		}
+		if _, err := client.VolumeSync(ctx, req); err != nil {
+			t.Fatalf("error while syncing: %v", err)
+		}
+	}
+	appendTo := func(p string, data string) {
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			t.Fatalf("cannot open for append: %v", err)
+		}
+		defer f.Close()
+		if _, err := f.Write([]byte(data)); err != nil {
+			t.Fatalf("cannot append: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("close failed: %v", err)
+		}
+	}
+
+	syncFrom(rpcClient2, volumeName2, pub1)
+
+	// append on both sides
+	appendTo(path.Join(mnt1.Dir, filename), append1)
+	appendTo(path.Join(mnt2.Dir, filename), append2)
+
+	ref, err := app1.GetVolumeByName(volumeName1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+	if err := ref.FS().SetAppendOnly(ctx, filename, true); err != nil {
+		t.Fatalf("cannot mark append-only: %v", err)
+	}
+
+	if open {
+		f, err := os.Open(path.Join(mnt1.Dir, filename))
+		if err != nil {
+			t.Fatalf("cannot open file: %v", err)
+		}
+		syncFrom(rpcClient1, volumeName1, pub2)
+		pending, err := ioutil.ReadDir(path.Join(mnt1.Dir, ".bazil", "pending"))
+		if err != nil {
+			t.Fatalf("cannot list pending: %v", err)
+		}
+		if len(pending) != 1 {
+			t.Errorf("open file should have a pending sync: %v", pending)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("close failed: %v", err)
+		}
+		// the kernel sends the release after close returns; wait
+		// for it without opening the file again
+		for tries := 0; len(pending) > 0 && tries < 1000; tries++ {
+			time.Sleep(10 * time.Millisecond)
+			pending, err = ioutil.ReadDir(path.Join(mnt1.Dir, ".bazil", "pending"))
+			if err != nil {
+				t.Fatalf("cannot list pending: %v", err)
+			}
+		}
+	} else {
+		syncFrom(rpcClient1, volumeName1, pub2)
+	}
+
+	buf, err := ioutil.ReadFile(path.Join(mnt1.Dir, filename))
+	if err != nil {
+		t.Fatalf("cannot read file: %v", err)
+	}
+	merged := string(buf)
+	if merged != input+append1+append2 && merged != input+append2+append1 {
+		t.Fatalf("appends not merged: %q", merged)
+	}
+	pending, err := ioutil.ReadDir(path.Join(mnt1.Dir, ".bazil", "pending"))
+	if err != nil {
+		t.Fatalf("cannot list pending: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("merge left pending conflicts: %v", pending)
+	}
+
+	// the merged version wins on the peer without the mark
+	syncFrom(rpcClient2, volumeName2, pub1)
+	buf, err = ioutil.ReadFile(path.Join(mnt2.Dir, filename))
+	if err != nil {
+		t.Fatalf("cannot read file: %v", err)
+	}
+	if g, e := string(buf), merged; g != e {
+		t.Errorf("peer did not take the merge: %q != %q", g, e)
+	}
+}
+
 func TestSyncSendPending(t *testing.T) {
 	tmp := tempdir.New(t)
 	defer tmp.Cleanup()
@@ -1078,3 +1319,109 @@ func TestSyncSendPending(t *testing.T) {
 		}
 	}
 }
+
+func TestRecover(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+	pub2 := (*peer.PublicKey)(app2.Keys.Sign.Pub)
+
+	const volumeName = "testvol"
+	sharingKey := [32]byte{1, 2, 3, 4, 5}
+
+	setup1 := func(tx *db.Tx) error {
+		peer, err := tx.Peers().Make(pub2)
+		if err != nil {
+			return err
+		}
+		if err := peer.Storage().Allow("local"); err != nil {
+			return err
+		}
+		sharingKey, err := tx.SharingKeys().Add("friends", &sharingKey)
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create(volumeName, "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		return peer.Volumes().Allow(v)
+	}
+	if err := app1.DB.Update(setup1); err != nil {
+		t.Fatalf("app1 setup: %v", err)
+	}
+
+	// app2 starts with nothing but the peer and the sharing secret
+	setup2 := func(tx *db.Tx) error {
+		if _, err := tx.Peers().Make(pub1); err != nil {
+			return err
+		}
+		if _, err := tx.SharingKeys().Add("friends", &sharingKey); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := app2.DB.Update(setup2); err != nil {
+		t.Fatalf("app2 setup: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+	setLocation(t, app2, app1.Keys.Sign.Pub, web1.Addr())
+
+	const (
+		dirname  = "sub"
+		filename = "greeting"
+		input    = "hello, world"
+	)
+	func() {
+		mnt := bazfstestutil.Mounted(t, app1, volumeName)
+		defer mnt.Close()
+		if err := os.Mkdir(path.Join(mnt.Dir, dirname), 0755); err != nil {
+			t.Fatalf("cannot create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(path.Join(mnt.Dir, dirname, filename), []byte(input), 0644); err != nil {
+			t.Fatalf("cannot create file: %v", err)
+		}
+	}()
+
+	ctrl := controltest.ListenAndServe(t, &wg, app2)
+	defer ctrl.Close()
+	rpcConn, err := grpcunix.Dial(filepath.Join(app2.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+	ctx := context.Background()
+	req := &wire.VolumeRecoverRequest{
+		Pub:             pub1[:],
+		VolumeName:      volumeName,
+		LocalVolumeName: volumeName,
+		SharingKeyName:  "friends",
+	}
+	resp, err := rpcClient.VolumeRecover(ctx, req)
+	if err != nil {
+		t.Fatalf("error while recovering: %v", err)
+	}
+	if g, e := resp.Dirs, uint64(2); g != e {
+		t.Errorf("wrong number of directories recovered: %d != %d", g, e)
+	}
+
+	mnt := bazfstestutil.Mounted(t, app2, volumeName)
+	defer mnt.Close()
+	buf, err := ioutil.ReadFile(path.Join(mnt.Dir, dirname, filename))
+	if err != nil {
+		t.Fatalf("cannot read file: %v", err)
+	}
+	if g, e := string(buf), input; g != e {
+		t.Fatalf("wrong content: %q != %q", g, e)
+	}
+}
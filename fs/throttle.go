@@ -0,0 +1,128 @@
+package fs
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+)
+
+// Defaults for write admission control, see SetWriteThrottle.
+const (
+	defaultMaxPendingWrites   = 64
+	defaultWriteLatencyTarget = 50 * time.Millisecond
+)
+
+// Longest time a single write is held back for being slow.
+const maxWriteDelay = time.Second
+
+// writeThrottle slows down incoming writes when storing file data or
+// committing metadata falls behind. At most a fixed number of writes
+// are processed at once, and once the recent latency of storage goes
+// over the target, each write is held back by the excess before
+// being acknowledged. Writers see their writes get gradually slower,
+// instead of memory growing until the whole mount stalls.
+type writeThrottle struct {
+	mu sync.Mutex
+	// nil means no limit on pending writes
+	sem chan struct{}
+	// zero means writes are never delayed
+	target time.Duration
+	// moving average of store and commit latency
+	latency   time.Duration
+	throttled uint64
+}
+
+// acquire waits for a slot to process a write in. The returned
+// function must be called once the write is done.
+func (w *writeThrottle) acquire(ctx context.Context) (release func(), err error) {
+	w.mu.Lock()
+	sem := w.sem
+	w.mu.Unlock()
+	if sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case sem <- struct{}{}:
+	default:
+		w.mu.Lock()
+		w.throttled++
+		w.mu.Unlock()
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fuse.Errno(syscall.EINTR)
+		}
+	}
+	return func() { <-sem }, nil
+}
+
+// observe records how long storing data or committing metadata took.
+func (w *writeThrottle) observe(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.latency += (d - w.latency) / 8
+}
+
+// pause holds back the caller while storage is slower than the
+// target. An interrupted pause just ends early.
+func (w *writeThrottle) pause(ctx context.Context) {
+	w.mu.Lock()
+	delay := time.Duration(0)
+	if w.target != 0 && w.latency > w.target {
+		delay = w.latency - w.target
+		if delay > maxWriteDelay {
+			delay = maxWriteDelay
+		}
+		w.throttled++
+	}
+	w.mu.Unlock()
+	if delay == 0 {
+		return
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// SetWriteThrottle limits how many writes to the volume are processed
+// at once, and the storage latency over which writes are slowed
+// down. Zero disables the respective limit.
+//
+// Writes in progress at the time of the call are not affected.
+func (v *Volume) SetWriteThrottle(maxPending int, target time.Duration) {
+	v.throttle.mu.Lock()
+	defer v.throttle.mu.Unlock()
+	v.throttle.sem = nil
+	if maxPending > 0 {
+		v.throttle.sem = make(chan struct{}, maxPending)
+	}
+	v.throttle.target = target
+}
+
+// WriteStats is a snapshot of the write admission control of a
+// volume.
+type WriteStats struct {
+	Pending uint64
+	// Writes that had to wait for a slot, or were slowed down.
+	Throttled uint64
+	// Recent average time taken to store data or commit metadata.
+	Latency time.Duration
+}
+
+// WriteStats returns the current write admission statistics.
+func (v *Volume) WriteStats() WriteStats {
+	v.throttle.mu.Lock()
+	defer v.throttle.mu.Unlock()
+	s := WriteStats{
+		Pending:   uint64(len(v.throttle.sem)),
+		Throttled: v.throttle.throttled,
+		Latency:   v.throttle.latency,
+	}
+	return s
+}
@@ -14,6 +14,7 @@ It has these top-level messages:
 	File
 	Dir
 	Tombstone
+	Perm
 */
 package wire
 
@@ -28,6 +29,9 @@ type Dirent struct {
 	File      *File      `protobuf:"bytes,2,opt,name=file" json:"file,omitempty"`
 	Dir       *Dir       `protobuf:"bytes,3,opt,name=dir" json:"dir,omitempty"`
 	Tombstone *Tombstone `protobuf:"bytes,4,opt,name=tombstone" json:"tombstone,omitempty"`
+	// Missing means the defaults: 0644 for files, 0755 for
+	// directories, owned by the user running the server.
+	Perm *Perm `protobuf:"bytes,5,opt,name=perm" json:"perm,omitempty"`
 }
 
 func (m *Dirent) Reset()         { *m = Dirent{} }
@@ -55,6 +59,13 @@ func (m *Dirent) GetTombstone() *Tombstone {
 	return nil
 }
 
+func (m *Dirent) GetPerm() *Perm {
+	if m != nil {
+		return m.Perm
+	}
+	return nil
+}
+
 type File struct {
 	Manifest *bazil_cas.Manifest `protobuf:"bytes,1,opt,name=manifest" json:"manifest,omitempty"`
 }
@@ -85,3 +96,16 @@ type Tombstone struct {
 func (m *Tombstone) Reset()         { *m = Tombstone{} }
 func (m *Tombstone) String() string { return proto.CompactTextString(m) }
 func (*Tombstone) ProtoMessage()    {}
+
+// Perm is the permission bits and ownership of an entry. It is local
+// to this node, and not synced with peers.
+type Perm struct {
+	// Permission bits, including setuid, setgid and sticky.
+	Mode uint32 `protobuf:"varint,1,opt,name=mode" json:"mode,omitempty"`
+	Uid  uint32 `protobuf:"varint,2,opt,name=uid" json:"uid,omitempty"`
+	Gid  uint32 `protobuf:"varint,3,opt,name=gid" json:"gid,omitempty"`
+}
+
+func (m *Perm) Reset()         { *m = Perm{} }
+func (m *Perm) String() string { return proto.CompactTextString(m) }
+func (*Perm) ProtoMessage()    {}
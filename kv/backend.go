@@ -0,0 +1,65 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory opens a storage backend. config is what follows the name
+// and a colon in the backend string, for example "bucket/prefix" in
+// "b2:bucket/prefix"; its meaning is up to the backend.
+type Factory func(config string) (KV, error)
+
+var backends struct {
+	mu        sync.Mutex
+	factories map[string]Factory
+}
+
+// RegisterBackend makes a storage backend available by name, so
+// that backend strings of the form "name:config" open it with
+// factory. This lets programs compile in backends of their own
+// without changing the server.
+//
+// It is meant to be called from init functions. Registering the same
+// name twice, or a name that cannot appear before the colon, panics.
+// The server's built-in backends take precedence over registered
+// ones by the same name.
+func RegisterBackend(name string, factory Factory) {
+	if name == "" || strings.ContainsAny(name, ":/") {
+		panic(fmt.Sprintf("kv: invalid backend name %q", name))
+	}
+	if factory == nil {
+		panic(fmt.Sprintf("kv: nil factory for backend %q", name))
+	}
+	backends.mu.Lock()
+	defer backends.mu.Unlock()
+	if _, dup := backends.factories[name]; dup {
+		panic(fmt.Sprintf("kv: backend %q registered twice", name))
+	}
+	if backends.factories == nil {
+		backends.factories = make(map[string]Factory)
+	}
+	backends.factories[name] = factory
+}
+
+// Backend returns the factory registered by name.
+func Backend(name string) (factory Factory, ok bool) {
+	backends.mu.Lock()
+	defer backends.mu.Unlock()
+	factory, ok = backends.factories[name]
+	return factory, ok
+}
+
+// Backends returns the names of the registered backends, in order.
+func Backends() []string {
+	backends.mu.Lock()
+	defer backends.mu.Unlock()
+	names := make([]string, 0, len(backends.factories))
+	for name := range backends.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
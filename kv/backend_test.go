@@ -0,0 +1,54 @@
+package kv_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/kv"
+	"bazil.org/bazil/kv/kvmock"
+)
+
+func TestRegisterBackend(t *testing.T) {
+	var gotConfig string
+	factory := func(config string) (kv.KV, error) {
+		gotConfig = config
+		return &kvmock.InMemory{}, nil
+	}
+	kv.RegisterBackend("testbackend", factory)
+
+	f, ok := kv.Backend("testbackend")
+	if !ok {
+		t.Fatal("registered backend not found")
+	}
+	if _, err := f("some/config"); err != nil {
+		t.Fatalf("factory failed: %v", err)
+	}
+	if g, e := gotConfig, "some/config"; g != e {
+		t.Errorf("wrong config: %q != %q", g, e)
+	}
+	if _, ok := kv.Backend("nosuchbackend"); ok {
+		t.Error("unregistered backend found")
+	}
+
+	found := false
+	for _, name := range kv.Backends() {
+		if name == "testbackend" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("backend not listed: %q", kv.Backends())
+	}
+}
+
+func TestRegisterBackendTwice(t *testing.T) {
+	factory := func(config string) (kv.KV, error) {
+		return &kvmock.InMemory{}, nil
+	}
+	kv.RegisterBackend("testtwice", factory)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	kv.RegisterBackend("testtwice", factory)
+}
@@ -1,7 +1,10 @@
 package kv
 
 import (
+	"errors"
 	"fmt"
+
+	"bazil.org/bazil/util/errkind"
 )
 
 // NotFoundError is the type of error returned by a KV when it cannot
@@ -15,3 +18,11 @@ var _ error = NotFoundError{}
 func (n NotFoundError) Error() string {
 	return fmt.Sprintf("Not found: %x", n.Key)
 }
+
+func (n NotFoundError) ErrorKind() errkind.Kind {
+	return errkind.NotFound
+}
+
+// ErrSpaceUnknown is returned when a KV cannot tell how much it can
+// hold.
+var ErrSpaceUnknown = errors.New("storage space is not known")
@@ -1,6 +1,7 @@
 package kv
 
 import (
+	"bazil.org/bazil/util/errkind"
 	"golang.org/x/net/context"
 )
 
@@ -8,3 +9,58 @@ type KV interface {
 	Get(ctx context.Context, key []byte) ([]byte, error)
 	Put(ctx context.Context, key, value []byte) error
 }
+
+// Deleter is implemented by KVs that can remove values.
+//
+// Content-addressed data is normally never deleted, but moving data
+// between storage tiers needs to.
+type Deleter interface {
+	// Delete removes the value stored for key. Deleting a key that
+	// does not exist is not an error.
+	Delete(ctx context.Context, key []byte) error
+}
+
+// Haser is implemented by KVs that can tell whether a value is
+// stored without fetching it.
+type Haser interface {
+	Has(ctx context.Context, key []byte) (bool, error)
+}
+
+// Has reports whether k has a value stored for key. KVs that do not
+// implement Haser are asked for the value itself.
+func Has(ctx context.Context, k KV, key []byte) (bool, error) {
+	if h, ok := k.(Haser); ok {
+		return h.Has(ctx, key)
+	}
+	_, err := k.Get(ctx, key)
+	if err == nil {
+		return true, nil
+	}
+	if errkind.Of(err) == errkind.NotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// Space tells how many bytes a KV can hold.
+type Space struct {
+	Total uint64
+	// Room left for new values.
+	Free uint64
+}
+
+// SpaceReporter is implemented by KVs that know how much room they
+// have. Stores that do not know, for example because they are on
+// another machine, return ErrSpaceUnknown.
+type SpaceReporter interface {
+	Space(ctx context.Context) (Space, error)
+}
+
+// SpaceOf returns the space of k, or ErrSpaceUnknown if k does not
+// implement SpaceReporter.
+func SpaceOf(ctx context.Context, k KV) (Space, error) {
+	if r, ok := k.(SpaceReporter); ok {
+		return r.Space(ctx)
+	}
+	return Space{}, ErrSpaceUnknown
+}
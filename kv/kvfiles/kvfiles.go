@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"syscall"
 
 	"bazil.org/bazil/kv"
 	"golang.org/x/net/context"
@@ -59,6 +60,50 @@ func (k *KVFiles) Get(ctx context.Context, key []byte) ([]byte, error) {
 	return data, nil
 }
 
+var _ kv.Deleter = (*KVFiles)(nil)
+
+func (k *KVFiles) Delete(ctx context.Context, key []byte) error {
+	safe := hex.EncodeToString(key)
+	path := path.Join(k.path, safe+".data")
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+var _ kv.Haser = (*KVFiles)(nil)
+
+func (k *KVFiles) Has(ctx context.Context, key []byte) (bool, error) {
+	safe := hex.EncodeToString(key)
+	path := path.Join(k.path, safe+".data")
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+var _ kv.SpaceReporter = (*KVFiles)(nil)
+
+// Space reports the size of the file system the values are stored
+// in. Other users of the file system make the free space shrink.
+func (k *KVFiles) Space(ctx context.Context) (kv.Space, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(k.path, &st); err != nil {
+		return kv.Space{}, err
+	}
+	s := kv.Space{
+		Total: uint64(st.Blocks) * uint64(st.Bsize),
+		// space reserved for root is not available to us
+		Free: uint64(st.Bavail) * uint64(st.Bsize),
+	}
+	return s, nil
+}
+
 func Open(path string) (*KVFiles, error) {
 	return &KVFiles{
 		path: path,
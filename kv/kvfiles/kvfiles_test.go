@@ -94,3 +94,50 @@ func TestGetNotFoundError(t *testing.T) {
 		t.Errorf("NotFoundError Key is wrong: %x != %x", g, w)
 	}
 }
+
+func TestDelete(t *testing.T) {
+	temp := tempdir.New(t)
+	defer temp.Cleanup()
+
+	c, err := kvfiles.Open(temp.Path)
+	if err != nil {
+		t.Fatalf("kvfiles.Open fail: %v\n", err)
+	}
+
+	ctx := context.Background()
+	if err := c.Put(ctx, []byte("quux"), []byte("foobar")); err != nil {
+		t.Fatalf("c.Put fail: %v\n", err)
+	}
+	if err := c.Delete(ctx, []byte("quux")); err != nil {
+		t.Fatalf("c.Delete fail: %v\n", err)
+	}
+	if _, err := c.Get(ctx, []byte("quux")); err == nil {
+		t.Fatalf("c.Get should have failed after Delete")
+	}
+	// deleting again is fine
+	if err := c.Delete(ctx, []byte("quux")); err != nil {
+		t.Fatalf("c.Delete of missing key fail: %v\n", err)
+	}
+}
+
+func TestSpace(t *testing.T) {
+	temp := tempdir.New(t)
+	defer temp.Cleanup()
+
+	k, err := kvfiles.Open(temp.Path)
+	if err != nil {
+		t.Fatalf("kvfiles.Open fail: %v\n", err)
+	}
+
+	ctx := context.Background()
+	space, err := kv.SpaceOf(ctx, k)
+	if err != nil {
+		t.Fatalf("kv.SpaceOf fail: %v\n", err)
+	}
+	if space.Total == 0 {
+		t.Errorf("no total space reported")
+	}
+	if space.Free > space.Total {
+		t.Errorf("more free than total space: %d > %d", space.Free, space.Total)
+	}
+}
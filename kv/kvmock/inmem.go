@@ -26,3 +26,17 @@ func (m *InMemory) Put(ctx context.Context, key, value []byte) error {
 	m.Data[string(key)] = string(value)
 	return nil
 }
+
+var _ kv.Haser = (*InMemory)(nil)
+
+func (m *InMemory) Has(ctx context.Context, key []byte) (bool, error) {
+	_, found := m.Data[string(key)]
+	return found, nil
+}
+
+var _ kv.Deleter = (*InMemory)(nil)
+
+func (m *InMemory) Delete(ctx context.Context, key []byte) error {
+	delete(m.Data, string(key))
+	return nil
+}
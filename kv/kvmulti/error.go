@@ -0,0 +1,124 @@
+package kvmulti
+
+import (
+	"bytes"
+	"fmt"
+
+	"bazil.org/bazil/kv"
+	"bazil.org/bazil/util/errkind"
+)
+
+// Outcome is the result of an operation on one backend.
+type Outcome struct {
+	// Backend is the index of the backend, in the order given to
+	// New.
+	Backend int
+	// Err is nil if the operation succeeded on this backend.
+	Err error
+}
+
+// Retryable reports whether the operation might succeed on this
+// backend if tried again later.
+func (o Outcome) Retryable() bool {
+	return o.Err != nil && IsRetryable(o.Err)
+}
+
+// Error is returned when an operation failed on some or all of the
+// backends. It records what happened on each backend tried.
+type Error struct {
+	Op       string
+	Key      []byte
+	Outcomes []Outcome
+}
+
+var _ error = (*Error)(nil)
+
+func (e *Error) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "kvmulti %s %x:", e.Op, e.Key)
+	for _, o := range e.Outcomes {
+		if o.Err == nil {
+			fmt.Fprintf(&buf, " [%d] ok;", o.Backend)
+			continue
+		}
+		fmt.Fprintf(&buf, " [%d] %v;", o.Backend, o.Err)
+	}
+	if len(e.Outcomes) == 0 {
+		buf.WriteString(" no backends")
+	}
+	return buf.String()
+}
+
+// Succeeded returns the backends the operation succeeded on.
+func (e *Error) Succeeded() []int {
+	var ok []int
+	for _, o := range e.Outcomes {
+		if o.Err == nil {
+			ok = append(ok, o.Backend)
+		}
+	}
+	return ok
+}
+
+// Failed returns the outcomes of the backends the operation failed
+// on.
+func (e *Error) Failed() []Outcome {
+	var failed []Outcome
+	for _, o := range e.Outcomes {
+		if o.Err != nil {
+			failed = append(failed, o)
+		}
+	}
+	return failed
+}
+
+// Retryable reports whether every failure is one that might go away
+// if tried again later. Backends that do not have the key are left
+// out, as asking them again does not help; the backends that failed
+// otherwise might still have it. An Error with no other failures is
+// not retryable.
+func (e *Error) Retryable() bool {
+	retryable := false
+	for _, o := range e.Failed() {
+		if _, ok := o.Err.(kv.NotFoundError); ok {
+			continue
+		}
+		if !o.Retryable() {
+			return false
+		}
+		retryable = true
+	}
+	return retryable
+}
+
+// ErrorKind tells what kind of failure this was: NotFound if no
+// backend had the key, Unavailable if trying again later might help.
+func (e *Error) ErrorKind() errkind.Kind {
+	switch {
+	case len(e.Outcomes) > 0 && isNotFound(e.Outcomes):
+		return errkind.NotFound
+	case e.Retryable():
+		return errkind.Unavailable
+	}
+	return errkind.Other
+}
+
+// IsRetryable reports whether err looks like a temporary problem,
+// such as a timeout or a peer that cannot be reached right now.
+func IsRetryable(err error) bool {
+	switch errkind.Of(err) {
+	case errkind.Unavailable, errkind.QuotaExceeded:
+		return true
+	}
+	return false
+}
+
+// isNotFound reports whether all outcomes are kv.NotFoundError.
+func isNotFound(outcomes []Outcome) bool {
+	for _, o := range outcomes {
+		if _, ok := o.Err.(kv.NotFoundError); !ok {
+			return false
+		}
+	}
+	return true
+}
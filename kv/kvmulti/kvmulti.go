@@ -1,8 +1,6 @@
 package kvmulti
 
 import (
-	"errors"
-
 	"bazil.org/bazil/kv"
 	"golang.org/x/net/context"
 )
@@ -17,44 +15,106 @@ func New(k ...kv.KV) *Multi {
 
 var _ kv.KV = (*Multi)(nil)
 
+// Get returns the value from the first backend that has it.
+//
+// If no backend has the key, returns kv.NotFoundError. If any backend
+// failed in some other way, returns an *Error with the outcome of
+// every backend tried.
 func (m *Multi) Get(ctx context.Context, key []byte) ([]byte, error) {
 	// TODO this needs to be a lot smarter
-	var firstErr error
-	for _, k := range m.list {
+	outcomes := make([]Outcome, 0, len(m.list))
+	for i, k := range m.list {
 		v, err := k.Get(ctx, key)
 		if err == nil {
 			return v, nil
 		}
-		if _, isNotFoundError := err.(kv.NotFoundError); !isNotFoundError && firstErr == nil {
-			firstErr = err
+		outcomes = append(outcomes, Outcome{Backend: i, Err: err})
+	}
+	if isNotFound(outcomes) {
+		return nil, kv.NotFoundError{Key: key}
+	}
+	return nil, &Error{Op: "get", Key: key, Outcomes: outcomes}
+}
+
+var _ kv.Haser = (*Multi)(nil)
+
+// Has reports whether any of the backends has the key. Backends that
+// fail are skipped, unless none of them has it.
+func (m *Multi) Has(ctx context.Context, key []byte) (bool, error) {
+	var outcomes []Outcome
+	for i, k := range m.list {
+		found, err := kv.Has(ctx, k, key)
+		if err != nil {
+			outcomes = append(outcomes, Outcome{Backend: i, Err: err})
+			continue
+		}
+		if found {
+			return true, nil
 		}
 	}
-	if firstErr != nil {
-		return nil, firstErr
+	if len(outcomes) > 0 {
+		return false, &Error{Op: "has", Key: key, Outcomes: outcomes}
 	}
-	return nil, kv.NotFoundError{Key: key}
+	return false, nil
 }
 
-func (m *Multi) Put(ctx context.Context, key, value []byte) error {
+func (m *Multi) put(ctx context.Context, key, value []byte) (outcomes []Outcome, success bool) {
 	// TODO this needs to be a lot smarter
-	var firstErr error
-	var success bool
-	for _, k := range m.list {
+	outcomes = make([]Outcome, 0, len(m.list))
+	for i, k := range m.list {
 		err := k.Put(ctx, key, value)
+		outcomes = append(outcomes, Outcome{Backend: i, Err: err})
 		if err == nil {
 			success = true
-			continue
-		}
-		if firstErr == nil {
-			firstErr = err
 		}
 	}
+	return outcomes, success
+}
+
+// Put stores the value in every backend. It succeeds if at least one
+// of the backends stored it; otherwise, it returns an *Error.
+func (m *Multi) Put(ctx context.Context, key, value []byte) error {
+	outcomes, success := m.put(ctx, key, value)
 	if !success {
-		if firstErr != nil {
-			return firstErr
+		return &Error{Op: "put", Key: key, Outcomes: outcomes}
+	}
+	return nil
+}
+
+// PutAll is like Put, but returns an *Error if any of the backends
+// failed, even when others succeeded. The value stays stored in the
+// backends that succeeded.
+func (m *Multi) PutAll(ctx context.Context, key, value []byte) error {
+	outcomes, _ := m.put(ctx, key, value)
+	for _, o := range outcomes {
+		if o.Err != nil {
+			return &Error{Op: "put", Key: key, Outcomes: outcomes}
 		}
-		// uhh... maybe the list was empty?
-		return errors.New("weird error in kvmulti")
 	}
 	return nil
 }
+
+var _ kv.SpaceReporter = (*Multi)(nil)
+
+// Space reports the space of the smallest backend, as values are
+// stored in all of them. Backends that do not know their space, or
+// fail to tell, are skipped; if none of them know, returns
+// kv.ErrSpaceUnknown.
+func (m *Multi) Space(ctx context.Context) (kv.Space, error) {
+	var space kv.Space
+	known := false
+	for _, k := range m.list {
+		s, err := kv.SpaceOf(ctx, k)
+		if err != nil {
+			continue
+		}
+		if !known || s.Free < space.Free {
+			space = s
+		}
+		known = true
+	}
+	if !known {
+		return kv.Space{}, kv.ErrSpaceUnknown
+	}
+	return space, nil
+}
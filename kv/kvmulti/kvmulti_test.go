@@ -1,12 +1,16 @@
 package kvmulti_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
+	"bazil.org/bazil/kv"
 	"bazil.org/bazil/kv/kvmock"
 	"bazil.org/bazil/kv/kvmulti"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestGetFallback(t *testing.T) {
@@ -41,3 +45,94 @@ func TestPut(t *testing.T) {
 		t.Errorf("bad data in b: %v", a.Data)
 	}
 }
+
+type failing struct {
+	err error
+}
+
+func (f failing) Get(ctx context.Context, key []byte) ([]byte, error) {
+	return nil, f.err
+}
+
+func (f failing) Put(ctx context.Context, key, value []byte) error {
+	return f.err
+}
+
+func TestGetNotFound(t *testing.T) {
+	a := &kvmock.InMemory{}
+	b := &kvmock.InMemory{}
+	multi := kvmulti.New(a, b)
+	ctx := context.Background()
+	_, err := multi.Get(ctx, []byte("k1"))
+	if _, ok := err.(kv.NotFoundError); !ok {
+		t.Fatalf("expected NotFoundError, got %T: %v", err, err)
+	}
+}
+
+func TestGetPartialFailure(t *testing.T) {
+	a := &kvmock.InMemory{}
+	b := failing{grpc.Errorf(codes.Unavailable, "peer is away")}
+	multi := kvmulti.New(a, b)
+	ctx := context.Background()
+	_, err := multi.Get(ctx, []byte("k1"))
+	merr, ok := err.(*kvmulti.Error)
+	if !ok {
+		t.Fatalf("expected *kvmulti.Error, got %T: %v", err, err)
+	}
+	if g, e := len(merr.Outcomes), 2; g != e {
+		t.Fatalf("wrong number of outcomes: %d != %d", g, e)
+	}
+	if _, ok := merr.Outcomes[0].Err.(kv.NotFoundError); !ok {
+		t.Errorf("expected NotFoundError from first backend: %v", merr.Outcomes[0].Err)
+	}
+	if !merr.Retryable() {
+		t.Errorf("expected error to be retryable: %v", merr)
+	}
+}
+
+func TestPutPartialFailure(t *testing.T) {
+	a := &kvmock.InMemory{}
+	b := failing{errors.New("disk on fire")}
+	multi := kvmulti.New(a, b)
+	ctx := context.Background()
+	if err := multi.Put(ctx, []byte("k1"), []byte("v1")); err != nil {
+		t.Fatalf("put should succeed with one backend working: %v", err)
+	}
+
+	err := multi.PutAll(ctx, []byte("k2"), []byte("v2"))
+	merr, ok := err.(*kvmulti.Error)
+	if !ok {
+		t.Fatalf("expected *kvmulti.Error, got %T: %v", err, err)
+	}
+	if g, e := merr.Succeeded(), []int{0}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong successful backends: %v != %v", g, e)
+	}
+	failed := merr.Failed()
+	if g, e := len(failed), 1; g != e {
+		t.Fatalf("wrong number of failures: %d != %d", g, e)
+	}
+	if g, e := failed[0].Backend, 1; g != e {
+		t.Errorf("wrong failed backend: %d != %d", g, e)
+	}
+	if merr.Retryable() {
+		t.Errorf("expected error to not be retryable: %v", merr)
+	}
+	if !reflect.DeepEqual(a.Data, map[string]string{"k1": "v1", "k2": "v2"}) {
+		t.Errorf("bad data in a: %v", a.Data)
+	}
+}
+
+func TestPutAllFail(t *testing.T) {
+	a := failing{errors.New("disk on fire")}
+	b := failing{errors.New("disk on fire")}
+	multi := kvmulti.New(a, b)
+	ctx := context.Background()
+	err := multi.Put(ctx, []byte("k1"), []byte("v1"))
+	merr, ok := err.(*kvmulti.Error)
+	if !ok {
+		t.Fatalf("expected *kvmulti.Error, got %T: %v", err, err)
+	}
+	if g := merr.Succeeded(); len(g) != 0 {
+		t.Errorf("expected no successful backends: %v", g)
+	}
+}
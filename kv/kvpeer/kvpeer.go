@@ -1,6 +1,7 @@
 package kvpeer
 
 import (
+	"fmt"
 	"io"
 
 	"golang.org/x/net/context"
@@ -12,12 +13,39 @@ import (
 )
 
 type KVPeer struct {
-	peer wire.PeerClient
+	peer  wire.PeerClient
+	count func(sent, received uint64)
 }
 
 var _ kv.KV = (*KVPeer)(nil)
 
+// Values smaller than this are sent without first asking whether the
+// peer has them; the extra round trip would cost more than the
+// upload.
+const hasCheckMinSize = 64 * 1024
+
 func (k *KVPeer) Put(ctx context.Context, key, value []byte) error {
+	return k.PutWithCapability(ctx, key, value, nil)
+}
+
+// PutWithCapability stores the value in the storage of the owner
+// named in the capability, instead of the storage for this peer.
+func (k *KVPeer) PutWithCapability(ctx context.Context, key, value []byte, capability *wire.TransferCapability) error {
+	if capability == nil && len(value) >= hasCheckMinSize {
+		// Content is addressed by hash, so the same data shared
+		// through several volumes has the same key; don't send it
+		// again. The storage of other owners cannot be asked.
+		found, err := k.Has(ctx, key)
+		switch {
+		case grpc.Code(err) == codes.Unimplemented:
+			// older peer, just send it
+		case err != nil:
+			return err
+		case found:
+			return nil
+		}
+	}
+
 	stream, err := k.peer.ObjectPut(ctx)
 	if err != nil {
 		return err
@@ -38,6 +66,7 @@ func (k *KVPeer) Put(ctx context.Context, key, value []byte) error {
 		req := &wire.ObjectPutRequest{Data: chunk}
 		if first {
 			req.Key = key
+			req.Capability = capability
 			first = false
 		}
 		if err := stream.Send(req); err != nil {
@@ -48,9 +77,35 @@ func (k *KVPeer) Put(ctx context.Context, key, value []byte) error {
 	if _, err := stream.CloseAndRecv(); err != nil {
 		return err
 	}
+	k.count(uint64(len(value)), 0)
 	return nil
 }
 
+var _ kv.Haser = (*KVPeer)(nil)
+
+func (k *KVPeer) Has(ctx context.Context, key []byte) (bool, error) {
+	found, err := k.HasMany(ctx, [][]byte{key})
+	if err != nil {
+		return false, err
+	}
+	return found[0], nil
+}
+
+// HasMany asks the peer about several keys at once. The result has
+// one entry for each key, in the same order.
+func (k *KVPeer) HasMany(ctx context.Context, keys [][]byte) ([]bool, error) {
+	resp, err := k.peer.ObjectHas(ctx, &wire.ObjectHasRequest{
+		Keys: keys,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Has) != len(keys) {
+		return nil, fmt.Errorf("peer answered about %d objects, asked for %d", len(resp.Has), len(keys))
+	}
+	return resp.Has, nil
+}
+
 func (k *KVPeer) Get(ctx context.Context, key []byte) ([]byte, error) {
 	stream, err := k.peer.ObjectGet(ctx, &wire.ObjectGetRequest{
 		Key: key,
@@ -73,11 +128,19 @@ func (k *KVPeer) Get(ctx context.Context, key []byte) ([]byte, error) {
 		}
 		data = append(data, resp.Data...)
 	}
+	k.count(0, uint64(len(data)))
 	return data, nil
 }
 
-func Open(peer wire.PeerClient) (*KVPeer, error) {
+// Open returns a KV that stores values at the peer. count is called
+// with the number of bytes of values sent and received; it may be
+// nil.
+func Open(peer wire.PeerClient, count func(sent, received uint64)) (*KVPeer, error) {
+	if count == nil {
+		count = func(sent, received uint64) {}
+	}
 	return &KVPeer{
-		peer: peer,
+		peer:  peer,
+		count: count,
 	}, nil
 }
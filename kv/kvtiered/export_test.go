@@ -0,0 +1,11 @@
+package kvtiered
+
+import (
+	"time"
+)
+
+// SetNow replaces the source of current time. This method is
+// intended for unit tests only.
+func (t *Tiered) SetNow(now func() time.Time) {
+	t.now = now
+}
@@ -0,0 +1,227 @@
+// Package kvtiered implements a KV that keeps recently used values
+// in a fast store, and demotes values that have not been read in a
+// while to a slower, cheaper store.
+//
+// Values are recalled to the fast store transparently when accessed.
+package kvtiered
+
+import (
+	"sync"
+	"time"
+
+	"bazil.org/bazil/kv"
+	"golang.org/x/net/context"
+)
+
+// Day is the granularity of access time tracking, in days since the
+// Unix epoch. Access times are only approximate, to avoid a database
+// write for every read.
+type Day uint32
+
+// DayOf returns the Day t falls on.
+func DayOf(t time.Time) Day {
+	return Day(t.Unix() / (24 * 60 * 60))
+}
+
+// AccessLog persists the last access times of keys in the fast
+// store.
+type AccessLog interface {
+	// Touch records that the keys were accessed on the given days.
+	// Keys are given as strings to allow use as map keys.
+	//
+	// Touch is never called from inside Get or Put, which may be
+	// called from inside transactions of the database the AccessLog
+	// writes to.
+	Touch(accessed map[string]Day) error
+
+	// Cold calls fn for every key in the fast store that has not
+	// been accessed on or after day. The key passed to fn is only
+	// valid during the call. fn must not call other AccessLog
+	// methods.
+	Cold(day Day, fn func(key []byte) error) error
+
+	// Demoted records that key has been moved to the slow store.
+	// Touch moves it back to the fast store.
+	Demoted(key []byte) error
+}
+
+// The number of in-memory access records to gather before writing
+// them to the AccessLog in the background.
+const maxPending = 1000
+
+// Tiered is a KV that stores values in a fast store, and demotes
+// values not accessed recently to a slow store.
+type Tiered struct {
+	fast interface {
+		kv.KV
+		kv.Deleter
+	}
+	slow kv.KV
+	log  AccessLog
+
+	// for tests
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]Day
+	// a background flush has been started and not finished yet
+	flushing bool
+	// background flushes in progress
+	wg sync.WaitGroup
+}
+
+var _ kv.KV = (*Tiered)(nil)
+
+// New returns a KV that stores data in fast, demoting cold values to
+// slow. Access times are recorded in log.
+func New(fast interface {
+	kv.KV
+	kv.Deleter
+}, slow kv.KV, log AccessLog) *Tiered {
+	t := &Tiered{
+		fast:    fast,
+		slow:    slow,
+		log:     log,
+		now:     time.Now,
+		pending: make(map[string]Day),
+	}
+	return t
+}
+
+func (t *Tiered) touch(key []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[string(key)] = DayOf(t.now())
+	if len(t.pending) < maxPending || t.flushing {
+		return
+	}
+	t.flushing = true
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		// on failure, the records stay pending and are written by
+		// a later flush
+		_ = t.flush()
+		t.mu.Lock()
+		t.flushing = false
+		t.mu.Unlock()
+	}()
+}
+
+func (t *Tiered) flush() error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = make(map[string]Day)
+	t.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := t.log.Touch(pending); err != nil {
+		// keep the records for the next try
+		t.mu.Lock()
+		for k, day := range pending {
+			if day > t.pending[k] {
+				t.pending[k] = day
+			}
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Flush writes pending access records to the AccessLog, after
+// waiting for background writes to finish. It must not be called
+// from inside a transaction of the database the AccessLog writes to.
+func (t *Tiered) Flush() error {
+	t.wg.Wait()
+	return t.flush()
+}
+
+func (t *Tiered) Get(ctx context.Context, key []byte) ([]byte, error) {
+	v, err := t.fast.Get(ctx, key)
+	if _, isNotFound := err.(kv.NotFoundError); isNotFound {
+		// maybe it was demoted; recall it
+		v, err = t.slow.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.fast.Put(ctx, key, v); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.touch(key)
+	return v, nil
+}
+
+func (t *Tiered) Put(ctx context.Context, key, value []byte) error {
+	if err := t.fast.Put(ctx, key, value); err != nil {
+		return err
+	}
+	t.touch(key)
+	return nil
+}
+
+var _ kv.SpaceReporter = (*Tiered)(nil)
+
+// Space reports the space of the fast store, as that is where new
+// values go. Demoting frees up room in it.
+func (t *Tiered) Space(ctx context.Context) (kv.Space, error) {
+	return kv.SpaceOf(ctx, t.fast)
+}
+
+// Demote moves values that have not been accessed for the given
+// duration from the fast store to the slow store. Canceling ctx stops
+// it between values.
+//
+// Values are written to the slow store before being removed from the
+// fast one, so an interrupted Demote never loses data.
+func (t *Tiered) Demote(ctx context.Context, unused time.Duration) (demoted int, err error) {
+	if err := t.Flush(); err != nil {
+		return 0, err
+	}
+	cutoff := DayOf(t.now().Add(-unused))
+
+	// gather keys first, to avoid holding the AccessLog open across
+	// slow network operations
+	var cold [][]byte
+	gather := func(key []byte) error {
+		cold = append(cold, append([]byte(nil), key...))
+		return nil
+	}
+	if err := t.log.Cold(cutoff, gather); err != nil {
+		return 0, err
+	}
+
+	for _, key := range cold {
+		if err := ctx.Err(); err != nil {
+			return demoted, err
+		}
+		v, err := t.fast.Get(ctx, key)
+		if _, isNotFound := err.(kv.NotFoundError); isNotFound {
+			// lost already; nothing to move, but stop tracking it
+			if err := t.log.Demoted(key); err != nil {
+				return demoted, err
+			}
+			continue
+		}
+		if err != nil {
+			return demoted, err
+		}
+		if err := t.slow.Put(ctx, key, v); err != nil {
+			return demoted, err
+		}
+		if err := t.log.Demoted(key); err != nil {
+			return demoted, err
+		}
+		if err := t.fast.Delete(ctx, key); err != nil {
+			return demoted, err
+		}
+		demoted++
+	}
+	return demoted, nil
+}
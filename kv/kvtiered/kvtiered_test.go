@@ -0,0 +1,144 @@
+package kvtiered_test
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"bazil.org/bazil/kv/kvmock"
+	"bazil.org/bazil/kv/kvtiered"
+	"golang.org/x/net/context"
+)
+
+type record struct {
+	day     kvtiered.Day
+	demoted bool
+}
+
+type memLog struct {
+	m map[string]record
+}
+
+var _ kvtiered.AccessLog = (*memLog)(nil)
+
+func (l *memLog) Touch(accessed map[string]kvtiered.Day) error {
+	for k, day := range accessed {
+		l.m[k] = record{day: day}
+	}
+	return nil
+}
+
+func (l *memLog) Cold(day kvtiered.Day, fn func(key []byte) error) error {
+	var keys []string
+	for k, r := range l.m {
+		if !r.demoted && r.day < day {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn([]byte(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *memLog) Demoted(key []byte) error {
+	r := l.m[string(key)]
+	r.demoted = true
+	l.m[string(key)] = r
+	return nil
+}
+
+func TestDemoteAndRecall(t *testing.T) {
+	fast := &kvmock.InMemory{}
+	slow := &kvmock.InMemory{}
+	log := &memLog{m: make(map[string]record)}
+	tier := kvtiered.New(fast, slow, log)
+
+	now := time.Date(2015, 1, 1, 12, 0, 0, 0, time.UTC)
+	tier.SetNow(func() time.Time { return now })
+
+	ctx := context.Background()
+	if err := tier.Put(ctx, []byte("old"), []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * 24 * time.Hour)
+	if err := tier.Put(ctx, []byte("new"), []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := tier.Demote(ctx, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("demote: %v", err)
+	}
+	if g, e := n, 1; g != e {
+		t.Errorf("wrong number of demoted values: %d != %d", g, e)
+	}
+	if _, ok := fast.Data["old"]; ok {
+		t.Errorf("cold value still in fast store")
+	}
+	if g, e := slow.Data["old"], "v1"; g != e {
+		t.Errorf("bad value in slow store: %q != %q", g, e)
+	}
+	if _, ok := fast.Data["new"]; !ok {
+		t.Errorf("recent value was demoted")
+	}
+
+	v, err := tier.Get(ctx, []byte("old"))
+	if err != nil {
+		t.Fatalf("get after demote: %v", err)
+	}
+	if g, e := string(v), "v1"; g != e {
+		t.Errorf("bad value: %q != %q", g, e)
+	}
+	if g, e := fast.Data["old"], "v1"; g != e {
+		t.Errorf("value was not recalled to fast store: %q != %q", g, e)
+	}
+}
+
+// blockingLog is an AccessLog whose Touch waits until release is
+// closed.
+type blockingLog struct {
+	memLog
+	release chan struct{}
+	mu      sync.Mutex
+}
+
+func (l *blockingLog) Touch(accessed map[string]kvtiered.Day) error {
+	<-l.release
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.memLog.Touch(accessed)
+}
+
+func TestTouchInBackground(t *testing.T) {
+	fast := &kvmock.InMemory{}
+	slow := &kvmock.InMemory{}
+	log := &blockingLog{
+		memLog:  memLog{m: make(map[string]record)},
+		release: make(chan struct{}),
+	}
+	tier := kvtiered.New(fast, slow, log)
+
+	// enough to fill a batch; writing it must not hold up the puts,
+	// which can happen inside transactions of the same database
+	const count = 1001
+	ctx := context.Background()
+	for i := 0; i < count; i++ {
+		if err := tier.Put(ctx, []byte(fmt.Sprintf("k%d", i)), []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	close(log.release)
+	if err := tier.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if g, e := len(log.m), count; g != e {
+		t.Errorf("wrong number of access records: %d != %d", g, e)
+	}
+}
@@ -0,0 +1,87 @@
+// Package kvtrace implements a KV that reports every call made to
+// it, for finding out what a workload does to storage.
+package kvtrace
+
+import (
+	"time"
+
+	"bazil.org/bazil/kv"
+	"golang.org/x/net/context"
+)
+
+// Call describes a single completed call to a KV.
+type Call struct {
+	// Get, Put or Has.
+	Op  string
+	Key []byte
+	// Size of the value written or read, if any.
+	Size     int
+	Duration time.Duration
+	Err      error
+}
+
+// Traced is a KV that passes calls through to another KV, reporting
+// each one once it returns.
+type Traced struct {
+	kv     kv.KV
+	report func(ctx context.Context, call *Call)
+}
+
+var _ kv.KV = (*Traced)(nil)
+
+// New returns a KV that stores data in k, calling report for every
+// call with the context of that call. report is called
+// synchronously, and should be quick.
+func New(k kv.KV, report func(ctx context.Context, call *Call)) *Traced {
+	return &Traced{
+		kv:     k,
+		report: report,
+	}
+}
+
+func (t *Traced) Get(ctx context.Context, key []byte) ([]byte, error) {
+	start := time.Now()
+	value, err := t.kv.Get(ctx, key)
+	t.report(ctx, &Call{
+		Op:       "Get",
+		Key:      key,
+		Size:     len(value),
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return value, err
+}
+
+func (t *Traced) Put(ctx context.Context, key, value []byte) error {
+	start := time.Now()
+	err := t.kv.Put(ctx, key, value)
+	t.report(ctx, &Call{
+		Op:       "Put",
+		Key:      key,
+		Size:     len(value),
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return err
+}
+
+var _ kv.Haser = (*Traced)(nil)
+
+func (t *Traced) Has(ctx context.Context, key []byte) (bool, error) {
+	start := time.Now()
+	found, err := kv.Has(ctx, t.kv, key)
+	t.report(ctx, &Call{
+		Op:       "Has",
+		Key:      key,
+		Duration: time.Since(start),
+		Err:      err,
+	})
+	return found, err
+}
+
+var _ kv.SpaceReporter = (*Traced)(nil)
+
+// Space reports the space of the underlying store. It is not traced.
+func (t *Traced) Space(ctx context.Context) (kv.Space, error) {
+	return kv.SpaceOf(ctx, t.kv)
+}
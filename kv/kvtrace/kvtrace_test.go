@@ -0,0 +1,43 @@
+package kvtrace_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/kv/kvmock"
+	"bazil.org/bazil/kv/kvtrace"
+	"golang.org/x/net/context"
+)
+
+func TestReport(t *testing.T) {
+	var calls []*kvtrace.Call
+	report := func(ctx context.Context, call *kvtrace.Call) {
+		calls = append(calls, call)
+	}
+	traced := kvtrace.New(&kvmock.InMemory{}, report)
+	ctx := context.Background()
+	if err := traced.Put(ctx, []byte("k1"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := traced.Get(ctx, []byte("k2")); err == nil {
+		t.Fatal("expected an error for missing key")
+	}
+
+	if g, e := len(calls), 2; g != e {
+		t.Fatalf("wrong number of calls: %d != %d", g, e)
+	}
+	if g, e := calls[0].Op, "Put"; g != e {
+		t.Errorf("wrong op: %q != %q", g, e)
+	}
+	if g, e := calls[0].Size, 5; g != e {
+		t.Errorf("wrong size: %d != %d", g, e)
+	}
+	if calls[0].Err != nil {
+		t.Errorf("unexpected error: %v", calls[0].Err)
+	}
+	if g, e := string(calls[1].Key), "k2"; g != e {
+		t.Errorf("wrong key: %q != %q", g, e)
+	}
+	if calls[1].Err == nil {
+		t.Error("error was not reported")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"bazil.org/bazil/cas"
 	"bazil.org/bazil/kv"
 	"bazil.org/bazil/tokens"
+	"bazil.org/bazil/util/errkind"
 	"github.com/codahale/blake2"
 	"golang.org/x/crypto/nacl/secretbox"
 	"golang.org/x/net/context"
@@ -78,6 +79,14 @@ func (s *Convergent) Put(ctx context.Context, key []byte, value []byte) error {
 	return err
 }
 
+var _ kv.SpaceReporter = (*Convergent)(nil)
+
+// Space reports the space of the underlying store. Values take up a
+// little more than their size, due to encryption.
+func (s *Convergent) Space(ctx context.Context) (kv.Space, error) {
+	return kv.SpaceOf(ctx, s.untrusted)
+}
+
 func New(store kv.KV, secret *[32]byte) *Convergent {
 	return &Convergent{
 		untrusted: store,
@@ -94,3 +103,7 @@ func (c CorruptError) Error() string {
 }
 
 var _ error = CorruptError{}
+
+func (c CorruptError) ErrorKind() errkind.Kind {
+	return errkind.Integrity
+}
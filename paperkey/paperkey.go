@@ -0,0 +1,149 @@
+// Package paperkey encodes the secrets that make up a node identity
+// in a form meant to be printed, or written down by hand, and typed
+// back in later.
+//
+// The secrets are packed into a compact binary form with a checksum,
+// and encoded as zbase32, which avoids easily confused characters.
+// The text is split into short groups for readability; whitespace
+// and lines starting with "#" are ignored when decoding.
+package paperkey
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tv42/zbase32"
+)
+
+var (
+	ErrChecksum = errors.New("paper key checksum mismatch; check for typos")
+	ErrVersion  = errors.New("paper key is from a newer version")
+	ErrTooShort = errors.New("paper key is truncated")
+)
+
+const version = 1
+
+const (
+	groupSize     = 4
+	groupsPerLine = 8
+	checksumSize  = 4
+)
+
+// SharingKey is a named sharing group secret.
+type SharingKey struct {
+	Name   string
+	Secret [32]byte
+}
+
+// Backup is everything needed to re-establish a node identity.
+type Backup struct {
+	// Seed of the ed25519 signing key of the node.
+	Seed    [32]byte
+	Sharing []SharingKey
+}
+
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:checksumSize]
+}
+
+// Encode returns the backup as text, without a trailing newline.
+func Encode(b *Backup) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(version)
+	buf.Write(b.Seed[:])
+	if len(b.Sharing) > 255 {
+		return "", errors.New("too many sharing keys")
+	}
+	buf.WriteByte(byte(len(b.Sharing)))
+	for _, s := range b.Sharing {
+		if len(s.Name) == 0 || len(s.Name) > 255 {
+			return "", fmt.Errorf("bad sharing key name: %q", s.Name)
+		}
+		buf.WriteByte(byte(len(s.Name)))
+		buf.WriteString(s.Name)
+		buf.Write(s.Secret[:])
+	}
+	buf.Write(checksum(buf.Bytes()))
+
+	text := zbase32.EncodeToString(buf.Bytes())
+	var lines []string
+	var groups []string
+	for len(text) > 0 {
+		n := groupSize
+		if n > len(text) {
+			n = len(text)
+		}
+		groups = append(groups, text[:n])
+		text = text[n:]
+		if len(groups) == groupsPerLine {
+			lines = append(lines, strings.Join(groups, " "))
+			groups = groups[:0]
+		}
+	}
+	if len(groups) > 0 {
+		lines = append(lines, strings.Join(groups, " "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Decode parses text produced by Encode.
+func Decode(text string) (*Backup, error) {
+	var compact []byte
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, r := range line {
+			switch r {
+			case ' ', '\t', '\r':
+				continue
+			}
+			compact = append(compact, byte(r))
+		}
+	}
+	data, err := zbase32.DecodeString(strings.ToLower(string(compact)))
+	if err != nil {
+		return nil, fmt.Errorf("paper key is not valid: %v", err)
+	}
+
+	if len(data) < 1+32+1+checksumSize {
+		return nil, ErrTooShort
+	}
+	body, sum := data[:len(data)-checksumSize], data[len(data)-checksumSize:]
+	if !bytes.Equal(checksum(body), sum) {
+		return nil, ErrChecksum
+	}
+	if body[0] != version {
+		return nil, ErrVersion
+	}
+	body = body[1:]
+
+	b := &Backup{}
+	copy(b.Seed[:], body)
+	body = body[32:]
+	count := int(body[0])
+	body = body[1:]
+	for i := 0; i < count; i++ {
+		if len(body) < 1 {
+			return nil, ErrTooShort
+		}
+		n := int(body[0])
+		body = body[1:]
+		if len(body) < n+32 {
+			return nil, ErrTooShort
+		}
+		s := SharingKey{Name: string(body[:n])}
+		copy(s.Secret[:], body[n:])
+		b.Sharing = append(b.Sharing, s)
+		body = body[n+32:]
+	}
+	if len(body) != 0 {
+		return nil, errors.New("paper key has trailing garbage")
+	}
+	return b, nil
+}
@@ -0,0 +1,62 @@
+package paperkey_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"bazil.org/bazil/paperkey"
+)
+
+func TestRoundtrip(t *testing.T) {
+	b := &paperkey.Backup{
+		Seed: [32]byte{1, 2, 3, 4},
+		Sharing: []paperkey.SharingKey{
+			{Name: "default", Secret: [32]byte{42}},
+			{Name: "friends", Secret: [32]byte{0xC0, 0xFF, 0xEE}},
+		},
+	}
+	text, err := paperkey.Encode(b)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	got, err := paperkey.Decode("# comment\n" + text + "\n")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Errorf("roundtrip mismatch:\n%+v\n%+v", got, b)
+	}
+}
+
+func TestTypo(t *testing.T) {
+	b := &paperkey.Backup{
+		Seed: [32]byte{1, 2, 3, 4},
+	}
+	text, err := paperkey.Encode(b)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	// swap in a different valid character
+	c := "y"
+	if text[:1] == c {
+		c = "b"
+	}
+	typo := c + text[1:]
+	if _, err := paperkey.Decode(typo); err != paperkey.ErrChecksum {
+		t.Errorf("expected ErrChecksum, got %v", err)
+	}
+}
+
+func TestUpperCase(t *testing.T) {
+	b := &paperkey.Backup{
+		Seed: [32]byte{1, 2, 3, 4},
+	}
+	text, err := paperkey.Encode(b)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if _, err := paperkey.Decode(strings.ToUpper(text)); err != nil {
+		t.Errorf("decode: %v", err)
+	}
+}
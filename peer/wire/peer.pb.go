@@ -23,6 +23,28 @@ It has these top-level messages:
 	File
 	Dir
 	Tombstone
+	Message
+	MessageSendRequest
+	MessageSendResponse
+	PairJoinRequest
+	PairVolume
+	PairSharingKey
+	PairJoinResponse
+	TransferGrant
+	TransferCapability
+	ObjectTransferRequest
+	ObjectTransferResponse
+	VolumeRevocation
+	SignedVolumeRevocation
+	ObjectHasRequest
+	ObjectHasResponse
+	PublishedFeedRequest
+	PublishedFeed
+	PublishedSnapshot
+	SignedPublishedFeed
+	PublishedObjectGetRequest
+	VolumeSyncPullDoneRequest
+	VolumeSyncPullDoneResponse
 */
 package wire
 
@@ -58,6 +80,44 @@ func (x VolumeSyncPullItem_Error) String() string {
 	return proto.EnumName(VolumeSyncPullItem_Error_name, int32(x))
 }
 
+type Message_Kind int32
+
+const (
+	Message_UNKNOWN Message_Kind = 0
+	// Free-form text meant for a human.
+	Message_NOTE Message_Kind = 1
+	// An invitation to connect to a volume.
+	Message_INVITATION Message_Kind = 2
+	// The sender can now be reached at a new network address.
+	Message_ADDRESS Message_Kind = 3
+	// The sender noticed a conflict that needs attention.
+	Message_CONFLICT Message_Kind = 4
+	// The sender stopped sharing a volume with the receiver, or
+	// started sharing it again. Body is a SignedVolumeRevocation.
+	Message_VOLUME_REVOKED Message_Kind = 5
+)
+
+var Message_Kind_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "NOTE",
+	2: "INVITATION",
+	3: "ADDRESS",
+	4: "CONFLICT",
+	5: "VOLUME_REVOKED",
+}
+var Message_Kind_value = map[string]int32{
+	"UNKNOWN":        0,
+	"NOTE":           1,
+	"INVITATION":     2,
+	"ADDRESS":        3,
+	"CONFLICT":       4,
+	"VOLUME_REVOKED": 5,
+}
+
+func (x Message_Kind) String() string {
+	return proto.EnumName(Message_Kind_name, int32(x))
+}
+
 type PingRequest struct {
 }
 
@@ -76,12 +136,22 @@ type ObjectPutRequest struct {
 	// Only set in the first streamed message.
 	Key  []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Set in the first streamed message when the object is sent on
+	// behalf of its owner, by a peer that is not the owner.
+	Capability *TransferCapability `protobuf:"bytes,3,opt,name=capability" json:"capability,omitempty"`
 }
 
 func (m *ObjectPutRequest) Reset()         { *m = ObjectPutRequest{} }
 func (m *ObjectPutRequest) String() string { return proto.CompactTextString(m) }
 func (*ObjectPutRequest) ProtoMessage()    {}
 
+func (m *ObjectPutRequest) GetCapability() *TransferCapability {
+	if m != nil {
+		return m.Capability
+	}
+	return nil
+}
+
 type ObjectPutResponse struct {
 }
 
@@ -143,10 +213,26 @@ type VolumeSyncPullItem struct {
 	//
 	// This can only be present in the first streamed message.
 	Peers map[uint32][]byte `protobuf:"bytes,2,rep,name=peers" json:"peers,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Same as peers, with room for peer identifiers wider than 32 bits.
+	// Identifiers are still allocated within 32 bits, because vector
+	// clocks store them that way; this map only lets the wire format
+	// widen first. Senders include every peer in both maps; receivers
+	// prefer this map when it is present, and reject identifiers that
+	// do not fit in 32 bits.
+	//
+	// This can only be present in the first streamed message.
+	Peers64 map[uint64][]byte `protobuf:"bytes,5,rep,name=peers64" json:"peers64,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// Logical clock for the directory itself.
 	//
 	// This can only be present in the first streamed message.
 	DirClock []byte `protobuf:"bytes,4,opt,name=dirClock,proto3" json:"dirClock,omitempty"`
+	// Identifies a pull of the whole volume. Once everything has been
+	// received and saved, the receiver confirms it with
+	// VolumeSyncPullDone, so the sender knows the receiver has caught
+	// up. Zero for pulls of only part of the volume.
+	//
+	// This can only be present in the first streamed message.
+	PullID uint64 `protobuf:"varint,6,opt,name=pullID" json:"pullID,omitempty"`
 	// Directory entries. More entries may follow in later streamed
 	// messages. The entries are required to be in lexicographical
 	// (bytewise) order, across all messages.
@@ -164,6 +250,13 @@ func (m *VolumeSyncPullItem) GetPeers() map[uint32][]byte {
 	return nil
 }
 
+func (m *VolumeSyncPullItem) GetPeers64() map[uint64][]byte {
+	if m != nil {
+		return m.Peers64
+	}
+	return nil
+}
+
 func (m *VolumeSyncPullItem) GetChildren() []*Dirent {
 	if m != nil {
 		return m.Children
@@ -233,8 +326,276 @@ func (m *Tombstone) Reset()         { *m = Tombstone{} }
 func (m *Tombstone) String() string { return proto.CompactTextString(m) }
 func (*Tombstone) ProtoMessage()    {}
 
+type Message struct {
+	// Sequence number assigned by the sender, increasing for every
+	// message sent to the same peer. Used to ignore duplicate
+	// deliveries.
+	Seq  uint64       `protobuf:"varint,1,opt,name=seq" json:"seq,omitempty"`
+	Kind Message_Kind `protobuf:"varint,2,opt,name=kind,enum=bazil.peer.Message_Kind" json:"kind,omitempty"`
+	Body []byte       `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	// When the message was queued, in seconds since the Unix epoch,
+	// according to the sender.
+	Sent int64 `protobuf:"varint,4,opt,name=sent" json:"sent,omitempty"`
+}
+
+func (m *Message) Reset()         { *m = Message{} }
+func (m *Message) String() string { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()    {}
+
+type MessageSendRequest struct {
+	Messages []*Message `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+}
+
+func (m *MessageSendRequest) Reset()         { *m = MessageSendRequest{} }
+func (m *MessageSendRequest) String() string { return proto.CompactTextString(m) }
+func (*MessageSendRequest) ProtoMessage()    {}
+
+func (m *MessageSendRequest) GetMessages() []*Message {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type MessageSendResponse struct {
+}
+
+func (m *MessageSendResponse) Reset()         { *m = MessageSendResponse{} }
+func (m *MessageSendResponse) String() string { return proto.CompactTextString(m) }
+func (*MessageSendResponse) ProtoMessage()    {}
+
+type PairJoinRequest struct {
+	// The secret token from the invitation.
+	Token []byte `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (m *PairJoinRequest) Reset()         { *m = PairJoinRequest{} }
+func (m *PairJoinRequest) String() string { return proto.CompactTextString(m) }
+func (*PairJoinRequest) ProtoMessage()    {}
+
+type PairVolume struct {
+	Name           string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	VolumeID       []byte `protobuf:"bytes,2,opt,name=volumeID,proto3" json:"volumeID,omitempty"`
+	SharingKeyName string `protobuf:"bytes,3,opt,name=sharingKeyName" json:"sharingKeyName,omitempty"`
+}
+
+func (m *PairVolume) Reset()         { *m = PairVolume{} }
+func (m *PairVolume) String() string { return proto.CompactTextString(m) }
+func (*PairVolume) ProtoMessage()    {}
+
+type PairSharingKey struct {
+	Name   string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Secret []byte `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+}
+
+func (m *PairSharingKey) Reset()         { *m = PairSharingKey{} }
+func (m *PairSharingKey) String() string { return proto.CompactTextString(m) }
+func (*PairSharingKey) ProtoMessage()    {}
+
+type PairJoinResponse struct {
+	Volumes     []*PairVolume     `protobuf:"bytes,1,rep,name=volumes" json:"volumes,omitempty"`
+	SharingKeys []*PairSharingKey `protobuf:"bytes,2,rep,name=sharingKeys" json:"sharingKeys,omitempty"`
+}
+
+func (m *PairJoinResponse) Reset()         { *m = PairJoinResponse{} }
+func (m *PairJoinResponse) String() string { return proto.CompactTextString(m) }
+func (*PairJoinResponse) ProtoMessage()    {}
+
+func (m *PairJoinResponse) GetVolumes() []*PairVolume {
+	if m != nil {
+		return m.Volumes
+	}
+	return nil
+}
+
+func (m *PairJoinResponse) GetSharingKeys() []*PairSharingKey {
+	if m != nil {
+		return m.SharingKeys
+	}
+	return nil
+}
+
+// TransferGrant allows the sender to put an object belonging to the
+// owner into the owner's storage at the receiver.
+type TransferGrant struct {
+	Owner    []byte `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	Sender   []byte `protobuf:"bytes,2,opt,name=sender,proto3" json:"sender,omitempty"`
+	Receiver []byte `protobuf:"bytes,3,opt,name=receiver,proto3" json:"receiver,omitempty"`
+	Key      []byte `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	// Seconds since the Unix epoch.
+	Expires int64 `protobuf:"varint,5,opt,name=expires" json:"expires,omitempty"`
+}
+
+func (m *TransferGrant) Reset()         { *m = TransferGrant{} }
+func (m *TransferGrant) String() string { return proto.CompactTextString(m) }
+func (*TransferGrant) ProtoMessage()    {}
+
+type TransferCapability struct {
+	// Marshaled TransferGrant.
+	Grant []byte `protobuf:"bytes,1,opt,name=grant,proto3" json:"grant,omitempty"`
+	// Signature of the grant by the owner.
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *TransferCapability) Reset()         { *m = TransferCapability{} }
+func (m *TransferCapability) String() string { return proto.CompactTextString(m) }
+func (*TransferCapability) ProtoMessage()    {}
+
+type ObjectTransferRequest struct {
+	Key []byte `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Public key of the peer to send the object to.
+	Receiver []byte `protobuf:"bytes,2,opt,name=receiver,proto3" json:"receiver,omitempty"`
+	// Network address of the receiver.
+	Address    string              `protobuf:"bytes,3,opt,name=address" json:"address,omitempty"`
+	Capability *TransferCapability `protobuf:"bytes,4,opt,name=capability" json:"capability,omitempty"`
+}
+
+func (m *ObjectTransferRequest) Reset()         { *m = ObjectTransferRequest{} }
+func (m *ObjectTransferRequest) String() string { return proto.CompactTextString(m) }
+func (*ObjectTransferRequest) ProtoMessage()    {}
+
+func (m *ObjectTransferRequest) GetCapability() *TransferCapability {
+	if m != nil {
+		return m.Capability
+	}
+	return nil
+}
+
+type ObjectTransferResponse struct {
+}
+
+func (m *ObjectTransferResponse) Reset()         { *m = ObjectTransferResponse{} }
+func (m *ObjectTransferResponse) String() string { return proto.CompactTextString(m) }
+func (*ObjectTransferResponse) ProtoMessage()    {}
+
+type VolumeRevocation struct {
+	VolumeID []byte `protobuf:"bytes,1,opt,name=volumeID,proto3" json:"volumeID,omitempty"`
+	// Public key of the peer the volume is no longer shared with.
+	Peer []byte `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"`
+	// Seconds since the Unix epoch.
+	Time int64 `protobuf:"varint,3,opt,name=time" json:"time,omitempty"`
+	// Set when the volume is shared again after having been revoked.
+	Restored bool `protobuf:"varint,4,opt,name=restored" json:"restored,omitempty"`
+}
+
+func (m *VolumeRevocation) Reset()         { *m = VolumeRevocation{} }
+func (m *VolumeRevocation) String() string { return proto.CompactTextString(m) }
+func (*VolumeRevocation) ProtoMessage()    {}
+
+type SignedVolumeRevocation struct {
+	// Marshaled VolumeRevocation.
+	Revocation []byte `protobuf:"bytes,1,opt,name=revocation,proto3" json:"revocation,omitempty"`
+	// Signature of the revocation by the peer sharing the volume.
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *SignedVolumeRevocation) Reset()         { *m = SignedVolumeRevocation{} }
+func (m *SignedVolumeRevocation) String() string { return proto.CompactTextString(m) }
+func (*SignedVolumeRevocation) ProtoMessage()    {}
+
+type ObjectHasRequest struct {
+	Keys [][]byte `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (m *ObjectHasRequest) Reset()         { *m = ObjectHasRequest{} }
+func (m *ObjectHasRequest) String() string { return proto.CompactTextString(m) }
+func (*ObjectHasRequest) ProtoMessage()    {}
+
+type ObjectHasResponse struct {
+	// Whether the object is stored, in the same order as the keys in
+	// the request.
+	Has []bool `protobuf:"varint,1,rep,packed,name=has" json:"has,omitempty"`
+}
+
+func (m *ObjectHasResponse) Reset()         { *m = ObjectHasResponse{} }
+func (m *ObjectHasResponse) String() string { return proto.CompactTextString(m) }
+func (*ObjectHasResponse) ProtoMessage()    {}
+
+type PublishedFeedRequest struct {
+	PublicID []byte `protobuf:"bytes,1,opt,name=publicID,proto3" json:"publicID,omitempty"`
+}
+
+func (m *PublishedFeedRequest) Reset()         { *m = PublishedFeedRequest{} }
+func (m *PublishedFeedRequest) String() string { return proto.CompactTextString(m) }
+func (*PublishedFeedRequest) ProtoMessage()    {}
+
+// PublishedFeed lists the snapshots of a published volume. Anyone
+// may fetch it, so it says nothing about the volume beyond its public
+// identifier.
+type PublishedFeed struct {
+	PublicID []byte `protobuf:"bytes,1,opt,name=publicID,proto3" json:"publicID,omitempty"`
+	// Public key of the peer publishing the volume.
+	Publisher []byte `protobuf:"bytes,2,opt,name=publisher,proto3" json:"publisher,omitempty"`
+	// Seconds since the Unix epoch. Lets mirrors ignore a feed older
+	// than one they already have.
+	Time      int64                `protobuf:"varint,3,opt,name=time" json:"time,omitempty"`
+	Snapshots []*PublishedSnapshot `protobuf:"bytes,4,rep,name=snapshots" json:"snapshots,omitempty"`
+}
+
+func (m *PublishedFeed) Reset()         { *m = PublishedFeed{} }
+func (m *PublishedFeed) String() string { return proto.CompactTextString(m) }
+func (*PublishedFeed) ProtoMessage()    {}
+
+func (m *PublishedFeed) GetSnapshots() []*PublishedSnapshot {
+	if m != nil {
+		return m.Snapshots
+	}
+	return nil
+}
+
+type PublishedSnapshot struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Key of the chunk holding the bazil.snap.Snapshot, with type
+	// "snap" and level 0.
+	Key []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (m *PublishedSnapshot) Reset()         { *m = PublishedSnapshot{} }
+func (m *PublishedSnapshot) String() string { return proto.CompactTextString(m) }
+func (*PublishedSnapshot) ProtoMessage()    {}
+
+type SignedPublishedFeed struct {
+	// Marshaled PublishedFeed.
+	Feed []byte `protobuf:"bytes,1,opt,name=feed,proto3" json:"feed,omitempty"`
+	// Signature of the feed by the publisher.
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *SignedPublishedFeed) Reset()         { *m = SignedPublishedFeed{} }
+func (m *SignedPublishedFeed) String() string { return proto.CompactTextString(m) }
+func (*SignedPublishedFeed) ProtoMessage()    {}
+
+type PublishedObjectGetRequest struct {
+	PublicID []byte `protobuf:"bytes,1,opt,name=publicID,proto3" json:"publicID,omitempty"`
+	Key      []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Type     string `protobuf:"bytes,3,opt,name=type" json:"type,omitempty"`
+	Level    uint32 `protobuf:"varint,4,opt,name=level" json:"level,omitempty"`
+}
+
+func (m *PublishedObjectGetRequest) Reset()         { *m = PublishedObjectGetRequest{} }
+func (m *PublishedObjectGetRequest) String() string { return proto.CompactTextString(m) }
+func (*PublishedObjectGetRequest) ProtoMessage()    {}
+
+type VolumeSyncPullDoneRequest struct {
+	VolumeID []byte `protobuf:"bytes,1,opt,name=volumeID,proto3" json:"volumeID,omitempty"`
+	// As sent in the first VolumeSyncPullItem of the pull.
+	PullID uint64 `protobuf:"varint,2,opt,name=pullID" json:"pullID,omitempty"`
+}
+
+func (m *VolumeSyncPullDoneRequest) Reset()         { *m = VolumeSyncPullDoneRequest{} }
+func (m *VolumeSyncPullDoneRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeSyncPullDoneRequest) ProtoMessage()    {}
+
+type VolumeSyncPullDoneResponse struct {
+}
+
+func (m *VolumeSyncPullDoneResponse) Reset()         { *m = VolumeSyncPullDoneResponse{} }
+func (m *VolumeSyncPullDoneResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeSyncPullDoneResponse) ProtoMessage()    {}
+
 func init() {
 	proto.RegisterEnum("bazil.peer.VolumeSyncPullItem_Error", VolumeSyncPullItem_Error_name, VolumeSyncPullItem_Error_value)
+	proto.RegisterEnum("bazil.peer.Message_Kind", Message_Kind_name, Message_Kind_value)
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -249,6 +610,13 @@ type PeerClient interface {
 	ObjectGet(ctx context.Context, in *ObjectGetRequest, opts ...grpc.CallOption) (Peer_ObjectGetClient, error)
 	VolumeConnect(ctx context.Context, in *VolumeConnectRequest, opts ...grpc.CallOption) (*VolumeConnectResponse, error)
 	VolumeSyncPull(ctx context.Context, in *VolumeSyncPullRequest, opts ...grpc.CallOption) (Peer_VolumeSyncPullClient, error)
+	MessageSend(ctx context.Context, in *MessageSendRequest, opts ...grpc.CallOption) (*MessageSendResponse, error)
+	PairJoin(ctx context.Context, in *PairJoinRequest, opts ...grpc.CallOption) (*PairJoinResponse, error)
+	ObjectTransfer(ctx context.Context, in *ObjectTransferRequest, opts ...grpc.CallOption) (*ObjectTransferResponse, error)
+	ObjectHas(ctx context.Context, in *ObjectHasRequest, opts ...grpc.CallOption) (*ObjectHasResponse, error)
+	PublishedFeed(ctx context.Context, in *PublishedFeedRequest, opts ...grpc.CallOption) (*SignedPublishedFeed, error)
+	PublishedObjectGet(ctx context.Context, in *PublishedObjectGetRequest, opts ...grpc.CallOption) (Peer_PublishedObjectGetClient, error)
+	VolumeSyncPullDone(ctx context.Context, in *VolumeSyncPullDoneRequest, opts ...grpc.CallOption) (*VolumeSyncPullDoneResponse, error)
 }
 
 type peerClient struct {
@@ -375,6 +743,92 @@ func (x *peerVolumeSyncPullClient) Recv() (*VolumeSyncPullItem, error) {
 	return m, nil
 }
 
+func (c *peerClient) MessageSend(ctx context.Context, in *MessageSendRequest, opts ...grpc.CallOption) (*MessageSendResponse, error) {
+	out := new(MessageSendResponse)
+	err := grpc.Invoke(ctx, "/bazil.peer.Peer/MessageSend", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) PairJoin(ctx context.Context, in *PairJoinRequest, opts ...grpc.CallOption) (*PairJoinResponse, error) {
+	out := new(PairJoinResponse)
+	err := grpc.Invoke(ctx, "/bazil.peer.Peer/PairJoin", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) ObjectTransfer(ctx context.Context, in *ObjectTransferRequest, opts ...grpc.CallOption) (*ObjectTransferResponse, error) {
+	out := new(ObjectTransferResponse)
+	err := grpc.Invoke(ctx, "/bazil.peer.Peer/ObjectTransfer", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) ObjectHas(ctx context.Context, in *ObjectHasRequest, opts ...grpc.CallOption) (*ObjectHasResponse, error) {
+	out := new(ObjectHasResponse)
+	err := grpc.Invoke(ctx, "/bazil.peer.Peer/ObjectHas", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) PublishedFeed(ctx context.Context, in *PublishedFeedRequest, opts ...grpc.CallOption) (*SignedPublishedFeed, error) {
+	out := new(SignedPublishedFeed)
+	err := grpc.Invoke(ctx, "/bazil.peer.Peer/PublishedFeed", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) PublishedObjectGet(ctx context.Context, in *PublishedObjectGetRequest, opts ...grpc.CallOption) (Peer_PublishedObjectGetClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Peer_serviceDesc.Streams[3], c.cc, "/bazil.peer.Peer/PublishedObjectGet", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &peerPublishedObjectGetClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Peer_PublishedObjectGetClient interface {
+	Recv() (*ObjectGetResponse, error)
+	grpc.ClientStream
+}
+
+type peerPublishedObjectGetClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerPublishedObjectGetClient) Recv() (*ObjectGetResponse, error) {
+	m := new(ObjectGetResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *peerClient) VolumeSyncPullDone(ctx context.Context, in *VolumeSyncPullDoneRequest, opts ...grpc.CallOption) (*VolumeSyncPullDoneResponse, error) {
+	out := new(VolumeSyncPullDoneResponse)
+	err := grpc.Invoke(ctx, "/bazil.peer.Peer/VolumeSyncPullDone", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Peer service
 
 type PeerServer interface {
@@ -383,6 +837,13 @@ type PeerServer interface {
 	ObjectGet(*ObjectGetRequest, Peer_ObjectGetServer) error
 	VolumeConnect(context.Context, *VolumeConnectRequest) (*VolumeConnectResponse, error)
 	VolumeSyncPull(*VolumeSyncPullRequest, Peer_VolumeSyncPullServer) error
+	MessageSend(context.Context, *MessageSendRequest) (*MessageSendResponse, error)
+	PairJoin(context.Context, *PairJoinRequest) (*PairJoinResponse, error)
+	ObjectTransfer(context.Context, *ObjectTransferRequest) (*ObjectTransferResponse, error)
+	ObjectHas(context.Context, *ObjectHasRequest) (*ObjectHasResponse, error)
+	PublishedFeed(context.Context, *PublishedFeedRequest) (*SignedPublishedFeed, error)
+	PublishedObjectGet(*PublishedObjectGetRequest, Peer_PublishedObjectGetServer) error
+	VolumeSyncPullDone(context.Context, *VolumeSyncPullDoneRequest) (*VolumeSyncPullDoneResponse, error)
 }
 
 func RegisterPeerServer(s *grpc.Server, srv PeerServer) {
@@ -481,6 +942,99 @@ func (x *peerVolumeSyncPullServer) Send(m *VolumeSyncPullItem) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Peer_MessageSend_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(MessageSendRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PeerServer).MessageSend(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Peer_PairJoin_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PairJoinRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PeerServer).PairJoin(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Peer_ObjectTransfer_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(ObjectTransferRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PeerServer).ObjectTransfer(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Peer_ObjectHas_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(ObjectHasRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PeerServer).ObjectHas(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Peer_PublishedFeed_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PublishedFeedRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PeerServer).PublishedFeed(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Peer_PublishedObjectGet_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PublishedObjectGetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerServer).PublishedObjectGet(m, &peerPublishedObjectGetServer{stream})
+}
+
+type Peer_PublishedObjectGetServer interface {
+	Send(*ObjectGetResponse) error
+	grpc.ServerStream
+}
+
+type peerPublishedObjectGetServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerPublishedObjectGetServer) Send(m *ObjectGetResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Peer_VolumeSyncPullDone_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeSyncPullDoneRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(PeerServer).VolumeSyncPullDone(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var _Peer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "bazil.peer.Peer",
 	HandlerType: (*PeerServer)(nil),
@@ -493,6 +1047,30 @@ var _Peer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "VolumeConnect",
 			Handler:    _Peer_VolumeConnect_Handler,
 		},
+		{
+			MethodName: "MessageSend",
+			Handler:    _Peer_MessageSend_Handler,
+		},
+		{
+			MethodName: "PairJoin",
+			Handler:    _Peer_PairJoin_Handler,
+		},
+		{
+			MethodName: "ObjectTransfer",
+			Handler:    _Peer_ObjectTransfer_Handler,
+		},
+		{
+			MethodName: "ObjectHas",
+			Handler:    _Peer_ObjectHas_Handler,
+		},
+		{
+			MethodName: "PublishedFeed",
+			Handler:    _Peer_PublishedFeed_Handler,
+		},
+		{
+			MethodName: "VolumeSyncPullDone",
+			Handler:    _Peer_VolumeSyncPullDone_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -510,5 +1088,10 @@ var _Peer_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Peer_VolumeSyncPull_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "PublishedObjectGet",
+			Handler:       _Peer_PublishedObjectGet_Handler,
+			ServerStreams: true,
+		},
 	},
 }
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"bazil.org/bazil/db"
+)
+
+// Automount mounts every volume that has a mountpoint configured.
+// A volume failing to mount does not stop the others; fn is called
+// once for every volume tried, with the result.
+func (app *App) Automount(fn func(name string, mountpoint string, err error)) error {
+	type automount struct {
+		name       string
+		mountpoint string
+	}
+	var todo []automount
+	find := func(tx *db.Tx) error {
+		volumes := tx.Volumes()
+		add := func(name string, volID *db.VolumeID) error {
+			v, err := volumes.GetByVolumeID(volID)
+			if err != nil {
+				return err
+			}
+			if mnt := v.Mountpoint(); mnt != "" {
+				todo = append(todo, automount{name: name, mountpoint: mnt})
+			}
+			return nil
+		}
+		return volumes.Names(add)
+	}
+	if err := app.DB.View(find); err != nil {
+		return err
+	}
+
+	for _, a := range todo {
+		ref, err := app.GetVolumeByName(a.name)
+		if err == nil {
+			err = ref.Mount(a.mountpoint)
+			ref.Close()
+		}
+		fn(a.name, a.mountpoint, err)
+	}
+	return nil
+}
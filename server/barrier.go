@@ -0,0 +1,164 @@
+package server
+
+import (
+	"errors"
+	"sync"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"golang.org/x/net/context"
+)
+
+var (
+	ErrNotEnoughPeers = errors.New("volume is not shared with enough peers")
+)
+
+// pullLog remembers which peers have pulled each volume in full, so
+// SyncBarrier can tell when they have seen a given state.
+type pullLog struct {
+	mu sync.Mutex
+	// broadcasts whenever a pull is confirmed
+	cond sync.Cond
+	// number of full pulls started so far
+	seq uint64
+	// for every volume and peer, the sequence number of the last
+	// full pull that was sent completely, but maybe not saved yet
+	sent map[db.VolumeID]map[peer.PublicKey]uint64
+	// for every volume and peer, the sequence number of the last
+	// full pull the peer confirmed having saved
+	done map[db.VolumeID]map[peer.PublicKey]uint64
+}
+
+func (l *pullLog) init() {
+	l.cond.L = &l.mu
+	l.sent = make(map[db.VolumeID]map[peer.PublicKey]uint64)
+	l.done = make(map[db.VolumeID]map[peer.PublicKey]uint64)
+}
+
+// raise sets the sequence number for the volume and peer in m to
+// seq, unless it is higher already.
+func raise(m map[db.VolumeID]map[peer.PublicKey]uint64, volID *db.VolumeID, pub *peer.PublicKey, seq uint64) {
+	peers, ok := m[*volID]
+	if !ok {
+		peers = make(map[peer.PublicKey]uint64)
+		m[*volID] = peers
+	}
+	if seq > peers[*pub] {
+		peers[*pub] = seq
+	}
+}
+
+// PullStarted is called when a peer starts pulling the whole volume.
+// The result is sent to the peer, and passed to PullSent once the
+// pull has been sent.
+func (app *App) PullStarted() uint64 {
+	app.pulls.mu.Lock()
+	defer app.pulls.mu.Unlock()
+	app.pulls.seq++
+	return app.pulls.seq
+}
+
+// PullSent records that a pull started with PullStarted has sent
+// everything to the peer. It only counts for sync barriers once the
+// peer confirms it with PullConfirmed.
+func (app *App) PullSent(volID *db.VolumeID, pub *peer.PublicKey, seq uint64) {
+	app.pulls.mu.Lock()
+	defer app.pulls.mu.Unlock()
+	raise(app.pulls.sent, volID, pub, seq)
+}
+
+// PullConfirmed records that the peer has saved everything sent by
+// the given pull. It reports false if no such pull was sent to the
+// peer.
+func (app *App) PullConfirmed(volID *db.VolumeID, pub *peer.PublicKey, seq uint64) bool {
+	app.pulls.mu.Lock()
+	defer app.pulls.mu.Unlock()
+	// the peer may confirm an older pull after a newer one was sent
+	if seq == 0 || seq > app.pulls.sent[*volID][*pub] {
+		return false
+	}
+	raise(app.pulls.done, volID, pub, seq)
+	app.pulls.cond.Broadcast()
+	return true
+}
+
+// caller must hold app.pulls.mu
+func (app *App) pulledSince(volID *db.VolumeID, seq uint64) []peer.PublicKey {
+	var pubs []peer.PublicKey
+	for pub, s := range app.pulls.done[*volID] {
+		if s > seq {
+			pubs = append(pubs, pub)
+		}
+	}
+	return pubs
+}
+
+// SyncBarrier waits until at least n peers have a copy of everything
+// written to the volume before the call, and returns them.
+//
+// Unsaved writes are saved first. Peers count once they have pulled
+// the whole volume after that, and confirmed saving what they
+// received; content is not copied by pulls, so it is only as safe as
+// the storage backends of the volume make it.
+//
+// Peers are not asked to pull; the barrier waits for them to do it
+// on their own, until ctx is done.
+func (app *App) SyncBarrier(ctx context.Context, volID *db.VolumeID, n int) ([]peer.PublicKey, error) {
+	shared := 0
+	count := func(tx *db.Tx) error {
+		vol, err := tx.Volumes().GetByVolumeID(volID)
+		if err != nil {
+			return err
+		}
+		c := tx.Peers().Cursor()
+		for p := c.First(); p != nil; p = c.Next() {
+			if p.Volumes().IsAllowed(vol) {
+				shared++
+			}
+		}
+		return nil
+	}
+	if err := app.DB.View(count); err != nil {
+		return nil, err
+	}
+	if shared < n {
+		return nil, ErrNotEnoughPeers
+	}
+
+	ref, err := app.GetVolume(volID)
+	if err != nil {
+		return nil, err
+	}
+	defer ref.Close()
+	if err := ref.FS().Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	app.pulls.mu.Lock()
+	defer app.pulls.mu.Unlock()
+	mark := app.pulls.seq
+
+	// wake up the wait below when ctx is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			app.pulls.mu.Lock()
+			app.pulls.cond.Broadcast()
+			app.pulls.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	for {
+		pubs := app.pulledSince(volID, mark)
+		if len(pubs) >= n {
+			return pubs, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return pubs, err
+		}
+		app.pulls.cond.Wait()
+	}
+}
@@ -0,0 +1,115 @@
+package control
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/wire"
+	"github.com/tv42/zbase32"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	pairTokenSize         = 16
+	pairInviteDefaultTime = 1 * time.Hour
+)
+
+var errBadInvite = errors.New("malformed pairing invitation")
+
+// formatInvite encodes everything the joining device needs to know
+// into one string, as PUB@HOST:PORT/TOKEN.
+func formatInvite(pub *peer.PublicKey, addr string, token []byte) string {
+	return fmt.Sprintf("%s@%s/%s", pub, addr, zbase32.EncodeToString(token))
+}
+
+func parseInvite(s string) (pub *peer.PublicKey, addr string, token []byte, err error) {
+	at := strings.IndexByte(s, '@')
+	slash := strings.LastIndexByte(s, '/')
+	if at == -1 || slash < at {
+		return nil, "", nil, errBadInvite
+	}
+	var p peer.PublicKey
+	if err := p.Set(s[:at]); err != nil {
+		return nil, "", nil, errBadInvite
+	}
+	addr = s[at+1 : slash]
+	if err := server.ValidatePeerAddr(addr); err != nil {
+		return nil, "", nil, errBadInvite
+	}
+	token, err = zbase32.DecodeString(s[slash+1:])
+	if err != nil || len(token) != pairTokenSize {
+		return nil, "", nil, errBadInvite
+	}
+	return &p, addr, token, nil
+}
+
+// PairInvite creates an invitation for a new device to pair with this
+// node. The device joining gets a new identity of its own, and is
+// added as a peer with access to the listed volumes.
+func (c controlRPC) PairInvite(ctx context.Context, req *wire.PairInviteRequest) (*wire.PairInviteResponse, error) {
+	if err := server.ValidatePeerAddr(req.Addr); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad address: %v", err)
+	}
+	storage := req.Storage
+	if storage == "" {
+		storage = "local"
+	}
+	if err := c.app.ValidateKV(storage); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid storage: %q", storage)
+	}
+	valid := pairInviteDefaultTime
+	if req.ValidSeconds > 0 {
+		valid = time.Duration(req.ValidSeconds) * time.Second
+	}
+
+	token := make([]byte, pairTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		log.Printf("pair invite: cannot generate token: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "cannot generate token")
+	}
+
+	invite := func(tx *db.Tx) error {
+		now := time.Now()
+		if err := tx.PairInvites().Expire(now); err != nil {
+			return err
+		}
+		inv, err := tx.PairInvites().Add(token, now.Add(valid))
+		if err != nil {
+			return err
+		}
+		if err := inv.AllowStorage(storage); err != nil {
+			return err
+		}
+		for _, name := range req.VolumeNames {
+			vol, err := tx.Volumes().GetByName(name)
+			if err != nil {
+				return err
+			}
+			if err := inv.AllowVolume(name, vol); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := c.app.DB.Update(invite); err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		log.Printf("db update error: pair invite: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	resp := &wire.PairInviteResponse{
+		Invite: formatInvite((*peer.PublicKey)(c.app.Keys.Sign.Pub), req.Addr, token),
+	}
+	return resp, nil
+}
@@ -0,0 +1,129 @@
+package control
+
+import (
+	"bytes"
+	"log"
+
+	"bazil.org/bazil/db"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PairJoin uses an invitation from PairInvite to link this node to
+// the inviting one. Both nodes become peers of each other, and the
+// volumes shared in the invitation are connected here, under the
+// same names.
+func (c controlRPC) PairJoin(ctx context.Context, req *wire.PairJoinRequest) (*wire.PairJoinResponse, error) {
+	pub, addr, token, err := parseInvite(req.Invite)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if bytes.Equal(pub[:], c.app.Keys.Sign.Pub[:]) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "cannot pair with self")
+	}
+	backend := req.Backend
+	if backend == "" {
+		backend = "peerkey:" + pub.String()
+	}
+	if err := c.app.ValidateKV(backend); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid backend: %q", backend)
+	}
+
+	addPeer := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pub)
+		if err != nil {
+			return err
+		}
+		return p.Locations().Set(addr)
+	}
+	if err := c.app.DB.Update(addPeer); err != nil {
+		log.Printf("db update error: pair join: add peer: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	client, err := c.app.DialPeer(pub)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	presp, err := client.PairJoin(ctx, &wirepeer.PairJoinRequest{
+		Token: token,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &wire.PairJoinResponse{}
+	configure := func(tx *db.Tx) error {
+		for _, k := range presp.SharingKeys {
+			if len(k.Secret) != sharingKeySize {
+				return grpc.Errorf(codes.FailedPrecondition, "peer sent a bad sharing key: %q", k.Name)
+			}
+			var secret [32]byte
+			copy(secret[:], k.Secret)
+			existing, err := tx.SharingKeys().Get(k.Name)
+			switch err {
+			case db.ErrSharingKeyNotFound:
+				if _, err := tx.SharingKeys().Add(k.Name, &secret); err != nil {
+					return err
+				}
+				continue
+			case nil:
+			default:
+				return err
+			}
+			var have [32]byte
+			existing.Secret(&have)
+			if have != secret {
+				return grpc.Errorf(codes.AlreadyExists, "sharing key %q exists already with a different secret", k.Name)
+			}
+		}
+
+		p, err := tx.Peers().Get(pub)
+		if err != nil {
+			return err
+		}
+		// the inviting node will want to fetch our changes too
+		if err := p.Storage().Allow("local"); err != nil {
+			return err
+		}
+		for _, v := range presp.Volumes {
+			var volID db.VolumeID
+			if err := volID.UnmarshalBinary(v.VolumeID); err != nil {
+				return grpc.Errorf(codes.FailedPrecondition, "peer sent a bad volume ID: %v", err)
+			}
+			sharingKey, err := tx.SharingKeys().Get(v.SharingKeyName)
+			if err != nil {
+				return err
+			}
+			vol, err := tx.Volumes().Add(v.Name, &volID, backend, sharingKey)
+			if err != nil {
+				return err
+			}
+			if err := p.Volumes().Allow(vol); err != nil {
+				return err
+			}
+			resp.VolumeNames = append(resp.VolumeNames, v.Name)
+		}
+		return nil
+	}
+	if err := c.app.DB.Update(configure); err != nil {
+		switch err {
+		case db.ErrVolNameInvalid:
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		case db.ErrVolNameExist, db.ErrVolumeIDExist:
+			return nil, grpc.Errorf(codes.AlreadyExists, "%v", err)
+		case db.ErrSharingKeyNotFound:
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		if grpc.Code(err) != codes.Unknown {
+			return nil, err
+		}
+		log.Printf("db update error: pair join: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
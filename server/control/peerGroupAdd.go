@@ -0,0 +1,42 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerGroupAdd(ctx context.Context, req *wire.PeerGroupAddRequest) (*wire.PeerGroupAddResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+
+	addMember := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(&pub)
+		if err != nil {
+			return err
+		}
+		g, err := tx.PeerGroups().Make(req.Group)
+		if err != nil {
+			return err
+		}
+		return g.Add(p)
+	}
+	if err := c.app.DB.Update(addMember); err != nil {
+		switch err {
+		case db.ErrPeerNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "peer not found")
+		case db.ErrPeerGroupNameInvalid:
+			return nil, grpc.Errorf(codes.InvalidArgument, "invalid peer group name")
+		}
+		log.Printf("db error: adding peer to group: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return &wire.PeerGroupAddResponse{}, nil
+}
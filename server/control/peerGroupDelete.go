@@ -0,0 +1,58 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerGroupDelete(ctx context.Context, req *wire.PeerGroupDeleteRequest) (*wire.PeerGroupDeleteResponse, error) {
+	var lost []lostVolume
+	deleteGroup := func(tx *db.Tx) error {
+		lost = nil
+		groups := tx.PeerGroups()
+		g, err := groups.Get(req.Group)
+		if err != nil {
+			return err
+		}
+		var members []peer.PublicKey
+		addMember := func(pub *peer.PublicKey) error {
+			members = append(members, *pub)
+			return nil
+		}
+		if err := g.Members(addMember); err != nil {
+			return err
+		}
+		var volIDs []db.VolumeID
+		addVolume := func(volID *db.VolumeID) error {
+			volIDs = append(volIDs, *volID)
+			return nil
+		}
+		if err := g.Volumes(addVolume); err != nil {
+			return err
+		}
+		if err := groups.Delete(req.Group); err != nil {
+			return err
+		}
+		lost, err = findLostVolumes(tx, members, volIDs)
+		return err
+	}
+	if err := c.app.DB.Update(deleteGroup); err != nil {
+		if err == db.ErrPeerGroupNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: deleting peer group: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	revoked, err := c.tellLostVolumes(ctx, lost)
+	if err != nil {
+		return nil, err
+	}
+	return &wire.PeerGroupDeleteResponse{Revoked: revoked}, nil
+}
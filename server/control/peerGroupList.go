@@ -0,0 +1,67 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerGroupList(ctx context.Context, req *wire.PeerGroupListRequest) (*wire.PeerGroupListResponse, error) {
+	resp := &wire.PeerGroupListResponse{}
+	list := func(tx *db.Tx) error {
+		resp.Groups = nil
+		names := make(map[db.VolumeID]string)
+		addName := func(name string, volID *db.VolumeID) error {
+			names[*volID] = name
+			return nil
+		}
+		if err := tx.Volumes().Names(addName); err != nil {
+			return err
+		}
+
+		cur := tx.PeerGroups().Cursor()
+		for g := cur.First(); g != nil; g = cur.Next() {
+			info := &wire.PeerGroupInfo{
+				Name: g.Name(),
+			}
+			addMember := func(pub *peer.PublicKey) error {
+				info.Members = append(info.Members, append([]byte(nil), pub[:]...))
+				return nil
+			}
+			if err := g.Members(addMember); err != nil {
+				return err
+			}
+			addStorage := func(backend string) error {
+				info.Storage = append(info.Storage, backend)
+				return nil
+			}
+			if err := g.Storage(addStorage); err != nil {
+				return err
+			}
+			addVolume := func(volID *db.VolumeID) error {
+				name, ok := names[*volID]
+				if !ok {
+					// volume is gone
+					return nil
+				}
+				info.Volumes = append(info.Volumes, name)
+				return nil
+			}
+			if err := g.Volumes(addVolume); err != nil {
+				return err
+			}
+			resp.Groups = append(resp.Groups, info)
+		}
+		return nil
+	}
+	if err := c.app.DB.View(list); err != nil {
+		log.Printf("db error: listing peer groups: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
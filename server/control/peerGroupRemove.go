@@ -0,0 +1,35 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerGroupRemove(ctx context.Context, req *wire.PeerGroupRemoveRequest) (*wire.PeerGroupRemoveResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+
+	removeMember := func(tx *db.Tx) error {
+		g, err := tx.PeerGroups().Get(req.Group)
+		if err != nil {
+			return err
+		}
+		return g.Remove(&pub)
+	}
+	if err := c.app.DB.Update(removeMember); err != nil {
+		if err == db.ErrPeerGroupNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "peer group not found")
+		}
+		log.Printf("db error: removing peer from group: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return &wire.PeerGroupRemoveResponse{}, nil
+}
@@ -0,0 +1,33 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerGroupStorageAllow(ctx context.Context, req *wire.PeerGroupStorageAllowRequest) (*wire.PeerGroupStorageAllowResponse, error) {
+	if err := c.app.ValidateKV(req.Backend); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "invalid backend: %q", req.Backend)
+	}
+
+	allowStorage := func(tx *db.Tx) error {
+		g, err := tx.PeerGroups().Make(req.Group)
+		if err != nil {
+			return err
+		}
+		return g.AllowStorage(req.Backend)
+	}
+	if err := c.app.DB.Update(allowStorage); err != nil {
+		if err == db.ErrPeerGroupNameInvalid {
+			return nil, grpc.Errorf(codes.InvalidArgument, "invalid peer group name")
+		}
+		log.Printf("db error: allowing peer group storage: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return &wire.PeerGroupStorageAllowResponse{}, nil
+}
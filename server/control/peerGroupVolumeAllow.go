@@ -0,0 +1,36 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerGroupVolumeAllow(ctx context.Context, req *wire.PeerGroupVolumeAllowRequest) (*wire.PeerGroupVolumeAllowResponse, error) {
+	allowVolume := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		g, err := tx.PeerGroups().Make(req.Group)
+		if err != nil {
+			return err
+		}
+		return g.AllowVolume(v)
+	}
+	if err := c.app.DB.Update(allowVolume); err != nil {
+		switch err {
+		case db.ErrVolNameNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "volume not found")
+		case db.ErrPeerGroupNameInvalid:
+			return nil, grpc.Errorf(codes.InvalidArgument, "invalid peer group name")
+		}
+		log.Printf("db error: allowing peer group volume: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return &wire.PeerGroupVolumeAllowResponse{}, nil
+}
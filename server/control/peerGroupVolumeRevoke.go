@@ -0,0 +1,140 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// lostVolume is a volume a peer can no longer see.
+type lostVolume struct {
+	pub   peer.PublicKey
+	volID db.VolumeID
+	name  string
+}
+
+// findLostVolumes returns the volumes out of volIDs that the peers
+// in members can no longer see, after their access through a group
+// was taken away.
+func findLostVolumes(tx *db.Tx, members []peer.PublicKey, volIDs []db.VolumeID) ([]lostVolume, error) {
+	var lost []lostVolume
+	for i := range members {
+		pub := &members[i]
+		p, err := tx.Peers().Get(pub)
+		if err == db.ErrPeerNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j := range volIDs {
+			volID := &volIDs[j]
+			v, err := tx.Volumes().GetByVolumeID(volID)
+			if err == db.ErrVolumeIDNotFound {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if p.Volumes().IsAllowed(v) {
+				continue
+			}
+			lost = append(lost, lostVolume{pub: *pub, volID: *volID})
+		}
+	}
+	if len(lost) == 0 {
+		return nil, nil
+	}
+	names := func(name string, volID *db.VolumeID) error {
+		for i := range lost {
+			if lost[i].volID == *volID {
+				lost[i].name = name
+			}
+		}
+		return nil
+	}
+	if err := tx.Volumes().Names(names); err != nil {
+		return nil, err
+	}
+	return lost, nil
+}
+
+// tellLostVolumes sends revocations to peers that can no longer see
+// volumes, like PeerVolumeRevoke does.
+func (c controlRPC) tellLostVolumes(ctx context.Context, lost []lostVolume) ([]*wire.PeerGroupRevocation, error) {
+	var revoked []*wire.PeerGroupRevocation
+	for i := range lost {
+		l := &lost[i]
+		if err := c.app.TellVolumeRevoked(&l.pub, &l.volID, false); err != nil {
+			log.Printf("db error: queueing volume revocation: %v", err)
+			return nil, grpc.Errorf(codes.Internal, "database error")
+		}
+	}
+	delivered := make(map[peer.PublicKey]bool)
+	for i := range lost {
+		l := &lost[i]
+		ok, seen := delivered[l.pub]
+		if !seen {
+			// The messages stay in the outbox if the peer cannot be
+			// reached now, and are delivered along with later
+			// messages.
+			ok = c.app.DeliverMessages(ctx, &l.pub) == nil
+			delivered[l.pub] = ok
+		}
+		revoked = append(revoked, &wire.PeerGroupRevocation{
+			Pub:        append([]byte(nil), l.pub[:]...),
+			VolumeName: l.name,
+			Delivered:  ok,
+		})
+	}
+	return revoked, nil
+}
+
+func (c controlRPC) PeerGroupVolumeRevoke(ctx context.Context, req *wire.PeerGroupVolumeRevokeRequest) (*wire.PeerGroupVolumeRevokeResponse, error) {
+	var lost []lostVolume
+	revokeVolume := func(tx *db.Tx) error {
+		lost = nil
+		v, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		g, err := tx.PeerGroups().Get(req.Group)
+		if err != nil {
+			return err
+		}
+		if err := g.RevokeVolume(v); err != nil {
+			return err
+		}
+		var members []peer.PublicKey
+		addMember := func(pub *peer.PublicKey) error {
+			members = append(members, *pub)
+			return nil
+		}
+		if err := g.Members(addMember); err != nil {
+			return err
+		}
+		var volID db.VolumeID
+		v.VolumeID(&volID)
+		lost, err = findLostVolumes(tx, members, []db.VolumeID{volID})
+		return err
+	}
+	if err := c.app.DB.Update(revokeVolume); err != nil {
+		switch err {
+		case db.ErrVolNameNotFound, db.ErrPeerGroupNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: revoking peer group volume: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	revoked, err := c.tellLostVolumes(ctx, lost)
+	if err != nil {
+		return nil, err
+	}
+	return &wire.PeerGroupVolumeRevokeResponse{Revoked: revoked}, nil
+}
@@ -0,0 +1,170 @@
+package control_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/controltest"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/util/grpcunix"
+	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
+)
+
+func TestPeerGroupRevoke(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := server.New(tmp.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctrl := controltest.ListenAndServe(t, &wg, app)
+	defer ctrl.Close()
+
+	pub1 := peer.PublicKey{1, 2, 3}
+	pub2 := peer.PublicKey{4, 5, 6}
+	setup := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		foo, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		bar, err := tx.Volumes().Create("bar", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		p1, err := tx.Peers().Make(&pub1)
+		if err != nil {
+			return err
+		}
+		if err := p1.Volumes().Allow(bar); err != nil {
+			return err
+		}
+		p2, err := tx.Peers().Make(&pub2)
+		if err != nil {
+			return err
+		}
+		friends, err := tx.PeerGroups().Make("friends")
+		if err != nil {
+			return err
+		}
+		if err := friends.Add(p1); err != nil {
+			return err
+		}
+		if err := friends.Add(p2); err != nil {
+			return err
+		}
+		if err := friends.AllowStorage("local"); err != nil {
+			return err
+		}
+		if err := friends.AllowVolume(foo); err != nil {
+			return err
+		}
+		if err := friends.AllowVolume(bar); err != nil {
+			return err
+		}
+		family, err := tx.PeerGroups().Make("family")
+		if err != nil {
+			return err
+		}
+		if err := family.Add(p2); err != nil {
+			return err
+		}
+		return family.AllowVolume(foo)
+	}
+	if err := app.DB.Update(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	rpcConn, err := grpcunix.Dial(filepath.Join(app.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+	ctx := context.Background()
+
+	list, err := rpcClient.PeerGroupList(ctx, &wire.PeerGroupListRequest{})
+	if err != nil {
+		t.Fatalf("peer group list failed: %v", err)
+	}
+	if g, e := len(list.Groups), 2; g != e {
+		t.Fatalf("wrong number of groups: %d != %d", g, e)
+	}
+	friends := list.Groups[1]
+	if g, e := friends.Name, "friends"; g != e {
+		t.Fatalf("wrong group: %q != %q", g, e)
+	}
+	if g, e := friends.Members, [][]byte{pub1[:], pub2[:]}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong members: %x != %x", g, e)
+	}
+	if g, e := friends.Storage, []string{"local"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong storage: %q != %q", g, e)
+	}
+	sort.Strings(friends.Volumes)
+	if g, e := friends.Volumes, []string{"bar", "foo"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong volumes: %q != %q", g, e)
+	}
+
+	// the other member still sees foo through family
+	revoke, err := rpcClient.PeerGroupVolumeRevoke(ctx, &wire.PeerGroupVolumeRevokeRequest{
+		Group:      "friends",
+		VolumeName: "foo",
+	})
+	if err != nil {
+		t.Fatalf("peer group volume revoke failed: %v", err)
+	}
+	if g, e := len(revoke.Revoked), 1; g != e {
+		t.Fatalf("wrong number of revocations: %d != %d", g, e)
+	}
+	if g, e := revoke.Revoked[0].Pub, pub1[:]; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong peer told: %x != %x", g, e)
+	}
+	if g, e := revoke.Revoked[0].VolumeName, "foo"; g != e {
+		t.Errorf("wrong volume: %q != %q", g, e)
+	}
+
+	// the first member still sees bar directly
+	del, err := rpcClient.PeerGroupDelete(ctx, &wire.PeerGroupDeleteRequest{
+		Group: "friends",
+	})
+	if err != nil {
+		t.Fatalf("peer group delete failed: %v", err)
+	}
+	if g, e := len(del.Revoked), 1; g != e {
+		t.Fatalf("wrong number of revocations: %d != %d", g, e)
+	}
+	if g, e := del.Revoked[0].Pub, pub2[:]; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong peer told: %x != %x", g, e)
+	}
+	if g, e := del.Revoked[0].VolumeName, "bar"; g != e {
+		t.Errorf("wrong volume: %q != %q", g, e)
+	}
+
+	list, err = rpcClient.PeerGroupList(ctx, &wire.PeerGroupListRequest{})
+	if err != nil {
+		t.Fatalf("peer group list failed: %v", err)
+	}
+	if g, e := len(list.Groups), 1; g != e {
+		t.Fatalf("wrong number of groups after delete: %d != %d", g, e)
+	}
+	if g, e := list.Groups[0].Name, "family"; g != e {
+		t.Errorf("wrong group left: %q != %q", g, e)
+	}
+
+	if _, err := rpcClient.PeerGroupDelete(ctx, &wire.PeerGroupDeleteRequest{Group: "friends"}); err == nil {
+		t.Errorf("expected error deleting a missing group")
+	}
+}
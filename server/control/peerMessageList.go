@@ -0,0 +1,82 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server/control/wire"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerMessageList(ctx context.Context, req *wire.PeerMessageListRequest) (*wire.PeerMessageListResponse, error) {
+	var only *peer.PublicKey
+	if len(req.Pub) > 0 {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(req.Pub); err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+		}
+		only = &pub
+	}
+
+	resp := &wire.PeerMessageListResponse{}
+	list := func(p *db.Peer) error {
+		inbox := p.Inbox()
+		var seen []uint64
+		cur := inbox.Cursor()
+		for item := cur.First(); item != nil; item = cur.Next() {
+			var msg wirepeer.Message
+			if err := proto.Unmarshal(item.Data, &msg); err != nil {
+				return err
+			}
+			resp.Messages = append(resp.Messages, &wire.PeerMessage{
+				Pub:  p.Pub()[:],
+				Seq:  item.Seq,
+				Kind: msg.Kind.String(),
+				Body: msg.Body,
+				Sent: msg.Sent,
+			})
+			seen = append(seen, item.Seq)
+		}
+		if req.Remove {
+			for _, seq := range seen {
+				if err := inbox.Remove(seq); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	read := func(tx *db.Tx) error {
+		if only != nil {
+			p, err := tx.Peers().Get(only)
+			if err != nil {
+				return err
+			}
+			return list(p)
+		}
+		c := tx.Peers().Cursor()
+		for p := c.First(); p != nil; p = c.Next() {
+			if err := list(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	txn := c.app.DB.View
+	if req.Remove {
+		txn = c.app.DB.Update
+	}
+	if err := txn(read); err != nil {
+		if err == db.ErrPeerNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "peer not found")
+		}
+		log.Printf("db error: listing messages: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
@@ -0,0 +1,45 @@
+package control
+
+import (
+	"log"
+	"strings"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerMessageSend(ctx context.Context, req *wire.PeerMessageSendRequest) (*wire.PeerMessageSendResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+	kind, ok := wirepeer.Message_Kind_value[strings.ToUpper(req.Kind)]
+	if !ok || kind == int32(wirepeer.Message_UNKNOWN) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "unknown message kind: %q", req.Kind)
+	}
+
+	if err := c.app.QueueMessage(&pub, wirepeer.Message_Kind(kind), req.Body); err != nil {
+		switch err {
+		case db.ErrPeerNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "peer not found")
+		case db.ErrOutboxFull:
+			return nil, grpc.Errorf(codes.ResourceExhausted, "%v", err)
+		}
+		log.Printf("db error: queueing message: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	resp := &wire.PeerMessageSendResponse{}
+	if err := c.app.DeliverMessages(ctx, &pub); err != nil {
+		// it stays queued for later
+		log.Printf("message delivery to %v deferred: %v", &pub, err)
+		return resp, nil
+	}
+	resp.Delivered = true
+	return resp, nil
+}
@@ -0,0 +1,41 @@
+package control
+
+import (
+	"bytes"
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PeerRekey accepts a new public key for a known peer.
+func (c controlRPC) PeerRekey(ctx context.Context, req *wire.PeerRekeyRequest) (*wire.PeerRekeyResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+	var newPub peer.PublicKey
+	if err := newPub.UnmarshalBinary(req.NewPub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad new public key: %v", err)
+	}
+	if bytes.Equal(newPub[:], c.app.Keys.Sign.Pub[:]) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "cannot rekey peer as self")
+	}
+
+	rekey := func(tx *db.Tx) error {
+		return tx.Peers().Rekey(&pub, &newPub)
+	}
+	if err := c.app.DB.Update(rekey); err != nil {
+		switch err {
+		case db.ErrPeerNotFound, db.ErrPeerKeyInUse:
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: rekeying peer: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return &wire.PeerRekeyResponse{}, nil
+}
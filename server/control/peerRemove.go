@@ -0,0 +1,74 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PeerRemove forgets a peer, along with everything shared with it,
+// and reports what the peer lost access to. With DryRun, nothing is
+// removed.
+func (c controlRPC) PeerRemove(ctx context.Context, req *wire.PeerRemoveRequest) (*wire.PeerRemoveResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+
+	var resp *wire.PeerRemoveResponse
+	remove := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(&pub)
+		if err != nil {
+			return err
+		}
+		resp = &wire.PeerRemoveResponse{}
+		volumes := tx.Volumes()
+		addVolume := func(name string, volID *db.VolumeID) error {
+			vol, err := volumes.GetByVolumeID(volID)
+			if err != nil {
+				return err
+			}
+			if p.Volumes().IsAllowed(vol) {
+				resp.Volumes = append(resp.Volumes, name)
+			}
+			return nil
+		}
+		if err := volumes.Names(addVolume); err != nil {
+			return err
+		}
+		groups := tx.PeerGroups().Cursor()
+		for g := groups.First(); g != nil; g = groups.Next() {
+			if g.IsMember(&pub) {
+				resp.Groups = append(resp.Groups, g.Name())
+			}
+		}
+		outbox := p.Outbox().Cursor()
+		for msg := outbox.First(); msg != nil; msg = outbox.Next() {
+			resp.UndeliveredMessages++
+		}
+
+		if err := tx.Peers().Remove(&pub); err != nil {
+			return err
+		}
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	}
+	if err := c.app.DB.Update(remove); err != nil && err != errDryRun {
+		switch err {
+		case db.ErrPeerNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		case db.ErrPeerStorageInUse:
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		log.Printf("db error: removing peer: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
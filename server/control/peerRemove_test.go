@@ -0,0 +1,106 @@
+package control_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/controltest"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/util/grpcunix"
+	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
+)
+
+func TestPeerRemoveDryRun(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := server.New(tmp.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctrl := controltest.ListenAndServe(t, &wg, app)
+	defer ctrl.Close()
+
+	pub := peer.PublicKey{1, 2, 3, 4, 5}
+	setup := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Get("default")
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Volumes().Create("bar", "local", sharingKey); err != nil {
+			return err
+		}
+		p, err := tx.Peers().Make(&pub)
+		if err != nil {
+			return err
+		}
+		if err := p.Volumes().Allow(v); err != nil {
+			return err
+		}
+		g, err := tx.PeerGroups().Make("friends")
+		if err != nil {
+			return err
+		}
+		return g.Add(p)
+	}
+	if err := app.DB.Update(setup); err != nil {
+		t.Fatal(err)
+	}
+
+	rpcConn, err := grpcunix.Dial(filepath.Join(app.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+
+	ctx := context.Background()
+	check := func(dryRun bool) {
+		resp, err := rpcClient.PeerRemove(ctx, &wire.PeerRemoveRequest{
+			Pub:    pub[:],
+			DryRun: dryRun,
+		})
+		if err != nil {
+			t.Fatalf("peer remove failed: %v", err)
+		}
+		if g, e := resp.Volumes, []string{"foo"}; !reflect.DeepEqual(g, e) {
+			t.Errorf("wrong volumes: %q != %q", g, e)
+		}
+		if g, e := resp.Groups, []string{"friends"}; !reflect.DeepEqual(g, e) {
+			t.Errorf("wrong groups: %q != %q", g, e)
+		}
+	}
+	exists := func() bool {
+		found := false
+		get := func(tx *db.Tx) error {
+			_, err := tx.Peers().Get(&pub)
+			found = err == nil
+			return nil
+		}
+		if err := app.DB.View(get); err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	check(true)
+	if !exists() {
+		t.Fatal("dry run removed the peer")
+	}
+	check(false)
+	if exists() {
+		t.Fatal("peer was not removed")
+	}
+}
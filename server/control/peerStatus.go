@@ -0,0 +1,54 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PeerStatus lists the known peers, with the keys pinned for them
+// and any alerts about other keys answering in their place.
+func (c controlRPC) PeerStatus(ctx context.Context, req *wire.PeerStatusRequest) (*wire.PeerStatusResponse, error) {
+	resp := &wire.PeerStatusResponse{}
+	list := func(tx *db.Tx) error {
+		c := tx.Peers().Cursor()
+		for p := c.First(); p != nil; p = c.Next() {
+			status := &wire.PeerStatus{
+				Pub: p.Pub()[:],
+				Id:  uint32(p.ID()),
+			}
+			addr, err := p.Locations().Get()
+			switch err {
+			case nil:
+				status.Location = addr
+			case db.ErrNoLocationForPeer:
+			default:
+				return err
+			}
+			alert, err := p.KeyAlert()
+			if err != nil {
+				return err
+			}
+			if alert != nil {
+				status.KeyAlert = &wire.PeerKeyAlert{
+					Pub:   alert.Pub,
+					Addr:  alert.Addr,
+					First: alert.First,
+					Last:  alert.Last,
+					Count: alert.Count,
+				}
+			}
+			resp.Peers = append(resp.Peers, status)
+		}
+		return nil
+	}
+	if err := c.app.DB.View(list); err != nil {
+		log.Printf("db error: listing peers: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
@@ -0,0 +1,81 @@
+package control
+
+import (
+	"log"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const defaultTrafficDays = 30
+
+func (c controlRPC) PeerTraffic(ctx context.Context, req *wire.PeerTrafficRequest) (*wire.PeerTrafficResponse, error) {
+	var only *peer.PublicKey
+	if len(req.Pub) > 0 {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(req.Pub); err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+		}
+		only = &pub
+	}
+	days := req.Days
+	if days == 0 {
+		days = defaultTrafficDays
+	}
+	today := uint32(time.Now().Unix() / (24 * 60 * 60))
+	var since uint32
+	if days <= today {
+		since = today - days + 1
+	}
+
+	// with a database copy, counts flushed here show up once the
+	// copy is refreshed
+	if err := c.app.FlushTraffic(); err != nil {
+		log.Printf("db update error: saving traffic counts: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	resp := &wire.PeerTrafficResponse{}
+	list := func(tx *db.Tx) error {
+		names := make(map[db.VolumeID]string)
+		getNames := func(name string, volID *db.VolumeID) error {
+			names[*volID] = name
+			return nil
+		}
+		if err := tx.Volumes().Names(getNames); err != nil {
+			return err
+		}
+		add := func(e *db.TrafficEntry) error {
+			if only != nil && e.Pub != *only {
+				return nil
+			}
+			t := &wire.PeerTraffic{
+				Pub:      e.Pub[:],
+				Day:      e.Day,
+				Sent:     e.Sent,
+				Received: e.Received,
+			}
+			if e.VolumeID != nil {
+				name, ok := names[*e.VolumeID]
+				if !ok {
+					// volume has been deleted since
+					name = e.VolumeID.String()
+				}
+				t.VolumeName = name
+			}
+			resp.Traffic = append(resp.Traffic, t)
+			return nil
+		}
+		return tx.Traffic().List(since, add)
+	}
+	if err := c.app.StatsView(list); err != nil {
+		log.Printf("db error: listing traffic: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
@@ -0,0 +1,57 @@
+package control_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/controltest"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/util/grpcunix"
+	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
+)
+
+func TestPeerTraffic(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := server.New(tmp.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctrl := controltest.ListenAndServe(t, &wg, app)
+	defer ctrl.Close()
+
+	pub := peer.PublicKey{1, 2, 3, 4, 5}
+	other := peer.PublicKey{6, 7, 8}
+	app.CountTraffic(&pub, nil, 10, 20)
+	app.CountTraffic(&pub, nil, 1, 2)
+	app.CountTraffic(&other, nil, 100, 0)
+
+	rpcConn, err := grpcunix.Dial(filepath.Join(app.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+
+	ctx := context.Background()
+	resp, err := rpcClient.PeerTraffic(ctx, &wire.PeerTrafficRequest{
+		Pub: pub[:],
+	})
+	if err != nil {
+		t.Fatalf("getting traffic failed: %v", err)
+	}
+	if g, e := len(resp.Traffic), 1; g != e {
+		t.Fatalf("wrong number of entries: %d != %d: %v", g, e, resp.Traffic)
+	}
+	got := resp.Traffic[0]
+	if got.VolumeName != "" || got.Sent != 11 || got.Received != 22 {
+		t.Errorf("wrong traffic: %v", got)
+	}
+}
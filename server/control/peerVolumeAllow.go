@@ -17,6 +17,8 @@ func (c controlRPC) PeerVolumeAllow(ctx context.Context, req *wire.PeerVolumeAll
 		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
 	}
 
+	var volID db.VolumeID
+	var wasRevoked bool
 	allowVolume := func(tx *db.Tx) error {
 		p, err := tx.Peers().Get(&pub)
 		if err != nil {
@@ -26,6 +28,10 @@ func (c controlRPC) PeerVolumeAllow(ctx context.Context, req *wire.PeerVolumeAll
 		if err != nil {
 			return err
 		}
+		v.VolumeID(&volID)
+		if _, wasRevoked, err = v.Revocations().IsRevoked(&pub); err != nil {
+			return err
+		}
 		return p.Volumes().Allow(v)
 	}
 	if err := c.app.DB.Update(allowVolume); err != nil {
@@ -35,5 +41,13 @@ func (c controlRPC) PeerVolumeAllow(ctx context.Context, req *wire.PeerVolumeAll
 		log.Printf("db error: allowing peer volume: %v", err)
 		return nil, grpc.Errorf(codes.Internal, "database error")
 	}
+	if wasRevoked {
+		// the peer stopped syncing when it was told about the
+		// revocation, tell it to start again
+		if err := c.app.TellVolumeRevoked(&pub, &volID, true); err != nil {
+			log.Printf("db error: queueing volume restore: %v", err)
+			return nil, grpc.Errorf(codes.Internal, "database error")
+		}
+	}
 	return &wire.PeerVolumeAllowResponse{}, nil
 }
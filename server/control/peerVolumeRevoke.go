@@ -0,0 +1,63 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) PeerVolumeRevoke(ctx context.Context, req *wire.PeerVolumeRevokeRequest) (*wire.PeerVolumeRevokeResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+
+	var volID db.VolumeID
+	groupAccess := false
+	revokeVolume := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(&pub)
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		v.VolumeID(&volID)
+		if err := p.Volumes().Revoke(v); err != nil {
+			return err
+		}
+		groupAccess = p.Volumes().IsAllowed(v)
+		return nil
+	}
+	if err := c.app.DB.Update(revokeVolume); err != nil {
+		switch err {
+		case db.ErrPeerNotFound, db.ErrVolNameNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: revoking peer volume: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	if groupAccess {
+		// the peer keeps syncing the volume, telling it otherwise
+		// would be wrong
+		return &wire.PeerVolumeRevokeResponse{GroupAccess: true}, nil
+	}
+	if err := c.app.TellVolumeRevoked(&pub, &volID, false); err != nil {
+		log.Printf("db error: queueing volume revocation: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	resp := &wire.PeerVolumeRevokeResponse{}
+	// The message stays in the outbox if the peer cannot be reached
+	// now, and is delivered along with later messages.
+	if err := c.app.DeliverMessages(ctx, &pub); err == nil {
+		resp.Delivered = true
+	}
+	return resp, nil
+}
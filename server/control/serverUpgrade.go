@@ -0,0 +1,28 @@
+package control
+
+import (
+	"path/filepath"
+
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) ServerUpgrade(ctx context.Context, req *wire.ServerUpgradeRequest) (*wire.ServerUpgradeResponse, error) {
+	if !filepath.IsAbs(req.Executable) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "executable path must be absolute")
+	}
+	unmounted, err := c.app.Upgrade(req.Executable)
+	if err != nil {
+		if err == server.ErrDraining {
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, grpc.Errorf(codes.Aborted, "%v", err)
+	}
+	resp := &wire.ServerUpgradeResponse{
+		Unmounted: unmounted,
+	}
+	return resp, nil
+}
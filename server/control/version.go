@@ -0,0 +1,20 @@
+package control
+
+import (
+	"runtime"
+
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/version"
+	"golang.org/x/net/context"
+)
+
+func (c controlRPC) Version(ctx context.Context, req *wire.VersionRequest) (*wire.VersionResponse, error) {
+	resp := &wire.VersionResponse{
+		Version:   version.Version,
+		GoVersion: runtime.Version(),
+		Os:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Features:  version.Features,
+	}
+	return resp, nil
+}
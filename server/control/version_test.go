@@ -0,0 +1,84 @@
+package control_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/controltest"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/util/grpcunix"
+	"bazil.org/bazil/util/tempdir"
+	"bazil.org/bazil/version"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+func TestVersion(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := server.New(tmp.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctrl := controltest.ListenAndServe(t, &wg, app)
+	defer ctrl.Close()
+
+	rpcConn, err := grpcunix.Dial(filepath.Join(app.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+
+	ctx := context.Background()
+	resp, err := rpcClient.Version(ctx, &wire.VersionRequest{})
+	if err != nil {
+		t.Fatalf("getting version failed: %v", err)
+	}
+	if g, e := resp.Version, version.Version; g != e {
+		t.Errorf("wrong version: %q != %q", g, e)
+	}
+	if g, e := resp.Features, version.Features; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong features: %q != %q", g, e)
+	}
+}
+
+func TestServerUpgradeRelative(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := server.New(tmp.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctrl := controltest.ListenAndServe(t, &wg, app)
+	defer ctrl.Close()
+
+	rpcConn, err := grpcunix.Dial(filepath.Join(app.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+
+	ctx := context.Background()
+	_, err = rpcClient.ServerUpgrade(ctx, &wire.ServerUpgradeRequest{
+		Executable: "bazil",
+	})
+	if err := checkRPCError(err, codes.InvalidArgument, "executable path must be absolute"); err != nil {
+		t.Error(err)
+	}
+	select {
+	case exe := <-app.UpgradeRequested():
+		t.Errorf("upgrade requested after failure: %q", exe)
+	default:
+	}
+}
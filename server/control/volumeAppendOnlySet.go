@@ -0,0 +1,34 @@
+package control
+
+import (
+	"syscall"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) VolumeAppendOnlySet(ctx context.Context, req *wire.VolumeAppendOnlySetRequest) (*wire.VolumeAppendOnlySetResponse, error) {
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	if err := ref.FS().SetAppendOnly(ctx, req.Path, !req.Off); err != nil {
+		switch err {
+		case fuse.ENOENT:
+			return nil, grpc.Errorf(codes.NotFound, "no such file or directory")
+		case fuse.Errno(syscall.ENOTDIR):
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, err
+	}
+	return &wire.VolumeAppendOnlySetResponse{}, nil
+}
@@ -0,0 +1,96 @@
+package control
+
+import (
+	"log"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// How often a followed journal is checked for new changes.
+const changesPollInterval = time.Second
+
+func (c controlRPC) VolumeChanges(req *wire.VolumeChangesRequest, stream wire.Control_VolumeChangesServer) error {
+	// Read the journal in batches, to avoid holding a transaction
+	// open while waiting for a slow consumer.
+	const maxBatch = 1000
+	after := req.After
+	for {
+		var batch []*wire.VolumeChange
+		list := func(tx *db.Tx) error {
+			vol, err := tx.Volumes().GetByName(req.VolumeName)
+			if err != nil {
+				return err
+			}
+			cursor, err := vol.Journal().List(after)
+			if err != nil {
+				return err
+			}
+			for e := cursor.First(); e != nil && len(batch) < maxBatch; e = cursor.Next() {
+				msg := &wire.VolumeChange{
+					Seq:         e.Seq,
+					Tx:          e.Tx,
+					ParentInode: e.ParentInode,
+					Name:        e.Name,
+				}
+				switch e.Op {
+				case db.JournalPut:
+					msg.Op = wire.VolumeChange_PUT
+				case db.JournalDelete:
+					msg.Op = wire.VolumeChange_DELETE
+				case db.JournalTombstone:
+					msg.Op = wire.VolumeChange_TOMBSTONE
+				case db.JournalClock:
+					msg.Op = wire.VolumeChange_CLOCK
+				}
+				if e.Clock != nil {
+					buf, err := e.Clock.MarshalBinary()
+					if err != nil {
+						return err
+					}
+					msg.Clock = buf
+				}
+				if de := e.Dirent; de != nil {
+					msg.Inode = de.Inode
+					if de.File != nil {
+						msg.Manifest = de.File.Manifest
+					}
+					msg.Dir = de.Dir != nil
+				}
+				batch = append(batch, msg)
+			}
+			return nil
+		}
+		if err := c.app.DB.View(list); err != nil {
+			switch err {
+			case db.ErrVolNameNotFound:
+				return grpc.Errorf(codes.InvalidArgument, "%v", err)
+			case db.ErrJournalTruncated:
+				return grpc.Errorf(codes.OutOfRange, "%v", err)
+			}
+			log.Printf("db error: listing volume changes: %v", err)
+			return grpc.Errorf(codes.Internal, "database error")
+		}
+
+		for _, msg := range batch {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+			after = msg.Seq
+		}
+		if len(batch) == maxBatch {
+			continue
+		}
+		if !req.Follow {
+			return nil
+		}
+		select {
+		case <-time.After(changesPollInterval):
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package control
+
+import (
+	"syscall"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumeCommit writes or removes several files in one go, so that
+// snapshots and syncs never see only some of the changes.
+func (c controlRPC) VolumeCommit(ctx context.Context, req *wire.VolumeCommitRequest) (*wire.VolumeCommitResponse, error) {
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	changes := make([]fs.Change, 0, len(req.Changes))
+	for _, ch := range req.Changes {
+		changes = append(changes, fs.Change{
+			Path:   ch.Path,
+			Data:   ch.Data,
+			Remove: ch.Remove,
+		})
+	}
+	if err := ref.FS().Commit(ctx, changes); err != nil {
+		switch err {
+		case fuse.ENOENT:
+			return nil, grpc.Errorf(codes.NotFound, "no such file or directory")
+		case fuse.EPERM:
+			return nil, grpc.Errorf(codes.PermissionDenied, "path is reserved")
+		case fuse.Errno(syscall.EISDIR), fuse.Errno(syscall.ENOTDIR):
+			return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		case fuse.Errno(syscall.EBUSY):
+			return nil, grpc.Errorf(codes.Aborted, "file has unsaved writes")
+		}
+		return nil, err
+	}
+	return &wire.VolumeCommitResponse{}, nil
+}
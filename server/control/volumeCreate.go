@@ -1,6 +1,7 @@
 package control
 
 import (
+	"bazil.org/bazil/cas"
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/server/control/wire"
 	"golang.org/x/net/context"
@@ -9,14 +10,26 @@ import (
 )
 
 func (c controlRPC) VolumeCreate(ctx context.Context, req *wire.VolumeCreateRequest) (*wire.VolumeCreateResponse, error) {
+	var hash cas.Hash
+	if req.Hash != "" {
+		if err := hash.Set(req.Hash); err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+	}
 	volumeCreate := func(tx *db.Tx) error {
 		sharingKey, err := tx.SharingKeys().Get(req.SharingKeyName)
 		if err != nil {
 			return err
 		}
-		if _, err := tx.Volumes().Create(req.VolumeName, req.Backend, sharingKey); err != nil {
+		vol, err := tx.Volumes().Create(req.VolumeName, req.Backend, sharingKey)
+		if err != nil {
 			return err
 		}
+		if hash != cas.HashBLAKE2b {
+			if err := vol.SetHash(hash); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 	if err := c.app.DB.Update(volumeCreate); err != nil {
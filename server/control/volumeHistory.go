@@ -0,0 +1,72 @@
+package control
+
+import (
+	"syscall"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// historyError translates errors from looking up file versions.
+func historyError(err error) error {
+	switch err {
+	case fuse.ENOENT:
+		return grpc.Errorf(codes.NotFound, "no such file or directory")
+	case db.ErrFileVersionNotFound:
+		return grpc.Errorf(codes.NotFound, "%v", err)
+	case fuse.EPERM:
+		return grpc.Errorf(codes.PermissionDenied, "path is reserved")
+	case fuse.Errno(syscall.EISDIR), fuse.Errno(syscall.ENOTDIR):
+		return grpc.Errorf(codes.FailedPrecondition, "%v", err)
+	case fuse.Errno(syscall.EBUSY):
+		return grpc.Errorf(codes.Aborted, "file has unsaved writes")
+	}
+	return err
+}
+
+func (c controlRPC) VolumeHistory(ctx context.Context, req *wire.VolumeHistoryRequest) (*wire.VolumeHistoryResponse, error) {
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	resp := &wire.VolumeHistoryResponse{}
+	add := func(fv *db.FileVersion) error {
+		resp.Versions = append(resp.Versions, &wire.FileVersion{
+			Version: fv.Time.UnixNano(),
+			Size:    fv.Manifest.Size,
+		})
+		return nil
+	}
+	if err := ref.FS().History(req.Path, add); err != nil {
+		return nil, historyError(err)
+	}
+	return resp, nil
+}
+
+// VolumeRestore brings back an earlier version of a file, as listed
+// by VolumeHistory.
+func (c controlRPC) VolumeRestore(ctx context.Context, req *wire.VolumeRestoreRequest) (*wire.VolumeRestoreResponse, error) {
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	if err := ref.FS().Restore(ctx, req.Path, time.Unix(0, req.Version)); err != nil {
+		return nil, historyError(err)
+	}
+	return &wire.VolumeRestoreResponse{}, nil
+}
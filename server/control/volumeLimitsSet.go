@@ -0,0 +1,28 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	wiredb "bazil.org/bazil/db/wire"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) VolumeLimitsSet(ctx context.Context, req *wire.VolumeLimitsSetRequest) (*wire.VolumeLimitsSetResponse, error) {
+	limits := &wiredb.VolumeLimits{
+		MaxFileSize:  req.MaxFileSize,
+		MaxNameBytes: req.MaxNameBytes,
+		MaxDepth:     req.MaxDepth,
+	}
+	if err := c.app.SetVolumeLimits(req.VolumeName, limits); err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: setting volume limits: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return &wire.VolumeLimitsSetResponse{}, nil
+}
@@ -1,17 +1,76 @@
 package control
 
 import (
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs"
+	"bazil.org/bazil/server"
 	"bazil.org/bazil/server/control/wire"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
+func accessFromWire(a wire.VolumeMountRequest_Access) (fs.Access, error) {
+	switch a {
+	case wire.VolumeMountRequest_NONE:
+		return fs.AccessNone, nil
+	case wire.VolumeMountRequest_READ:
+		return fs.AccessRead, nil
+	case wire.VolumeMountRequest_OWNER:
+		return fs.AccessOwner, nil
+	}
+	return fs.AccessNone, grpc.Errorf(codes.InvalidArgument, "unknown access level: %v", a)
+}
+
 func (c controlRPC) VolumeMount(ctx context.Context, req *wire.VolumeMountRequest) (*wire.VolumeMountResponse, error) {
+	if req.Snapshot != "" {
+		return c.snapshotMount(ctx, req)
+	}
+	var options []server.MountOption
+	if req.AllowOther {
+		others, err := accessFromWire(req.Others)
+		if err != nil {
+			return nil, err
+		}
+		access := &fs.UserAccess{
+			Others: others,
+			Users:  make(map[uint32]fs.Access, len(req.Users)),
+		}
+		for uid, a := range req.Users {
+			access.Users[uid], err = accessFromWire(a)
+			if err != nil {
+				return nil, err
+			}
+		}
+		options = append(options, server.AllowOther(access))
+	} else if req.Others != wire.VolumeMountRequest_NONE || len(req.Users) > 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "access for other users needs allowOther")
+	}
+
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		return nil, err
+	}
+	defer ref.Close()
+	if err := ref.Mount(req.Mountpoint, options...); err != nil {
+		return nil, err
+	}
+	return &wire.VolumeMountResponse{}, nil
+}
+
+func (c controlRPC) snapshotMount(ctx context.Context, req *wire.VolumeMountRequest) (*wire.VolumeMountResponse, error) {
+	if req.AllowOther || req.Others != wire.VolumeMountRequest_NONE || len(req.Users) > 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "snapshots cannot be mounted for other users")
+	}
 	ref, err := c.app.GetVolumeByName(req.VolumeName)
 	if err != nil {
 		return nil, err
 	}
 	defer ref.Close()
-	if err := ref.Mount(req.Mountpoint); err != nil {
+	if err := ref.MountSnapshot(ctx, req.Snapshot, req.Mountpoint); err != nil {
+		if err == db.ErrSnapshotNotFound {
+			return nil, grpc.Errorf(codes.NotFound, "%v", err)
+		}
 		return nil, err
 	}
 	return &wire.VolumeMountResponse{}, nil
@@ -0,0 +1,58 @@
+package control
+
+import (
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumePause holds back FUSE requests to the volume for the given
+// duration, or until the client goes away, for maintenance done
+// outside of the server. The server's maximum pause still applies.
+func (c controlRPC) VolumePause(ctx context.Context, req *wire.VolumePauseRequest) (*wire.VolumePauseResponse, error) {
+	if req.Duration <= 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "pause duration must be positive")
+	}
+
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	resume, err := ref.FS().Pause(ctx)
+	if err != nil {
+		return nil, pauseError(err)
+	}
+	defer resume()
+
+	t := time.NewTimer(time.Duration(req.Duration))
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+		return nil, grpc.Errorf(codes.Canceled, "%v", ctx.Err())
+	}
+	return &wire.VolumePauseResponse{}, nil
+}
+
+// pauseError converts an error from fs.Volume.Pause.
+func pauseError(err error) error {
+	switch err {
+	case fs.ErrPaused:
+		return grpc.Errorf(codes.FailedPrecondition, "%v", err)
+	case fs.ErrPauseExpired:
+		return grpc.Errorf(codes.Unavailable, "%v", err)
+	case context.Canceled, context.DeadlineExceeded:
+		return grpc.Errorf(codes.Canceled, "%v", err)
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package control
+
+import (
+	"syscall"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumePermRewrite changes permissions of a whole directory tree at
+// once, streaming progress as it goes. The change is only visible
+// once the last message, with Done set, has been sent.
+func (c controlRPC) VolumePermRewrite(req *wire.VolumePermRewriteRequest, stream wire.Control_VolumePermRewriteServer) error {
+	if req.FilesOnly && req.DirsOnly {
+		return grpc.Errorf(codes.InvalidArgument, "files only and directories only are exclusive")
+	}
+	const modeBits = 07777
+	if req.ClearMode&^modeBits != 0 || req.SetMode&^modeBits != 0 {
+		return grpc.Errorf(codes.InvalidArgument, "invalid permission bits")
+	}
+
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return err
+	}
+	defer ref.Close()
+
+	change := &fs.PermChange{
+		ClearMode: req.ClearMode,
+		SetMode:   req.SetMode,
+		FilesOnly: req.FilesOnly,
+		DirsOnly:  req.DirsOnly,
+	}
+	if req.ChangeOwner {
+		change.UID = &req.Uid
+	}
+	if req.ChangeGroup {
+		change.GID = &req.Gid
+	}
+	undoName := req.UndoName
+	if undoName == "" {
+		undoName = time.Now().UTC().Format("20060102T150405.000000000Z")
+	}
+
+	// a failed send cancels the rewrite, rolling it back
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	var sendErr error
+	progress := func(seen, changed uint64) {
+		if sendErr != nil {
+			return
+		}
+		msg := &wire.VolumePermRewriteProgress{
+			Seen:    seen,
+			Changed: changed,
+		}
+		if sendErr = stream.Send(msg); sendErr != nil {
+			cancel()
+		}
+	}
+	changed, err := ref.FS().RewritePerms(ctx, req.Path, change, undoName, progress)
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		switch err {
+		case fuse.ENOENT:
+			return grpc.Errorf(codes.NotFound, "no such file or directory")
+		case fuse.Errno(syscall.ENOTDIR):
+			return grpc.Errorf(codes.FailedPrecondition, "%v", err)
+		case db.ErrPermUndoExist, db.ErrPermUndoNameInvalid:
+			return grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return pauseError(err)
+	}
+	msg := &wire.VolumePermRewriteProgress{
+		Changed:  changed,
+		Done:     true,
+		UndoName: undoName,
+	}
+	return stream.Send(msg)
+}
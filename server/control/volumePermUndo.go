@@ -0,0 +1,29 @@
+package control
+
+import (
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) VolumePermUndo(ctx context.Context, req *wire.VolumePermUndoRequest) (*wire.VolumePermUndoResponse, error) {
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	restored, err := ref.FS().UndoPerms(ctx, req.UndoName)
+	if err != nil {
+		if err == db.ErrPermUndoNotFound {
+			return nil, grpc.Errorf(codes.NotFound, "%v", err)
+		}
+		return nil, pauseError(err)
+	}
+	return &wire.VolumePermUndoResponse{Restored: restored}, nil
+}
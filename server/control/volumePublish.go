@@ -0,0 +1,40 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) VolumePublish(ctx context.Context, req *wire.VolumePublishRequest) (*wire.VolumePublishResponse, error) {
+	resp := &wire.VolumePublishResponse{
+		Publisher: c.app.Keys.Sign.Pub[:],
+	}
+	publish := func(tx *db.Tx) error {
+		vol, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		if req.Off {
+			return vol.Unpublish()
+		}
+		id, err := vol.Publish()
+		if err != nil {
+			return err
+		}
+		resp.PublicID = id[:]
+		return nil
+	}
+	if err := c.app.DB.Update(publish); err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: publishing volume: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
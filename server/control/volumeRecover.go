@@ -0,0 +1,102 @@
+package control
+
+import (
+	"path"
+
+	"bazil.org/bazil/db"
+	wirefs "bazil.org/bazil/fs/wire"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/tokens"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumeRecover rebuilds a volume that was lost locally from a copy
+// held by a peer. The volume is connected like in VolumeConnect, and
+// then every directory is pulled from the peer, top down.
+//
+// Snapshots are not recovered, as peers do not share them.
+func (c controlRPC) VolumeRecover(ctx context.Context, req *wire.VolumeRecoverRequest) (*wire.VolumeRecoverResponse, error) {
+	var pub peer.PublicKey
+	if err := pub.UnmarshalBinary(req.Pub); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	}
+	backend := req.Backend
+	if backend == "" {
+		backend = "peerkey:" + pub.String()
+	}
+	connReq := &wire.VolumeConnectRequest{
+		Pub:             req.Pub,
+		VolumeName:      req.VolumeName,
+		LocalVolumeName: req.LocalVolumeName,
+		Backend:         backend,
+		SharingKeyName:  req.SharingKeyName,
+	}
+	if _, err := c.VolumeConnect(ctx, connReq); err != nil {
+		return nil, err
+	}
+
+	var volID db.VolumeID
+	loadVolume := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName(req.LocalVolumeName)
+		if err != nil {
+			return err
+		}
+		v.VolumeID(&volID)
+		return nil
+	}
+	if err := c.app.DB.View(loadVolume); err != nil {
+		return nil, err
+	}
+
+	ref, err := c.app.GetVolume(&volID)
+	if err != nil {
+		return nil, err
+	}
+	defer ref.Close()
+
+	type pending struct {
+		path  string
+		inode uint64
+	}
+	queue := []pending{{path: "", inode: tokens.InodeRoot}}
+	resp := &wire.VolumeRecoverResponse{}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		n, err := c.syncFromPeer(ctx, ref, &volID, &pub, dir.path)
+		if err != nil {
+			return nil, err
+		}
+		resp.Dirs++
+		resp.Dirents += n
+
+		findSubdirs := func(tx *db.Tx) error {
+			v, err := tx.Volumes().GetByVolumeID(&volID)
+			if err != nil {
+				return err
+			}
+			cur := v.Dirs().List(dir.inode)
+			for item := cur.First(); item != nil; item = cur.Next() {
+				var de wirefs.Dirent
+				if err := item.Unmarshal(&de); err != nil {
+					return err
+				}
+				if de.Dir == nil {
+					continue
+				}
+				queue = append(queue, pending{
+					path:  path.Join(dir.path, item.Name()),
+					inode: de.Inode,
+				})
+			}
+			return nil
+		}
+		if err := c.app.DB.View(findSubdirs); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
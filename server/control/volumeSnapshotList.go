@@ -0,0 +1,47 @@
+package control
+
+import (
+	"errors"
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) VolumeSnapshotList(ctx context.Context, req *wire.VolumeSnapshotListRequest) (*wire.VolumeSnapshotListResponse, error) {
+	resp := &wire.VolumeSnapshotListResponse{}
+	list := func(tx *db.Tx) error {
+		vol, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		b := vol.SnapBucket()
+		if b == nil {
+			return errors.New("snapshot bucket missing")
+		}
+		sc := vol.SnapshotChunks()
+		cur := b.Cursor()
+		for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+			name := string(k)
+			usage, ok := sc.Usage(name)
+			resp.Snapshots = append(resp.Snapshots, &wire.VolumeSnapshot{
+				Name:           name,
+				UsageKnown:     ok,
+				TotalBytes:     usage.Total,
+				ExclusiveBytes: usage.Exclusive,
+			})
+		}
+		return nil
+	}
+	if err := c.app.StatsView(list); err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: listing snapshots: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
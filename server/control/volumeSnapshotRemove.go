@@ -0,0 +1,55 @@
+package control
+
+import (
+	"errors"
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// errDryRun aborts the transaction of a dry run, after the impact of
+// the removal has been computed.
+var errDryRun = errors.New("dry run")
+
+// VolumeSnapshotRemove removes snapshots, reporting how much storage
+// only they referred to. With DryRun, nothing is removed.
+func (c controlRPC) VolumeSnapshotRemove(ctx context.Context, req *wire.VolumeSnapshotRemoveRequest) (*wire.VolumeSnapshotRemoveResponse, error) {
+	if len(req.Names) == 0 {
+		return nil, grpc.Errorf(codes.InvalidArgument, "no snapshots to remove")
+	}
+	resp := &wire.VolumeSnapshotRemoveResponse{}
+	remove := func(tx *db.Tx) error {
+		vol, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		freed, ok := vol.SnapshotChunks().Freed(req.Names)
+		resp.TotalBytes = freed.Total
+		resp.ExclusiveBytes = freed.Exclusive
+		resp.UsageKnown = ok
+		for _, name := range req.Names {
+			if err := vol.RemoveSnapshot(name); err != nil {
+				return err
+			}
+		}
+		if req.DryRun {
+			return errDryRun
+		}
+		return nil
+	}
+	if err := c.app.DB.Update(remove); err != nil && err != errDryRun {
+		switch err {
+		case db.ErrVolNameNotFound:
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		case db.ErrSnapshotNotFound:
+			return nil, grpc.Errorf(codes.NotFound, "%v", err)
+		}
+		log.Printf("db error: removing snapshots: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
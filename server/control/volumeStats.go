@@ -0,0 +1,37 @@
+package control
+
+import (
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (c controlRPC) VolumeStats(ctx context.Context, req *wire.VolumeStatsRequest) (*wire.VolumeStatsResponse, error) {
+	ref, err := c.app.GetVolumeByName(req.VolumeName)
+	if err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return nil, err
+	}
+	defer ref.Close()
+
+	stats := ref.FS().HandleStats()
+	writes := ref.FS().WriteStats()
+	pause := ref.FS().PauseStats()
+	resp := &wire.VolumeStatsResponse{
+		OpenHandles:     stats.Open,
+		HandleLimit:     stats.Limit,
+		PeakHandles:     stats.Peak,
+		RefusedHandles:  stats.Refused,
+		PendingWrites:   writes.Pending,
+		ThrottledWrites: writes.Throttled,
+		WriteLatency:    int64(writes.Latency),
+		Paused:          pause.Paused,
+		QueuedRequests:  pause.Queued,
+		ExpiredPauses:   pause.Expired,
+	}
+	return resp, nil
+}
@@ -2,11 +2,17 @@ package control
 
 import (
 	"io"
+	"log"
+	"math"
+	"sync"
+	"time"
 
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/peer"
 	wirepeer "bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server"
 	"bazil.org/bazil/server/control/wire"
+	"github.com/golang/protobuf/proto"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -29,67 +35,192 @@ func (c controlRPC) VolumeSync(ctx context.Context, req *wire.VolumeSyncRequest)
 		return nil, err
 	}
 
-	var pub peer.PublicKey
-	if err := pub.UnmarshalBinary(req.Pub); err != nil {
-		return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+	var pubs []peer.PublicKey
+	rawPubs := req.Pubs
+	if req.Pub != nil || len(rawPubs) == 0 {
+		rawPubs = append([][]byte{req.Pub}, rawPubs...)
+	}
+	seen := make(map[peer.PublicKey]struct{}, len(rawPubs))
+	for _, buf := range rawPubs {
+		var pub peer.PublicKey
+		if err := pub.UnmarshalBinary(buf); err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "bad peer public key: %v", err)
+		}
+		if _, dup := seen[pub]; dup {
+			continue
+		}
+		seen[pub] = struct{}{}
+		pubs = append(pubs, pub)
 	}
 
-	client, err := c.app.DialPeer(&pub)
+	ref, err := c.app.GetVolume(&volID)
 	if err != nil {
 		return nil, err
 	}
+	defer ref.Close()
+
+	if len(pubs) == 1 {
+		// keep reporting errors directly, when there is only one
+		// peer to blame
+		n, err := c.syncFromPeer(ctx, ref, &volID, &pubs[0], req.Path)
+		if err != nil {
+			return nil, err
+		}
+		resp := &wire.VolumeSyncResponse{
+			Results: []*wire.VolumeSyncResult{
+				{Pub: pubs[0][:], Dirents: n},
+			},
+		}
+		return resp, nil
+	}
+
+	// Pull from all peers at once. Each batch of changes is applied in
+	// its own transaction, and the vector clocks resolve the order
+	// between peers, so a slow or failing peer does not hold back the
+	// others.
+	results := make([]*wire.VolumeSyncResult, len(pubs))
+	var wg sync.WaitGroup
+	for i := range pubs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			pub := &pubs[i]
+			res := &wire.VolumeSyncResult{
+				Pub: pub[:],
+			}
+			n, err := c.syncFromPeer(ctx, ref, &volID, pub, req.Path)
+			res.Dirents = n
+			if err != nil {
+				res.Error = err.Error()
+			}
+			results[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	ok := false
+	for _, res := range results {
+		if res.Error == "" {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		return nil, grpc.Errorf(codes.Unavailable, "sync failed with every peer, first error: %v", results[0].Error)
+	}
+
+	resp := &wire.VolumeSyncResponse{
+		Results: results,
+	}
+	return resp, nil
+}
+
+// syncFromPeer pulls changes to path from one peer, and returns the
+// number of directory entries received.
+func (c controlRPC) syncFromPeer(ctx context.Context, ref *server.VolumeRef, volID *db.VolumeID, pub *peer.PublicKey, path string) (uint64, error) {
+	var since time.Time
+	var revoked bool
+	checkRevoked := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByVolumeID(volID)
+		if err != nil {
+			return err
+		}
+		since, revoked, err = v.Revocations().IsRevoked(pub)
+		return err
+	}
+	if err := c.app.DB.View(checkRevoked); err != nil {
+		return 0, err
+	}
+	if revoked {
+		return 0, grpc.Errorf(codes.FailedPrecondition, "volume is no longer shared with peer, since %v", since)
+	}
+
+	client, err := c.app.DialPeer(pub)
+	if err != nil {
+		return 0, err
+	}
 	defer client.Close()
 	volIDBuf, err := volID.MarshalBinary()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
 	peerReq := &wirepeer.VolumeSyncPullRequest{
 		VolumeID: volIDBuf,
-		Path:     req.Path,
+		Path:     path,
 	}
 	stream, err := client.VolumeSyncPull(ctx, peerReq)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
 	first, err := stream.Recv()
 	if err != nil && err != io.EOF {
-		return nil, err
+		return 0, err
 	}
+	c.app.CountTraffic(pub, volID, 0, uint64(proto.Size(first)))
 
 	switch first.Error {
 	case wirepeer.VolumeSyncPullItem_SUCCESS:
 		// nothing
 	case wirepeer.VolumeSyncPullItem_NOT_A_DIRECTORY:
 		// TODO maybe we should handle the path not being a dir, somehow
-		return nil, grpc.Errorf(codes.FailedPrecondition, "path to sync is not a directory")
+		return 0, grpc.Errorf(codes.FailedPrecondition, "path to sync is not a directory")
 	default:
-		return nil, grpc.Errorf(codes.FailedPrecondition, "peer gave error: %v", first.Error.String())
+		return 0, grpc.Errorf(codes.FailedPrecondition, "peer gave error: %v", first.Error.String())
+	}
+
+	peers, err := peerIDs(first)
+	if err != nil {
+		return 0, err
 	}
 
+	var received uint64
 	recv := func() ([]*wirepeer.Dirent, error) {
 		if first.Children != nil {
 			tmp := first.Children
 			first.Children = nil
+			received += uint64(len(tmp))
 			return tmp, nil
 		}
 		item, err := stream.Recv()
 		if err != nil {
 			return nil, err
 		}
+		c.app.CountTraffic(pub, volID, 0, uint64(proto.Size(item)))
+		received += uint64(len(item.Children))
 		return item.Children, nil
 	}
 
-	ref, err := c.app.GetVolume(&volID)
-	if err != nil {
-		return nil, err
+	if err := ref.FS().SyncReceive(ctx, path, peers, first.DirClock, recv); err != nil {
+		return received, err
 	}
-	defer ref.Close()
-
-	if err := ref.FS().SyncReceive(ctx, req.Path, first.Peers, first.DirClock, recv); err != nil {
-		return nil, err
+	if first.PullID != 0 {
+		// lets sync barriers on the peer count us as caught up
+		done := &wirepeer.VolumeSyncPullDoneRequest{
+			VolumeID: volIDBuf,
+			PullID:   first.PullID,
+		}
+		if _, err := client.VolumeSyncPullDone(ctx, done); err != nil {
+			log.Printf("confirming sync with peer %v: %v", pub, err)
+		}
 	}
+	return received, nil
+}
 
-	return &wire.VolumeSyncResponse{}, nil
+// peerIDs returns the peer identifier mapping sent by the peer,
+// preferring the 64-bit map when the peer sends one.
+func peerIDs(item *wirepeer.VolumeSyncPullItem) (map[uint32][]byte, error) {
+	if len(item.Peers64) == 0 {
+		return item.Peers, nil
+	}
+	peers := make(map[uint32][]byte, len(item.Peers64))
+	for id, pub := range item.Peers64 {
+		if id > math.MaxUint32 {
+			// clocks only have room for 32-bit peer identifiers
+			return nil, grpc.Errorf(codes.Unimplemented, "peer identifier too large: %d", id)
+		}
+		peers[uint32(id)] = pub
+	}
+	return peers, nil
 }
@@ -0,0 +1,52 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumeSyncBarrier blocks until enough peers have pulled everything
+// written to the volume before the call. The deadline of the call
+// limits how long to wait.
+func (c controlRPC) VolumeSyncBarrier(ctx context.Context, req *wire.VolumeSyncBarrierRequest) (*wire.VolumeSyncBarrierResponse, error) {
+	var volID db.VolumeID
+	loadVolume := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		v.VolumeID(&volID)
+		return nil
+	}
+	if err := c.app.DB.View(loadVolume); err != nil {
+		if err == db.ErrVolNameNotFound {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: loading volume: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+
+	pubs, err := c.app.SyncBarrier(ctx, &volID, int(req.Peers))
+	switch err {
+	case nil:
+	case server.ErrNotEnoughPeers:
+		return nil, grpc.Errorf(codes.FailedPrecondition, "%v", err)
+	case context.DeadlineExceeded:
+		return nil, grpc.Errorf(codes.DeadlineExceeded, "only %d peers caught up", len(pubs))
+	case context.Canceled:
+		return nil, grpc.Errorf(codes.Canceled, "%v", err)
+	default:
+		return nil, err
+	}
+	resp := &wire.VolumeSyncBarrierResponse{}
+	for i := range pubs {
+		resp.Peers = append(resp.Peers, pubs[i][:])
+	}
+	return resp, nil
+}
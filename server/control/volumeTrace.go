@@ -0,0 +1,69 @@
+package control
+
+import (
+	"log"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/control/wire"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumeTrace streams sampled FUSE requests to the volume, until the
+// client goes away.
+func (c controlRPC) VolumeTrace(req *wire.VolumeTraceRequest, stream wire.Control_VolumeTraceServer) error {
+	var volID db.VolumeID
+	loadVolume := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName(req.VolumeName)
+		if err != nil {
+			return err
+		}
+		v.VolumeID(&volID)
+		return nil
+	}
+	if err := c.app.DB.View(loadVolume); err != nil {
+		if err == db.ErrVolNameNotFound {
+			return grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		log.Printf("db error: loading volume: %v", err)
+		return grpc.Errorf(codes.Internal, "database error")
+	}
+
+	config := server.TraceConfig{
+		SampleEvery:  req.SampleEvery,
+		MaxPerSecond: req.MaxPerSecond,
+	}
+	events, stop := c.app.Trace(&volID, config)
+	defer stop()
+	ctx := stream.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-events:
+			msg := &wire.VolumeTraceEvent{
+				Start:     ev.Start.UnixNano(),
+				Request:   ev.Request,
+				Duration:  int64(ev.Duration),
+				KvOmitted: ev.KVOmitted,
+				Dropped:   ev.Dropped,
+			}
+			for _, call := range ev.KV {
+				kc := &wire.VolumeTraceKVCall{
+					Op:       call.Op,
+					Key:      call.Key,
+					Size:     uint64(call.Size),
+					Duration: int64(call.Duration),
+				}
+				if call.Err != nil {
+					kc.Error = call.Err.Error()
+				}
+				msg.Kv = append(msg.Kv, kc)
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
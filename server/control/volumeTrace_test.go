@@ -0,0 +1,80 @@
+package control_test
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/server/control/controltest"
+	"bazil.org/bazil/server/control/wire"
+	"bazil.org/bazil/util/grpcunix"
+	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
+)
+
+func TestVolumeTrace(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app := bazfstestutil.NewApp(t, tmp.Subdir("data"))
+	defer app.Close()
+	bazfstestutil.CreateVolume(t, app, "default")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctrl := controltest.ListenAndServe(t, &wg, app)
+	defer ctrl.Close()
+
+	mnt := bazfstestutil.Mounted(t, app, "default")
+	defer mnt.Close()
+
+	rpcConn, err := grpcunix.Dial(filepath.Join(app.DataDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpcConn.Close()
+	rpcClient := wire.NewControlClient(rpcConn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := rpcClient.VolumeTrace(ctx, &wire.VolumeTraceRequest{VolumeName: "default"})
+	if err != nil {
+		t.Fatalf("trace failed: %v", err)
+	}
+
+	// the trace starts some time after the call returns, keep
+	// making requests until they are seen
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			_, _ = os.Stat(path.Join(mnt.Dir, "tracer"))
+		}
+	}()
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("receiving trace failed: %v", err)
+		}
+		if !strings.Contains(msg.Request, "Lookup") || !strings.Contains(msg.Request, "tracer") {
+			continue
+		}
+		if msg.Start == 0 {
+			t.Errorf("event has no start time: %v", msg)
+		}
+		if msg.Duration <= 0 {
+			t.Errorf("event has no duration: %v", msg)
+		}
+		break
+	}
+}
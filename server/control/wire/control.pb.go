@@ -15,6 +15,10 @@ It is generated from these files:
 It has these top-level messages:
 	PingRequest
 	PingResponse
+	VersionRequest
+	VersionResponse
+	ServerUpgradeRequest
+	ServerUpgradeResponse
 */
 package wire
 
@@ -42,6 +46,45 @@ func (m *PingResponse) Reset()         { *m = PingResponse{} }
 func (m *PingResponse) String() string { return proto.CompactTextString(m) }
 func (*PingResponse) ProtoMessage()    {}
 
+type VersionRequest struct {
+}
+
+func (m *VersionRequest) Reset()         { *m = VersionRequest{} }
+func (m *VersionRequest) String() string { return proto.CompactTextString(m) }
+func (*VersionRequest) ProtoMessage()    {}
+
+type VersionResponse struct {
+	Version string `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+	// Version of the Go toolchain the server was built with.
+	GoVersion string `protobuf:"bytes,2,opt,name=goVersion" json:"goVersion,omitempty"`
+	Os        string `protobuf:"bytes,3,opt,name=os" json:"os,omitempty"`
+	Arch      string `protobuf:"bytes,4,opt,name=arch" json:"arch,omitempty"`
+	// Optional functionality the server supports, by name.
+	Features []string `protobuf:"bytes,5,rep,name=features" json:"features,omitempty"`
+}
+
+func (m *VersionResponse) Reset()         { *m = VersionResponse{} }
+func (m *VersionResponse) String() string { return proto.CompactTextString(m) }
+func (*VersionResponse) ProtoMessage()    {}
+
+type ServerUpgradeRequest struct {
+	// Absolute path of the new server executable.
+	Executable string `protobuf:"bytes,1,opt,name=executable" json:"executable,omitempty"`
+}
+
+func (m *ServerUpgradeRequest) Reset()         { *m = ServerUpgradeRequest{} }
+func (m *ServerUpgradeRequest) String() string { return proto.CompactTextString(m) }
+func (*ServerUpgradeRequest) ProtoMessage()    {}
+
+type ServerUpgradeResponse struct {
+	// Mountpoints that were unmounted to allow the upgrade.
+	Unmounted []string `protobuf:"bytes,1,rep,name=unmounted" json:"unmounted,omitempty"`
+}
+
+func (m *ServerUpgradeResponse) Reset()         { *m = ServerUpgradeResponse{} }
+func (m *ServerUpgradeResponse) String() string { return proto.CompactTextString(m) }
+func (*ServerUpgradeResponse) ProtoMessage()    {}
+
 // Reference imports to suppress errors if they are not otherwise used.
 var _ context.Context
 var _ grpc.ClientConn
@@ -61,6 +104,40 @@ type ControlClient interface {
 	PeerLocationSet(ctx context.Context, in *PeerLocationSetRequest, opts ...grpc.CallOption) (*PeerLocationSetResponse, error)
 	PeerStorageAllow(ctx context.Context, in *PeerStorageAllowRequest, opts ...grpc.CallOption) (*PeerStorageAllowResponse, error)
 	PeerVolumeAllow(ctx context.Context, in *PeerVolumeAllowRequest, opts ...grpc.CallOption) (*PeerVolumeAllowResponse, error)
+	PeerGroupAdd(ctx context.Context, in *PeerGroupAddRequest, opts ...grpc.CallOption) (*PeerGroupAddResponse, error)
+	PeerGroupRemove(ctx context.Context, in *PeerGroupRemoveRequest, opts ...grpc.CallOption) (*PeerGroupRemoveResponse, error)
+	PeerGroupStorageAllow(ctx context.Context, in *PeerGroupStorageAllowRequest, opts ...grpc.CallOption) (*PeerGroupStorageAllowResponse, error)
+	PeerGroupVolumeAllow(ctx context.Context, in *PeerGroupVolumeAllowRequest, opts ...grpc.CallOption) (*PeerGroupVolumeAllowResponse, error)
+	VolumeChanges(ctx context.Context, in *VolumeChangesRequest, opts ...grpc.CallOption) (Control_VolumeChangesClient, error)
+	VolumeStats(ctx context.Context, in *VolumeStatsRequest, opts ...grpc.CallOption) (*VolumeStatsResponse, error)
+	PeerMessageSend(ctx context.Context, in *PeerMessageSendRequest, opts ...grpc.CallOption) (*PeerMessageSendResponse, error)
+	PeerMessageList(ctx context.Context, in *PeerMessageListRequest, opts ...grpc.CallOption) (*PeerMessageListResponse, error)
+	VolumeRecover(ctx context.Context, in *VolumeRecoverRequest, opts ...grpc.CallOption) (*VolumeRecoverResponse, error)
+	PairInvite(ctx context.Context, in *PairInviteRequest, opts ...grpc.CallOption) (*PairInviteResponse, error)
+	PairJoin(ctx context.Context, in *PairJoinRequest, opts ...grpc.CallOption) (*PairJoinResponse, error)
+	VolumeCommit(ctx context.Context, in *VolumeCommitRequest, opts ...grpc.CallOption) (*VolumeCommitResponse, error)
+	VolumeSnapshotList(ctx context.Context, in *VolumeSnapshotListRequest, opts ...grpc.CallOption) (*VolumeSnapshotListResponse, error)
+	PeerTraffic(ctx context.Context, in *PeerTrafficRequest, opts ...grpc.CallOption) (*PeerTrafficResponse, error)
+	VolumeHistory(ctx context.Context, in *VolumeHistoryRequest, opts ...grpc.CallOption) (*VolumeHistoryResponse, error)
+	VolumeRestore(ctx context.Context, in *VolumeRestoreRequest, opts ...grpc.CallOption) (*VolumeRestoreResponse, error)
+	VolumeSnapshotRemove(ctx context.Context, in *VolumeSnapshotRemoveRequest, opts ...grpc.CallOption) (*VolumeSnapshotRemoveResponse, error)
+	PeerVolumeRevoke(ctx context.Context, in *PeerVolumeRevokeRequest, opts ...grpc.CallOption) (*PeerVolumeRevokeResponse, error)
+	Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error)
+	ServerUpgrade(ctx context.Context, in *ServerUpgradeRequest, opts ...grpc.CallOption) (*ServerUpgradeResponse, error)
+	VolumeLimitsSet(ctx context.Context, in *VolumeLimitsSetRequest, opts ...grpc.CallOption) (*VolumeLimitsSetResponse, error)
+	VolumeSyncBarrier(ctx context.Context, in *VolumeSyncBarrierRequest, opts ...grpc.CallOption) (*VolumeSyncBarrierResponse, error)
+	VolumePermRewrite(ctx context.Context, in *VolumePermRewriteRequest, opts ...grpc.CallOption) (Control_VolumePermRewriteClient, error)
+	VolumePermUndo(ctx context.Context, in *VolumePermUndoRequest, opts ...grpc.CallOption) (*VolumePermUndoResponse, error)
+	VolumeTrace(ctx context.Context, in *VolumeTraceRequest, opts ...grpc.CallOption) (Control_VolumeTraceClient, error)
+	PeerStatus(ctx context.Context, in *PeerStatusRequest, opts ...grpc.CallOption) (*PeerStatusResponse, error)
+	PeerRekey(ctx context.Context, in *PeerRekeyRequest, opts ...grpc.CallOption) (*PeerRekeyResponse, error)
+	VolumePublish(ctx context.Context, in *VolumePublishRequest, opts ...grpc.CallOption) (*VolumePublishResponse, error)
+	VolumeAppendOnlySet(ctx context.Context, in *VolumeAppendOnlySetRequest, opts ...grpc.CallOption) (*VolumeAppendOnlySetResponse, error)
+	VolumePause(ctx context.Context, in *VolumePauseRequest, opts ...grpc.CallOption) (*VolumePauseResponse, error)
+	PeerRemove(ctx context.Context, in *PeerRemoveRequest, opts ...grpc.CallOption) (*PeerRemoveResponse, error)
+	PeerGroupList(ctx context.Context, in *PeerGroupListRequest, opts ...grpc.CallOption) (*PeerGroupListResponse, error)
+	PeerGroupDelete(ctx context.Context, in *PeerGroupDeleteRequest, opts ...grpc.CallOption) (*PeerGroupDeleteResponse, error)
+	PeerGroupVolumeRevoke(ctx context.Context, in *PeerGroupVolumeRevokeRequest, opts ...grpc.CallOption) (*PeerGroupVolumeRevokeResponse, error)
 }
 
 type controlClient struct {
@@ -179,6 +256,381 @@ func (c *controlClient) PeerVolumeAllow(ctx context.Context, in *PeerVolumeAllow
 	return out, nil
 }
 
+func (c *controlClient) PeerGroupAdd(ctx context.Context, in *PeerGroupAddRequest, opts ...grpc.CallOption) (*PeerGroupAddResponse, error) {
+	out := new(PeerGroupAddResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupAdd", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerGroupRemove(ctx context.Context, in *PeerGroupRemoveRequest, opts ...grpc.CallOption) (*PeerGroupRemoveResponse, error) {
+	out := new(PeerGroupRemoveResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupRemove", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerGroupStorageAllow(ctx context.Context, in *PeerGroupStorageAllowRequest, opts ...grpc.CallOption) (*PeerGroupStorageAllowResponse, error) {
+	out := new(PeerGroupStorageAllowResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupStorageAllow", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerGroupVolumeAllow(ctx context.Context, in *PeerGroupVolumeAllowRequest, opts ...grpc.CallOption) (*PeerGroupVolumeAllowResponse, error) {
+	out := new(PeerGroupVolumeAllowResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupVolumeAllow", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeChanges(ctx context.Context, in *VolumeChangesRequest, opts ...grpc.CallOption) (Control_VolumeChangesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Control_serviceDesc.Streams[0], c.cc, "/bazil.control.Control/VolumeChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlVolumeChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Control_VolumeChangesClient interface {
+	Recv() (*VolumeChange, error)
+	grpc.ClientStream
+}
+
+type controlVolumeChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlVolumeChangesClient) Recv() (*VolumeChange, error) {
+	m := new(VolumeChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *controlClient) VolumeStats(ctx context.Context, in *VolumeStatsRequest, opts ...grpc.CallOption) (*VolumeStatsResponse, error) {
+	out := new(VolumeStatsResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeStats", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerMessageSend(ctx context.Context, in *PeerMessageSendRequest, opts ...grpc.CallOption) (*PeerMessageSendResponse, error) {
+	out := new(PeerMessageSendResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerMessageSend", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerMessageList(ctx context.Context, in *PeerMessageListRequest, opts ...grpc.CallOption) (*PeerMessageListResponse, error) {
+	out := new(PeerMessageListResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerMessageList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeRecover(ctx context.Context, in *VolumeRecoverRequest, opts ...grpc.CallOption) (*VolumeRecoverResponse, error) {
+	out := new(VolumeRecoverResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeRecover", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PairInvite(ctx context.Context, in *PairInviteRequest, opts ...grpc.CallOption) (*PairInviteResponse, error) {
+	out := new(PairInviteResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PairInvite", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PairJoin(ctx context.Context, in *PairJoinRequest, opts ...grpc.CallOption) (*PairJoinResponse, error) {
+	out := new(PairJoinResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PairJoin", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeCommit(ctx context.Context, in *VolumeCommitRequest, opts ...grpc.CallOption) (*VolumeCommitResponse, error) {
+	out := new(VolumeCommitResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeCommit", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeSnapshotList(ctx context.Context, in *VolumeSnapshotListRequest, opts ...grpc.CallOption) (*VolumeSnapshotListResponse, error) {
+	out := new(VolumeSnapshotListResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeSnapshotList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerTraffic(ctx context.Context, in *PeerTrafficRequest, opts ...grpc.CallOption) (*PeerTrafficResponse, error) {
+	out := new(PeerTrafficResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerTraffic", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeHistory(ctx context.Context, in *VolumeHistoryRequest, opts ...grpc.CallOption) (*VolumeHistoryResponse, error) {
+	out := new(VolumeHistoryResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeHistory", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeRestore(ctx context.Context, in *VolumeRestoreRequest, opts ...grpc.CallOption) (*VolumeRestoreResponse, error) {
+	out := new(VolumeRestoreResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeRestore", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeSnapshotRemove(ctx context.Context, in *VolumeSnapshotRemoveRequest, opts ...grpc.CallOption) (*VolumeSnapshotRemoveResponse, error) {
+	out := new(VolumeSnapshotRemoveResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeSnapshotRemove", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerVolumeRevoke(ctx context.Context, in *PeerVolumeRevokeRequest, opts ...grpc.CallOption) (*PeerVolumeRevokeResponse, error) {
+	out := new(PeerVolumeRevokeResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerVolumeRevoke", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Version(ctx context.Context, in *VersionRequest, opts ...grpc.CallOption) (*VersionResponse, error) {
+	out := new(VersionResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/Version", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ServerUpgrade(ctx context.Context, in *ServerUpgradeRequest, opts ...grpc.CallOption) (*ServerUpgradeResponse, error) {
+	out := new(ServerUpgradeResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/ServerUpgrade", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeLimitsSet(ctx context.Context, in *VolumeLimitsSetRequest, opts ...grpc.CallOption) (*VolumeLimitsSetResponse, error) {
+	out := new(VolumeLimitsSetResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeLimitsSet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeSyncBarrier(ctx context.Context, in *VolumeSyncBarrierRequest, opts ...grpc.CallOption) (*VolumeSyncBarrierResponse, error) {
+	out := new(VolumeSyncBarrierResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeSyncBarrier", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumePermRewrite(ctx context.Context, in *VolumePermRewriteRequest, opts ...grpc.CallOption) (Control_VolumePermRewriteClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Control_serviceDesc.Streams[1], c.cc, "/bazil.control.Control/VolumePermRewrite", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlVolumePermRewriteClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Control_VolumePermRewriteClient interface {
+	Recv() (*VolumePermRewriteProgress, error)
+	grpc.ClientStream
+}
+
+type controlVolumePermRewriteClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlVolumePermRewriteClient) Recv() (*VolumePermRewriteProgress, error) {
+	m := new(VolumePermRewriteProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *controlClient) VolumePermUndo(ctx context.Context, in *VolumePermUndoRequest, opts ...grpc.CallOption) (*VolumePermUndoResponse, error) {
+	out := new(VolumePermUndoResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumePermUndo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeTrace(ctx context.Context, in *VolumeTraceRequest, opts ...grpc.CallOption) (Control_VolumeTraceClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Control_serviceDesc.Streams[2], c.cc, "/bazil.control.Control/VolumeTrace", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlVolumeTraceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Control_VolumeTraceClient interface {
+	Recv() (*VolumeTraceEvent, error)
+	grpc.ClientStream
+}
+
+type controlVolumeTraceClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlVolumeTraceClient) Recv() (*VolumeTraceEvent, error) {
+	m := new(VolumeTraceEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *controlClient) PeerStatus(ctx context.Context, in *PeerStatusRequest, opts ...grpc.CallOption) (*PeerStatusResponse, error) {
+	out := new(PeerStatusResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerRekey(ctx context.Context, in *PeerRekeyRequest, opts ...grpc.CallOption) (*PeerRekeyResponse, error) {
+	out := new(PeerRekeyResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerRekey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumePublish(ctx context.Context, in *VolumePublishRequest, opts ...grpc.CallOption) (*VolumePublishResponse, error) {
+	out := new(VolumePublishResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumePublish", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumeAppendOnlySet(ctx context.Context, in *VolumeAppendOnlySetRequest, opts ...grpc.CallOption) (*VolumeAppendOnlySetResponse, error) {
+	out := new(VolumeAppendOnlySetResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumeAppendOnlySet", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VolumePause(ctx context.Context, in *VolumePauseRequest, opts ...grpc.CallOption) (*VolumePauseResponse, error) {
+	out := new(VolumePauseResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/VolumePause", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerRemove(ctx context.Context, in *PeerRemoveRequest, opts ...grpc.CallOption) (*PeerRemoveResponse, error) {
+	out := new(PeerRemoveResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerRemove", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerGroupList(ctx context.Context, in *PeerGroupListRequest, opts ...grpc.CallOption) (*PeerGroupListResponse, error) {
+	out := new(PeerGroupListResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupList", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerGroupDelete(ctx context.Context, in *PeerGroupDeleteRequest, opts ...grpc.CallOption) (*PeerGroupDeleteResponse, error) {
+	out := new(PeerGroupDeleteResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupDelete", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) PeerGroupVolumeRevoke(ctx context.Context, in *PeerGroupVolumeRevokeRequest, opts ...grpc.CallOption) (*PeerGroupVolumeRevokeResponse, error) {
+	out := new(PeerGroupVolumeRevokeResponse)
+	err := grpc.Invoke(ctx, "/bazil.control.Control/PeerGroupVolumeRevoke", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for Control service
 
 type ControlServer interface {
@@ -194,6 +646,40 @@ type ControlServer interface {
 	PeerLocationSet(context.Context, *PeerLocationSetRequest) (*PeerLocationSetResponse, error)
 	PeerStorageAllow(context.Context, *PeerStorageAllowRequest) (*PeerStorageAllowResponse, error)
 	PeerVolumeAllow(context.Context, *PeerVolumeAllowRequest) (*PeerVolumeAllowResponse, error)
+	PeerGroupAdd(context.Context, *PeerGroupAddRequest) (*PeerGroupAddResponse, error)
+	PeerGroupRemove(context.Context, *PeerGroupRemoveRequest) (*PeerGroupRemoveResponse, error)
+	PeerGroupStorageAllow(context.Context, *PeerGroupStorageAllowRequest) (*PeerGroupStorageAllowResponse, error)
+	PeerGroupVolumeAllow(context.Context, *PeerGroupVolumeAllowRequest) (*PeerGroupVolumeAllowResponse, error)
+	VolumeChanges(*VolumeChangesRequest, Control_VolumeChangesServer) error
+	VolumeStats(context.Context, *VolumeStatsRequest) (*VolumeStatsResponse, error)
+	PeerMessageSend(context.Context, *PeerMessageSendRequest) (*PeerMessageSendResponse, error)
+	PeerMessageList(context.Context, *PeerMessageListRequest) (*PeerMessageListResponse, error)
+	VolumeRecover(context.Context, *VolumeRecoverRequest) (*VolumeRecoverResponse, error)
+	PairInvite(context.Context, *PairInviteRequest) (*PairInviteResponse, error)
+	PairJoin(context.Context, *PairJoinRequest) (*PairJoinResponse, error)
+	VolumeCommit(context.Context, *VolumeCommitRequest) (*VolumeCommitResponse, error)
+	VolumeSnapshotList(context.Context, *VolumeSnapshotListRequest) (*VolumeSnapshotListResponse, error)
+	PeerTraffic(context.Context, *PeerTrafficRequest) (*PeerTrafficResponse, error)
+	VolumeHistory(context.Context, *VolumeHistoryRequest) (*VolumeHistoryResponse, error)
+	VolumeRestore(context.Context, *VolumeRestoreRequest) (*VolumeRestoreResponse, error)
+	VolumeSnapshotRemove(context.Context, *VolumeSnapshotRemoveRequest) (*VolumeSnapshotRemoveResponse, error)
+	PeerVolumeRevoke(context.Context, *PeerVolumeRevokeRequest) (*PeerVolumeRevokeResponse, error)
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	ServerUpgrade(context.Context, *ServerUpgradeRequest) (*ServerUpgradeResponse, error)
+	VolumeLimitsSet(context.Context, *VolumeLimitsSetRequest) (*VolumeLimitsSetResponse, error)
+	VolumeSyncBarrier(context.Context, *VolumeSyncBarrierRequest) (*VolumeSyncBarrierResponse, error)
+	VolumePermRewrite(*VolumePermRewriteRequest, Control_VolumePermRewriteServer) error
+	VolumePermUndo(context.Context, *VolumePermUndoRequest) (*VolumePermUndoResponse, error)
+	VolumeTrace(*VolumeTraceRequest, Control_VolumeTraceServer) error
+	PeerStatus(context.Context, *PeerStatusRequest) (*PeerStatusResponse, error)
+	PeerRekey(context.Context, *PeerRekeyRequest) (*PeerRekeyResponse, error)
+	VolumePublish(context.Context, *VolumePublishRequest) (*VolumePublishResponse, error)
+	VolumeAppendOnlySet(context.Context, *VolumeAppendOnlySetRequest) (*VolumeAppendOnlySetResponse, error)
+	VolumePause(context.Context, *VolumePauseRequest) (*VolumePauseResponse, error)
+	PeerRemove(context.Context, *PeerRemoveRequest) (*PeerRemoveResponse, error)
+	PeerGroupList(context.Context, *PeerGroupListRequest) (*PeerGroupListResponse, error)
+	PeerGroupDelete(context.Context, *PeerGroupDeleteRequest) (*PeerGroupDeleteResponse, error)
+	PeerGroupVolumeRevoke(context.Context, *PeerGroupVolumeRevokeRequest) (*PeerGroupVolumeRevokeResponse, error)
 }
 
 func RegisterControlServer(s *grpc.Server, srv ControlServer) {
@@ -344,13 +830,448 @@ func _Control_PeerVolumeAllow_Handler(srv interface{}, ctx context.Context, code
 	return out, nil
 }
 
-var _Control_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "bazil.control.Control",
-	HandlerType: (*ControlServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Ping",
-			Handler:    _Control_Ping_Handler,
+func _Control_PeerGroupAdd_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupAddRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupAdd(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerGroupRemove_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupRemoveRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupRemove(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerGroupStorageAllow_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupStorageAllowRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupStorageAllow(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerGroupVolumeAllow_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupVolumeAllowRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupVolumeAllow(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VolumeChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).VolumeChanges(m, &controlVolumeChangesServer{stream})
+}
+
+type Control_VolumeChangesServer interface {
+	Send(*VolumeChange) error
+	grpc.ServerStream
+}
+
+type controlVolumeChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlVolumeChangesServer) Send(m *VolumeChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Control_VolumeStats_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeStatsRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeStats(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerMessageSend_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerMessageSendRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerMessageSend(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerMessageList_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerMessageListRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerMessageList(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeRecover_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeRecoverRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeRecover(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PairInvite_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PairInviteRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PairInvite(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PairJoin_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PairJoinRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PairJoin(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeCommit_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeCommitRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeCommit(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeSnapshotList_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeSnapshotListRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeSnapshotList(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerTraffic_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerTrafficRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerTraffic(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeHistory_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeHistoryRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeHistory(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeRestore_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeRestoreRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeRestore(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeSnapshotRemove_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeSnapshotRemoveRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeSnapshotRemove(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerVolumeRevoke_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerVolumeRevokeRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerVolumeRevoke(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_Version_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VersionRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).Version(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_ServerUpgrade_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(ServerUpgradeRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).ServerUpgrade(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeLimitsSet_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeLimitsSetRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeLimitsSet(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeSyncBarrier_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeSyncBarrierRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeSyncBarrier(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumePermRewrite_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VolumePermRewriteRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).VolumePermRewrite(m, &controlVolumePermRewriteServer{stream})
+}
+
+type Control_VolumePermRewriteServer interface {
+	Send(*VolumePermRewriteProgress) error
+	grpc.ServerStream
+}
+
+type controlVolumePermRewriteServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlVolumePermRewriteServer) Send(m *VolumePermRewriteProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Control_VolumePermUndo_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumePermUndoRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumePermUndo(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeTrace_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(VolumeTraceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).VolumeTrace(m, &controlVolumeTraceServer{stream})
+}
+
+type Control_VolumeTraceServer interface {
+	Send(*VolumeTraceEvent) error
+	grpc.ServerStream
+}
+
+type controlVolumeTraceServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlVolumeTraceServer) Send(m *VolumeTraceEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Control_PeerStatus_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerStatusRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerStatus(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerRekey_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerRekeyRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerRekey(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumePublish_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumePublishRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumePublish(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumeAppendOnlySet_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumeAppendOnlySetRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumeAppendOnlySet(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_VolumePause_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(VolumePauseRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).VolumePause(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerRemove_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerRemoveRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerRemove(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerGroupList_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupListRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupList(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerGroupDelete_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupDeleteRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupDelete(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Control_PeerGroupVolumeRevoke_Handler(srv interface{}, ctx context.Context, codec grpc.Codec, buf []byte) (interface{}, error) {
+	in := new(PeerGroupVolumeRevokeRequest)
+	if err := codec.Unmarshal(buf, in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(ControlServer).PeerGroupVolumeRevoke(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var _Control_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "bazil.control.Control",
+	HandlerType: (*ControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Ping",
+			Handler:    _Control_Ping_Handler,
 		},
 		{
 			MethodName: "PublicKeyGet",
@@ -396,6 +1317,146 @@ var _Control_serviceDesc = grpc.ServiceDesc{
 			MethodName: "PeerVolumeAllow",
 			Handler:    _Control_PeerVolumeAllow_Handler,
 		},
+		{
+			MethodName: "PeerGroupAdd",
+			Handler:    _Control_PeerGroupAdd_Handler,
+		},
+		{
+			MethodName: "PeerGroupRemove",
+			Handler:    _Control_PeerGroupRemove_Handler,
+		},
+		{
+			MethodName: "PeerGroupStorageAllow",
+			Handler:    _Control_PeerGroupStorageAllow_Handler,
+		},
+		{
+			MethodName: "PeerGroupVolumeAllow",
+			Handler:    _Control_PeerGroupVolumeAllow_Handler,
+		},
+		{
+			MethodName: "VolumeStats",
+			Handler:    _Control_VolumeStats_Handler,
+		},
+		{
+			MethodName: "PeerMessageSend",
+			Handler:    _Control_PeerMessageSend_Handler,
+		},
+		{
+			MethodName: "PeerMessageList",
+			Handler:    _Control_PeerMessageList_Handler,
+		},
+		{
+			MethodName: "VolumeRecover",
+			Handler:    _Control_VolumeRecover_Handler,
+		},
+		{
+			MethodName: "PairInvite",
+			Handler:    _Control_PairInvite_Handler,
+		},
+		{
+			MethodName: "PairJoin",
+			Handler:    _Control_PairJoin_Handler,
+		},
+		{
+			MethodName: "VolumeCommit",
+			Handler:    _Control_VolumeCommit_Handler,
+		},
+		{
+			MethodName: "VolumeSnapshotList",
+			Handler:    _Control_VolumeSnapshotList_Handler,
+		},
+		{
+			MethodName: "PeerTraffic",
+			Handler:    _Control_PeerTraffic_Handler,
+		},
+		{
+			MethodName: "VolumeHistory",
+			Handler:    _Control_VolumeHistory_Handler,
+		},
+		{
+			MethodName: "VolumeRestore",
+			Handler:    _Control_VolumeRestore_Handler,
+		},
+		{
+			MethodName: "VolumeSnapshotRemove",
+			Handler:    _Control_VolumeSnapshotRemove_Handler,
+		},
+		{
+			MethodName: "PeerVolumeRevoke",
+			Handler:    _Control_PeerVolumeRevoke_Handler,
+		},
+		{
+			MethodName: "Version",
+			Handler:    _Control_Version_Handler,
+		},
+		{
+			MethodName: "ServerUpgrade",
+			Handler:    _Control_ServerUpgrade_Handler,
+		},
+		{
+			MethodName: "VolumeLimitsSet",
+			Handler:    _Control_VolumeLimitsSet_Handler,
+		},
+		{
+			MethodName: "VolumeSyncBarrier",
+			Handler:    _Control_VolumeSyncBarrier_Handler,
+		},
+		{
+			MethodName: "VolumePermUndo",
+			Handler:    _Control_VolumePermUndo_Handler,
+		},
+		{
+			MethodName: "PeerStatus",
+			Handler:    _Control_PeerStatus_Handler,
+		},
+		{
+			MethodName: "PeerRekey",
+			Handler:    _Control_PeerRekey_Handler,
+		},
+		{
+			MethodName: "VolumePublish",
+			Handler:    _Control_VolumePublish_Handler,
+		},
+		{
+			MethodName: "VolumeAppendOnlySet",
+			Handler:    _Control_VolumeAppendOnlySet_Handler,
+		},
+		{
+			MethodName: "VolumePause",
+			Handler:    _Control_VolumePause_Handler,
+		},
+		{
+			MethodName: "PeerRemove",
+			Handler:    _Control_PeerRemove_Handler,
+		},
+		{
+			MethodName: "PeerGroupList",
+			Handler:    _Control_PeerGroupList_Handler,
+		},
+		{
+			MethodName: "PeerGroupDelete",
+			Handler:    _Control_PeerGroupDelete_Handler,
+		},
+		{
+			MethodName: "PeerGroupVolumeRevoke",
+			Handler:    _Control_PeerGroupVolumeRevoke_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "VolumeChanges",
+			Handler:       _Control_VolumeChanges_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "VolumePermRewrite",
+			Handler:       _Control_VolumePermRewrite_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "VolumeTrace",
+			Handler:       _Control_VolumeTrace_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams: []grpc.StreamDesc{},
 }
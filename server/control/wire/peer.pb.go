@@ -75,3 +75,429 @@ type PeerVolumeAllowResponse struct {
 func (m *PeerVolumeAllowResponse) Reset()         { *m = PeerVolumeAllowResponse{} }
 func (m *PeerVolumeAllowResponse) String() string { return proto.CompactTextString(m) }
 func (*PeerVolumeAllowResponse) ProtoMessage()    {}
+
+type PeerGroupAddRequest struct {
+	Group string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	// Must be exactly 32 bytes long.
+	Pub []byte `protobuf:"bytes,2,opt,name=pub,proto3" json:"pub,omitempty"`
+}
+
+func (m *PeerGroupAddRequest) Reset()         { *m = PeerGroupAddRequest{} }
+func (m *PeerGroupAddRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupAddRequest) ProtoMessage()    {}
+
+type PeerGroupAddResponse struct {
+}
+
+func (m *PeerGroupAddResponse) Reset()         { *m = PeerGroupAddResponse{} }
+func (m *PeerGroupAddResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupAddResponse) ProtoMessage()    {}
+
+type PeerGroupRemoveRequest struct {
+	Group string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	// Must be exactly 32 bytes long.
+	Pub []byte `protobuf:"bytes,2,opt,name=pub,proto3" json:"pub,omitempty"`
+}
+
+func (m *PeerGroupRemoveRequest) Reset()         { *m = PeerGroupRemoveRequest{} }
+func (m *PeerGroupRemoveRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupRemoveRequest) ProtoMessage()    {}
+
+type PeerGroupRemoveResponse struct {
+}
+
+func (m *PeerGroupRemoveResponse) Reset()         { *m = PeerGroupRemoveResponse{} }
+func (m *PeerGroupRemoveResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupRemoveResponse) ProtoMessage()    {}
+
+type PeerGroupStorageAllowRequest struct {
+	Group   string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	Backend string `protobuf:"bytes,2,opt,name=backend" json:"backend,omitempty"`
+}
+
+func (m *PeerGroupStorageAllowRequest) Reset()         { *m = PeerGroupStorageAllowRequest{} }
+func (m *PeerGroupStorageAllowRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupStorageAllowRequest) ProtoMessage()    {}
+
+type PeerGroupStorageAllowResponse struct {
+}
+
+func (m *PeerGroupStorageAllowResponse) Reset()         { *m = PeerGroupStorageAllowResponse{} }
+func (m *PeerGroupStorageAllowResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupStorageAllowResponse) ProtoMessage()    {}
+
+type PeerGroupVolumeAllowRequest struct {
+	Group      string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	VolumeName string `protobuf:"bytes,2,opt,name=volumeName" json:"volumeName,omitempty"`
+}
+
+func (m *PeerGroupVolumeAllowRequest) Reset()         { *m = PeerGroupVolumeAllowRequest{} }
+func (m *PeerGroupVolumeAllowRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupVolumeAllowRequest) ProtoMessage()    {}
+
+type PeerGroupVolumeAllowResponse struct {
+}
+
+func (m *PeerGroupVolumeAllowResponse) Reset()         { *m = PeerGroupVolumeAllowResponse{} }
+func (m *PeerGroupVolumeAllowResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupVolumeAllowResponse) ProtoMessage()    {}
+
+type PeerGroupListRequest struct {
+}
+
+func (m *PeerGroupListRequest) Reset()         { *m = PeerGroupListRequest{} }
+func (m *PeerGroupListRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupListRequest) ProtoMessage()    {}
+
+type PeerGroupListResponse struct {
+	Groups []*PeerGroupInfo `protobuf:"bytes,1,rep,name=groups" json:"groups,omitempty"`
+}
+
+func (m *PeerGroupListResponse) Reset()         { *m = PeerGroupListResponse{} }
+func (m *PeerGroupListResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupListResponse) ProtoMessage()    {}
+
+func (m *PeerGroupListResponse) GetGroups() []*PeerGroupInfo {
+	if m != nil {
+		return m.Groups
+	}
+	return nil
+}
+
+type PeerGroupInfo struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Public keys of the members, each exactly 32 bytes long.
+	Members [][]byte `protobuf:"bytes,2,rep,name=members,proto3" json:"members,omitempty"`
+	Storage []string `protobuf:"bytes,3,rep,name=storage" json:"storage,omitempty"`
+	// Names of the volumes shared with the group.
+	Volumes []string `protobuf:"bytes,4,rep,name=volumes" json:"volumes,omitempty"`
+}
+
+func (m *PeerGroupInfo) Reset()         { *m = PeerGroupInfo{} }
+func (m *PeerGroupInfo) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupInfo) ProtoMessage()    {}
+
+type PeerGroupDeleteRequest struct {
+	Group string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+}
+
+func (m *PeerGroupDeleteRequest) Reset()         { *m = PeerGroupDeleteRequest{} }
+func (m *PeerGroupDeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupDeleteRequest) ProtoMessage()    {}
+
+type PeerGroupDeleteResponse struct {
+	Revoked []*PeerGroupRevocation `protobuf:"bytes,1,rep,name=revoked" json:"revoked,omitempty"`
+}
+
+func (m *PeerGroupDeleteResponse) Reset()         { *m = PeerGroupDeleteResponse{} }
+func (m *PeerGroupDeleteResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupDeleteResponse) ProtoMessage()    {}
+
+func (m *PeerGroupDeleteResponse) GetRevoked() []*PeerGroupRevocation {
+	if m != nil {
+		return m.Revoked
+	}
+	return nil
+}
+
+type PeerGroupVolumeRevokeRequest struct {
+	Group      string `protobuf:"bytes,1,opt,name=group" json:"group,omitempty"`
+	VolumeName string `protobuf:"bytes,2,opt,name=volumeName" json:"volumeName,omitempty"`
+}
+
+func (m *PeerGroupVolumeRevokeRequest) Reset()         { *m = PeerGroupVolumeRevokeRequest{} }
+func (m *PeerGroupVolumeRevokeRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupVolumeRevokeRequest) ProtoMessage()    {}
+
+type PeerGroupVolumeRevokeResponse struct {
+	Revoked []*PeerGroupRevocation `protobuf:"bytes,1,rep,name=revoked" json:"revoked,omitempty"`
+}
+
+func (m *PeerGroupVolumeRevokeResponse) Reset()         { *m = PeerGroupVolumeRevokeResponse{} }
+func (m *PeerGroupVolumeRevokeResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupVolumeRevokeResponse) ProtoMessage()    {}
+
+func (m *PeerGroupVolumeRevokeResponse) GetRevoked() []*PeerGroupRevocation {
+	if m != nil {
+		return m.Revoked
+	}
+	return nil
+}
+
+// A member that could no longer see a volume, and was sent a
+// revocation.
+type PeerGroupRevocation struct {
+	// Exactly 32 bytes long.
+	Pub        []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	VolumeName string `protobuf:"bytes,2,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Whether the peer has been told already; if not, it is told later.
+	Delivered bool `protobuf:"varint,3,opt,name=delivered" json:"delivered,omitempty"`
+}
+
+func (m *PeerGroupRevocation) Reset()         { *m = PeerGroupRevocation{} }
+func (m *PeerGroupRevocation) String() string { return proto.CompactTextString(m) }
+func (*PeerGroupRevocation) ProtoMessage()    {}
+
+type PeerMessageSendRequest struct {
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// One of the kinds of bazil.peer.Message, by name.
+	Kind string `protobuf:"bytes,2,opt,name=kind" json:"kind,omitempty"`
+	Body []byte `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (m *PeerMessageSendRequest) Reset()         { *m = PeerMessageSendRequest{} }
+func (m *PeerMessageSendRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerMessageSendRequest) ProtoMessage()    {}
+
+type PeerMessageSendResponse struct {
+	// Whether the message reached the peer already. If not, it stays
+	// queued until the peer can be reached.
+	Delivered bool `protobuf:"varint,1,opt,name=delivered" json:"delivered,omitempty"`
+}
+
+func (m *PeerMessageSendResponse) Reset()         { *m = PeerMessageSendResponse{} }
+func (m *PeerMessageSendResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerMessageSendResponse) ProtoMessage()    {}
+
+type PeerMessageListRequest struct {
+	// Only list messages from this peer. Empty means all peers.
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// Remove the listed messages from the inbox.
+	Remove bool `protobuf:"varint,2,opt,name=remove" json:"remove,omitempty"`
+}
+
+func (m *PeerMessageListRequest) Reset()         { *m = PeerMessageListRequest{} }
+func (m *PeerMessageListRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerMessageListRequest) ProtoMessage()    {}
+
+type PeerMessage struct {
+	Pub  []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	Seq  uint64 `protobuf:"varint,2,opt,name=seq" json:"seq,omitempty"`
+	Kind string `protobuf:"bytes,3,opt,name=kind" json:"kind,omitempty"`
+	Body []byte `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	Sent int64  `protobuf:"varint,5,opt,name=sent" json:"sent,omitempty"`
+}
+
+func (m *PeerMessage) Reset()         { *m = PeerMessage{} }
+func (m *PeerMessage) String() string { return proto.CompactTextString(m) }
+func (*PeerMessage) ProtoMessage()    {}
+
+type PeerMessageListResponse struct {
+	Messages []*PeerMessage `protobuf:"bytes,1,rep,name=messages" json:"messages,omitempty"`
+}
+
+func (m *PeerMessageListResponse) Reset()         { *m = PeerMessageListResponse{} }
+func (m *PeerMessageListResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerMessageListResponse) ProtoMessage()    {}
+
+func (m *PeerMessageListResponse) GetMessages() []*PeerMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type PairInviteRequest struct {
+	// Address the joining device can reach this node at, as
+	// HOST:PORT.
+	Addr        string   `protobuf:"bytes,1,opt,name=addr" json:"addr,omitempty"`
+	VolumeNames []string `protobuf:"bytes,2,rep,name=volumeNames" json:"volumeNames,omitempty"`
+	// Storage offered to the joining device. Defaults to "local".
+	Storage string `protobuf:"bytes,3,opt,name=storage" json:"storage,omitempty"`
+	// How long the invitation can be used for. Defaults to one hour.
+	ValidSeconds uint32 `protobuf:"varint,4,opt,name=validSeconds" json:"validSeconds,omitempty"`
+}
+
+func (m *PairInviteRequest) Reset()         { *m = PairInviteRequest{} }
+func (m *PairInviteRequest) String() string { return proto.CompactTextString(m) }
+func (*PairInviteRequest) ProtoMessage()    {}
+
+type PairInviteResponse struct {
+	Invite string `protobuf:"bytes,1,opt,name=invite" json:"invite,omitempty"`
+}
+
+func (m *PairInviteResponse) Reset()         { *m = PairInviteResponse{} }
+func (m *PairInviteResponse) String() string { return proto.CompactTextString(m) }
+func (*PairInviteResponse) ProtoMessage()    {}
+
+type PairJoinRequest struct {
+	Invite string `protobuf:"bytes,1,opt,name=invite" json:"invite,omitempty"`
+	// Storage backend for the joined volumes. Defaults to reading the
+	// chunks from the inviting node.
+	Backend string `protobuf:"bytes,2,opt,name=backend" json:"backend,omitempty"`
+}
+
+func (m *PairJoinRequest) Reset()         { *m = PairJoinRequest{} }
+func (m *PairJoinRequest) String() string { return proto.CompactTextString(m) }
+func (*PairJoinRequest) ProtoMessage()    {}
+
+type PairJoinResponse struct {
+	VolumeNames []string `protobuf:"bytes,1,rep,name=volumeNames" json:"volumeNames,omitempty"`
+}
+
+func (m *PairJoinResponse) Reset()         { *m = PairJoinResponse{} }
+func (m *PairJoinResponse) String() string { return proto.CompactTextString(m) }
+func (*PairJoinResponse) ProtoMessage()    {}
+
+type PeerTrafficRequest struct {
+	// Only report this peer, if set. Must be exactly 32 bytes long.
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// How many days back to report, including today. Zero means the
+	// default.
+	Days uint32 `protobuf:"varint,2,opt,name=days" json:"days,omitempty"`
+}
+
+func (m *PeerTrafficRequest) Reset()         { *m = PeerTrafficRequest{} }
+func (m *PeerTrafficRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerTrafficRequest) ProtoMessage()    {}
+
+type PeerTraffic struct {
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// Empty for traffic not tied to a volume, such as storing chunks.
+	VolumeName string `protobuf:"bytes,2,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Days since the Unix epoch.
+	Day      uint32 `protobuf:"varint,3,opt,name=day" json:"day,omitempty"`
+	Sent     uint64 `protobuf:"varint,4,opt,name=sent" json:"sent,omitempty"`
+	Received uint64 `protobuf:"varint,5,opt,name=received" json:"received,omitempty"`
+}
+
+func (m *PeerTraffic) Reset()         { *m = PeerTraffic{} }
+func (m *PeerTraffic) String() string { return proto.CompactTextString(m) }
+func (*PeerTraffic) ProtoMessage()    {}
+
+type PeerTrafficResponse struct {
+	Traffic []*PeerTraffic `protobuf:"bytes,1,rep,name=traffic" json:"traffic,omitempty"`
+}
+
+func (m *PeerTrafficResponse) Reset()         { *m = PeerTrafficResponse{} }
+func (m *PeerTrafficResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerTrafficResponse) ProtoMessage()    {}
+
+func (m *PeerTrafficResponse) GetTraffic() []*PeerTraffic {
+	if m != nil {
+		return m.Traffic
+	}
+	return nil
+}
+
+type PeerVolumeRevokeRequest struct {
+	// Must be exactly 32 bytes long.
+	Pub        []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	VolumeName string `protobuf:"bytes,2,opt,name=volumeName" json:"volumeName,omitempty"`
+}
+
+func (m *PeerVolumeRevokeRequest) Reset()         { *m = PeerVolumeRevokeRequest{} }
+func (m *PeerVolumeRevokeRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerVolumeRevokeRequest) ProtoMessage()    {}
+
+type PeerVolumeRevokeResponse struct {
+	// Whether the peer has been told already; if not, it is told later.
+	Delivered bool `protobuf:"varint,1,opt,name=delivered" json:"delivered,omitempty"`
+	// The peer can still see the volume through a peer group, and is
+	// not told anything.
+	GroupAccess bool `protobuf:"varint,2,opt,name=groupAccess" json:"groupAccess,omitempty"`
+}
+
+func (m *PeerVolumeRevokeResponse) Reset()         { *m = PeerVolumeRevokeResponse{} }
+func (m *PeerVolumeRevokeResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerVolumeRevokeResponse) ProtoMessage()    {}
+
+type PeerStatusRequest struct {
+}
+
+func (m *PeerStatusRequest) Reset()         { *m = PeerStatusRequest{} }
+func (m *PeerStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerStatusRequest) ProtoMessage()    {}
+
+type PeerStatus struct {
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	Id  uint32 `protobuf:"varint,2,opt,name=id" json:"id,omitempty"`
+	// Empty if no location is known.
+	Location string `protobuf:"bytes,3,opt,name=location" json:"location,omitempty"`
+	// Missing unless a different key answered at the location of the
+	// peer. Connections are refused until the peer is rekeyed.
+	KeyAlert *PeerKeyAlert `protobuf:"bytes,4,opt,name=keyAlert" json:"keyAlert,omitempty"`
+}
+
+func (m *PeerStatus) Reset()         { *m = PeerStatus{} }
+func (m *PeerStatus) String() string { return proto.CompactTextString(m) }
+func (*PeerStatus) ProtoMessage()    {}
+
+func (m *PeerStatus) GetKeyAlert() *PeerKeyAlert {
+	if m != nil {
+		return m.KeyAlert
+	}
+	return nil
+}
+
+type PeerKeyAlert struct {
+	// The public key presented instead.
+	Pub  []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	Addr string `protobuf:"bytes,2,opt,name=addr" json:"addr,omitempty"`
+	// When it was first and last seen, as Unix seconds.
+	First int64  `protobuf:"varint,3,opt,name=first" json:"first,omitempty"`
+	Last  int64  `protobuf:"varint,4,opt,name=last" json:"last,omitempty"`
+	Count uint64 `protobuf:"varint,5,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *PeerKeyAlert) Reset()         { *m = PeerKeyAlert{} }
+func (m *PeerKeyAlert) String() string { return proto.CompactTextString(m) }
+func (*PeerKeyAlert) ProtoMessage()    {}
+
+type PeerStatusResponse struct {
+	Peers []*PeerStatus `protobuf:"bytes,1,rep,name=peers" json:"peers,omitempty"`
+}
+
+func (m *PeerStatusResponse) Reset()         { *m = PeerStatusResponse{} }
+func (m *PeerStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerStatusResponse) ProtoMessage()    {}
+
+func (m *PeerStatusResponse) GetPeers() []*PeerStatus {
+	if m != nil {
+		return m.Peers
+	}
+	return nil
+}
+
+type PeerRekeyRequest struct {
+	// Must be exactly 32 bytes long.
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// The key to accept for the peer from now on. Must be exactly 32
+	// bytes long.
+	NewPub []byte `protobuf:"bytes,2,opt,name=newPub,proto3" json:"newPub,omitempty"`
+}
+
+func (m *PeerRekeyRequest) Reset()         { *m = PeerRekeyRequest{} }
+func (m *PeerRekeyRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerRekeyRequest) ProtoMessage()    {}
+
+type PeerRekeyResponse struct {
+}
+
+func (m *PeerRekeyResponse) Reset()         { *m = PeerRekeyResponse{} }
+func (m *PeerRekeyResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerRekeyResponse) ProtoMessage()    {}
+
+type PeerRemoveRequest struct {
+	// Must be exactly 32 bytes long.
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// Only report what removing the peer would affect.
+	DryRun bool `protobuf:"varint,2,opt,name=dryRun" json:"dryRun,omitempty"`
+}
+
+func (m *PeerRemoveRequest) Reset()         { *m = PeerRemoveRequest{} }
+func (m *PeerRemoveRequest) String() string { return proto.CompactTextString(m) }
+func (*PeerRemoveRequest) ProtoMessage()    {}
+
+type PeerRemoveResponse struct {
+	// Volumes the peer could see, directly or through peer groups.
+	Volumes []string `protobuf:"bytes,1,rep,name=volumes" json:"volumes,omitempty"`
+	// Peer groups the peer was a member of.
+	Groups []string `protobuf:"bytes,2,rep,name=groups" json:"groups,omitempty"`
+	// Messages queued for the peer that are dropped undelivered.
+	UndeliveredMessages uint64 `protobuf:"varint,3,opt,name=undeliveredMessages" json:"undeliveredMessages,omitempty"`
+}
+
+func (m *PeerRemoveResponse) Reset()         { *m = PeerRemoveResponse{} }
+func (m *PeerRemoveResponse) String() string { return proto.CompactTextString(m) }
+func (*PeerRemoveResponse) ProtoMessage()    {}
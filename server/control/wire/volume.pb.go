@@ -5,19 +5,95 @@
 package wire
 
 import proto "github.com/golang/protobuf/proto"
+import bazil_cas "bazil.org/bazil/cas/wire"
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
 
+type VolumeMountRequest_Access int32
+
+const (
+	VolumeMountRequest_NONE VolumeMountRequest_Access = 0
+	// Read files and list directories.
+	VolumeMountRequest_READ VolumeMountRequest_Access = 1
+	// Everything the owner of the volume can do.
+	VolumeMountRequest_OWNER VolumeMountRequest_Access = 2
+)
+
+var VolumeMountRequest_Access_name = map[int32]string{
+	0: "NONE",
+	1: "READ",
+	2: "OWNER",
+}
+var VolumeMountRequest_Access_value = map[string]int32{
+	"NONE":  0,
+	"READ":  1,
+	"OWNER": 2,
+}
+
+func (x VolumeMountRequest_Access) String() string {
+	return proto.EnumName(VolumeMountRequest_Access_name, int32(x))
+}
+
+type VolumeChange_Op int32
+
+const (
+	VolumeChange_UNKNOWN VolumeChange_Op = 0
+	// The directory entry was created or changed.
+	VolumeChange_PUT VolumeChange_Op = 1
+	// The directory entry was removed without a trace.
+	VolumeChange_DELETE VolumeChange_Op = 2
+	// The directory entry was replaced by a tombstone.
+	VolumeChange_TOMBSTONE VolumeChange_Op = 3
+	// Only the vector clock of the directory entry changed.
+	VolumeChange_CLOCK VolumeChange_Op = 4
+)
+
+var VolumeChange_Op_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "PUT",
+	2: "DELETE",
+	3: "TOMBSTONE",
+	4: "CLOCK",
+}
+var VolumeChange_Op_value = map[string]int32{
+	"UNKNOWN":   0,
+	"PUT":       1,
+	"DELETE":    2,
+	"TOMBSTONE": 3,
+	"CLOCK":     4,
+}
+
+func (x VolumeChange_Op) String() string {
+	return proto.EnumName(VolumeChange_Op_name, int32(x))
+}
+
 type VolumeMountRequest struct {
 	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
 	Mountpoint string `protobuf:"bytes,2,opt,name=mountpoint" json:"mountpoint,omitempty"`
+	// Make the mount visible to other local users. Their access is
+	// decided by others and users.
+	AllowOther bool `protobuf:"varint,3,opt,name=allowOther" json:"allowOther,omitempty"`
+	// Access for local users not listed in users.
+	Others VolumeMountRequest_Access `protobuf:"varint,4,opt,name=others,enum=bazil.control.VolumeMountRequest_Access" json:"others,omitempty"`
+	// Access for specific local users, by UID.
+	Users map[uint32]VolumeMountRequest_Access `protobuf:"bytes,5,rep,name=users" json:"users,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value,enum=bazil.control.VolumeMountRequest_Access"`
+	// Mount this snapshot of the volume, read-only, instead of the
+	// live contents.
+	Snapshot string `protobuf:"bytes,6,opt,name=snapshot" json:"snapshot,omitempty"`
 }
 
 func (m *VolumeMountRequest) Reset()         { *m = VolumeMountRequest{} }
 func (m *VolumeMountRequest) String() string { return proto.CompactTextString(m) }
 func (*VolumeMountRequest) ProtoMessage()    {}
 
+func (m *VolumeMountRequest) GetUsers() map[uint32]VolumeMountRequest_Access {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
 type VolumeMountResponse struct {
 }
 
@@ -29,6 +105,8 @@ type VolumeCreateRequest struct {
 	VolumeName     string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
 	Backend        string `protobuf:"bytes,2,opt,name=backend" json:"backend,omitempty"`
 	SharingKeyName string `protobuf:"bytes,3,opt,name=sharingKeyName" json:"sharingKeyName,omitempty"`
+	// Hash algorithm for content of the volume, empty for the default.
+	Hash string `protobuf:"bytes,4,opt,name=hash" json:"hash,omitempty"`
 }
 
 func (m *VolumeCreateRequest) Reset()         { *m = VolumeCreateRequest{} }
@@ -85,6 +163,9 @@ type VolumeSyncRequest struct {
 	// Must be exactly 32 bytes long.
 	Pub  []byte `protobuf:"bytes,2,opt,name=pub,proto3" json:"pub,omitempty"`
 	Path string `protobuf:"bytes,3,opt,name=path" json:"path,omitempty"`
+	// Additional peers to sync with concurrently. Each must be
+	// exactly 32 bytes long.
+	Pubs [][]byte `protobuf:"bytes,4,rep,name=pubs,proto3" json:"pubs,omitempty"`
 }
 
 func (m *VolumeSyncRequest) Reset()         { *m = VolumeSyncRequest{} }
@@ -92,8 +173,489 @@ func (m *VolumeSyncRequest) String() string { return proto.CompactTextString(m)
 func (*VolumeSyncRequest) ProtoMessage()    {}
 
 type VolumeSyncResponse struct {
+	// One result per peer, in request order, with duplicates
+	// removed. The call fails if no peer could be synced with.
+	Results []*VolumeSyncResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
 }
 
 func (m *VolumeSyncResponse) Reset()         { *m = VolumeSyncResponse{} }
 func (m *VolumeSyncResponse) String() string { return proto.CompactTextString(m) }
 func (*VolumeSyncResponse) ProtoMessage()    {}
+
+func (m *VolumeSyncResponse) GetResults() []*VolumeSyncResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type VolumeSyncResult struct {
+	Pub []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	// Empty on success.
+	Error string `protobuf:"bytes,2,opt,name=error" json:"error,omitempty"`
+	// Number of directory entries received from the peer.
+	Dirents uint64 `protobuf:"varint,3,opt,name=dirents" json:"dirents,omitempty"`
+}
+
+func (m *VolumeSyncResult) Reset()         { *m = VolumeSyncResult{} }
+func (m *VolumeSyncResult) String() string { return proto.CompactTextString(m) }
+func (*VolumeSyncResult) ProtoMessage()    {}
+
+type VolumeChangesRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Resume after this sequence number. Zero means start from the
+	// oldest change still kept.
+	After uint64 `protobuf:"varint,2,opt,name=after" json:"after,omitempty"`
+	// Keep the stream open after the most recent change, and send
+	// new changes as they are made.
+	Follow bool `protobuf:"varint,3,opt,name=follow" json:"follow,omitempty"`
+}
+
+func (m *VolumeChangesRequest) Reset()         { *m = VolumeChangesRequest{} }
+func (m *VolumeChangesRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeChangesRequest) ProtoMessage()    {}
+
+type VolumeChange struct {
+	// Sequence number; pass this as VolumeChangesRequest.after to
+	// resume.
+	Seq         uint64          `protobuf:"varint,1,opt,name=seq" json:"seq,omitempty"`
+	Op          VolumeChange_Op `protobuf:"varint,2,opt,name=op,enum=bazil.control.VolumeChange_Op" json:"op,omitempty"`
+	ParentInode uint64          `protobuf:"varint,3,opt,name=parentInode" json:"parentInode,omitempty"`
+	Name        string          `protobuf:"bytes,4,opt,name=name" json:"name,omitempty"`
+	// Changes with the same tx were made atomically, and are sent
+	// one after another.
+	Tx uint64 `protobuf:"varint,5,opt,name=tx" json:"tx,omitempty"`
+	// Inode of the entry, for PUT and TOMBSTONE.
+	Inode uint64 `protobuf:"varint,6,opt,name=inode" json:"inode,omitempty"`
+	// Vector clock of the entry after the change, if it has one.
+	// Peer identifiers in it are local to this server.
+	Clock []byte `protobuf:"bytes,7,opt,name=clock,proto3" json:"clock,omitempty"`
+	// Contents of the file, for PUT of a file.
+	Manifest *bazil_cas.Manifest `protobuf:"bytes,8,opt,name=manifest" json:"manifest,omitempty"`
+	// Set for PUT of a directory.
+	Dir bool `protobuf:"varint,9,opt,name=dir" json:"dir,omitempty"`
+}
+
+func (m *VolumeChange) Reset()         { *m = VolumeChange{} }
+func (m *VolumeChange) String() string { return proto.CompactTextString(m) }
+func (*VolumeChange) ProtoMessage()    {}
+
+func (m *VolumeChange) GetManifest() *bazil_cas.Manifest {
+	if m != nil {
+		return m.Manifest
+	}
+	return nil
+}
+
+type VolumeStatsRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+}
+
+func (m *VolumeStatsRequest) Reset()         { *m = VolumeStatsRequest{} }
+func (m *VolumeStatsRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeStatsRequest) ProtoMessage()    {}
+
+type VolumeStatsResponse struct {
+	OpenHandles uint64 `protobuf:"varint,1,opt,name=openHandles" json:"openHandles,omitempty"`
+	// Zero means no limit.
+	HandleLimit uint64 `protobuf:"varint,2,opt,name=handleLimit" json:"handleLimit,omitempty"`
+	PeakHandles uint64 `protobuf:"varint,3,opt,name=peakHandles" json:"peakHandles,omitempty"`
+	// Opens that failed because the limit was reached.
+	RefusedHandles uint64 `protobuf:"varint,4,opt,name=refusedHandles" json:"refusedHandles,omitempty"`
+	PendingWrites  uint64 `protobuf:"varint,5,opt,name=pendingWrites" json:"pendingWrites,omitempty"`
+	// Writes that waited for their turn, or were slowed down.
+	ThrottledWrites uint64 `protobuf:"varint,6,opt,name=throttledWrites" json:"throttledWrites,omitempty"`
+	// Recent average time taken to store written data, in
+	// nanoseconds.
+	WriteLatency int64 `protobuf:"varint,7,opt,name=writeLatency" json:"writeLatency,omitempty"`
+	Paused       bool  `protobuf:"varint,8,opt,name=paused" json:"paused,omitempty"`
+	// Requests that had to wait for a pause to end.
+	QueuedRequests uint64 `protobuf:"varint,9,opt,name=queuedRequests" json:"queuedRequests,omitempty"`
+	// Pauses that went on for longer than allowed.
+	ExpiredPauses uint64 `protobuf:"varint,10,opt,name=expiredPauses" json:"expiredPauses,omitempty"`
+}
+
+func (m *VolumeStatsResponse) Reset()         { *m = VolumeStatsResponse{} }
+func (m *VolumeStatsResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeStatsResponse) ProtoMessage()    {}
+
+type VolumeRecoverRequest struct {
+	// Must be exactly 32 bytes long.
+	Pub             []byte `protobuf:"bytes,1,opt,name=pub,proto3" json:"pub,omitempty"`
+	VolumeName      string `protobuf:"bytes,2,opt,name=volumeName" json:"volumeName,omitempty"`
+	LocalVolumeName string `protobuf:"bytes,3,opt,name=localVolumeName" json:"localVolumeName,omitempty"`
+	// Defaults to reading the chunks from the storage the peer offers.
+	Backend        string `protobuf:"bytes,4,opt,name=backend" json:"backend,omitempty"`
+	SharingKeyName string `protobuf:"bytes,5,opt,name=sharingKeyName" json:"sharingKeyName,omitempty"`
+}
+
+func (m *VolumeRecoverRequest) Reset()         { *m = VolumeRecoverRequest{} }
+func (m *VolumeRecoverRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeRecoverRequest) ProtoMessage()    {}
+
+type VolumeRecoverResponse struct {
+	Dirs    uint64 `protobuf:"varint,1,opt,name=dirs" json:"dirs,omitempty"`
+	Dirents uint64 `protobuf:"varint,2,opt,name=dirents" json:"dirents,omitempty"`
+}
+
+func (m *VolumeRecoverResponse) Reset()         { *m = VolumeRecoverResponse{} }
+func (m *VolumeRecoverResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeRecoverResponse) ProtoMessage()    {}
+
+type VolumeCommitChange struct {
+	// Path of the file, relative to the volume root.
+	Path string `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	// The full new content of the file.
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Remove the file instead of writing data to it.
+	Remove bool `protobuf:"varint,3,opt,name=remove" json:"remove,omitempty"`
+}
+
+func (m *VolumeCommitChange) Reset()         { *m = VolumeCommitChange{} }
+func (m *VolumeCommitChange) String() string { return proto.CompactTextString(m) }
+func (*VolumeCommitChange) ProtoMessage()    {}
+
+type VolumeCommitRequest struct {
+	VolumeName string                `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	Changes    []*VolumeCommitChange `protobuf:"bytes,2,rep,name=changes" json:"changes,omitempty"`
+}
+
+func (m *VolumeCommitRequest) Reset()         { *m = VolumeCommitRequest{} }
+func (m *VolumeCommitRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeCommitRequest) ProtoMessage()    {}
+
+func (m *VolumeCommitRequest) GetChanges() []*VolumeCommitChange {
+	if m != nil {
+		return m.Changes
+	}
+	return nil
+}
+
+type VolumeCommitResponse struct {
+}
+
+func (m *VolumeCommitResponse) Reset()         { *m = VolumeCommitResponse{} }
+func (m *VolumeCommitResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeCommitResponse) ProtoMessage()    {}
+
+type VolumeSnapshotListRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+}
+
+func (m *VolumeSnapshotListRequest) Reset()         { *m = VolumeSnapshotListRequest{} }
+func (m *VolumeSnapshotListRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeSnapshotListRequest) ProtoMessage()    {}
+
+type VolumeSnapshot struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Whether storage usage is known; it is not for snapshots made
+	// before usage was tracked.
+	UsageKnown bool `protobuf:"varint,2,opt,name=usageKnown" json:"usageKnown,omitempty"`
+	// Total size of the data the snapshot refers to.
+	TotalBytes uint64 `protobuf:"varint,3,opt,name=totalBytes" json:"totalBytes,omitempty"`
+	// Size of the data only this snapshot refers to.
+	ExclusiveBytes uint64 `protobuf:"varint,4,opt,name=exclusiveBytes" json:"exclusiveBytes,omitempty"`
+}
+
+func (m *VolumeSnapshot) Reset()         { *m = VolumeSnapshot{} }
+func (m *VolumeSnapshot) String() string { return proto.CompactTextString(m) }
+func (*VolumeSnapshot) ProtoMessage()    {}
+
+type VolumeSnapshotListResponse struct {
+	Snapshots []*VolumeSnapshot `protobuf:"bytes,1,rep,name=snapshots" json:"snapshots,omitempty"`
+}
+
+func (m *VolumeSnapshotListResponse) Reset()         { *m = VolumeSnapshotListResponse{} }
+func (m *VolumeSnapshotListResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeSnapshotListResponse) ProtoMessage()    {}
+
+func (m *VolumeSnapshotListResponse) GetSnapshots() []*VolumeSnapshot {
+	if m != nil {
+		return m.Snapshots
+	}
+	return nil
+}
+
+type VolumeHistoryRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	Path       string `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+}
+
+func (m *VolumeHistoryRequest) Reset()         { *m = VolumeHistoryRequest{} }
+func (m *VolumeHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeHistoryRequest) ProtoMessage()    {}
+
+type FileVersion struct {
+	// When the version was saved, in nanoseconds since the Unix epoch.
+	// This also identifies the version for restoring.
+	Version int64  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Size    uint64 `protobuf:"varint,2,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *FileVersion) Reset()         { *m = FileVersion{} }
+func (m *FileVersion) String() string { return proto.CompactTextString(m) }
+func (*FileVersion) ProtoMessage()    {}
+
+type VolumeHistoryResponse struct {
+	// Oldest first.
+	Versions []*FileVersion `protobuf:"bytes,1,rep,name=versions" json:"versions,omitempty"`
+}
+
+func (m *VolumeHistoryResponse) Reset()         { *m = VolumeHistoryResponse{} }
+func (m *VolumeHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeHistoryResponse) ProtoMessage()    {}
+
+func (m *VolumeHistoryResponse) GetVersions() []*FileVersion {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type VolumeRestoreRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	Path       string `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	Version    int64  `protobuf:"varint,3,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *VolumeRestoreRequest) Reset()         { *m = VolumeRestoreRequest{} }
+func (m *VolumeRestoreRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeRestoreRequest) ProtoMessage()    {}
+
+type VolumeRestoreResponse struct {
+}
+
+func (m *VolumeRestoreResponse) Reset()         { *m = VolumeRestoreResponse{} }
+func (m *VolumeRestoreResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeRestoreResponse) ProtoMessage()    {}
+
+type VolumeSnapshotRemoveRequest struct {
+	VolumeName string   `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	Names      []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	// Only report what removing the snapshots would free.
+	DryRun bool `protobuf:"varint,3,opt,name=dryRun" json:"dryRun,omitempty"`
+}
+
+func (m *VolumeSnapshotRemoveRequest) Reset()         { *m = VolumeSnapshotRemoveRequest{} }
+func (m *VolumeSnapshotRemoveRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeSnapshotRemoveRequest) ProtoMessage()    {}
+
+type VolumeSnapshotRemoveResponse struct {
+	// Total size of the data the snapshots refer to.
+	TotalBytes uint64 `protobuf:"varint,1,opt,name=totalBytes" json:"totalBytes,omitempty"`
+	// Size of the data no other snapshot refers to; this is what the
+	// removal frees.
+	ExclusiveBytes uint64 `protobuf:"varint,2,opt,name=exclusiveBytes" json:"exclusiveBytes,omitempty"`
+	// Whether usage was known for all the snapshots; if not, the sizes
+	// are too low.
+	UsageKnown bool `protobuf:"varint,3,opt,name=usageKnown" json:"usageKnown,omitempty"`
+}
+
+func (m *VolumeSnapshotRemoveResponse) Reset()         { *m = VolumeSnapshotRemoveResponse{} }
+func (m *VolumeSnapshotRemoveResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeSnapshotRemoveResponse) ProtoMessage()    {}
+
+type VolumeLimitsSetRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Zero means the limit of the server applies.
+	MaxFileSize  uint64 `protobuf:"varint,2,opt,name=maxFileSize" json:"maxFileSize,omitempty"`
+	MaxNameBytes uint32 `protobuf:"varint,3,opt,name=maxNameBytes" json:"maxNameBytes,omitempty"`
+	MaxDepth     uint32 `protobuf:"varint,4,opt,name=maxDepth" json:"maxDepth,omitempty"`
+}
+
+func (m *VolumeLimitsSetRequest) Reset()         { *m = VolumeLimitsSetRequest{} }
+func (m *VolumeLimitsSetRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeLimitsSetRequest) ProtoMessage()    {}
+
+type VolumeLimitsSetResponse struct {
+}
+
+func (m *VolumeLimitsSetResponse) Reset()         { *m = VolumeLimitsSetResponse{} }
+func (m *VolumeLimitsSetResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeLimitsSetResponse) ProtoMessage()    {}
+
+type VolumeSyncBarrierRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Number of peers that must have pulled the volume.
+	Peers uint32 `protobuf:"varint,2,opt,name=peers" json:"peers,omitempty"`
+}
+
+func (m *VolumeSyncBarrierRequest) Reset()         { *m = VolumeSyncBarrierRequest{} }
+func (m *VolumeSyncBarrierRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeSyncBarrierRequest) ProtoMessage()    {}
+
+type VolumeSyncBarrierResponse struct {
+	// Public keys of the peers that have everything written before the
+	// request.
+	Peers [][]byte `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+}
+
+func (m *VolumeSyncBarrierResponse) Reset()         { *m = VolumeSyncBarrierResponse{} }
+func (m *VolumeSyncBarrierResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeSyncBarrierResponse) ProtoMessage()    {}
+
+type VolumePermRewriteRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Directory to change, recursively. Empty means the whole volume.
+	Path string `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	// Unix permission bits to clear, and then to set.
+	ClearMode   uint32 `protobuf:"varint,3,opt,name=clearMode" json:"clearMode,omitempty"`
+	SetMode     uint32 `protobuf:"varint,4,opt,name=setMode" json:"setMode,omitempty"`
+	ChangeOwner bool   `protobuf:"varint,5,opt,name=changeOwner" json:"changeOwner,omitempty"`
+	Uid         uint32 `protobuf:"varint,6,opt,name=uid" json:"uid,omitempty"`
+	ChangeGroup bool   `protobuf:"varint,7,opt,name=changeGroup" json:"changeGroup,omitempty"`
+	Gid         uint32 `protobuf:"varint,8,opt,name=gid" json:"gid,omitempty"`
+	FilesOnly   bool   `protobuf:"varint,9,opt,name=filesOnly" json:"filesOnly,omitempty"`
+	DirsOnly    bool   `protobuf:"varint,10,opt,name=dirsOnly" json:"dirsOnly,omitempty"`
+	// Name of the undo record to keep. Empty means the server picks
+	// one.
+	UndoName string `protobuf:"bytes,11,opt,name=undoName" json:"undoName,omitempty"`
+}
+
+func (m *VolumePermRewriteRequest) Reset()         { *m = VolumePermRewriteRequest{} }
+func (m *VolumePermRewriteRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumePermRewriteRequest) ProtoMessage()    {}
+
+type VolumePermRewriteProgress struct {
+	Seen    uint64 `protobuf:"varint,1,opt,name=seen" json:"seen,omitempty"`
+	Changed uint64 `protobuf:"varint,2,opt,name=changed" json:"changed,omitempty"`
+	// Set on the last message, once the change has been committed.
+	Done     bool   `protobuf:"varint,3,opt,name=done" json:"done,omitempty"`
+	UndoName string `protobuf:"bytes,4,opt,name=undoName" json:"undoName,omitempty"`
+}
+
+func (m *VolumePermRewriteProgress) Reset()         { *m = VolumePermRewriteProgress{} }
+func (m *VolumePermRewriteProgress) String() string { return proto.CompactTextString(m) }
+func (*VolumePermRewriteProgress) ProtoMessage()    {}
+
+type VolumePermUndoRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	UndoName   string `protobuf:"bytes,2,opt,name=undoName" json:"undoName,omitempty"`
+}
+
+func (m *VolumePermUndoRequest) Reset()         { *m = VolumePermUndoRequest{} }
+func (m *VolumePermUndoRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumePermUndoRequest) ProtoMessage()    {}
+
+type VolumePermUndoResponse struct {
+	Restored uint64 `protobuf:"varint,1,opt,name=restored" json:"restored,omitempty"`
+}
+
+func (m *VolumePermUndoResponse) Reset()         { *m = VolumePermUndoResponse{} }
+func (m *VolumePermUndoResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumePermUndoResponse) ProtoMessage()    {}
+
+type VolumeTraceRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Trace one in this many requests. Zero traces every request.
+	SampleEvery uint64 `protobuf:"varint,2,opt,name=sampleEvery" json:"sampleEvery,omitempty"`
+	// Most events per second. Zero means no limit.
+	MaxPerSecond uint32 `protobuf:"varint,3,opt,name=maxPerSecond" json:"maxPerSecond,omitempty"`
+}
+
+func (m *VolumeTraceRequest) Reset()         { *m = VolumeTraceRequest{} }
+func (m *VolumeTraceRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeTraceRequest) ProtoMessage()    {}
+
+type VolumeTraceEvent struct {
+	// When the request started, in nanoseconds since the Unix epoch.
+	Start int64 `protobuf:"varint,1,opt,name=start" json:"start,omitempty"`
+	// The request, with its arguments.
+	Request string `protobuf:"bytes,2,opt,name=request" json:"request,omitempty"`
+	// In nanoseconds.
+	Duration int64               `protobuf:"varint,3,opt,name=duration" json:"duration,omitempty"`
+	Kv       []*VolumeTraceKVCall `protobuf:"bytes,5,rep,name=kv" json:"kv,omitempty"`
+	// Storage calls left out of kv, to keep the event small.
+	KvOmitted uint64 `protobuf:"varint,6,opt,name=kvOmitted" json:"kvOmitted,omitempty"`
+	// Events dropped before this one because the client was too
+	// slow.
+	Dropped uint64 `protobuf:"varint,7,opt,name=dropped" json:"dropped,omitempty"`
+}
+
+func (m *VolumeTraceEvent) Reset()         { *m = VolumeTraceEvent{} }
+func (m *VolumeTraceEvent) String() string { return proto.CompactTextString(m) }
+func (*VolumeTraceEvent) ProtoMessage()    {}
+
+func (m *VolumeTraceEvent) GetKv() []*VolumeTraceKVCall {
+	if m != nil {
+		return m.Kv
+	}
+	return nil
+}
+
+type VolumeTraceKVCall struct {
+	Op   string `protobuf:"bytes,1,opt,name=op" json:"op,omitempty"`
+	Key  []byte `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Size uint64 `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+	// In nanoseconds.
+	Duration int64  `protobuf:"varint,4,opt,name=duration" json:"duration,omitempty"`
+	Error    string `protobuf:"bytes,5,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *VolumeTraceKVCall) Reset()         { *m = VolumeTraceKVCall{} }
+func (m *VolumeTraceKVCall) String() string { return proto.CompactTextString(m) }
+func (*VolumeTraceKVCall) ProtoMessage()    {}
+
+type VolumePublishRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// Stop publishing instead.
+	Off bool `protobuf:"varint,2,opt,name=off" json:"off,omitempty"`
+}
+
+func (m *VolumePublishRequest) Reset()         { *m = VolumePublishRequest{} }
+func (m *VolumePublishRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumePublishRequest) ProtoMessage()    {}
+
+type VolumePublishResponse struct {
+	// The identifier the snapshots are published under. Empty when
+	// publishing was turned off.
+	PublicID []byte `protobuf:"bytes,1,opt,name=publicID,proto3" json:"publicID,omitempty"`
+	// Public key of this node, which consumers need to check the
+	// feed with.
+	Publisher []byte `protobuf:"bytes,2,opt,name=publisher,proto3" json:"publisher,omitempty"`
+}
+
+func (m *VolumePublishResponse) Reset()         { *m = VolumePublishResponse{} }
+func (m *VolumePublishResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumePublishResponse) ProtoMessage()    {}
+
+type VolumeAppendOnlySetRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// File or directory in the volume.
+	Path string `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	// Remove the mark instead.
+	Off bool `protobuf:"varint,3,opt,name=off" json:"off,omitempty"`
+}
+
+func (m *VolumeAppendOnlySetRequest) Reset()         { *m = VolumeAppendOnlySetRequest{} }
+func (m *VolumeAppendOnlySetRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumeAppendOnlySetRequest) ProtoMessage()    {}
+
+type VolumeAppendOnlySetResponse struct {
+}
+
+func (m *VolumeAppendOnlySetResponse) Reset()         { *m = VolumeAppendOnlySetResponse{} }
+func (m *VolumeAppendOnlySetResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumeAppendOnlySetResponse) ProtoMessage()    {}
+
+type VolumePauseRequest struct {
+	VolumeName string `protobuf:"bytes,1,opt,name=volumeName" json:"volumeName,omitempty"`
+	// How long to hold back requests, in nanoseconds.
+	Duration int64 `protobuf:"varint,2,opt,name=duration" json:"duration,omitempty"`
+}
+
+func (m *VolumePauseRequest) Reset()         { *m = VolumePauseRequest{} }
+func (m *VolumePauseRequest) String() string { return proto.CompactTextString(m) }
+func (*VolumePauseRequest) ProtoMessage()    {}
+
+type VolumePauseResponse struct {
+}
+
+func (m *VolumePauseResponse) Reset()         { *m = VolumePauseResponse{} }
+func (m *VolumePauseResponse) String() string { return proto.CompactTextString(m) }
+func (*VolumePauseResponse) ProtoMessage()    {}
+
+func init() {
+	proto.RegisterEnum("bazil.control.VolumeMountRequest_Access", VolumeMountRequest_Access_name, VolumeMountRequest_Access_value)
+	proto.RegisterEnum("bazil.control.VolumeChange_Op", VolumeChange_Op_name, VolumeChange_Op_value)
+}
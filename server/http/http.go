@@ -2,6 +2,8 @@ package http
 
 import (
 	"net"
+	"os"
+	"path/filepath"
 
 	"bazil.org/bazil/server"
 	"bazil.org/bazil/server/peer"
@@ -20,6 +22,23 @@ func New(app *server.App, listener net.Listener) (*Web, error) {
 	return w, nil
 }
 
+// NewUnix prepares to serve peers on the same host, through a Unix
+// domain socket in the data directory. Peers reach it with the
+// location unix:PATH, where PATH is Addr.
+func NewUnix(app *server.App) (*Web, error) {
+	socketPath := filepath.Join(app.DataDir, "peer")
+	// because app holds lock, this is safe
+	err := os.Remove(socketPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	return New(app, l)
+}
+
 func (w *Web) Close() {
 	_ = w.listener.Close()
 }
@@ -27,3 +27,18 @@ func ServeHTTP(t testing.TB, wg *sync.WaitGroup, app *server.App) *http.Web {
 	}()
 	return web
 }
+
+// ServeUnix serves peers through the Unix domain socket in the data
+// directory of app.
+func ServeUnix(t testing.TB, wg *sync.WaitGroup, app *server.App) *http.Web {
+	web, err := http.NewUnix(app)
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = web.Serve()
+	}()
+	return web
+}
@@ -1,9 +1,13 @@
 package server
 
 import (
+	"bytes"
 	"crypto/rand"
+	"errors"
 	"fmt"
 
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/paperkey"
 	"bazil.org/bazil/tokens"
 	"github.com/agl/ed25519"
 	"github.com/agl/ed25519/extra25519"
@@ -27,12 +31,21 @@ func extractEd25519Pubkey(priv *[ed25519.PrivateKeySize]byte) *[ed25519.PublicKe
 	return &pub
 }
 
+// keyFromSeed returns the ed25519 private key derived from seed.
+func keyFromSeed(seed *[32]byte) (*[ed25519.PrivateKeySize]byte, error) {
+	_, priv, err := ed25519.GenerateKey(bytes.NewReader(seed[:]))
+	return priv, err
+}
+
 // loadOrGenerateKeys reads the master signing key from the global
 // state in DB, (generating one if it's not already there), and
 // generates the boxing keys based on it.
 //
+// If restore is not nil, the master key is derived from it instead of
+// generated. A different existing key is an error.
+//
 // This is meant to be called exactly once at startup time.
-func loadOrGenerateKeys(db *bolt.DB) (*CryptoKeys, error) {
+func loadOrGenerateKeys(db *bolt.DB, restore *[32]byte) (*CryptoKeys, error) {
 	var k CryptoKeys
 
 	getKey := func(tx *bolt.Tx) error {
@@ -54,10 +67,25 @@ func loadOrGenerateKeys(db *bolt.DB) (*CryptoKeys, error) {
 		return nil, err
 	}
 
+	if restore != nil && k.Sign.Priv != nil {
+		restored, err := keyFromSeed(restore)
+		if err != nil {
+			return nil, err
+		}
+		if *restored != *k.Sign.Priv {
+			return nil, errors.New("data directory already has a different identity")
+		}
+	}
+
 	if k.Sign.Priv == nil {
 		// did not load keys from database
 		var err error
-		_, signPriv, err := ed25519.GenerateKey(rand.Reader)
+		var signPriv *[ed25519.PrivateKeySize]byte
+		if restore != nil {
+			signPriv, err = keyFromSeed(restore)
+		} else {
+			_, signPriv, err = ed25519.GenerateKey(rand.Reader)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -82,3 +110,44 @@ func loadOrGenerateKeys(db *bolt.DB) (*CryptoKeys, error) {
 	extra25519.PublicKeyToCurve25519(k.Box.Pub, k.Sign.Pub)
 	return &k, nil
 }
+
+// PaperBackup returns the secrets needed to restore the identity of
+// this node, and access to the volumes it shares, on another machine.
+func (app *App) PaperBackup() (*paperkey.Backup, error) {
+	b := &paperkey.Backup{}
+	copy(b.Seed[:], app.Keys.Sign.Priv[:32])
+	export := func(tx *db.Tx) error {
+		c := tx.SharingKeys().Cursor()
+		for item := c.First(); item != nil; item = c.Next() {
+			s := paperkey.SharingKey{Name: item.Name()}
+			item.Secret(&s.Secret)
+			b.Sharing = append(b.Sharing, s)
+		}
+		return nil
+	}
+	if err := app.DB.View(export); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// RestoreSharingKeys adds the sharing keys from a paper backup.
+// Existing keys with the same name are replaced; this is meant for
+// freshly created data directories, where the only key is a new
+// random default.
+func (app *App) RestoreSharingKeys(b *paperkey.Backup) error {
+	restore := func(tx *db.Tx) error {
+		keys := tx.SharingKeys()
+		for i := range b.Sharing {
+			s := &b.Sharing[i]
+			if err := keys.Delete(s.Name); err != nil && err != db.ErrSharingKeyNotFound {
+				return err
+			}
+			if _, err := keys.Add(s.Name, &s.Secret); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return app.DB.Update(restore)
+}
@@ -19,3 +19,22 @@ func TestExtractEd25519PublicKey(t *testing.T) {
 		t.Errorf("assumed public key would be in private key: %x != %x", g, e)
 	}
 }
+
+func TestKeyFromSeed(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seed [32]byte
+	copy(seed[:], priv[:32])
+	got, err := keyFromSeed(&seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *priv {
+		t.Errorf("key from seed differs from original")
+	}
+	if g, e := extractEd25519Pubkey(got), pub; !bytes.Equal(g[:], e[:]) {
+		t.Errorf("wrong public key: %x != %x", g, e)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+)
+
+// keyMismatch records that other answered when pub was dialed at
+// addr. The connection is refused by the caller; this makes the
+// problem stick around until someone looks at it, as it may mean
+// someone is impersonating the peer.
+func (app *App) keyMismatch(pub *peer.PublicKey, addr string, other *peer.PublicKey) {
+	log.Printf("peer %s: refusing %s at %s, it has a different key", pub, other, addr)
+	record := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(pub)
+		if err != nil {
+			return err
+		}
+		return p.RecordKeyAlert(other, addr, time.Now())
+	}
+	// peers are dialed while opening volumes, from within
+	// transactions; don't wait for a write transaction here
+	go func() {
+		if err := app.DB.Update(record); err != nil && err != db.ErrPeerNotFound {
+			log.Printf("db error: recording key alert: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"bazil.org/bazil/db"
+	wiredb "bazil.org/bazil/db/wire"
+	"bazil.org/bazil/fs"
+)
+
+// volumeLimits fills in the limits a volume does not configure with
+// the server-wide ones.
+func (app *App) volumeLimits(l *wiredb.VolumeLimits) fs.Limits {
+	limits := app.limits
+	if l.MaxFileSize != 0 {
+		limits.MaxFileSize = l.MaxFileSize
+	}
+	if l.MaxNameBytes != 0 {
+		limits.MaxNameBytes = l.MaxNameBytes
+	}
+	if l.MaxDepth != 0 {
+		limits.MaxDepth = l.MaxDepth
+	}
+	return limits
+}
+
+// SetVolumeLimits configures the limits of the volume, replacing any
+// earlier ones. Zero fields use the server-wide limits. If the volume
+// is open, the change takes effect right away.
+func (app *App) SetVolumeLimits(volumeName string, l *wiredb.VolumeLimits) error {
+	var volID db.VolumeID
+	set := func(tx *db.Tx) error {
+		vol, err := tx.Volumes().GetByName(volumeName)
+		if err != nil {
+			return err
+		}
+		vol.VolumeID(&volID)
+		return vol.SetLimits(l)
+	}
+	if err := app.DB.Update(set); err != nil {
+		return err
+	}
+
+	app.volumes.Lock()
+	defer app.volumes.Unlock()
+	if ref, ok := app.volumes.open[volID]; ok {
+		ref.fs.SetLimits(app.volumeLimits(l))
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// The number of queued messages to send to a peer in one request.
+const maxMessageBatch = 100
+
+// QueueMessage adds a message to the outbox of the peer. It is
+// delivered by DeliverMessages.
+func (app *App) QueueMessage(pub *peer.PublicKey, kind wirepeer.Message_Kind, body []byte) error {
+	msg := &wirepeer.Message{
+		Kind: kind,
+		Body: body,
+		Sent: time.Now().Unix(),
+	}
+	buf, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	queue := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(pub)
+		if err != nil {
+			return err
+		}
+		_, err = p.Outbox().Add(buf)
+		return err
+	}
+	return app.DB.Update(queue)
+}
+
+// DeliverMessages sends the messages queued for the peer. Messages
+// are removed from the outbox only once the peer has accepted them,
+// so a failed delivery can simply be retried; the peer ignores
+// duplicates.
+func (app *App) DeliverMessages(ctx context.Context, pub *peer.PublicKey) error {
+	var client PeerClient
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+	}()
+
+	for {
+		var batch []*wirepeer.Message
+		gather := func(tx *db.Tx) error {
+			p, err := tx.Peers().Get(pub)
+			if err != nil {
+				return err
+			}
+			c := p.Outbox().Cursor()
+			for item := c.First(); item != nil && len(batch) < maxMessageBatch; item = c.Next() {
+				var msg wirepeer.Message
+				if err := proto.Unmarshal(item.Data, &msg); err != nil {
+					return err
+				}
+				msg.Seq = item.Seq
+				batch = append(batch, &msg)
+			}
+			return nil
+		}
+		if err := app.DB.View(gather); err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if client == nil {
+			c, err := app.DialPeer(pub)
+			if err != nil {
+				return err
+			}
+			client = c
+		}
+		req := &wirepeer.MessageSendRequest{
+			Messages: batch,
+		}
+		if _, err := client.MessageSend(ctx, req); err != nil {
+			return err
+		}
+
+		sent := func(tx *db.Tx) error {
+			p, err := tx.Peers().Get(pub)
+			if err != nil {
+				return err
+			}
+			outbox := p.Outbox()
+			for _, msg := range batch {
+				if err := outbox.Remove(msg.Seq); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := app.DB.Update(sent); err != nil {
+			return err
+		}
+	}
+}
+
+// PendingMessages returns the peers that have messages waiting for
+// delivery.
+func (app *App) PendingMessages() ([]*peer.PublicKey, error) {
+	var pubs []*peer.PublicKey
+	find := func(tx *db.Tx) error {
+		c := tx.Peers().Cursor()
+		for p := c.First(); p != nil; p = c.Next() {
+			if p.Outbox().Cursor().First() != nil {
+				pub := *p.Pub()
+				pubs = append(pubs, &pub)
+			}
+		}
+		return nil
+	}
+	if err := app.DB.View(find); err != nil {
+		return nil, err
+	}
+	return pubs, nil
+}
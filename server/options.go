@@ -1,11 +1,28 @@
 package server
 
+import (
+	"errors"
+	"time"
+
+	"bazil.org/bazil/fs"
+)
+
 type appOption func(*appConfig) error
 
 type AppOption appOption
 
 type appConfig struct {
 	debug func(msg interface{})
+	tier  struct {
+		backend string
+		after   time.Duration
+	}
+	handleLimit   uint64
+	writeThrottle *writeThrottleConfig
+	limits        fs.Limits
+	restoreSeed   *[32]byte
+	statsMaxAge   time.Duration
+	maxPause      time.Duration
 }
 
 func Debug(fn func(msg interface{})) AppOption {
@@ -14,3 +31,116 @@ func Debug(fn func(msg interface{})) AppOption {
 		return nil
 	}
 }
+
+// Tiering makes the local chunk store demote data not accessed for
+// the given duration to backend. Demoted data is recalled
+// automatically when read. See App.DemoteColdChunks.
+func Tiering(backend string, after time.Duration) AppOption {
+	return func(conf *appConfig) error {
+		if backend == "" || backend == "local" {
+			return errors.New("tiering backend must not be the local store")
+		}
+		conf.tier.backend = backend
+		conf.tier.after = after
+		return nil
+	}
+}
+
+// HandleLimit limits the number of files that can be open at once in
+// each volume. Zero means no limit.
+func HandleLimit(n uint64) AppOption {
+	return func(conf *appConfig) error {
+		conf.handleLimit = n
+		return nil
+	}
+}
+
+type writeThrottleConfig struct {
+	maxPending int
+	target     time.Duration
+}
+
+// WriteThrottle changes how writes to volumes are slowed down when
+// storage falls behind: at most maxPending writes per volume are
+// processed at once, and writes are held back while storing data
+// takes longer than target. Zero disables the respective limit. See
+// fs.Volume.SetWriteThrottle.
+func WriteThrottle(maxPending int, target time.Duration) AppOption {
+	return func(conf *appConfig) error {
+		if maxPending < 0 || target < 0 {
+			return errors.New("write throttle limits must not be negative")
+		}
+		conf.writeThrottle = &writeThrottleConfig{
+			maxPending: maxPending,
+			target:     target,
+		}
+		return nil
+	}
+}
+
+// Limits sets the limits of volumes that do not configure their own.
+// See fs.Limits.
+func Limits(l fs.Limits) AppOption {
+	return func(conf *appConfig) error {
+		conf.limits = l
+		return nil
+	}
+}
+
+// RestoreIdentity makes a new data directory use the node identity
+// derived from seed, as found in a paper backup, instead of creating
+// a new one. Opening a data directory with a different identity
+// fails.
+func RestoreIdentity(seed *[32]byte) AppOption {
+	return func(conf *appConfig) error {
+		conf.restoreSeed = seed
+		return nil
+	}
+}
+
+// StatsReplica makes statistics and listings read a copy of the
+// database that is refreshed when older than maxAge, instead of
+// holding long transactions open on the live one. See App.StatsView.
+func StatsReplica(maxAge time.Duration) AppOption {
+	return func(conf *appConfig) error {
+		if maxAge < 0 {
+			return errors.New("database copy age must not be negative")
+		}
+		conf.statsMaxAge = maxAge
+		return nil
+	}
+}
+
+// MaxPause limits how long maintenance can hold back requests to a
+// volume, after which they are let through regardless. See
+// fs.Volume.Pause.
+func MaxPause(d time.Duration) AppOption {
+	return func(conf *appConfig) error {
+		if d <= 0 {
+			return errors.New("maximum pause must be positive")
+		}
+		conf.maxPause = d
+		return nil
+	}
+}
+
+type mountOption func(*mountConfig) error
+
+type MountOption mountOption
+
+type mountConfig struct {
+	// nil unless other local users can see the mount
+	access *fs.UserAccess
+}
+
+// AllowOther makes the mount visible to other local users. What they
+// can do is decided by access, not by file permissions.
+func AllowOther(access *fs.UserAccess) MountOption {
+	return func(conf *mountConfig) error {
+		if access == nil {
+			return errors.New("allowing other users needs an access policy")
+		}
+		conf.access = access
+		return nil
+	}
+}
@@ -1,7 +1,10 @@
 package server
 
 import (
+	"errors"
 	"io"
+	"net"
+	"strings"
 	"time"
 
 	"bazil.org/bazil/db"
@@ -49,6 +52,28 @@ func (p *peerClient) Close() error {
 	return p.conn.Close()
 }
 
+// UnixAddrPrefix marks a peer location as the path of a Unix domain
+// socket, for peers running on the same host.
+const UnixAddrPrefix = "unix:"
+
+// ValidatePeerAddr checks that addr can be used as the network
+// location of a peer: either host:port, or UnixAddrPrefix followed by
+// a socket path.
+func ValidatePeerAddr(addr string) error {
+	if strings.HasPrefix(addr, UnixAddrPrefix) {
+		if len(addr) == len(UnixAddrPrefix) {
+			return errors.New("missing socket path")
+		}
+		return nil
+	}
+	_, _, err := net.SplitHostPort(addr)
+	return err
+}
+
+func dialUnix(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
+
 func (app *App) DialPeer(pub *peer.PublicKey) (PeerClient, error) {
 	var addr string
 	find := func(tx *db.Tx) error {
@@ -66,18 +91,32 @@ func (app *App) DialPeer(pub *peer.PublicKey) (PeerClient, error) {
 	if err := app.DB.View(find); err != nil {
 		return nil, err
 	}
+	return app.DialPeerAt(pub, addr)
+}
 
+// DialPeerAt connects to the peer at the given address, which need
+// not be one of its known locations; the peer may even be unknown.
+func (app *App) DialPeerAt(pub *peer.PublicKey, addr string) (PeerClient, error) {
+	keyMismatch := func(addr string, other *[ed25519.PublicKeySize]byte) {
+		app.keyMismatch(pub, addr, (*peer.PublicKey)(other))
+	}
 	auth := &grpcedtls.Authenticator{
-		Config:  app.GetTLSConfig,
-		PeerPub: (*[ed25519.PublicKeySize]byte)(pub),
+		Config:      app.GetTLSConfig,
+		PeerPub:     (*[ed25519.PublicKeySize]byte)(pub),
+		KeyMismatch: keyMismatch,
 	}
 
 	// TODO never delay here.
 	// https://github.com/grpc/grpc-go/blob/8ce50750fe22e967aa8b1d308b21511844674b57/clientconn.go#L85
-	conn, err := grpc.Dial(addr,
+	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(auth),
-		grpc.WithTimeout(30*time.Second),
-	)
+		grpc.WithTimeout(30 * time.Second),
+	}
+	if strings.HasPrefix(addr, UnixAddrPrefix) {
+		addr = addr[len(UnixAddrPrefix):]
+		opts = append(opts, grpc.WithDialer(dialUnix))
+	}
+	conn, err := grpc.Dial(addr, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,94 @@
+package peer
+
+import (
+	"log"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func (p *peers) MessageSend(ctx context.Context, req *wire.MessageSendRequest) (*wire.MessageSendResponse, error) {
+	pub, err := p.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := func(tx *db.Tx) error {
+		sender, err := tx.Peers().Get(pub)
+		if err != nil {
+			return err
+		}
+		inbox := sender.Inbox()
+		for _, msg := range req.Messages {
+			buf, err := proto.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			// the sender's sequence number makes redelivery harmless
+			if err := inbox.Put(msg.Seq, buf); err != nil {
+				return err
+			}
+
+			if msg.Kind == wire.Message_ADDRESS {
+				addr := string(msg.Body)
+				if err := server.ValidatePeerAddr(addr); err != nil {
+					log.Printf("ignoring bad address from peer %v: %q: %v", pub, addr, err)
+					continue
+				}
+				if err := sender.Locations().Set(addr); err != nil {
+					return err
+				}
+			}
+
+			if msg.Kind == wire.Message_VOLUME_REVOKED {
+				if err := p.volumeRevoked(tx, pub, msg.Body); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := p.app.DB.Update(store); err != nil {
+		if err == db.ErrInboxFull {
+			// the sender keeps the messages and tries again later
+			return nil, grpc.Errorf(codes.ResourceExhausted, "%v", err)
+		}
+		return nil, err
+	}
+	return &wire.MessageSendResponse{}, nil
+}
+
+// volumeRevoked records that the sender stopped sharing a volume
+// with us, or started sharing it again. Bad or unknown revocations
+// are only logged, as the message itself has been stored already.
+func (p *peers) volumeRevoked(tx *db.Tx, sender *peer.PublicKey, body []byte) error {
+	var signed wire.SignedVolumeRevocation
+	if err := proto.Unmarshal(body, &signed); err != nil {
+		log.Printf("ignoring bad revocation from peer %v: %v", sender, err)
+		return nil
+	}
+	rev, err := p.app.OpenVolumeRevocation(&signed, sender)
+	if err != nil {
+		log.Printf("ignoring bad revocation from peer %v: %v", sender, err)
+		return nil
+	}
+	var volID db.VolumeID
+	if err := volID.UnmarshalBinary(rev.VolumeID); err != nil {
+		log.Printf("ignoring bad revocation from peer %v: %v", sender, err)
+		return nil
+	}
+	vol, err := tx.Volumes().GetByVolumeID(&volID)
+	if err == db.ErrVolumeIDNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return vol.Revocations().Record(sender, time.Unix(rev.Time, 0), !rev.Restored)
+}
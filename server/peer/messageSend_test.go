@@ -0,0 +1,108 @@
+package peer_test
+
+import (
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"bazil.org/bazil/db"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server/http/httptest"
+	"bazil.org/bazil/util/tempdir"
+	"github.com/golang/protobuf/proto"
+)
+
+func TestMessageDeliver(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+	pub2 := (*peer.PublicKey)(app2.Keys.Sign.Pub)
+
+	setup1 := func(tx *db.Tx) error {
+		if _, err := tx.Peers().Make(pub2); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := app1.DB.Update(setup1); err != nil {
+		t.Fatalf("app1 setup: %v", err)
+	}
+
+	setup2 := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pub1)
+		if err != nil {
+			return err
+		}
+		if err := p.Locations().Set(web1.Addr().String()); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := app2.DB.Update(setup2); err != nil {
+		t.Fatalf("app2 setup location: %v", err)
+	}
+
+	if err := app2.QueueMessage(pub1, wire.Message_NOTE, []byte("hello")); err != nil {
+		t.Fatalf("queue: %v", err)
+	}
+	ctx := context.Background()
+	if err := app2.DeliverMessages(ctx, pub1); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	checkOutbox := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(pub1)
+		if err != nil {
+			return err
+		}
+		if item := p.Outbox().Cursor().First(); item != nil {
+			t.Errorf("outbox not empty after delivery: %d", item.Seq)
+		}
+		return nil
+	}
+	if err := app2.DB.View(checkOutbox); err != nil {
+		t.Fatal(err)
+	}
+
+	checkInbox := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(pub2)
+		if err != nil {
+			return err
+		}
+		c := p.Inbox().Cursor()
+		item := c.First()
+		if item == nil {
+			t.Fatal("inbox is empty")
+		}
+		var msg wire.Message
+		if err := proto.Unmarshal(item.Data, &msg); err != nil {
+			return err
+		}
+		if g, e := msg.Kind, wire.Message_NOTE; g != e {
+			t.Errorf("wrong kind: %v != %v", g, e)
+		}
+		if g, e := string(msg.Body), "hello"; g != e {
+			t.Errorf("wrong body: %q != %q", g, e)
+		}
+		if item := c.Next(); item != nil {
+			t.Errorf("unexpected extra message: %d", item.Seq)
+		}
+		return nil
+	}
+	if err := app1.DB.View(checkInbox); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -4,12 +4,28 @@ import (
 	"log"
 
 	"bazil.org/bazil/db"
-	"bazil.org/bazil/kv"
 	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/util/errkind"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
 
+// storageError converts a storage failure into an error to return
+// to the peer. Beyond a missing key, only the kind of failure is
+// revealed; the details are logged.
+func storageError(err error, doing string) error {
+	kind := errkind.Of(err)
+	if kind == errkind.NotFound {
+		return grpc.Errorf(codes.NotFound, err.Error())
+	}
+	// TODO safe errors
+	log.Printf("kv error: %s: %v", doing, err)
+	if kind == errkind.Other {
+		return grpc.Errorf(codes.Internal, "internal error")
+	}
+	return grpc.Errorf(errkind.Code(err), "storage problem: %v", kind)
+}
+
 func (p *peers) ObjectGet(req *wire.ObjectGetRequest, stream wire.Peer_ObjectGetServer) error {
 	pub, err := p.auth(stream.Context())
 	if err != nil {
@@ -25,14 +41,18 @@ func (p *peers) ObjectGet(req *wire.ObjectGetRequest, stream wire.Peer_ObjectGet
 
 	buf, err := store.Get(stream.Context(), req.Key)
 	if err != nil {
-		if _, ok := err.(kv.NotFoundError); ok {
-			return grpc.Errorf(codes.NotFound, err.Error())
-		}
-		// TODO safe errors
-		log.Printf("kv error: getting key for peer: %v", err)
-		return grpc.Errorf(codes.Internal, "internal error")
+		return storageError(err, "getting key for peer")
 	}
 
+	p.app.CountTraffic(pub, nil, uint64(len(buf)), 0)
+	return sendObject(stream, buf)
+}
+
+// sendObject streams the object contents in pieces small enough for
+// a single gRPC message.
+func sendObject(stream interface {
+	Send(*wire.ObjectGetResponse) error
+}, buf []byte) error {
 	const chunkSize = 4 * 1024 * 1024
 	var chunk []byte
 	for len(buf) > 0 {
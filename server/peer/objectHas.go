@@ -0,0 +1,42 @@
+package peer
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/kv"
+	"bazil.org/bazil/peer/wire"
+)
+
+// How many keys can be asked about in one request.
+const maxObjectHasKeys = 1024
+
+func (p *peers) ObjectHas(ctx context.Context, req *wire.ObjectHasRequest) (*wire.ObjectHasResponse, error) {
+	pub, err := p.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Keys) > maxObjectHasKeys {
+		return nil, grpc.Errorf(codes.InvalidArgument, "too many keys, at most %d", maxObjectHasKeys)
+	}
+	store, err := p.app.OpenKVForPeer(pub)
+	if err != nil {
+		if err == db.ErrNoStorageForPeer {
+			return nil, grpc.Errorf(codes.PermissionDenied, "%v", err)
+		}
+		return nil, err
+	}
+	resp := &wire.ObjectHasResponse{
+		Has: make([]bool, len(req.Keys)),
+	}
+	for i, key := range req.Keys {
+		found, err := kv.Has(ctx, store, key)
+		if err != nil {
+			return nil, storageError(err, "checking object")
+		}
+		resp.Has[i] = found
+	}
+	return resp, nil
+}
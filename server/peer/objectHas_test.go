@@ -0,0 +1,107 @@
+package peer_test
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"bazil.org/bazil/db"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/kv/kvpeer"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/http/httptest"
+	"bazil.org/bazil/util/tempdir"
+)
+
+func TestObjectHas(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+	pub2 := (*peer.PublicKey)(app2.Keys.Sign.Pub)
+
+	setup1 := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pub2)
+		if err != nil {
+			return err
+		}
+		return p.Storage().Allow("local")
+	}
+	if err := app1.DB.Update(setup1); err != nil {
+		t.Fatalf("app1 setup: %v", err)
+	}
+	setup2 := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pub1)
+		if err != nil {
+			return err
+		}
+		return p.Locations().Set(web1.Addr().String())
+	}
+	if err := app2.DB.Update(setup2); err != nil {
+		t.Fatalf("app2 setup: %v", err)
+	}
+
+	client, err := app2.DialPeer(pub1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var sent uint64
+	count := func(s, r uint64) { sent += s }
+	store, err := kvpeer.Open(client, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	other := []byte("fedcba9876543210fedcba9876543210")
+	big := bytes.Repeat([]byte("hello, world\n"), 10000)
+	if err := store.Put(ctx, key, big); err != nil {
+		t.Fatal(err)
+	}
+	if g, e := sent, uint64(len(big)); g != e {
+		t.Errorf("wrong bytes sent: %d != %d", g, e)
+	}
+
+	found, err := store.HasMany(ctx, [][]byte{key, other})
+	if err != nil {
+		t.Fatalf("has failed: %v", err)
+	}
+	if g, e := found, []bool{true, false}; !reflect.DeepEqual(g, e) {
+		t.Errorf("wrong answer: %v != %v", g, e)
+	}
+
+	// the second upload is skipped
+	if err := store.Put(ctx, key, big); err != nil {
+		t.Fatal(err)
+	}
+	if g, e := sent, uint64(len(big)); g != e {
+		t.Errorf("object was sent again: %d != %d", g, e)
+	}
+
+	// small objects are sent without asking
+	sent = 0
+	const greeting = "hello, world\n"
+	smallKey := []byte("0123456789abcdef0123456789abcde0")
+	for i := 0; i < 2; i++ {
+		if err := store.Put(ctx, smallKey, []byte(greeting)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g, e := sent, uint64(2*len(greeting)); g != e {
+		t.Errorf("wrong bytes sent for small object: %d != %d", g, e)
+	}
+}
@@ -3,28 +3,24 @@ package peer
 import (
 	"io"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 
 	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
 	"bazil.org/bazil/peer/wire"
 )
 
 func (p *peers) ObjectPut(stream wire.Peer_ObjectPutServer) error {
-	pub, err := p.auth(stream.Context())
+	pub, err := remotePub(stream.Context())
 	if err != nil {
 		return err
 	}
-	store, err := p.app.OpenKVForPeer(pub)
-	if err != nil {
-		if err == db.ErrNoStorageForPeer {
-			return grpc.Errorf(codes.PermissionDenied, "%v", err)
-		}
-		return err
-	}
 
 	var key []byte
 	var data []byte
+	var owner *peer.PublicKey
 	for {
 		req, err := stream.Recv()
 		if err != nil {
@@ -38,12 +34,43 @@ func (p *peers) ObjectPut(stream wire.Peer_ObjectPutServer) error {
 				return grpc.Errorf(codes.InvalidArgument, "ObjectPutRequest.Key must be set in first streamed message")
 			}
 			key = req.Key
+			if owner, err = p.objectOwner(stream.Context(), pub, key, req.Capability); err != nil {
+				return err
+			}
 		}
 		data = append(data, req.Data...)
 	}
+	if owner == nil {
+		// nothing was sent
+		if owner, err = p.objectOwner(stream.Context(), pub, key, nil); err != nil {
+			return err
+		}
+	}
+	store, err := p.app.OpenKVForPeer(owner)
+	if err != nil {
+		if err == db.ErrNoStorageForPeer || err == db.ErrPeerNotFound {
+			return grpc.Errorf(codes.PermissionDenied, "%v", err)
+		}
+		return err
+	}
+	p.app.CountTraffic(pub, nil, 0, uint64(len(data)))
 
 	if err := store.Put(stream.Context(), key, data); err != nil {
 		return err
 	}
 	return stream.SendAndClose(&wire.ObjectPutResponse{})
 }
+
+// objectOwner decides whose storage an object sent by pub goes in.
+// Normally that is the sender's own, but with a capability, a peer
+// may send objects on behalf of their owner.
+func (p *peers) objectOwner(ctx context.Context, pub *peer.PublicKey, key []byte, capability *wire.TransferCapability) (*peer.PublicKey, error) {
+	if capability == nil {
+		return p.auth(ctx)
+	}
+	owner, err := p.app.VerifyTransfer(capability, pub, key)
+	if err != nil {
+		return nil, grpc.Errorf(codes.PermissionDenied, "%v", err)
+	}
+	return owner, nil
+}
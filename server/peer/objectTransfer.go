@@ -0,0 +1,67 @@
+package peer
+
+import (
+	"bytes"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/kv/kvpeer"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// ObjectTransfer sends an object stored for the caller straight to
+// another peer, so that moving data between two peers does not need
+// to route it through the owner.
+func (p *peers) ObjectTransfer(ctx context.Context, req *wire.ObjectTransferRequest) (*wire.ObjectTransferResponse, error) {
+	pub, err := p.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var receiver peer.PublicKey
+	if err := receiver.UnmarshalBinary(req.Receiver); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad receiver public key: %v", err)
+	}
+	grant, owner, err := server.OpenTransferCapability(req.Capability)
+	if err != nil {
+		return nil, grpc.Errorf(codes.PermissionDenied, "%v", err)
+	}
+	if *owner != *pub ||
+		!bytes.Equal(grant.Sender, p.app.Keys.Sign.Pub[:]) ||
+		!bytes.Equal(grant.Receiver, req.Receiver) ||
+		!bytes.Equal(grant.Key, req.Key) {
+		return nil, grpc.Errorf(codes.PermissionDenied, "capability does not match the transfer")
+	}
+
+	store, err := p.app.OpenKVForPeer(pub)
+	if err != nil {
+		if err == db.ErrNoStorageForPeer {
+			return nil, grpc.Errorf(codes.PermissionDenied, "%v", err)
+		}
+		return nil, err
+	}
+	buf, err := store.Get(ctx, req.Key)
+	if err != nil {
+		return nil, storageError(err, "getting key for transfer")
+	}
+
+	client, err := p.app.DialPeerAt(&receiver, req.Address)
+	if err != nil {
+		return nil, grpc.Errorf(codes.Unavailable, "cannot reach receiver: %v", err)
+	}
+	defer client.Close()
+	count := func(sent, received uint64) {
+		p.app.CountTraffic(&receiver, nil, sent, received)
+	}
+	dst, err := kvpeer.Open(client, count)
+	if err != nil {
+		return nil, err
+	}
+	if err := dst.PutWithCapability(ctx, req.Key, buf, req.Capability); err != nil {
+		return nil, err
+	}
+	return &wire.ObjectTransferResponse{}, nil
+}
@@ -0,0 +1,98 @@
+package peer_test
+
+import (
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"bazil.org/bazil/db"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/server/http/httptest"
+	"bazil.org/bazil/util/tempdir"
+)
+
+func TestObjectTransfer(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	appOwner := bazfstestutil.NewAppWithName(t, tmp.Subdir("owner"), "owner")
+	defer appOwner.Close()
+	appA := bazfstestutil.NewAppWithName(t, tmp.Subdir("a"), "a")
+	defer appA.Close()
+	appB := bazfstestutil.NewAppWithName(t, tmp.Subdir("b"), "b")
+	defer appB.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	webA := httptest.ServeHTTP(t, &wg, appA)
+	defer webA.Close()
+	webB := httptest.ServeHTTP(t, &wg, appB)
+	defer webB.Close()
+
+	pubOwner := (*peer.PublicKey)(appOwner.Keys.Sign.Pub)
+	pubA := (*peer.PublicKey)(appA.Keys.Sign.Pub)
+	pubB := (*peer.PublicKey)(appB.Keys.Sign.Pub)
+
+	// A and B store objects for the owner, but do not know each other
+	allowOwner := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pubOwner)
+		if err != nil {
+			return err
+		}
+		return p.Storage().Allow("local")
+	}
+	if err := appA.DB.Update(allowOwner); err != nil {
+		t.Fatalf("a setup: %v", err)
+	}
+	if err := appB.DB.Update(allowOwner); err != nil {
+		t.Fatalf("b setup: %v", err)
+	}
+	setupOwner := func(tx *db.Tx) error {
+		a, err := tx.Peers().Make(pubA)
+		if err != nil {
+			return err
+		}
+		if err := a.Locations().Set(webA.Addr().String()); err != nil {
+			return err
+		}
+		b, err := tx.Peers().Make(pubB)
+		if err != nil {
+			return err
+		}
+		if err := b.Locations().Set(webB.Addr().String()); err != nil {
+			return err
+		}
+		return nil
+	}
+	if err := appOwner.DB.Update(setupOwner); err != nil {
+		t.Fatalf("owner setup: %v", err)
+	}
+
+	ctx := context.Background()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	const greeting = "hello, world\n"
+	storeA, err := appA.OpenKVForPeer(pubOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storeA.Put(ctx, key, []byte(greeting)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := appOwner.TransferObject(ctx, pubA, pubB, key); err != nil {
+		t.Fatalf("transfer failed: %v", err)
+	}
+
+	storeB, err := appB.OpenKVForPeer(pubOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := storeB.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("object not at receiver: %v", err)
+	}
+	if g, e := string(buf), greeting; g != e {
+		t.Errorf("wrong content: %q != %q", g, e)
+	}
+}
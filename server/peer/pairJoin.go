@@ -0,0 +1,99 @@
+package peer
+
+import (
+	"bytes"
+	"log"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PairJoin lets a new device use a pairing invitation. The caller
+// need not be a known peer; knowing the invitation token is enough.
+// The caller is added as a peer, given the access the invitation
+// grants, and told what volumes it can connect to, along with the
+// sharing keys needed for them.
+func (p *peers) PairJoin(ctx context.Context, req *wire.PairJoinRequest) (*wire.PairJoinResponse, error) {
+	pub, err := remotePub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(pub[:], p.app.Keys.Sign.Pub[:]) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "cannot pair with self")
+	}
+
+	resp := &wire.PairJoinResponse{}
+	join := func(tx *db.Tx) error {
+		dbp, err := tx.Peers().Make(pub)
+		if err != nil {
+			return err
+		}
+		keys := make(map[string]struct{})
+		use := func(inv *db.PairInvite) error {
+			allowStorage := func(backend string) error {
+				return dbp.Storage().Allow(backend)
+			}
+			if err := inv.Storage(allowStorage); err != nil {
+				return err
+			}
+			allowVolume := func(name string, volID *db.VolumeID) error {
+				vol, err := tx.Volumes().GetByVolumeID(volID)
+				if err == db.ErrVolumeIDNotFound {
+					// removed since the invitation was made
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if err := dbp.Volumes().Allow(vol); err != nil {
+					return err
+				}
+				c := vol.Storage().Cursor()
+				item := c.First()
+				if item == nil {
+					return nil
+				}
+				keyName, err := item.SharingKeyName()
+				if err != nil {
+					return err
+				}
+				keys[keyName] = struct{}{}
+				resp.Volumes = append(resp.Volumes, &wire.PairVolume{
+					Name:           name,
+					VolumeID:       append([]byte(nil), volID[:]...),
+					SharingKeyName: keyName,
+				})
+				return nil
+			}
+			return inv.Volumes(allowVolume)
+		}
+		if err := tx.PairInvites().Use(req.Token, time.Now(), use); err != nil {
+			return err
+		}
+		for name := range keys {
+			sk, err := tx.SharingKeys().Get(name)
+			if err != nil {
+				return err
+			}
+			var secret [32]byte
+			sk.Secret(&secret)
+			resp.SharingKeys = append(resp.SharingKeys, &wire.PairSharingKey{
+				Name:   name,
+				Secret: secret[:],
+			})
+		}
+		return nil
+	}
+	if err := p.app.DB.Update(join); err != nil {
+		if err == db.ErrPairInviteNotFound {
+			return nil, grpc.Errorf(codes.PermissionDenied, "%v", err)
+		}
+		log.Printf("db update error: pair join: %v", err)
+		return nil, grpc.Errorf(codes.Internal, "database error")
+	}
+	return resp, nil
+}
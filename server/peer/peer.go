@@ -12,7 +12,9 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
-func (p *peers) auth(ctx context.Context) (*peer.PublicKey, error) {
+// remotePub returns the public key the caller authenticated with.
+// The caller is not necessarily a known peer; see auth.
+func remotePub(ctx context.Context) (*peer.PublicKey, error) {
 	authInfo, ok := credentials.FromContext(ctx)
 	if !ok {
 		return nil, grpc.Errorf(codes.Unauthenticated, "unauthenticated")
@@ -21,7 +23,14 @@ func (p *peers) auth(ctx context.Context) (*peer.PublicKey, error) {
 	if !ok {
 		return nil, grpc.Errorf(codes.Unauthenticated, "unauthenticated")
 	}
-	pub := (*peer.PublicKey)(auth.PeerPub)
+	return (*peer.PublicKey)(auth.PeerPub), nil
+}
+
+func (p *peers) auth(ctx context.Context) (*peer.PublicKey, error) {
+	pub, err := remotePub(ctx)
+	if err != nil {
+		return nil, err
+	}
 	getPeer := func(tx *db.Tx) error {
 		_, err := tx.Peers().Get(pub)
 		return err
@@ -35,6 +44,20 @@ func (p *peers) auth(ctx context.Context) (*peer.PublicKey, error) {
 	return pub, nil
 }
 
+// countPublished counts traffic for serving a published volume.
+// Anyone can fetch those, so only known peers are counted, to keep
+// strangers from filling the traffic log.
+func (p *peers) countPublished(pub *peer.PublicKey, sent uint64) {
+	known := func(tx *db.Tx) error {
+		_, err := tx.Peers().Get(pub)
+		return err
+	}
+	if err := p.app.DB.View(known); err != nil {
+		return
+	}
+	p.app.CountTraffic(pub, nil, sent, 0)
+}
+
 type peers struct {
 	app *server.App
 }
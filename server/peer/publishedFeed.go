@@ -0,0 +1,31 @@
+package peer
+
+import (
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PublishedFeed serves the snapshot list of a published volume. The
+// caller does not need to be a known peer.
+func (p *peers) PublishedFeed(ctx context.Context, req *wire.PublishedFeedRequest) (*wire.SignedPublishedFeed, error) {
+	pub, err := remotePub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var id db.PublicID
+	if err := id.UnmarshalBinary(req.PublicID); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	feed, err := p.app.PublishedFeed(&id)
+	if err != nil {
+		if err == db.ErrNotPublished {
+			return nil, grpc.Errorf(codes.NotFound, "%v", err)
+		}
+		return nil, err
+	}
+	p.countPublished(pub, uint64(len(feed.Feed)))
+	return feed, nil
+}
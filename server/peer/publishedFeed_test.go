@@ -0,0 +1,149 @@
+package peer_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"bazil.org/bazil/db"
+	bazfstestutil "bazil.org/bazil/fs/fstestutil"
+	wiresnap "bazil.org/bazil/fs/snap/wire"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server"
+	"bazil.org/bazil/server/http/httptest"
+	"bazil.org/bazil/util/tempdir"
+)
+
+func TestPublishedFeed(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+
+	const volumeName = "foo"
+	bazfstestutil.CreateVolume(t, app1, volumeName)
+	func() {
+		mnt := bazfstestutil.Mounted(t, app1, volumeName)
+		defer mnt.Close()
+		if err := ioutil.WriteFile(path.Join(mnt.Dir, "greeting"), []byte("hello, world\n"), 0644); err != nil {
+			t.Fatalf("cannot create file: %v", err)
+		}
+		if err := os.Mkdir(path.Join(mnt.Dir, ".snap", "mysnap"), 0755); err != nil {
+			t.Fatalf("snapshot failed: %v", err)
+		}
+	}()
+
+	var id *db.PublicID
+	publish := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName(volumeName)
+		if err != nil {
+			return err
+		}
+		id, err = v.Publish()
+		return err
+	}
+	if err := app1.DB.Update(publish); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// app2 is not a peer of app1
+	client, err := app2.DialPeerAt(pub1, web1.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	signed, err := client.PublishedFeed(ctx, &wire.PublishedFeedRequest{PublicID: id[:]})
+	if err != nil {
+		t.Fatalf("published feed: %v", err)
+	}
+	feed, err := server.OpenPublishedFeed(signed, pub1, id)
+	if err != nil {
+		t.Fatalf("open feed: %v", err)
+	}
+	if g, e := len(feed.Snapshots), 1; g != e {
+		t.Fatalf("wrong number of snapshots: %d != %d", g, e)
+	}
+	if g, e := feed.Snapshots[0].Name, "mysnap"; g != e {
+		t.Errorf("wrong snapshot name: %q != %q", g, e)
+	}
+
+	// a feed does not verify against anyone else's key
+	if _, err := server.OpenPublishedFeed(signed, (*peer.PublicKey)(app2.Keys.Sign.Pub), id); err != server.ErrBadPublishedFeed {
+		t.Errorf("expected ErrBadPublishedFeed with wrong key: %v", err)
+	}
+
+	get := func(key []byte, typ string) ([]byte, error) {
+		stream, err := client.PublishedObjectGet(ctx, &wire.PublishedObjectGetRequest{
+			PublicID: id[:],
+			Key:      key,
+			Type:     typ,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		for {
+			msg, err := stream.Recv()
+			if err == io.EOF {
+				return buf.Bytes(), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(msg.Data)
+		}
+	}
+	data, err := get(feed.Snapshots[0].Key, "snap")
+	if err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	var snap wiresnap.Snapshot
+	if err := proto.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("corrupt snapshot: %v", err)
+	}
+	if g, e := snap.Name, "mysnap"; g != e {
+		t.Errorf("wrong snapshot in chunk: %q != %q", g, e)
+	}
+
+	// chunks no snapshot refers to are not served
+	other := make([]byte, len(feed.Snapshots[0].Key))
+	other[0] = 42
+	if _, err := get(other, "blob"); grpc.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for unpublished chunk: %v", err)
+	}
+
+	unpublish := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByName(volumeName)
+		if err != nil {
+			return err
+		}
+		return v.Unpublish()
+	}
+	if err := app1.DB.Update(unpublish); err != nil {
+		t.Fatalf("unpublish: %v", err)
+	}
+	if _, err := client.PublishedFeed(ctx, &wire.PublishedFeedRequest{PublicID: id[:]}); grpc.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound after unpublishing: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package peer
+
+import (
+	"math"
+
+	"bazil.org/bazil/cas"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// PublishedObjectGet serves a chunk of a published volume, decrypted.
+// The caller does not need to be a known peer, but only gets chunks
+// that the published snapshots refer to.
+func (p *peers) PublishedObjectGet(req *wire.PublishedObjectGetRequest, stream wire.Peer_PublishedObjectGetServer) error {
+	ctx := stream.Context()
+	pub, err := remotePub(ctx)
+	if err != nil {
+		return err
+	}
+	var id db.PublicID
+	if err := id.UnmarshalBinary(req.PublicID); err != nil {
+		return grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	var key cas.Key
+	if err := key.UnmarshalBinary(req.Key); err != nil {
+		return grpc.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if req.Level > math.MaxUint8 {
+		return grpc.Errorf(codes.InvalidArgument, "level out of range")
+	}
+
+	buf, err := p.app.GetPublishedChunk(ctx, &id, key, req.Type, uint8(req.Level))
+	switch err {
+	case nil:
+	case db.ErrNotPublished, server.ErrNotPublishedChunk:
+		return grpc.Errorf(codes.NotFound, "%v", err)
+	default:
+		return storageError(err, "getting published chunk")
+	}
+
+	p.countPublished(pub, uint64(len(buf)))
+	return sendObject(stream, buf)
+}
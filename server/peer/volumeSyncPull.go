@@ -1,9 +1,12 @@
 package peer
 
 import (
+	"path"
+
 	"bazil.org/bazil/db"
 	"bazil.org/bazil/peer/wire"
 	"bazil.org/fuse"
+	"github.com/golang/protobuf/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 )
@@ -46,11 +49,29 @@ func (p *peers) VolumeSyncPull(req *wire.VolumeSyncPullRequest, stream wire.Peer
 	}
 	defer v.Close()
 
-	if err := v.FS().SyncSend(ctx, req.Path, stream.Send); err != nil {
+	// only pulls of the whole volume count for sync barriers
+	full := path.Clean("/"+req.Path) == "/"
+	var seq uint64
+	if full {
+		seq = p.app.PullStarted()
+	}
+	first := true
+	send := func(item *wire.VolumeSyncPullItem) error {
+		if first {
+			item.PullID = seq
+			first = false
+		}
+		p.app.CountTraffic(pub, &volID, uint64(proto.Size(item)), 0)
+		return stream.Send(item)
+	}
+	if err := v.FS().SyncSend(ctx, req.Path, send); err != nil {
 		if err == fuse.ENOENT {
 			return grpc.Errorf(codes.NotFound, "not found")
 		}
 		return err
 	}
+	if full {
+		p.app.PullSent(&volID, pub, seq)
+	}
 	return nil
 }
@@ -0,0 +1,27 @@
+package peer
+
+import (
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer/wire"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// VolumeSyncPullDone lets a peer confirm that it has saved everything
+// it received in a pull of the whole volume, so sync barriers can
+// count it.
+func (p *peers) VolumeSyncPullDone(ctx context.Context, req *wire.VolumeSyncPullDoneRequest) (*wire.VolumeSyncPullDoneResponse, error) {
+	pub, err := p.auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var volID db.VolumeID
+	if err := volID.UnmarshalBinary(req.VolumeID); err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, "bad volume ID: %v", err)
+	}
+	if !p.app.PullConfirmed(&volID, pub, req.PullID) {
+		return nil, grpc.Errorf(codes.NotFound, "no such pull")
+	}
+	return &wire.VolumeSyncPullDoneResponse{}, nil
+}
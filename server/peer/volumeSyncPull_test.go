@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"golang.org/x/net/context"
 
@@ -18,8 +19,11 @@ import (
 	bazfstestutil "bazil.org/bazil/fs/fstestutil"
 	"bazil.org/bazil/peer"
 	"bazil.org/bazil/peer/wire"
+	"bazil.org/bazil/server"
 	"bazil.org/bazil/server/http/httptest"
 	"bazil.org/bazil/util/tempdir"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
 func TestSyncPull(t *testing.T) {
@@ -214,5 +218,137 @@ func TestSyncPull(t *testing.T) {
 	}
 }
 
+func TestSyncBarrier(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app1 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app1"), "1")
+	defer app1.Close()
+	app2 := bazfstestutil.NewAppWithName(t, tmp.Subdir("app2"), "2")
+	defer app2.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	web1 := httptest.ServeHTTP(t, &wg, app1)
+	defer web1.Close()
+
+	pub1 := (*peer.PublicKey)(app1.Keys.Sign.Pub)
+	pub2 := (*peer.PublicKey)(app2.Keys.Sign.Pub)
+
+	var volID db.VolumeID
+	sharingKey := [32]byte{42, 42, 42, 13}
+	setup1 := func(tx *db.Tx) error {
+		sharingKey, err := tx.SharingKeys().Add("testkey", &sharingKey)
+		if err != nil {
+			return err
+		}
+		v, err := tx.Volumes().Create("foo", "local", sharingKey)
+		if err != nil {
+			return err
+		}
+		v.VolumeID(&volID)
+		p, err := tx.Peers().Make(pub2)
+		if err != nil {
+			return err
+		}
+		return p.Volumes().Allow(v)
+	}
+	if err := app1.DB.Update(setup1); err != nil {
+		t.Fatalf("app1 setup: %v", err)
+	}
+	setup2 := func(tx *db.Tx) error {
+		p, err := tx.Peers().Make(pub1)
+		if err != nil {
+			return err
+		}
+		return p.Locations().Set(web1.Addr().String())
+	}
+	if err := app2.DB.Update(setup2); err != nil {
+		t.Fatalf("app2 setup: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := app1.SyncBarrier(ctx, &volID, 2); err != server.ErrNotEnoughPeers {
+		t.Fatalf("expected ErrNotEnoughPeers, got %v", err)
+	}
+
+	type result struct {
+		pubs []peer.PublicKey
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		pubs, err := app1.SyncBarrier(ctx, &volID, 1)
+		done <- result{pubs, err}
+	}()
+
+	client, err := app2.DialPeer(pub1)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	volIDBuf, err := volID.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal volume id: %v", err)
+	}
+	pull := func() uint64 {
+		stream, err := client.VolumeSyncPull(ctx, &wire.VolumeSyncPullRequest{
+			VolumeID: volIDBuf,
+		})
+		if err != nil {
+			t.Fatalf("sync failed: %v", err)
+		}
+		var pullID uint64
+		for {
+			item, err := stream.Recv()
+			if err == io.EOF {
+				return pullID
+			}
+			if err != nil {
+				t.Fatalf("sync stream failed: %v", err)
+			}
+			if pullID == 0 {
+				pullID = item.PullID
+			}
+		}
+	}
+	confirm := func(pullID uint64) error {
+		_, err := client.VolumeSyncPullDone(ctx, &wire.VolumeSyncPullDoneRequest{
+			VolumeID: volIDBuf,
+			PullID:   pullID,
+		})
+		return err
+	}
+
+	// a pull that was never sent cannot be confirmed
+	if err := confirm(1000); grpc.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for unknown pull, got %v", err)
+	}
+
+	// pulls that start before the barrier do not count, so keep
+	// pulling until it notices
+	for {
+		pullID := pull()
+		if pullID == 0 {
+			t.Fatal("full pull did not get a pull ID")
+		}
+		if err := confirm(pullID); err != nil {
+			t.Fatalf("confirming pull: %v", err)
+		}
+		select {
+		case r := <-done:
+			if r.err != nil {
+				t.Fatalf("barrier failed: %v", r.err)
+			}
+			if len(r.pubs) != 1 || r.pubs[0] != *pub2 {
+				t.Errorf("wrong peers passed the barrier: %v", r.pubs)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
 // TODO TestSyncPullBadNotPeer
 // TODO TestSyncPullBadPeerNotAllowed
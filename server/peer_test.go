@@ -0,0 +1,26 @@
+package server
+
+import "testing"
+
+func TestValidatePeerAddr(t *testing.T) {
+	good := []string{
+		"example.com:2000",
+		"[::1]:2000",
+		"unix:/tmp/bazil/peer.sock",
+	}
+	for _, addr := range good {
+		if err := ValidatePeerAddr(addr); err != nil {
+			t.Errorf("expected %q to be valid: %v", addr, err)
+		}
+	}
+	bad := []string{
+		"",
+		"example.com",
+		"unix:",
+	}
+	for _, addr := range bad {
+		if err := ValidatePeerAddr(addr); err == nil {
+			t.Errorf("expected %q to be invalid", addr)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"bazil.org/bazil/cas"
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/fs/wire"
+	"bazil.org/bazil/peer"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"github.com/agl/ed25519"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// Published feeds are signed with the node key too, and travel to
+// anyone who asks; the prefix keeps a feed from passing as any other
+// signed message.
+const publishSignPrefix = "bazil-publish\n"
+
+var (
+	ErrBadPublishedFeed = errors.New("published feed is not valid")
+	// ErrNotPublishedChunk means no snapshot of the published volume
+	// refers to the chunk.
+	ErrNotPublishedChunk = errors.New("chunk is not published")
+)
+
+// PublishedFeed returns the signed list of snapshots of the volume
+// published under id.
+//
+// If there is no such published volume, returns db.ErrNotPublished.
+func (app *App) PublishedFeed(id *db.PublicID) (*wirepeer.SignedPublishedFeed, error) {
+	feed := &wirepeer.PublishedFeed{
+		PublicID:  id[:],
+		Publisher: app.Keys.Sign.Pub[:],
+		Time:      time.Now().Unix(),
+	}
+	list := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByPublicID(id)
+		if err != nil {
+			return err
+		}
+		c := v.SnapBucket().Cursor()
+		for k, val := c.First(); k != nil; k, val = c.Next() {
+			var ref wire.SnapshotRef
+			if err := proto.Unmarshal(val, &ref); err != nil {
+				return fmt.Errorf("corrupt snapshot reference: %q: %v", k, err)
+			}
+			feed.Snapshots = append(feed.Snapshots, &wirepeer.PublishedSnapshot{
+				Name: string(k),
+				Key:  ref.Key,
+			})
+		}
+		return nil
+	}
+	if err := app.DB.View(list); err != nil {
+		return nil, err
+	}
+
+	buf, err := proto.Marshal(feed)
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, 0, len(publishSignPrefix)+len(buf))
+	msg = append(msg, publishSignPrefix...)
+	msg = append(msg, buf...)
+	sig := ed25519.Sign(app.Keys.Sign.Priv, msg)
+	s := &wirepeer.SignedPublishedFeed{
+		Feed:      buf,
+		Signature: sig[:],
+	}
+	return s, nil
+}
+
+// OpenPublishedFeed checks that the feed was signed by publisher and
+// is for the volume published under id, and returns its contents.
+func OpenPublishedFeed(s *wirepeer.SignedPublishedFeed, publisher *peer.PublicKey, id *db.PublicID) (*wirepeer.PublishedFeed, error) {
+	if s == nil || len(s.Signature) != ed25519.SignatureSize {
+		return nil, ErrBadPublishedFeed
+	}
+	var sig [ed25519.SignatureSize]byte
+	copy(sig[:], s.Signature)
+	msg := make([]byte, 0, len(publishSignPrefix)+len(s.Feed))
+	msg = append(msg, publishSignPrefix...)
+	msg = append(msg, s.Feed...)
+	if !ed25519.Verify((*[ed25519.PublicKeySize]byte)(publisher), msg, &sig) {
+		return nil, ErrBadPublishedFeed
+	}
+	var feed wirepeer.PublishedFeed
+	if err := proto.Unmarshal(s.Feed, &feed); err != nil {
+		return nil, ErrBadPublishedFeed
+	}
+	if !bytes.Equal(feed.Publisher, publisher[:]) ||
+		!bytes.Equal(feed.PublicID, id[:]) {
+		return nil, ErrBadPublishedFeed
+	}
+	return &feed, nil
+}
+
+// GetPublishedChunk returns the contents of a chunk of the volume
+// published under id. Only chunks that a snapshot of the volume
+// refers to are available; anything else, including the current
+// contents of the volume, returns ErrNotPublishedChunk.
+//
+// If there is no such published volume, returns db.ErrNotPublished.
+func (app *App) GetPublishedChunk(ctx context.Context, id *db.PublicID, key cas.Key, typ string, level uint8) ([]byte, error) {
+	var volID db.VolumeID
+	check := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByPublicID(id)
+		if err != nil {
+			return err
+		}
+		c := &db.SnapshotChunk{Key: key, Type: typ, Level: level}
+		if !v.SnapshotChunks().Refers(c) {
+			return ErrNotPublishedChunk
+		}
+		v.VolumeID(&volID)
+		return nil
+	}
+	if err := app.DB.View(check); err != nil {
+		return nil, err
+	}
+	ref, err := app.GetVolume(&volID)
+	if err != nil {
+		return nil, err
+	}
+	defer ref.Close()
+	chunk, err := ref.FS().ChunkStore().Get(ctx, key, typ, level)
+	if err != nil {
+		return nil, err
+	}
+	return chunk.Buf, nil
+}
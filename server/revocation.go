@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"github.com/agl/ed25519"
+	"github.com/golang/protobuf/proto"
+)
+
+// Revocations are signed with the node key, like transfer grants;
+// the prefix keeps the two from being confused.
+const revocationSignPrefix = "bazil-revoke\n"
+
+var (
+	ErrBadRevocation = errors.New("volume revocation is not valid")
+)
+
+// TellVolumeRevoked records that the volume is no longer shared with
+// the peer, or is shared again if restored is set, and queues a
+// signed message telling the peer about it. Use DeliverMessages to
+// send it right away.
+func (app *App) TellVolumeRevoked(pub *peer.PublicKey, volID *db.VolumeID, restored bool) error {
+	now := time.Now()
+	rev := &wirepeer.VolumeRevocation{
+		VolumeID: volID[:],
+		Peer:     pub[:],
+		Time:     now.Unix(),
+		Restored: restored,
+	}
+	buf, err := proto.Marshal(rev)
+	if err != nil {
+		return err
+	}
+	msg := make([]byte, 0, len(revocationSignPrefix)+len(buf))
+	msg = append(msg, revocationSignPrefix...)
+	msg = append(msg, buf...)
+	sig := ed25519.Sign(app.Keys.Sign.Priv, msg)
+	body, err := proto.Marshal(&wirepeer.SignedVolumeRevocation{
+		Revocation: buf,
+		Signature:  sig[:],
+	})
+	if err != nil {
+		return err
+	}
+
+	record := func(tx *db.Tx) error {
+		v, err := tx.Volumes().GetByVolumeID(volID)
+		if err != nil {
+			return err
+		}
+		return v.Revocations().Record(pub, now, !restored)
+	}
+	if err := app.DB.Update(record); err != nil {
+		return err
+	}
+	return app.QueueMessage(pub, wirepeer.Message_VOLUME_REVOKED, body)
+}
+
+// OpenVolumeRevocation checks that the revocation was signed by
+// signer and is addressed to this node, and returns its contents.
+func (app *App) OpenVolumeRevocation(s *wirepeer.SignedVolumeRevocation, signer *peer.PublicKey) (*wirepeer.VolumeRevocation, error) {
+	if len(s.Signature) != ed25519.SignatureSize {
+		return nil, ErrBadRevocation
+	}
+	var sig [ed25519.SignatureSize]byte
+	copy(sig[:], s.Signature)
+	msg := make([]byte, 0, len(revocationSignPrefix)+len(s.Revocation))
+	msg = append(msg, revocationSignPrefix...)
+	msg = append(msg, s.Revocation...)
+	if !ed25519.Verify((*[ed25519.PublicKeySize]byte)(signer), msg, &sig) {
+		return nil, ErrBadRevocation
+	}
+	var rev wirepeer.VolumeRevocation
+	if err := proto.Unmarshal(s.Revocation, &rev); err != nil {
+		return nil, ErrBadRevocation
+	}
+	if !bytes.Equal(rev.Peer, app.Keys.Sign.Pub[:]) {
+		return nil, ErrBadRevocation
+	}
+	return &rev, nil
+}
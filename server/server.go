@@ -3,11 +3,14 @@ package server
 import (
 	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"bazil.org/bazil/cas/chunks/kvchunks"
 	"bazil.org/bazil/db"
@@ -16,12 +19,14 @@ import (
 	"bazil.org/bazil/kv/kvfiles"
 	"bazil.org/bazil/kv/kvmulti"
 	"bazil.org/bazil/kv/kvpeer"
+	"bazil.org/bazil/kv/kvtrace"
 	"bazil.org/bazil/kv/untrusted"
 	"bazil.org/bazil/peer"
 	"bazil.org/bazil/tokens"
 	"bazil.org/fuse"
 	fusefs "bazil.org/fuse/fs"
 	"github.com/boltdb/bolt"
+	"golang.org/x/net/context"
 )
 
 type App struct {
@@ -35,12 +40,29 @@ type App struct {
 		// state, changes.
 		sync.Cond
 		open map[db.VolumeID]*VolumeRef
+		// set once an upgrade starts; no new mounts are made
+		draining bool
 	}
 	Keys *CryptoKeys
 	tls  struct {
 		config atomic.Value
 		gen    sync.Mutex
 	}
+	tier tier
+	// limit of open files per volume, or zero
+	handleLimit uint64
+	// nil for the defaults of package fs
+	writeThrottle *writeThrottleConfig
+	// zero for the default of package fs
+	maxPause time.Duration
+	// defaults for volumes that do not set their own
+	limits  fs.Limits
+	traffic trafficLog
+	pulls   pullLog
+	traces  tracing
+	stats   statsReplica
+	// receives the executable to replace the server with
+	upgrade chan string
 }
 
 func New(dataDir string, options ...AppOption) (app *App, err error) {
@@ -91,7 +113,7 @@ func New(dataDir string, options ...AppOption) (app *App, err error) {
 		return nil, err
 	}
 
-	keys, err := loadOrGenerateKeys(database.DB)
+	keys, err := loadOrGenerateKeys(database.DB, config.restoreSeed)
 	if err != nil {
 		return nil, err
 	}
@@ -103,8 +125,17 @@ func New(dataDir string, options ...AppOption) (app *App, err error) {
 		debug:    config.debug,
 		Keys:     keys,
 	}
+	app.handleLimit = config.handleLimit
+	app.writeThrottle = config.writeThrottle
+	app.maxPause = config.maxPause
+	app.limits = config.limits
+	app.stats.maxAge = config.statsMaxAge
+	app.tier.backend = config.tier.backend
+	app.tier.after = config.tier.after
 	app.volumes.Cond.L = &app.volumes.Mutex
 	app.volumes.open = make(map[db.VolumeID]*VolumeRef)
+	app.upgrade = make(chan string, 1)
+	app.pulls.init()
 	return app, nil
 }
 
@@ -116,6 +147,14 @@ func (app *App) Close() {
 	}
 	app.volumes.Unlock()
 
+	if err := app.flushTier(); err != nil {
+		log.Printf("saving chunk access times: %v", err)
+	}
+	app.traffic.wg.Wait()
+	if err := app.FlushTraffic(); err != nil {
+		log.Printf("saving traffic counts: %v", err)
+	}
+	app.closeStats()
 	app.DB.Close()
 	app.lockFile.Close()
 }
@@ -177,20 +216,39 @@ func (app *App) openVolume(tx *db.Tx, id *db.VolumeID) (*fs.Volume, error) {
 	if err != nil {
 		return nil, err
 	}
-	kvstore, err := app.OpenKV(tx, v.Storage())
+	kvstore, err := app.openKV(tx, v.Storage(), id)
 	if err != nil {
 		return nil, err
 	}
 
+	kvstore = kvtrace.New(kvstore, traceKV)
 	chunkStore := kvchunks.New(kvstore)
 	vol, err := fs.Open(app.DB, chunkStore, id, (*peer.PublicKey)(app.Keys.Sign.Pub))
 	if err != nil {
 		return nil, err
 	}
+	vol.SetHandleLimit(app.handleLimit)
+	if t := app.writeThrottle; t != nil {
+		vol.SetWriteThrottle(t.maxPending, t.target)
+	}
+	if app.maxPause != 0 {
+		vol.SetMaxPause(app.maxPause)
+	}
+	limits, err := v.Limits()
+	if err != nil {
+		return nil, err
+	}
+	vol.SetLimits(app.volumeLimits(limits))
 	return vol, nil
 }
 
 func (app *App) OpenKV(tx *db.Tx, storage *db.VolumeStorage) (kv.KV, error) {
+	return app.openKV(tx, storage, nil)
+}
+
+// openKV opens the storage of a volume. Traffic with peers is
+// counted against volID, if not nil.
+func (app *App) openKV(tx *db.Tx, storage *db.VolumeStorage, volID *db.VolumeID) (kv.KV, error) {
 	var kvstores []kv.KV
 
 	c := storage.Cursor()
@@ -199,7 +257,7 @@ func (app *App) OpenKV(tx *db.Tx, storage *db.VolumeStorage) (kv.KV, error) {
 		if err != nil {
 			return nil, err
 		}
-		s, err := app.openStorage(backend)
+		s, err := app.openStorageFor(backend, volID)
 		if err != nil {
 			return nil, err
 		}
@@ -223,8 +281,17 @@ func (app *App) OpenKV(tx *db.Tx, storage *db.VolumeStorage) (kv.KV, error) {
 }
 
 func (app *App) openStorage(backend string) (kv.KV, error) {
+	return app.openStorageFor(backend, nil)
+}
+
+// openStorageFor opens a storage backend. Traffic with peers is
+// counted against volID, if not nil.
+func (app *App) openStorageFor(backend string, volID *db.VolumeID) (kv.KV, error) {
 	switch backend {
 	case "local":
+		if app.tier.backend != "" {
+			return app.tieredStorage()
+		}
 		kvpath := filepath.Join(app.DataDir, "chunks")
 		return kvfiles.Open(kvpath)
 	}
@@ -244,8 +311,14 @@ func (app *App) openStorage(backend string) (kv.KV, error) {
 			if err != nil {
 				return nil, err
 			}
+			count := func(sent, received uint64) {
+				app.CountTraffic(&key, volID, sent, received)
+			}
 			// TODO Close
-			return kvpeer.Open(p)
+			return kvpeer.Open(p, count)
+		}
+		if factory, ok := kv.Backend(scheme); ok {
+			return factory(rest)
 		}
 	}
 	return nil, errors.New("unknown storage backend")
@@ -266,9 +339,13 @@ type VolumeRef struct {
 
 	// fields protected by App.volumes.Mutex
 
-	refs    uint32
-	mounted bool
-	conn    *fuse.Conn
+	refs uint32
+	// active mounts, by mountpoint
+	mounts map[string]*fuse.Conn
+	// active read-only mounts of snapshots, by mountpoint
+	snapMounts map[string]*fuse.Conn
+	// access for other users, shared by all mounts
+	access *fs.UserAccess
 }
 
 func (ref *VolumeRef) Close() {
@@ -295,51 +372,98 @@ func (ref *VolumeRef) FS() *fs.Volume {
 func (ref *VolumeRef) Protocol() (*fuse.Protocol, error) {
 	ref.app.volumes.Lock()
 	defer ref.app.volumes.Unlock()
-	if !ref.mounted {
-		return nil, errors.New("not mounted")
+	for _, conn := range ref.mounts {
+		p := conn.Protocol()
+		return &p, nil
 	}
-	p := ref.conn.Protocol()
-	return &p, nil
+	return nil, errors.New("not mounted")
 }
 
 // Mount makes the contents of the volume visible at the given
 // mountpoint. If Mount returns with a nil error, the mount has
 // occurred.
-func (ref *VolumeRef) Mount(mountpoint string) error {
+//
+// A volume can be mounted at several mountpoints at once. All of the
+// mounts see the same content, as described in bazil.org/bazil/fs,
+// and must agree on what access other users have.
+func (ref *VolumeRef) Mount(mountpoint string, options ...MountOption) error {
+	var conf mountConfig
+	for _, fn := range options {
+		if err := fn(&conf); err != nil {
+			return err
+		}
+	}
+
 	ref.app.volumes.Lock()
 	defer ref.app.volumes.Unlock()
 
-	if ref.mounted {
-		return errors.New("volume already mounted")
+	if ref.app.volumes.draining {
+		return ErrDraining
+	}
+	if _, ok := ref.mounts[mountpoint]; ok {
+		return errors.New("volume already mounted there")
+	}
+	if _, ok := ref.snapMounts[mountpoint]; ok {
+		return errors.New("volume already mounted there")
+	}
+	if len(ref.mounts) > 0 && !reflect.DeepEqual(conf.access, ref.access) {
+		return errors.New("volume already mounted with different access for other users")
 	}
 
-	conn, err := fuse.Mount(mountpoint,
-		fuse.MaxReadahead(32*1024*1024),
+	fuseOptions := []fuse.MountOption{
+		fuse.MaxReadahead(32 * 1024 * 1024),
 		fuse.AsyncRead(),
-	)
+	}
+	if conf.access != nil {
+		fuseOptions = append(fuseOptions, fuse.AllowOther())
+	}
+	conn, err := fuse.Mount(mountpoint, fuseOptions...)
 	if err != nil {
 		return fmt.Errorf("mount fail: %v", err)
 	}
 
+	// set before serving any requests; equal to what other mounts
+	// use, if any
+	ref.access = conf.access
+	ref.fs.SetUserAccess(conf.access)
+
+	trace := newMountTrace(ref.app, &ref.volID)
 	srv := fusefs.New(conn, &fusefs.Config{
 		Debug: ref.debug,
+		WithContext: func(ctx context.Context, req fuse.Request) context.Context {
+			ctx = trace.withContext(ctx, req)
+			switch req.(type) {
+			case *fuse.ForgetRequest, *fuse.InterruptRequest:
+				// these only let go of what other requests
+				// hold, there is nothing to gain in waiting
+			default:
+				// The FUSE server learns of the request only
+				// after this returns, so interrupting it does
+				// not end the wait; the maximum pause does.
+				ref.fs.WaitPause(ctx)
+			}
+			return ctx
+		},
 	})
 	serveErr := make(chan error, 1)
 	go func() {
 		defer func() {
 			// remove map entry on unmount or failed mount
 			ref.app.volumes.Lock()
-			ref.mounted = false
-			ref.conn = nil
+			if ref.mounts[mountpoint] == conn {
+				delete(ref.mounts, mountpoint)
+			}
+			if len(ref.mounts) == 0 {
+				ref.access = nil
+				ref.fs.SetUserAccess(nil)
+			}
 			ref.app.volumes.Unlock()
 			ref.app.volumes.Broadcast()
 			ref.Close()
 		}()
 		defer conn.Close()
-		ref.fs.SetFUSE(srv)
-		defer func() {
-			ref.fs.SetFUSE(nil)
-		}()
+		ref.fs.AddFUSE(srv)
+		defer ref.fs.RemoveFUSE(srv)
 		serveErr <- srv.Serve(ref.fs)
 	}()
 
@@ -349,8 +473,10 @@ func (ref *VolumeRef) Mount(mountpoint string) error {
 			return fmt.Errorf("mount fail (delayed): %v", err)
 		}
 		ref.refs++
-		ref.mounted = true
-		ref.conn = conn
+		if ref.mounts == nil {
+			ref.mounts = make(map[string]*fuse.Conn)
+		}
+		ref.mounts[mountpoint] = conn
 		ref.app.volumes.Broadcast()
 		return nil
 	case err := <-serveErr:
@@ -383,13 +509,34 @@ func (ref *VolumeRef) debug(msg interface{}) {
 
 var ErrNotMounted = errors.New("not currently mounted")
 
+// WaitForUnmount waits until the volume is no longer mounted
+// anywhere.
 func (ref *VolumeRef) WaitForUnmount() error {
 	ref.app.volumes.Lock()
 	defer ref.app.volumes.Unlock()
-	if !ref.mounted {
+	if len(ref.mounts) == 0 {
+		return ErrNotMounted
+	}
+	for len(ref.mounts) > 0 {
+		ref.app.volumes.Wait()
+	}
+	return nil
+}
+
+// WaitForUnmountAt waits until the volume is no longer mounted at
+// mountpoint. Other mounts of the volume may remain.
+func (ref *VolumeRef) WaitForUnmountAt(mountpoint string) error {
+	ref.app.volumes.Lock()
+	defer ref.app.volumes.Unlock()
+	mountedAt := func() bool {
+		_, live := ref.mounts[mountpoint]
+		_, snap := ref.snapMounts[mountpoint]
+		return live || snap
+	}
+	if !mountedAt() {
 		return ErrNotMounted
 	}
-	for ref.mounted {
+	for mountedAt() {
 		ref.app.volumes.Wait()
 	}
 	return nil
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"bazil.org/fuse"
+	fusefs "bazil.org/fuse/fs"
+	"golang.org/x/net/context"
+)
+
+// snapshotFS serves one snapshot as a filesystem of its own.
+type snapshotFS struct {
+	root fusefs.Node
+}
+
+var _ fusefs.FS = snapshotFS{}
+
+func (s snapshotFS) Root() (fusefs.Node, error) {
+	return s.root, nil
+}
+
+// MountSnapshot makes the contents of the named snapshot visible,
+// read-only, at the given mountpoint. The mount is independent of
+// any mounts of the live volume, and only the user running the
+// server can access it.
+//
+// If there is no such snapshot, returns db.ErrSnapshotNotFound.
+func (ref *VolumeRef) MountSnapshot(ctx context.Context, name string, mountpoint string) error {
+	root, err := ref.fs.OpenSnapshot(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	ref.app.volumes.Lock()
+	defer ref.app.volumes.Unlock()
+
+	if ref.app.volumes.draining {
+		return ErrDraining
+	}
+	if _, ok := ref.mounts[mountpoint]; ok {
+		return errors.New("volume already mounted there")
+	}
+	if _, ok := ref.snapMounts[mountpoint]; ok {
+		return errors.New("volume already mounted there")
+	}
+
+	conn, err := fuse.Mount(mountpoint,
+		fuse.ReadOnly(),
+		fuse.MaxReadahead(32*1024*1024),
+		fuse.AsyncRead(),
+	)
+	if err != nil {
+		return fmt.Errorf("mount fail: %v", err)
+	}
+
+	srv := fusefs.New(conn, &fusefs.Config{
+		Debug: ref.debug,
+	})
+	serveErr := make(chan error, 1)
+	go func() {
+		defer func() {
+			// remove map entry on unmount or failed mount
+			ref.app.volumes.Lock()
+			if ref.snapMounts[mountpoint] == conn {
+				delete(ref.snapMounts, mountpoint)
+			}
+			ref.app.volumes.Unlock()
+			ref.app.volumes.Broadcast()
+			ref.Close()
+		}()
+		defer conn.Close()
+		serveErr <- srv.Serve(snapshotFS{root: root})
+	}()
+
+	select {
+	case <-conn.Ready:
+		if err := conn.MountError; err != nil {
+			return fmt.Errorf("mount fail (delayed): %v", err)
+		}
+		ref.refs++
+		if ref.snapMounts == nil {
+			ref.snapMounts = make(map[string]*fuse.Conn)
+		}
+		ref.snapMounts[mountpoint] = conn
+		ref.app.volumes.Broadcast()
+		return nil
+	case err := <-serveErr:
+		// Serve quit early
+		if err != nil {
+			return fmt.Errorf("filesystem failure: %v", err)
+		}
+		return errors.New("Serve exited early")
+	}
+}
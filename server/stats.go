@@ -0,0 +1,150 @@
+package server
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"bazil.org/bazil/db"
+)
+
+// The copy of the database that StatsView reads, in the data
+// directory.
+const statsDBName = "stats.bolt"
+
+// A copy that took d to make is kept for at least statsCopySpacing*d,
+// even if older than the maximum age, so the live database spends at
+// most a small part of its time copying.
+const statsCopySpacing = 10
+
+// statsReplica is a periodically refreshed copy of the database.
+// Reading it does not keep long transactions open on the live
+// database, where they would delay growing the file and keep freed
+// pages from being reused while volumes are busy writing.
+//
+// Making the copy has that cost itself: Bolt only gives a consistent
+// copy from inside a read transaction, which stays open for as long
+// as the copy takes. It is paid once per refresh instead of once per
+// query, and refreshes are spaced out; see statsCopySpacing.
+type statsReplica struct {
+	// how old the copy may get, or zero to not use a copy at all
+	maxAge time.Duration
+	// refreshes running in the background
+	wg sync.WaitGroup
+
+	// protects refreshing, taken and took
+	state      sync.Mutex
+	refreshing bool
+	taken      time.Time
+	// how long making the last copy took
+	took time.Duration
+
+	// held for reading while the copy is in use, for writing while
+	// it is replaced
+	mu sync.RWMutex
+	db *db.DB
+}
+
+// StatsView calls fn in a read-only transaction, for queries that
+// walk a lot of the database to compute statistics or listings. The
+// data seen can be out of date by about the maximum age set with
+// StatsReplica. Without that option, or until the first copy has
+// been made, the live database is read.
+//
+// An old copy is replaced in the background, so the call does not
+// wait for the database to be copied.
+func (app *App) StatsView(fn func(*db.Tx) error) error {
+	if app.stats.maxAge == 0 {
+		return app.DB.View(fn)
+	}
+	app.startRefreshStats()
+	app.stats.mu.RLock()
+	defer app.stats.mu.RUnlock()
+	if app.stats.db == nil {
+		return app.DB.View(fn)
+	}
+	return app.stats.db.View(fn)
+}
+
+// startRefreshStats starts replacing the copy of the database, if it
+// is too old and not being replaced already.
+func (app *App) startRefreshStats() {
+	app.stats.state.Lock()
+	defer app.stats.state.Unlock()
+	if app.stats.refreshing {
+		return
+	}
+	if !app.stats.taken.IsZero() {
+		wait := app.stats.maxAge
+		if w := statsCopySpacing * app.stats.took; w > wait {
+			wait = w
+		}
+		if time.Since(app.stats.taken) < wait {
+			return
+		}
+	}
+	app.stats.refreshing = true
+	app.stats.wg.Add(1)
+	go func() {
+		defer app.stats.wg.Done()
+		if err := app.refreshStats(); err != nil {
+			log.Printf("copying database for statistics: %v", err)
+		}
+	}()
+}
+
+// refreshStats replaces the copy of the database. The copy is made in
+// a read transaction on the live database; see statsReplica.
+func (app *App) refreshStats() error {
+	now := time.Now()
+	defer func() {
+		app.stats.state.Lock()
+		app.stats.refreshing = false
+		app.stats.taken = now
+		app.stats.took = time.Since(now)
+		app.stats.state.Unlock()
+	}()
+
+	path := filepath.Join(app.DataDir, statsDBName)
+	tmp := path + ".tmp"
+	snapshot := func(tx *db.Tx) error {
+		return tx.CopyFile(tmp, 0600)
+	}
+	if err := app.DB.View(snapshot); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	app.stats.mu.Lock()
+	defer app.stats.mu.Unlock()
+	if app.stats.db != nil {
+		app.stats.db.Close()
+		app.stats.db = nil
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	replica, err := db.OpenReadOnly(path)
+	if err != nil {
+		return err
+	}
+	app.stats.db = replica
+	return nil
+}
+
+// closeStats closes and removes the copy of the database.
+func (app *App) closeStats() {
+	app.stats.wg.Wait()
+	app.stats.mu.Lock()
+	defer app.stats.mu.Unlock()
+	if app.stats.db == nil {
+		return
+	}
+	app.stats.db.Close()
+	app.stats.db = nil
+	if err := os.Remove(filepath.Join(app.DataDir, statsDBName)); err != nil {
+		log.Printf("removing database copy: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/util/tempdir"
+)
+
+func TestStatsView(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := New(tmp.Subdir("data"), StatsReplica(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	create := func(name string) {
+		fn := func(tx *db.Tx) error {
+			sharingKey, err := tx.SharingKeys().Get("default")
+			if err != nil {
+				return err
+			}
+			_, err = tx.Volumes().Create(name, "local", sharingKey)
+			return err
+		}
+		if err := app.DB.Update(fn); err != nil {
+			t.Fatalf("creating volume %q: %v", name, err)
+		}
+	}
+	names := func() []string {
+		var list []string
+		fn := func(tx *db.Tx) error {
+			add := func(name string, _ *db.VolumeID) error {
+				list = append(list, name)
+				return nil
+			}
+			return tx.Volumes().Names(add)
+		}
+		if err := app.StatsView(fn); err != nil {
+			t.Fatalf("stats view: %v", err)
+		}
+		return list
+	}
+
+	create("one")
+	// no copy yet, the live database is read while one is made
+	if g, e := names(), []string{"one"}; len(g) != len(e) {
+		t.Fatalf("wrong volumes: %q != %q", g, e)
+	}
+	app.stats.wg.Wait()
+
+	// the copy is fresh, so the new volume is not in it yet
+	create("two")
+	if g, e := names(), []string{"one"}; len(g) != len(e) {
+		t.Fatalf("copy was refreshed too early: %q != %q", g, e)
+	}
+
+	// an old copy is replaced in the background
+	app.stats.state.Lock()
+	app.stats.taken = time.Now().Add(-2 * time.Hour)
+	app.stats.state.Unlock()
+	names()
+	app.stats.wg.Wait()
+	if g, e := names(), []string{"one", "two"}; len(g) != len(e) {
+		t.Fatalf("copy was not refreshed: %q != %q", g, e)
+	}
+
+	// a copy that was slow to make is kept for longer
+	create("three")
+	app.stats.state.Lock()
+	app.stats.taken = time.Now().Add(-2 * time.Hour)
+	app.stats.took = time.Hour
+	app.stats.state.Unlock()
+	names()
+	app.stats.wg.Wait()
+	if g, e := names(), []string{"one", "two"}; len(g) != len(e) {
+		t.Fatalf("slow copy was refreshed too early: %q != %q", g, e)
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/kv"
+	"bazil.org/bazil/kv/kvfiles"
+	"bazil.org/bazil/kv/kvtiered"
+	"golang.org/x/net/context"
+)
+
+type tier struct {
+	backend string
+	after   time.Duration
+
+	mu sync.Mutex
+	// nil until opened successfully
+	kv *kvtiered.Tiered
+}
+
+// slowTier opens the tiering backend on first use, so that opening
+// local storage does not wait for a slow or unreachable backend.
+// Failures to open are retried on the next use.
+type slowTier struct {
+	app *App
+
+	mu sync.Mutex
+	kv kv.KV
+}
+
+var _ kv.KV = (*slowTier)(nil)
+
+func (s *slowTier) open() (kv.KV, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.kv == nil {
+		k, err := s.app.openStorage(s.app.tier.backend)
+		if err != nil {
+			return nil, err
+		}
+		s.kv = k
+	}
+	return s.kv, nil
+}
+
+func (s *slowTier) Get(ctx context.Context, key []byte) ([]byte, error) {
+	k, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	return k.Get(ctx, key)
+}
+
+func (s *slowTier) Put(ctx context.Context, key, value []byte) error {
+	k, err := s.open()
+	if err != nil {
+		return err
+	}
+	return k.Put(ctx, key, value)
+}
+
+// accessLog stores chunk access times in the app database.
+type accessLog struct {
+	db *db.DB
+}
+
+var _ kvtiered.AccessLog = accessLog{}
+
+func (l accessLog) Touch(accessed map[string]kvtiered.Day) error {
+	touch := func(tx *db.Tx) error {
+		ca := tx.ChunkAccess()
+		for k, day := range accessed {
+			if err := ca.Touch([]byte(k), uint32(day)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return l.db.Update(touch)
+}
+
+func (l accessLog) Cold(day kvtiered.Day, fn func(key []byte) error) error {
+	cold := func(tx *db.Tx) error {
+		return tx.ChunkAccess().Cold(uint32(day), fn)
+	}
+	return l.db.View(cold)
+}
+
+func (l accessLog) Demoted(key []byte) error {
+	demoted := func(tx *db.Tx) error {
+		return tx.ChunkAccess().Demoted(key)
+	}
+	return l.db.Update(demoted)
+}
+
+func (app *App) tieredStorage() (*kvtiered.Tiered, error) {
+	app.tier.mu.Lock()
+	defer app.tier.mu.Unlock()
+	if app.tier.kv == nil {
+		kvpath := filepath.Join(app.DataDir, "chunks")
+		fast, err := kvfiles.Open(kvpath)
+		if err != nil {
+			return nil, err
+		}
+		slow := &slowTier{app: app}
+		app.tier.kv = kvtiered.New(fast, slow, accessLog{db: app.DB})
+	}
+	return app.tier.kv, nil
+}
+
+// flushTier writes pending chunk access times, if tiered storage has
+// been opened.
+func (app *App) flushTier() error {
+	app.tier.mu.Lock()
+	t := app.tier.kv
+	app.tier.mu.Unlock()
+	if t == nil {
+		return nil
+	}
+	return t.Flush()
+}
+
+// DemoteColdChunks moves locally stored chunks that have not been
+// accessed recently to the tiering backend, and returns how many were
+// moved. It does nothing unless the app was created with the Tiering
+// option.
+func (app *App) DemoteColdChunks(ctx context.Context) (int, error) {
+	if app.tier.backend == "" {
+		return 0, nil
+	}
+	t, err := app.tieredStorage()
+	if err != nil {
+		return 0, err
+	}
+	return t.Demote(ctx, app.tier.after)
+}
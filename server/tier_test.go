@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/kv"
+	"bazil.org/bazil/kv/kvmock"
+	"bazil.org/bazil/util/tempdir"
+	"golang.org/x/net/context"
+)
+
+// flakyTier is a tiering backend that fails to open until up is set.
+var flakyTier struct {
+	mu    sync.Mutex
+	up    bool
+	opens int
+	kv    kvmock.InMemory
+}
+
+func init() {
+	kv.RegisterBackend("testflakytier", func(config string) (kv.KV, error) {
+		flakyTier.mu.Lock()
+		defer flakyTier.mu.Unlock()
+		flakyTier.opens++
+		if !flakyTier.up {
+			return nil, errors.New("tier backend is down")
+		}
+		return &flakyTier.kv, nil
+	})
+}
+
+func TestTierSlowBackendRetried(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	// a negative age makes everything cold
+	app, err := New(tmp.Subdir("data"), Tiering("testflakytier:", -48*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	local, err := app.openStorage("local")
+	if err != nil {
+		t.Fatalf("opening local storage: %v", err)
+	}
+	flakyTier.mu.Lock()
+	opens := flakyTier.opens
+	flakyTier.mu.Unlock()
+	if opens != 0 {
+		t.Errorf("tier backend opened with local storage")
+	}
+	if err := local.Put(ctx, []byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.DemoteColdChunks(ctx); err == nil {
+		t.Fatal("expected demote to fail while the backend is down")
+	}
+
+	flakyTier.mu.Lock()
+	flakyTier.up = true
+	flakyTier.mu.Unlock()
+
+	n, err := app.DemoteColdChunks(ctx)
+	if err != nil {
+		t.Fatalf("demote after the backend came up: %v", err)
+	}
+	if g, e := n, 1; g != e {
+		t.Errorf("wrong number of demoted chunks: %d != %d", g, e)
+	}
+	if g, e := flakyTier.kv.Data["k"], "v"; g != e {
+		t.Errorf("bad value in tier backend: %q != %q", g, e)
+	}
+}
+
+func TestTierTouchInsideTransaction(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := New(tmp.Subdir("data"), Tiering("testflakytier:", time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	local, err := app.openStorage("local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// chunks are written from inside transactions; enough of them
+	// to fill a batch of access times used to write it right away
+	// and wait for this transaction
+	const count = 1001
+	put := func(tx *db.Tx) error {
+		for i := 0; i < count; i++ {
+			if err := local.Put(ctx, []byte(fmt.Sprintf("k%d", i)), []byte("v")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := app.DB.Update(put); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.flushTier(); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := 0
+	cold := func(tx *db.Tx) error {
+		return tx.ChunkAccess().Cold(^uint32(0), func(key []byte) error {
+			seen++
+			return nil
+		})
+	}
+	if err := app.DB.View(cold); err != nil {
+		t.Fatal(err)
+	}
+	if g, e := seen, count; g != e {
+		t.Errorf("wrong number of access times: %d != %d", g, e)
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/kv/kvtrace"
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+)
+
+// Most storage calls kept for a single traced request; the rest are
+// only counted.
+const traceMaxKVCalls = 64
+
+// Events buffered for a slow reader before dropping them.
+const traceBuffer = 100
+
+// TraceEvent describes a single sampled FUSE request.
+type TraceEvent struct {
+	Start time.Time
+	// The request, with its arguments.
+	Request  string
+	Duration time.Duration
+	// Storage calls made while serving the request. Data written
+	// to storage later, for example when the file is closed, is
+	// not included.
+	KV        []kvtrace.Call
+	KVOmitted uint64
+	// Events dropped before this one because the reader was too
+	// slow.
+	Dropped uint64
+}
+
+// TraceConfig decides which requests are traced.
+type TraceConfig struct {
+	// Trace one in this many requests. Zero traces every request.
+	SampleEvery uint64
+	// Most events per second. Zero means no limit.
+	MaxPerSecond uint32
+}
+
+type traceSub struct {
+	volID  db.VolumeID
+	config TraceConfig
+	events chan *TraceEvent
+
+	// fields protected by tracing.mu
+
+	seen uint64
+	// rate limit, as a token bucket holding a second's worth
+	tokens float64
+	last   time.Time
+
+	// protected by tracing.sendMu
+	dropped uint64
+}
+
+// caller must hold tracing.mu
+func (s *traceSub) sample(now time.Time) bool {
+	s.seen++
+	if s.config.SampleEvery > 1 && s.seen%s.config.SampleEvery != 0 {
+		return false
+	}
+	if s.config.MaxPerSecond == 0 {
+		return true
+	}
+	max := float64(s.config.MaxPerSecond)
+	s.tokens += now.Sub(s.last).Seconds() * max
+	s.last = now
+	if s.tokens > max {
+		s.tokens = max
+	}
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+// tracing keeps track of who is listening to traces of which volume.
+type tracing struct {
+	mu   sync.Mutex
+	subs map[*traceSub]struct{}
+	// serializes sending events
+	sendMu sync.Mutex
+}
+
+// Trace starts sampling the FUSE requests served for the volume.
+// Events are sent on the returned channel until stop is called. When
+// the receiver falls behind, events are dropped rather than slowing
+// down the requests.
+//
+// While no one is listening, tracing costs a lock per request.
+func (app *App) Trace(volID *db.VolumeID, config TraceConfig) (events <-chan *TraceEvent, stop func()) {
+	sub := &traceSub{
+		volID:  *volID,
+		config: config,
+		events: make(chan *TraceEvent, traceBuffer),
+		tokens: float64(config.MaxPerSecond),
+		last:   time.Now(),
+	}
+	app.traces.mu.Lock()
+	if app.traces.subs == nil {
+		app.traces.subs = make(map[*traceSub]struct{})
+	}
+	app.traces.subs[sub] = struct{}{}
+	app.traces.mu.Unlock()
+
+	stop = func() {
+		app.traces.mu.Lock()
+		delete(app.traces.subs, sub)
+		app.traces.mu.Unlock()
+	}
+	return sub.events, stop
+}
+
+// sample returns the subscribers that want to see the next request
+// to the volume.
+func (t *tracing) sample(volID *db.VolumeID) []*traceSub {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var subs []*traceSub
+	now := time.Now()
+	for sub := range t.subs {
+		if sub.volID == *volID && sub.sample(now) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+func (t *tracing) send(subs []*traceSub, ev TraceEvent) {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	for _, sub := range subs {
+		ev := ev
+		ev.Dropped = sub.dropped
+		select {
+		case sub.events <- &ev:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+type traceKey struct{}
+
+// traceRecord gathers what happens while serving a sampled request.
+type traceRecord struct {
+	subs []*traceSub
+
+	mu    sync.Mutex
+	event TraceEvent
+}
+
+func (r *traceRecord) addKV(call *kvtrace.Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.event.KV) >= traceMaxKVCalls {
+		r.event.KVOmitted++
+		return
+	}
+	c := *call
+	// the caller owns the key
+	c.Key = append([]byte(nil), c.Key...)
+	r.event.KV = append(r.event.KV, c)
+}
+
+// traceKV attributes storage calls to the traced request they are
+// made for, if any.
+func traceKV(ctx context.Context, call *kvtrace.Call) {
+	if r, ok := ctx.Value(traceKey{}).(*traceRecord); ok {
+		r.addKV(call)
+	}
+}
+
+// mountTrace follows the requests of a single mount from start to
+// response.
+type mountTrace struct {
+	app   *App
+	volID db.VolumeID
+}
+
+func newMountTrace(app *App, volID *db.VolumeID) *mountTrace {
+	return &mountTrace{
+		app:   app,
+		volID: *volID,
+	}
+}
+
+// withContext is called as each request starts being served.
+//
+// The FUSE server cancels the context of a request once it has
+// responded to it, or when the request is interrupted, and that ends
+// the trace. Nothing is kept for
+// requests that never end, beyond the goroutine waiting for them.
+func (m *mountTrace) withContext(ctx context.Context, req fuse.Request) context.Context {
+	subs := m.app.traces.sample(&m.volID)
+	if len(subs) == 0 {
+		return ctx
+	}
+	r := &traceRecord{
+		subs: subs,
+		event: TraceEvent{
+			Start:   time.Now(),
+			Request: req.String(),
+		},
+	}
+	go m.done(ctx, r)
+	return context.WithValue(ctx, traceKey{}, r)
+}
+
+func (m *mountTrace) done(ctx context.Context, r *traceRecord) {
+	<-ctx.Done()
+	r.mu.Lock()
+	ev := r.event
+	r.mu.Unlock()
+	ev.Duration = time.Since(ev.Start)
+	m.app.traces.send(r.subs, ev)
+}
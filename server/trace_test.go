@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTraceSample(t *testing.T) {
+	now := time.Now()
+	sub := &traceSub{
+		config: TraceConfig{SampleEvery: 3, MaxPerSecond: 2},
+		tokens: 2,
+		last:   now,
+	}
+	var sampled int
+	for i := 0; i < 30; i++ {
+		if sub.sample(now) {
+			sampled++
+		}
+	}
+	// 10 requests pass sampling, but only 2 fit in the rate
+	if g, e := sampled, 2; g != e {
+		t.Errorf("wrong number of sampled requests: %d != %d", g, e)
+	}
+	if !sub.sample(now.Add(time.Second)) && !sub.sample(now.Add(time.Second)) && !sub.sample(now.Add(time.Second)) {
+		t.Error("rate limit did not recover")
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+)
+
+// The number of transfers to count in memory before writing the
+// counts to the database.
+const trafficMaxPending = 1000
+
+// How many days of traffic counts to keep.
+const trafficKeepDays = 90
+
+type trafficKey struct {
+	day    uint32
+	pub    peer.PublicKey
+	vol    db.VolumeID
+	hasVol bool
+}
+
+type trafficCount struct {
+	sent     uint64
+	received uint64
+}
+
+// trafficLog gathers byte counts of transfers with peers, to avoid a
+// database write for every transfer.
+type trafficLog struct {
+	mu      sync.Mutex
+	pending map[trafficKey]trafficCount
+	updates int
+	// a background flush has been started and not finished yet
+	flushing bool
+	// background flushes in progress
+	wg sync.WaitGroup
+}
+
+func trafficDay(t time.Time) uint32 {
+	return uint32(t.Unix() / (24 * 60 * 60))
+}
+
+// CountTraffic records bytes sent to and received from a peer. volID
+// is nil for traffic not tied to a volume, such as storing chunks.
+//
+// Counts are written to the database in batches; see FlushTraffic.
+func (app *App) CountTraffic(pub *peer.PublicKey, volID *db.VolumeID, sent, received uint64) {
+	if sent == 0 && received == 0 {
+		return
+	}
+	k := trafficKey{
+		day: trafficDay(time.Now()),
+		pub: *pub,
+	}
+	if volID != nil {
+		k.vol = *volID
+		k.hasVol = true
+	}
+
+	app.traffic.mu.Lock()
+	defer app.traffic.mu.Unlock()
+	app.traffic.addLocked(k, trafficCount{sent: sent, received: received})
+	app.traffic.updates++
+	if app.traffic.updates < trafficMaxPending || app.traffic.flushing {
+		return
+	}
+	// Transfers are counted from inside database transactions, so
+	// the counts cannot be written from here.
+	app.traffic.flushing = true
+	app.traffic.wg.Add(1)
+	go func() {
+		defer app.traffic.wg.Done()
+		if err := app.FlushTraffic(); err != nil {
+			// counts are informational only, don't fail transfers
+			log.Printf("saving traffic counts: %v", err)
+		}
+		app.traffic.mu.Lock()
+		app.traffic.flushing = false
+		app.traffic.mu.Unlock()
+	}()
+}
+
+// caller must hold t.mu
+func (t *trafficLog) addLocked(k trafficKey, c trafficCount) {
+	if t.pending == nil {
+		t.pending = make(map[trafficKey]trafficCount)
+	}
+	old := t.pending[k]
+	old.sent += c.sent
+	old.received += c.received
+	t.pending[k] = old
+}
+
+// FlushTraffic writes pending traffic counts to the database. It must
+// not be called from inside a database transaction.
+func (app *App) FlushTraffic() error {
+	app.traffic.mu.Lock()
+	pending := app.traffic.pending
+	app.traffic.pending = nil
+	app.traffic.updates = 0
+	app.traffic.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	flush := func(tx *db.Tx) error {
+		t := tx.Traffic()
+		for k, c := range pending {
+			var volID *db.VolumeID
+			if k.hasVol {
+				volID = &k.vol
+			}
+			if err := t.Add(k.day, &k.pub, volID, c.sent, c.received); err != nil {
+				return err
+			}
+		}
+		return t.Expire(trafficDay(time.Now()) - trafficKeepDays)
+	}
+	if err := app.DB.Update(flush); err != nil {
+		// keep the counts for the next try
+		app.traffic.mu.Lock()
+		for k, c := range pending {
+			app.traffic.addLocked(k, c)
+		}
+		app.traffic.mu.Unlock()
+		return err
+	}
+	return nil
+}
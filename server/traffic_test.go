@@ -0,0 +1,56 @@
+package server
+
+import (
+	"testing"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	"bazil.org/bazil/util/tempdir"
+)
+
+func TestCountTrafficInsideTransaction(t *testing.T) {
+	tmp := tempdir.New(t)
+	defer tmp.Cleanup()
+	app, err := New(tmp.Subdir("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer app.Close()
+
+	pub := peer.PublicKey{1, 2, 3}
+	count := func(tx *db.Tx) error {
+		// enough to fill the batch, which used to write it right
+		// away and wait for this transaction
+		for i := 0; i < trafficMaxPending+1; i++ {
+			app.CountTraffic(&pub, nil, 1, 2)
+		}
+		return nil
+	}
+	if err := app.DB.Update(count); err != nil {
+		t.Fatal(err)
+	}
+	app.traffic.wg.Wait()
+	if err := app.FlushTraffic(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []db.TrafficEntry
+	list := func(tx *db.Tx) error {
+		return tx.Traffic().List(0, func(e *db.TrafficEntry) error {
+			got = append(got, *e)
+			return nil
+		})
+	}
+	if err := app.DB.View(list); err != nil {
+		t.Fatal(err)
+	}
+	if g, e := len(got), 1; g != e {
+		t.Fatalf("wrong number of entries: %d != %d: %v", g, e, got)
+	}
+	if g, e := got[0].Sent, uint64(trafficMaxPending+1); g != e {
+		t.Errorf("wrong bytes sent: %d != %d", g, e)
+	}
+	if g, e := got[0].Received, uint64(2*(trafficMaxPending+1)); g != e {
+		t.Errorf("wrong bytes received: %d != %d", g, e)
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"bazil.org/bazil/db"
+	"bazil.org/bazil/peer"
+	wirepeer "bazil.org/bazil/peer/wire"
+	"github.com/agl/ed25519"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+)
+
+// How long a transfer capability stays valid. The transfer is
+// expected to start right away.
+const transferTTL = 10 * time.Minute
+
+// Transfer grants are signed with the same key as everything else,
+// so the signed message starts with this to keep it from being
+// mistaken for anything else.
+const transferSignPrefix = "bazil-transfer\n"
+
+var (
+	ErrTransferDenied = errors.New("transfer capability is not valid")
+)
+
+// TransferObject makes the peer from send the object with the given
+// key directly to the peer to, without the data passing through this
+// node. Both peers must be storing objects for this node, and the
+// location of to must be known.
+func (app *App) TransferObject(ctx context.Context, from, to *peer.PublicKey, key []byte) error {
+	var addr string
+	find := func(tx *db.Tx) error {
+		p, err := tx.Peers().Get(to)
+		if err != nil {
+			return err
+		}
+		a, err := p.Locations().Get()
+		if err != nil {
+			return err
+		}
+		addr = a
+		return nil
+	}
+	if err := app.DB.View(find); err != nil {
+		return err
+	}
+
+	capability, err := app.transferCapability(from, to, key)
+	if err != nil {
+		return err
+	}
+	client, err := app.DialPeer(from)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	req := &wirepeer.ObjectTransferRequest{
+		Key:        key,
+		Receiver:   to[:],
+		Address:    addr,
+		Capability: capability,
+	}
+	if _, err := client.ObjectTransfer(ctx, req); err != nil {
+		return err
+	}
+	return nil
+}
+
+// transferCapability signs a grant allowing from to put the object
+// into the storage of this node at to.
+func (app *App) transferCapability(from, to *peer.PublicKey, key []byte) (*wirepeer.TransferCapability, error) {
+	grant := &wirepeer.TransferGrant{
+		Owner:    app.Keys.Sign.Pub[:],
+		Sender:   from[:],
+		Receiver: to[:],
+		Key:      key,
+		Expires:  time.Now().Add(transferTTL).Unix(),
+	}
+	buf, err := proto.Marshal(grant)
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, 0, len(transferSignPrefix)+len(buf))
+	msg = append(msg, transferSignPrefix...)
+	msg = append(msg, buf...)
+	sig := ed25519.Sign(app.Keys.Sign.Priv, msg)
+	c := &wirepeer.TransferCapability{
+		Grant:     buf,
+		Signature: sig[:],
+	}
+	return c, nil
+}
+
+// OpenTransferCapability checks the signature on the capability, and
+// returns the grant in it and the owner who signed it.
+//
+// The caller is responsible for checking that the grant applies to
+// what is being done.
+func OpenTransferCapability(c *wirepeer.TransferCapability) (*wirepeer.TransferGrant, *peer.PublicKey, error) {
+	if c == nil || len(c.Signature) != ed25519.SignatureSize {
+		return nil, nil, ErrTransferDenied
+	}
+	var grant wirepeer.TransferGrant
+	if err := proto.Unmarshal(c.Grant, &grant); err != nil {
+		return nil, nil, ErrTransferDenied
+	}
+	var owner peer.PublicKey
+	if err := owner.UnmarshalBinary(grant.Owner); err != nil {
+		return nil, nil, ErrTransferDenied
+	}
+	var sig [ed25519.SignatureSize]byte
+	copy(sig[:], c.Signature)
+	msg := make([]byte, 0, len(transferSignPrefix)+len(c.Grant))
+	msg = append(msg, transferSignPrefix...)
+	msg = append(msg, c.Grant...)
+	if !ed25519.Verify((*[ed25519.PublicKeySize]byte)(&owner), msg, &sig) {
+		return nil, nil, ErrTransferDenied
+	}
+	if time.Now().Unix() > grant.Expires {
+		return nil, nil, ErrTransferDenied
+	}
+	return &grant, &owner, nil
+}
+
+// VerifyTransfer checks that the capability allows sender to put the
+// object with the given key into storage at this node, and returns
+// the owner whose storage the object belongs in.
+func (app *App) VerifyTransfer(c *wirepeer.TransferCapability, sender *peer.PublicKey, key []byte) (*peer.PublicKey, error) {
+	grant, owner, err := OpenTransferCapability(c)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(grant.Sender, sender[:]) ||
+		!bytes.Equal(grant.Receiver, app.Keys.Sign.Pub[:]) ||
+		!bytes.Equal(grant.Key, key) {
+		return nil, ErrTransferDenied
+	}
+	return owner, nil
+}
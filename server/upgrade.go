@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bazil.org/fuse"
+)
+
+var (
+	ErrDraining = errors.New("server is shutting down for an upgrade")
+)
+
+// Upgrade prepares the server for being replaced by the executable
+// at the given path. New mounts are refused from here on, and all
+// current mounts are unmounted. Once nothing is mounted, the
+// executable is handed to whoever is watching UpgradeRequested,
+// which is expected to stop the server and run it.
+//
+// If a volume cannot be unmounted, for example because a file in it
+// is open, the upgrade is called off; the volumes that were already
+// unmounted stay that way. Volumes with a mountpoint configured are
+// mounted again by the new server.
+func (app *App) Upgrade(executable string) (unmounted []string, err error) {
+	if !filepath.IsAbs(executable) {
+		return nil, fmt.Errorf("executable path must be absolute: %q", executable)
+	}
+	fi, err := os.Stat(executable)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+		return nil, fmt.Errorf("not an executable: %q", executable)
+	}
+
+	app.volumes.Lock()
+	if app.volumes.draining {
+		app.volumes.Unlock()
+		return nil, ErrDraining
+	}
+	app.volumes.draining = true
+	var mountpoints []string
+	for _, ref := range app.volumes.open {
+		for mnt := range ref.mounts {
+			mountpoints = append(mountpoints, mnt)
+		}
+		for mnt := range ref.snapMounts {
+			mountpoints = append(mountpoints, mnt)
+		}
+	}
+	app.volumes.Unlock()
+
+	for _, mnt := range mountpoints {
+		if err := fuse.Unmount(mnt); err != nil {
+			app.volumes.Lock()
+			app.volumes.draining = false
+			app.volumes.Unlock()
+			return unmounted, fmt.Errorf("cannot unmount %s: %v", mnt, err)
+		}
+		unmounted = append(unmounted, mnt)
+	}
+
+	// the serving goroutines forget the mounts asynchronously
+	app.volumes.Lock()
+	for app.mounted() {
+		app.volumes.Wait()
+	}
+	app.volumes.Unlock()
+
+	select {
+	case app.upgrade <- executable:
+	default:
+		// only one upgrade gets this far, as draining is never
+		// cleared after this point
+	}
+	return unmounted, nil
+}
+
+// caller must hold App.volumes.Mutex
+func (app *App) mounted() bool {
+	for _, ref := range app.volumes.open {
+		if len(ref.mounts) > 0 || len(ref.snapMounts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// UpgradeRequested returns a channel that receives the path of the
+// new executable once Upgrade has drained the server.
+func (app *App) UpgradeRequested() <-chan string {
+	return app.upgrade
+}
@@ -25,4 +25,25 @@ const (
 	// just the raw public key, or empty for tombstone. Peer IDs are
 	// never reused.
 	BucketPeerID = "peerID"
+
+	// The DB bucket that contains a sub-bucket per named group of
+	// peers. See PeerGroupState* for the contents.
+	BucketPeerGroup = "peerGroup"
+
+	// The DB bucket that tracks approximate last access times of
+	// values in the local chunk store, for demoting cold data to
+	// slower storage. Key is the key in the local store, value is
+	// <day:uint32_be><state:uint8>.
+	BucketChunkAccess = "chunkAccess"
+
+	// The DB bucket that contains a sub-bucket per pending pairing
+	// invitation, named by the secret invitation token. See
+	// PairInviteState* for the contents.
+	BucketPairInvite = "pairInvite"
+
+	// The DB bucket that counts bytes transferred to and from peers,
+	// per day. Key is <day:uint32_be><pub:32>[<volumeID:64>], where
+	// the volume ID is missing for storage traffic not tied to a
+	// volume; value is <sent:uint64_be><received:uint64_be>.
+	BucketTraffic = "traffic"
 )
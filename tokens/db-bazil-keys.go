@@ -3,4 +3,8 @@ package tokens
 // Keys in the bucket BucketBazil.
 const (
 	GlobalStateKey = "key"
+
+	// The schema version of the database, as decimal text. See
+	// SchemaVersion.
+	GlobalStateSchemaVersion = "schemaVersion"
 )
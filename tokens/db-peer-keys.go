@@ -16,4 +16,50 @@ const (
 	// The DB bucket that configures what volumes peer can see.
 	// Key is volume ID, value is empty for now.
 	PeerStateVolume = "volume"
+
+	// The DB bucket that holds messages waiting to be delivered to
+	// the peer. Key is sequence number as uint64_be, value is a
+	// protobuf-encoded bazil.peer.Message without the sequence
+	// number.
+	PeerStateOutbox = "outbox"
+
+	// The DB bucket that holds messages received from the peer. Key
+	// is the sequence number assigned by the sender as uint64_be,
+	// value is a protobuf-encoded bazil.peer.Message.
+	PeerStateInbox = "inbox"
+
+	// A different public key answered at an address of the peer.
+	// Value is a protobuf-encoded bazil.db.PeerKeyAlert; missing if
+	// there is nothing to report.
+	PeerStateKeyAlert = "keyalert"
+)
+
+// Keys in the bucket BucketPeerGroup/NAME
+const (
+	// The DB bucket that contains the members of the group. Key is
+	// peer public key, value is empty.
+	PeerGroupStateMember = "member"
+
+	// The DB bucket that configures what storage to offer to all
+	// members of the group. Same format as PeerStateStorage.
+	PeerGroupStateStorage = "storage"
+
+	// The DB bucket that configures what volumes all members of the
+	// group can see. Same format as PeerStateVolume.
+	PeerGroupStateVolume = "volume"
+)
+
+// Keys in the bucket BucketPairInvite/TOKEN
+const (
+	// When the invitation stops being valid, as Unix seconds in
+	// uint64_be.
+	PairInviteStateExpires = "expires"
+
+	// The DB bucket that contains the volumes the invited peer gets
+	// access to. Key is volume name, value is volume ID.
+	PairInviteStateVolume = "volume"
+
+	// The DB bucket that contains the storage backends offered to the
+	// invited peer. Key is storage backend, value is empty.
+	PairInviteStateStorage = "storage"
 )
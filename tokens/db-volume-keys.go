@@ -30,4 +30,86 @@ const (
 	// For the purposes of this, the root directory has parent inode 0
 	// and empty string as name.
 	VolumeStateConflict = "conflict"
+
+	// The DB bucket that records changes to directory entries, for
+	// consumers that want to follow along. Only the most recent
+	// entries are kept.
+	//
+	// Key is <seq:uint64_be>, value is
+	// <op:uint8><txid:uvarint><keyLen:uvarint><dirInode:uint64_be><name>
+	// <clockLen:uvarint><clock><dirent>, where dirent is the entry
+	// after the change, empty for deletes and clock changes.
+	VolumeStateJournal = "journal"
+
+	// The DB bucket that lists the chunks each snapshot refers to.
+	//
+	// Key is snapshot name, value is a bucket with key
+	// <key:64><level:uint8><type> and value <size:uint64_be>.
+	VolumeStateSnapChunks = "snapchunks"
+
+	// The DB bucket that counts how many snapshots refer to each
+	// chunk.
+	//
+	// Key is <key:64><level:uint8><type>, value is
+	// <count:uint32_be>.
+	VolumeStateChunkRef = "chunkref"
+
+	// The DB bucket that keeps the recent versions of each file.
+	//
+	// Key is <inode:uint64_be><time:uint64_be>, with time in
+	// nanoseconds since the Unix epoch, value is protobuf
+	// bazil.cas.Manifest.
+	VolumeStateHistory = "history"
+
+	// The DB bucket that keeps the logical clock of each file
+	// version in VolumeStateHistory, to tell which versions a peer
+	// has seen.
+	//
+	// Key is the same as in VolumeStateHistory, value is the
+	// marshaled clock. Versions recorded before clocks were kept
+	// have no entry.
+	VolumeStateHistoryClock = "historyclock"
+
+	// The DB bucket that tracks which peers have stopped sharing the
+	// volume with us, or we with them.
+	//
+	// Key is the peer public key, value is
+	// <time:int64_be><revoked:uint8>, with time in seconds since the
+	// Unix epoch.
+	VolumeStateRevoked = "revoked"
+
+	// The hash algorithm used for new content in the volume, as a
+	// single byte cas.Hash. Missing means the original algorithm.
+	VolumeStateHash = "hash"
+
+	// Where the server mounts the volume when it starts, as a path.
+	// Missing means the volume is only mounted on request.
+	VolumeStateMountpoint = "mountpoint"
+
+	// Limits on file size, name length and nesting depth in the
+	// volume, as protobuf bazil.db.VolumeLimits. Missing means the
+	// server-wide defaults apply.
+	VolumeStateLimits = "limits"
+
+	// The DB bucket that remembers what bulk permission changes
+	// replaced, so they can be undone. Contains a bucket for each
+	// change, by name.
+	//
+	// Key is <dirInode:uint64_be><name>, value is protobuf
+	// bazil.db.Perm, or empty for the default permissions.
+	VolumeStatePermUndo = "permundo"
+
+	// The public identifier the snapshots of the volume are
+	// published under, as <id:16><on:uint8>, with on 1 while the
+	// volume is published. The identifier is kept when publishing is
+	// turned off, so it stays the same if turned on again. Missing
+	// means the volume has never been published.
+	VolumeStatePublish = "publish"
+
+	// The DB bucket that marks files and directories whose
+	// concurrent appends are merged instead of conflicting. Marking
+	// a directory covers everything below it.
+	//
+	// Key is <inode:uint64_be>, value is empty.
+	VolumeStateAppendOnly = "appendonly"
 )
@@ -0,0 +1,151 @@
+package tokens
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SchemaVersion is the version of the database layout described by
+// the names registered here. Bump it, and register the new names
+// with the new version, whenever the layout changes in a way older
+// code would not understand.
+//
+// Version history:
+//
+//	1: names registered when the registry was introduced
+//	2: peer message outbox and inbox
+//	3: pairing invitations
+//	4: per-volume chunk hash algorithm
+//	5: snapshot chunk lists and chunk reference counts
+//	6: traffic counters
+//	7: file version history
+//	8: volume revocations
+//	9: remembered mountpoints
+//	10: per-volume limits
+//	11: permission rewrite undo records
+//	12: peer key change alerts
+//	13: published volumes
+//	14: append-only marks
+//	15: clocks of file versions
+const SchemaVersion = 15
+
+// Scopes that names are registered in. ScopeTop is for the top-level
+// DB buckets; the others are for keys inside the bucket of the same
+// name.
+const (
+	ScopeTop        = ""
+	ScopeBazil      = BucketBazil
+	ScopeVolume     = BucketVolume
+	ScopePeer       = BucketPeer
+	ScopePeerGroup  = BucketPeerGroup
+	ScopePairInvite = BucketPairInvite
+)
+
+// Registered describes a name used in the database.
+type Registered struct {
+	Scope string
+	Name  string
+	// The schema version the name was introduced in.
+	Since int
+}
+
+var registry = make(map[string]map[string]Registered)
+
+func register(scope string, name string, since int) {
+	if since > SchemaVersion {
+		panic(fmt.Sprintf("token %q registered for future schema version %d", name, since))
+	}
+	names := registry[scope]
+	if names == nil {
+		names = make(map[string]Registered)
+		registry[scope] = names
+	}
+	if _, ok := names[name]; ok {
+		panic(fmt.Sprintf("token %q registered twice in scope %q", name, scope))
+	}
+	names[name] = Registered{
+		Scope: scope,
+		Name:  name,
+		Since: since,
+	}
+}
+
+// Lookup returns the registration for name in scope, if any.
+func Lookup(scope string, name string) (Registered, bool) {
+	r, ok := registry[scope][name]
+	return r, ok
+}
+
+// Names returns all names registered in scope, sorted.
+func Names(scope string) []Registered {
+	var list []Registered
+	for _, r := range registry[scope] {
+		list = append(list, r)
+	}
+	sort.Sort(byName(list))
+	return list
+}
+
+// Buckets returns all registered top-level DB buckets, sorted by
+// name.
+func Buckets() []Registered {
+	return Names(ScopeTop)
+}
+
+type byName []Registered
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func init() {
+	register(ScopeTop, BucketBazil, 1)
+	register(ScopeTop, BucketVolume, 1)
+	register(ScopeTop, BucketVolName, 1)
+	register(ScopeTop, BucketSharing, 1)
+	register(ScopeTop, BucketPeer, 1)
+	register(ScopeTop, BucketPeerID, 1)
+	register(ScopeTop, BucketPeerGroup, 1)
+	register(ScopeTop, BucketChunkAccess, 1)
+	register(ScopeTop, BucketPairInvite, 3)
+	register(ScopeTop, BucketTraffic, 6)
+
+	register(ScopeBazil, GlobalStateKey, 1)
+	register(ScopeBazil, GlobalStateSchemaVersion, 1)
+
+	register(ScopeVolume, VolumeStateDir, 1)
+	register(ScopeVolume, VolumeStateInode, 1)
+	register(ScopeVolume, VolumeStateSnap, 1)
+	register(ScopeVolume, VolumeStateEpoch, 1)
+	register(ScopeVolume, VolumeStateStorage, 1)
+	register(ScopeVolume, VolumeStateClock, 1)
+	register(ScopeVolume, VolumeStateConflict, 1)
+	register(ScopeVolume, VolumeStateJournal, 1)
+	register(ScopeVolume, VolumeStateHash, 4)
+	register(ScopeVolume, VolumeStateSnapChunks, 5)
+	register(ScopeVolume, VolumeStateChunkRef, 5)
+	register(ScopeVolume, VolumeStateHistory, 7)
+	register(ScopeVolume, VolumeStateRevoked, 8)
+	register(ScopeVolume, VolumeStateMountpoint, 9)
+	register(ScopeVolume, VolumeStateLimits, 10)
+	register(ScopeVolume, VolumeStatePermUndo, 11)
+	register(ScopeVolume, VolumeStatePublish, 13)
+	register(ScopeVolume, VolumeStateAppendOnly, 14)
+	register(ScopeVolume, VolumeStateHistoryClock, 15)
+
+	register(ScopePeer, PeerStateID, 1)
+	register(ScopePeer, PeerStateLocation, 1)
+	register(ScopePeer, PeerStateStorage, 1)
+	register(ScopePeer, PeerStateVolume, 1)
+	register(ScopePeer, PeerStateOutbox, 2)
+	register(ScopePeer, PeerStateInbox, 2)
+	register(ScopePeer, PeerStateKeyAlert, 12)
+
+	register(ScopePeerGroup, PeerGroupStateMember, 1)
+	register(ScopePeerGroup, PeerGroupStateStorage, 1)
+	register(ScopePeerGroup, PeerGroupStateVolume, 1)
+
+	register(ScopePairInvite, PairInviteStateExpires, 3)
+	register(ScopePairInvite, PairInviteStateVolume, 3)
+	register(ScopePairInvite, PairInviteStateStorage, 3)
+}
@@ -0,0 +1,49 @@
+package tokens_test
+
+import (
+	"testing"
+
+	"bazil.org/bazil/tokens"
+)
+
+func TestBucketsSorted(t *testing.T) {
+	list := tokens.Buckets()
+	if len(list) == 0 {
+		t.Fatal("no buckets registered")
+	}
+	for i := 1; i < len(list); i++ {
+		if list[i-1].Name >= list[i].Name {
+			t.Errorf("buckets not sorted: %q >= %q", list[i-1].Name, list[i].Name)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	r, ok := tokens.Lookup(tokens.ScopeVolume, tokens.VolumeStateClock)
+	if !ok {
+		t.Fatalf("volume clock bucket not registered")
+	}
+	if g, e := r.Scope, tokens.ScopeVolume; g != e {
+		t.Errorf("wrong scope: %q != %q", g, e)
+	}
+	if _, ok := tokens.Lookup(tokens.ScopeTop, "nonexistent"); ok {
+		t.Errorf("unexpected registration found")
+	}
+}
+
+func TestSchemaVersion(t *testing.T) {
+	for _, scope := range []string{
+		tokens.ScopeTop,
+		tokens.ScopeBazil,
+		tokens.ScopeVolume,
+		tokens.ScopePeer,
+		tokens.ScopePeerGroup,
+		tokens.ScopePairInvite,
+	} {
+		for _, r := range tokens.Names(scope) {
+			if r.Since < 1 || r.Since > tokens.SchemaVersion {
+				t.Errorf("%q in scope %q has bad schema version %d", r.Name, scope, r.Since)
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package errkind classifies errors, so callers can decide what to
+// do about an error without knowing what package it came from.
+//
+// Errors tell their kind by implementing ErrorKind; errors from
+// outside bazil, such as network and RPC errors, are recognized too.
+package errkind
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Kind is a class of errors.
+type Kind int
+
+const (
+	// Other is anything not covered by the other kinds.
+	Other Kind = iota
+	// NotFound means the thing asked for does not exist.
+	NotFound
+	// Unavailable means the operation might succeed if tried again
+	// later, such as when a peer cannot be reached right now.
+	Unavailable
+	// Integrity means stored data is damaged.
+	Integrity
+	// QuotaExceeded means there is no room for more data, for now.
+	QuotaExceeded
+	// PermissionDenied means the operation is not allowed.
+	PermissionDenied
+)
+
+var kindNames = [...]string{
+	Other:            "other",
+	NotFound:         "not found",
+	Unavailable:      "unavailable",
+	Integrity:        "integrity",
+	QuotaExceeded:    "quota exceeded",
+	PermissionDenied: "permission denied",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return kindNames[Other]
+	}
+	return kindNames[k]
+}
+
+// Kinder is implemented by errors that know their kind.
+type Kinder interface {
+	ErrorKind() Kind
+}
+
+type kindError struct {
+	kind Kind
+	text string
+}
+
+var _ Kinder = (*kindError)(nil)
+
+func (e *kindError) Error() string {
+	return e.text
+}
+
+func (e *kindError) ErrorKind() Kind {
+	return e.kind
+}
+
+// New returns an error of the given kind with the text. Like
+// errors.New, each call returns a distinct error, so it can be used
+// for sentinel values.
+func New(kind Kind, text string) error {
+	return &kindError{kind: kind, text: text}
+}
+
+// Of returns the kind of err. A nil error is of kind Other.
+func Of(err error) Kind {
+	if err == nil {
+		return Other
+	}
+	if k, ok := err.(Kinder); ok {
+		return k.ErrorKind()
+	}
+	switch err {
+	case context.DeadlineExceeded, context.Canceled:
+		return Unavailable
+	}
+	if e, ok := err.(net.Error); ok && (e.Temporary() || e.Timeout()) {
+		return Unavailable
+	}
+	switch grpc.Code(err) {
+	case codes.NotFound:
+		return NotFound
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted:
+		return Unavailable
+	case codes.DataLoss:
+		return Integrity
+	case codes.ResourceExhausted:
+		return QuotaExceeded
+	case codes.PermissionDenied:
+		return PermissionDenied
+	}
+	return Other
+}
+
+// Code returns the RPC status code for reporting err, based on its
+// kind. Errors of kind Other are reported as codes.Internal.
+func Code(err error) codes.Code {
+	switch Of(err) {
+	case NotFound:
+		return codes.NotFound
+	case Unavailable:
+		return codes.Unavailable
+	case Integrity:
+		return codes.DataLoss
+	case QuotaExceeded:
+		return codes.ResourceExhausted
+	case PermissionDenied:
+		return codes.PermissionDenied
+	}
+	return codes.Internal
+}
+
+// Errno returns the error number for reporting err to the kernel,
+// based on its kind. Errors of kind Other are reported as EIO.
+func Errno(err error) syscall.Errno {
+	switch Of(err) {
+	case NotFound:
+		return syscall.ENOENT
+	case Unavailable:
+		return syscall.EAGAIN
+	case QuotaExceeded:
+		return syscall.EDQUOT
+	case PermissionDenied:
+		return syscall.EACCES
+	}
+	return syscall.EIO
+}
@@ -0,0 +1,63 @@
+package errkind_test
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"bazil.org/bazil/kv"
+	"bazil.org/bazil/kv/untrusted"
+	"bazil.org/bazil/util/errkind"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestOf(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want errkind.Kind
+	}{
+		{nil, errkind.Other},
+		{errors.New("beep"), errkind.Other},
+		{errkind.New(errkind.QuotaExceeded, "full"), errkind.QuotaExceeded},
+		{kv.NotFoundError{Key: []byte("k")}, errkind.NotFound},
+		{untrusted.CorruptError{Key: []byte("k")}, errkind.Integrity},
+		{context.DeadlineExceeded, errkind.Unavailable},
+		{grpc.Errorf(codes.Unavailable, "away"), errkind.Unavailable},
+		{grpc.Errorf(codes.NotFound, "nope"), errkind.NotFound},
+		{grpc.Errorf(codes.PermissionDenied, "no"), errkind.PermissionDenied},
+		{grpc.Errorf(codes.Internal, "oops"), errkind.Other},
+	} {
+		if g, e := errkind.Of(tc.err), tc.want; g != e {
+			t.Errorf("wrong kind for %v: %v != %v", tc.err, g, e)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	// the RPC code of each kind maps back to the same kind, so the
+	// kind survives a trip to a peer
+	for _, k := range []errkind.Kind{
+		errkind.NotFound,
+		errkind.Unavailable,
+		errkind.Integrity,
+		errkind.QuotaExceeded,
+		errkind.PermissionDenied,
+	} {
+		err := errkind.New(k, "test")
+		remote := grpc.Errorf(errkind.Code(err), "%v", err)
+		if g, e := errkind.Of(remote), k; g != e {
+			t.Errorf("kind lost over RPC: %v != %v", g, e)
+		}
+	}
+}
+
+func TestErrno(t *testing.T) {
+	if g, e := errkind.Errno(errkind.New(errkind.QuotaExceeded, "full")), syscall.EDQUOT; g != e {
+		t.Errorf("wrong errno: %v != %v", g, e)
+	}
+	if g, e := errkind.Errno(errors.New("beep")), syscall.EIO; g != e {
+		t.Errorf("wrong errno: %v != %v", g, e)
+	}
+}
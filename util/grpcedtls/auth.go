@@ -19,6 +19,10 @@ var (
 type Authenticator struct {
 	Config  func() (*tls.Config, error)
 	PeerPub *[ed25519.PublicKeySize]byte
+	// KeyMismatch, if not nil, is called when the server at addr
+	// presents a public key other than PeerPub. The connection is
+	// refused regardless.
+	KeyMismatch func(addr string, pub *[ed25519.PublicKeySize]byte)
 }
 
 var _ credentials.TransportAuthenticator = (*Authenticator)(nil)
@@ -51,6 +55,9 @@ func (a *Authenticator) ClientHandshake(addr string, rawConn net.Conn, timeout t
 	conf.InsecureSkipVerify = true
 	tconn, err := edtls.NewClient(rawConn, conf, a.PeerPub)
 	if err != nil {
+		if wrong, ok := err.(*edtls.WrongPublicKeyError); ok && a.KeyMismatch != nil {
+			a.KeyMismatch(addr, wrong.Pub)
+		}
 		return nil, nil, err
 	}
 
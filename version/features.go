@@ -0,0 +1,14 @@
+package version
+
+// Features lists optional functionality by name, so that a client
+// and a server built from different sources can tell what the other
+// one supports. Names are only ever added.
+var Features = []string{
+	"automount",
+	"file-history",
+	"object-transfer",
+	"snapshot-remove",
+	"unix-peers",
+	"upgrade",
+	"volume-revoke",
+}